@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/blob"
+	"github.com/String-sg/teacher-workspace/server/pkg/scan"
+)
+
+// attachmentBlobs returns where `tw serve` keeps post attachments: the S3
+// bucket cfg names, if it names one, or else dir. With no dir either,
+// attachments go beside the dataset at data, or failing that in a
+// temporary directory.
+func attachmentBlobs(cfg blob.S3Config, dir, data string) (blob.Store, error) {
+	if cfg.Bucket != "" {
+		return blob.NewS3(cfg, nil)
+	}
+	switch {
+	case dir != "":
+	case data != "":
+		dir = filepath.Join(filepath.Dir(data), "attachments")
+	default:
+		var err error
+		if dir, err = os.MkdirTemp("", "tw-attachments-"); err != nil {
+			return nil, err
+		}
+		slog.Warn("no attachments directory given; keeping attachments in a temporary one", "dir", dir)
+	}
+	return blob.NewDir(dir)
+}
+
+// attachmentScanner returns a scanner that runs command, split into words,
+// or nil if it is empty.
+func attachmentScanner(command string) scan.Scanner {
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		slog.Warn("no scan command given; attachments are not scanned for malware")
+		return nil
+	}
+	return scan.Command{Name: words[0], Args: words[1:]}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/token"
+)
+
+// runGatewayKey implements `tw gateway-key`, which mints a key for Parents
+// Gateway to call the gateway routes with. It prints the key, to give to
+// Parents Gateway, and its hash, to start `tw serve` with.
+func runGatewayKey(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("gateway-key", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := token.New(token.KindGateway)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "key:  %s\nhash: %s\n", key, token.Hash(key))
+	return err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/rosterimport"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+// mappingFlag collects repeated -map field=header flags.
+type mappingFlag map[string]string
+
+func (m mappingFlag) String() string { return fmt.Sprint(map[string]string(m)) }
+
+func (m mappingFlag) Set(v string) error {
+	field, header, ok := strings.Cut(v, "=")
+	if !ok || field == "" || header == "" {
+		return errors.New("must be field=header")
+	}
+	m[strings.TrimSpace(field)] = strings.TrimSpace(header)
+	return nil
+}
+
+// runImport implements `tw import`, which imports a CSV or XLSX class list
+// into a school in the -data dataset and saves it in place. It prints the
+// import report as JSON and fails when any row is invalid.
+func runImport(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	data := fs.String("data", os.Getenv("TW_DATA"), "dataset to import into (default $TW_DATA)")
+	school := fs.String("school", "", "ID or code of the school to import into")
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	mapping := mappingFlag{}
+	fs.Var(mapping, "map", "read `field=header` from the column with that header; repeatable")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: tw import -data data.json -school CODE [-dry-run] [-map field=header ...] FILE")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *data == "" || *school == "" {
+		fs.Usage()
+		return errors.New("-data, -school and one file are required")
+	}
+	path := fs.Arg(0)
+
+	s, err := store.Load(*data)
+	if err != nil {
+		return err
+	}
+	schoolID, err := findSchool(s, *school)
+	if err != nil {
+		return err
+	}
+
+	rows, err := readClassList(path)
+	if err != nil {
+		return err
+	}
+	svc := rosterimport.New(s, audit.New(s, time.Now), random.DefaultIDs)
+	report, err := svc.ImportSchool(schoolID, rows, rosterimport.Options{Mapping: mapping, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d problems in %s; nothing imported", len(report.Errors), path)
+	}
+	if !report.Applied {
+		return nil
+	}
+	return s.Save(*data)
+}
+
+// findSchool returns the ID of the school whose ID or code is ref.
+func findSchool(s *store.Store, ref string) (string, error) {
+	for _, school := range s.Schools() {
+		if school.ID == ref || school.Code == ref {
+			return school.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no school %q", ref)
+}
+
+func readClassList(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(f, head)
+	format, err := rosterimport.FormatOf(path, head[:n])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return rosterimport.Read(format, f)
+}
@@ -3,36 +3,156 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
 	"github.com/String-sg/teacher-workspace/server/internal/handler"
+	"github.com/String-sg/teacher-workspace/server/internal/insights"
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
 	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/blob"
+	"github.com/String-sg/teacher-workspace/server/pkg/email"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
 )
 
 const (
-	addr            = ":3000"
-	shutdownTimeout = 30 * time.Second
+	defaultAddr = ":3000"
+	// defaultInsightsHour is when the nightly insights run starts, in local
+	// time.
+	defaultInsightsHour = 2
+	shutdownTimeout     = 30 * time.Second
 )
 
 func main() {
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
 
-	mux := handler.NewMux()
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	var err error
+	switch cmd {
+	case "serve":
+		err = serve(args)
+	case "seed":
+		err = runSeed(args, os.Stdout)
+	case "import":
+		err = runImport(args, os.Stdout)
+	case "gateway-key":
+		err = runGatewayKey(args, os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "tw: unknown command %q\n", cmd)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tw %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+// serve implements `tw serve`, the default command, which runs the HTTP
+// server until SIGINT or SIGTERM. Changes are saved back to the dataset
+// file whenever a post is scheduled or published, as notifications are
+// sent, and on shutdown.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", defaultAddr, "listen address")
+	data := fs.String("data", os.Getenv("TW_DATA"), "dataset written by `tw seed`, which changes are saved back to (default $TW_DATA)")
+	insightsHour := fs.Int("insights-hour", defaultInsightsHour, "local `hour` of the nightly insights run")
+	gatewayKeyHash := fs.String("gateway-key-hash", os.Getenv("TW_GATEWAY_KEY_HASH"), "`hash` of the Parents Gateway key from `tw gateway-key` (default $TW_GATEWAY_KEY_HASH)")
+	attachmentsDir := fs.String("attachments", os.Getenv("TW_ATTACHMENTS_DIR"), "`directory` to keep post attachments in (default $TW_ATTACHMENTS_DIR, or beside the dataset)")
+	s3 := blob.S3Config{AccessKeyID: os.Getenv("AWS_ACCESS_KEY_ID"), SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY")}
+	fs.StringVar(&s3.Endpoint, "s3-endpoint", os.Getenv("TW_S3_ENDPOINT"), "`URL` of the S3-compatible store to keep attachments in instead (default $TW_S3_ENDPOINT)")
+	fs.StringVar(&s3.Region, "s3-region", os.Getenv("TW_S3_REGION"), "`region` of the S3 bucket (default $TW_S3_REGION)")
+	fs.StringVar(&s3.Bucket, "s3-bucket", os.Getenv("TW_S3_BUCKET"), "S3 `bucket` for attachments, reached with $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY (default $TW_S3_BUCKET)")
+	smtp := email.SMTPConfig{Password: os.Getenv("TW_SMTP_PASSWORD")}
+	fs.StringVar(&smtp.Addr, "smtp-addr", os.Getenv("TW_SMTP_ADDR"), "`host:port` of the SMTP relay guardians are emailed through (default $TW_SMTP_ADDR)")
+	fs.StringVar(&smtp.Username, "smtp-username", os.Getenv("TW_SMTP_USERNAME"), "`user` to sign in to the SMTP relay as, with $TW_SMTP_PASSWORD (default $TW_SMTP_USERNAME)")
+	smtpFrom := fs.String("smtp-from", os.Getenv("TW_SMTP_FROM"), "`address` guardians are emailed from, as in \"Rivervale Primary <noreply@example.sg>\" (default $TW_SMTP_FROM)")
+	scanCommand := fs.String("scan-command", os.Getenv("TW_SCAN_COMMAND"), "`command` that scans an attachment on its standard input, as in \"clamdscan --no-summary -\" (default $TW_SCAN_COMMAND)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *insightsHour < 0 || *insightsHour > 23 {
+		return errors.New("-insights-hour must be 0 to 23")
+	}
+
+	s := store.New(&store.Dataset{})
+	var save func() error
+	if *data != "" {
+		var err error
+		if s, err = store.Load(*data); err != nil {
+			return err
+		}
+		// The scheduler and the notification dispatcher both save, and
+		// each save must write a newer snapshot than the one before.
+		var saving sync.Mutex
+		save = func() error {
+			saving.Lock()
+			defer saving.Unlock()
+			return s.Save(*data)
+		}
+	} else {
+		slog.Warn("no dataset given; starting with an empty store that is not saved")
+	}
+
+	runner := jobs.New(random.DefaultIDs, time.Now, jobs.DefaultWorkers)
+	defer runner.Close()
+	blobs, err := attachmentBlobs(s3, *attachmentsDir, *data)
+	if err != nil {
+		return err
+	}
+	dispatcher, err := notifier(s, smtp, *smtpFrom, save)
+	if err != nil {
+		return err
+	}
+	var release func(ids ...string)
+	if dispatcher != nil {
+		release = dispatcher.Release
+	}
+	postsSvc := posts.New(s, random.DefaultIDs, time.Now, audit.New(s, time.Now), save, blobs, attachmentScanner(*scanCommand), release)
+	engine := insights.New(s, gradebook.New(s, random.DefaultIDs), postsSvc, time.Now)
+	if *gatewayKeyHash == "" {
+		slog.Warn("no gateway key hash given; Parents Gateway cannot pass on consent responses")
+	}
+	mux := handler.NewMux(handler.Options{Store: s, Insights: engine, Jobs: runner, Posts: postsSvc, GatewayKeyHash: *gatewayKeyHash})
 	srv := &http.Server{
-		Addr:    addr,
+		Addr:    *addr,
 		Handler: middleware.RequestID(middleware.RequestLog(mux)),
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	go engine.RunNightly(ctx, *insightsHour)
+	scheduled := make(chan struct{})
 	go func() {
-		slog.Info("listening", "addr", addr)
+		defer close(scheduled)
+		postsSvc.RunScheduler(ctx, posts.DefaultSchedulerInterval)
+	}()
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		if dispatcher != nil {
+			dispatcher.Run(ctx)
+		}
+	}()
+
+	go func() {
+		slog.Info("listening", "addr", *addr)
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("listen failed", "err", err)
 			os.Exit(1)
@@ -45,8 +165,21 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
+	// Requests still running when the timeout ends are cut off, but what
+	// was done until then is saved all the same.
+	var errs []error
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Error("shutdown failed", "err", err)
-		os.Exit(1)
+		errs = append(errs, fmt.Errorf("shutdown failed: %w", err))
+	}
+	// Let a scheduler run in progress finish and save what it published,
+	// and the dispatcher record what it sent.
+	<-scheduled
+	<-dispatched
+	if save != nil {
+		if err := save(); err != nil {
+			errs = append(errs, fmt.Errorf("saving %s: %w", *data, err))
+		}
 	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/notify"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/email"
+)
+
+// notifier returns the Dispatcher that emails guardians from the address
+// from through the relay cfg names, or nil if it names none.
+func notifier(s *store.Store, cfg email.SMTPConfig, from string, save func() error) (*notify.Dispatcher, error) {
+	if cfg.Addr == "" {
+		slog.Warn("no SMTP relay given; guardians are not emailed when posts are published")
+		return nil, nil
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("-smtp-from must be an email address: %w", err)
+	}
+	relay, err := email.NewSMTP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return notify.New(s, relay, *addr, time.Now, save), nil
+}
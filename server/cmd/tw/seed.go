@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/String-sg/teacher-workspace/server/internal/seed"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// runSeed implements `tw seed`, which writes a synthetic dataset as JSON to
+// the -out file, or to stdout when -out is not set. Every teacher is given a
+// development API key; -keys writes the plaintext keys as CSV.
+func runSeed(args []string, stdout io.Writer) error {
+	cfg := seed.DefaultConfig()
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.Uint64Var(&cfg.Seed, "seed", cfg.Seed, "seed for the random generator; equal seeds yield equal datasets")
+	fs.IntVar(&cfg.Schools, "schools", cfg.Schools, "number of schools")
+	fs.IntVar(&cfg.LevelsPerSchool, "levels", cfg.LevelsPerSchool, "levels per school")
+	fs.IntVar(&cfg.ClassesPerLevel, "classes", cfg.ClassesPerLevel, "classes per level")
+	fs.IntVar(&cfg.StudentsPerClass, "students", cfg.StudentsPerClass, "students per class")
+	fs.IntVar(&cfg.SchoolDays, "days", cfg.SchoolDays, "school days of attendance")
+	fs.IntVar(&cfg.Terms, "terms", cfg.Terms, "terms of assessments")
+	fs.IntVar(&cfg.AssessmentsPerTerm, "assessments", cfg.AssessmentsPerTerm, "assessments per subject per term")
+	fs.IntVar(&cfg.PostsPerTeacher, "posts", cfg.PostsPerTeacher, "posts per teacher")
+	start := fs.String("start", cfg.Start.String(), "first school day (YYYY-MM-DD)")
+	out := fs.String("out", "", "output file (default stdout)")
+	keys := fs.String("keys", "", "file to write each teacher's plaintext API key to, as CSV")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if cfg.Start, err = store.ParseDate(*start); err != nil {
+		return err
+	}
+
+	ds, err := seed.Generate(cfg)
+	if err != nil {
+		return err
+	}
+	creds, err := seed.MintAPIKeys(ds, cfg)
+	if err != nil {
+		return err
+	}
+	if *keys != "" {
+		if err := writeCredentials(*keys, creds); err != nil {
+			return err
+		}
+	}
+
+	if *out == "" {
+		return writeDataset(stdout, ds)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeDataset(f, ds); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeDataset(w io.Writer, ds *store.Dataset) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ds)
+}
+
+func writeCredentials(path string, creds []seed.Credential) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"teacher_id", "email", "role", "api_key"})
+	for _, c := range creds {
+		_ = w.Write([]string{c.TeacherID, c.Email, string(c.Role), c.Token})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,281 @@
+package attendance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+var (
+	// ErrNotFound is returned for classes and students that do not exist or
+	// that the viewer may not see.
+	ErrNotFound = errors.New("attendance: not found")
+	// ErrForbidden is returned when the viewer may see a class but not take
+	// its attendance.
+	ErrForbidden = errors.New("attendance: only the form teacher or a school leader may take attendance")
+	// ErrInvalid wraps every validation failure of a submission.
+	ErrInvalid = errors.New("attendance: invalid submission")
+)
+
+// MaxReasonLength bounds the free-text reason of a mark, in characters.
+const MaxReasonLength = 200
+
+// ParseStatus validates s as an attendance status.
+func ParseStatus(s string) (store.AttendanceStatus, error) {
+	switch v := store.AttendanceStatus(s); v {
+	case store.AttendancePresent, store.AttendanceAbsent, store.AttendanceLate,
+		store.AttendanceMC, store.AttendanceOfficialLeave:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%w: unknown status %q", ErrInvalid, s)
+	}
+}
+
+// Mark is one student's attendance in a class submission.
+type Mark struct {
+	StudentID string                 `json:"student_id"`
+	Status    store.AttendanceStatus `json:"status"`
+	Reason    string                 `json:"reason"`
+}
+
+// Entry is one student's line in a class register. Status is empty when the
+// student's attendance has not been taken.
+type Entry struct {
+	StudentID   string                 `json:"student_id"`
+	Name        string                 `json:"name"`
+	IndexNumber int                    `json:"index_number"`
+	Status      store.AttendanceStatus `json:"status,omitempty"`
+	Reason      string                 `json:"reason,omitempty"`
+}
+
+// Register is a class's attendance for one day.
+type Register struct {
+	ClassID string     `json:"class_id"`
+	Date    store.Date `json:"date"`
+	// Taken counts the students whose attendance has been recorded.
+	Taken    int     `json:"taken"`
+	Students []Entry `json:"students"`
+}
+
+// StudentSummary is one student's line in a class summary.
+type StudentSummary struct {
+	StudentID   string `json:"student_id"`
+	Name        string `json:"name"`
+	IndexNumber int    `json:"index_number"`
+	Summary
+}
+
+// ClassSummary summarises a class's attendance over a period.
+type ClassSummary struct {
+	ClassID string `json:"class_id"`
+	// Days counts the distinct school days with any record.
+	Days int `json:"days"`
+	// Rate is the share of all student-days attended, as in Summary.
+	Rate     float64          `json:"rate"`
+	Students []StudentSummary `json:"students"`
+}
+
+// StudentAttendance is one student's records and summary over a period.
+type StudentAttendance struct {
+	StudentID string             `json:"student_id"`
+	Summary   Summary            `json:"summary"`
+	Records   []store.Attendance `json:"records"`
+}
+
+// Period bounds a summary. Zero fields leave that end open.
+type Period struct {
+	From store.Date
+	To   store.Date
+}
+
+// Contains reports whether d falls within p.
+func (p Period) Contains(d store.Date) bool {
+	return (p.From.IsZero() || !d.Before(p.From)) && (p.To.IsZero() || !d.After(p.To))
+}
+
+// Service records and summarises attendance, limited to what the viewing
+// teacher may see and change.
+type Service struct {
+	store *store.Store
+	now   func() time.Time
+}
+
+// New returns a Service over s. now supplies the time recorded against
+// submissions and the date after which attendance cannot be taken.
+func New(s *store.Store, now func() time.Time) *Service {
+	return &Service{store: s, now: now}
+}
+
+// Register returns the attendance of class classID on date.
+func (s *Service) Register(scope authz.Scope, classID string, date store.Date) (Register, error) {
+	if _, err := s.class(scope, classID); err != nil {
+		return Register{}, err
+	}
+	return s.register(scope, classID, date), nil
+}
+
+// Submit records marks for class classID on date as the viewing teacher and
+// returns the updated register. Marks for students already taken that day
+// are late edits and are kept in the class's audit trail. Submissions are
+// validated as a whole, so either every mark is recorded or none is.
+func (s *Service) Submit(scope authz.Scope, classID string, date store.Date, marks []Mark) (Register, error) {
+	c, err := s.class(scope, classID)
+	if err != nil {
+		return Register{}, err
+	}
+	if !scope.CanTakeAttendance(c) {
+		return Register{}, ErrForbidden
+	}
+
+	now := s.now()
+	if date.After(store.DateOf(now)) {
+		return Register{}, fmt.Errorf("%w: %s is in the future", ErrInvalid, date)
+	}
+	if len(marks) == 0 {
+		return Register{}, fmt.Errorf("%w: no marks", ErrInvalid)
+	}
+
+	records := make([]store.Attendance, 0, len(marks))
+	seen := make(map[string]bool, len(marks))
+	for _, m := range marks {
+		st, ok := s.store.Student(m.StudentID)
+		if !ok || st.ClassID != classID {
+			return Register{}, fmt.Errorf("%w: student %q is not in class", ErrInvalid, m.StudentID)
+		}
+		if seen[m.StudentID] {
+			return Register{}, fmt.Errorf("%w: student %q is marked twice", ErrInvalid, m.StudentID)
+		}
+		seen[m.StudentID] = true
+
+		status, err := ParseStatus(string(m.Status))
+		if err != nil {
+			return Register{}, err
+		}
+		reason := strings.TrimSpace(m.Reason)
+		if len([]rune(reason)) > MaxReasonLength {
+			return Register{}, fmt.Errorf("%w: reason is longer than %d characters", ErrInvalid, MaxReasonLength)
+		}
+		records = append(records, store.Attendance{
+			StudentID: m.StudentID,
+			ClassID:   classID,
+			Date:      date,
+			Status:    status,
+			Reason:    reason,
+		})
+	}
+
+	s.store.PutAttendance(records, scope.Teacher.ID, now)
+	return s.register(scope, classID, date), nil
+}
+
+// History returns the audit trail of late edits to class classID's attendance
+// on date, oldest first.
+func (s *Service) History(scope authz.Scope, classID string, date store.Date) ([]store.AttendanceEdit, error) {
+	if _, err := s.class(scope, classID); err != nil {
+		return nil, err
+	}
+
+	edits := s.store.AttendanceEdits(classID, date)
+	out := edits[:0]
+	for _, e := range edits {
+		if st, ok := s.store.Student(e.StudentID); ok && scope.CanViewStudent(st) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Class summarises the attendance of every student in class classID the
+// viewer may see, over period p.
+func (s *Service) Class(scope authz.Scope, classID string, p Period) (ClassSummary, error) {
+	if _, err := s.class(scope, classID); err != nil {
+		return ClassSummary{}, err
+	}
+
+	out := ClassSummary{ClassID: classID, Students: []StudentSummary{}}
+	days := make(map[store.Date]bool)
+	var attended, total int
+	for _, st := range s.store.StudentsInClass(classID) {
+		if !scope.CanViewStudent(st) {
+			continue
+		}
+		records := s.records(st.ID, p, classID)
+		for _, r := range records {
+			days[r.Date] = true
+		}
+		sum := Summarize(records)
+		attended += sum.Present + sum.Late + sum.OfficialLeave
+		total += sum.Days
+		out.Students = append(out.Students, StudentSummary{
+			StudentID:   st.ID,
+			Name:        st.Name,
+			IndexNumber: st.IndexNumber,
+			Summary:     sum,
+		})
+	}
+
+	out.Days = len(days)
+	out.Rate = 1
+	if total > 0 {
+		out.Rate = math.Round(float64(attended)/float64(total)*1000) / 1000
+	}
+	return out, nil
+}
+
+// Student returns the attendance of student studentID over period p.
+func (s *Service) Student(scope authz.Scope, studentID string, p Period) (StudentAttendance, error) {
+	st, ok := s.store.Student(studentID)
+	if !ok || !scope.CanViewStudent(st) {
+		return StudentAttendance{}, ErrNotFound
+	}
+
+	records := s.records(studentID, p, "")
+	return StudentAttendance{StudentID: studentID, Summary: Summarize(records), Records: records}, nil
+}
+
+func (s *Service) class(scope authz.Scope, id string) (store.Class, error) {
+	c, ok := s.store.Class(id)
+	if !ok || !scope.CanViewClass(c) {
+		return store.Class{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Service) register(scope authz.Scope, classID string, date store.Date) Register {
+	taken := make(map[string]store.Attendance)
+	for _, r := range s.store.AttendanceOn(classID, date) {
+		taken[r.StudentID] = r
+	}
+
+	reg := Register{ClassID: classID, Date: date, Students: []Entry{}}
+	for _, st := range s.store.StudentsInClass(classID) {
+		if !scope.CanViewStudent(st) {
+			continue
+		}
+		e := Entry{StudentID: st.ID, Name: st.Name, IndexNumber: st.IndexNumber}
+		if r, ok := taken[st.ID]; ok {
+			e.Status, e.Reason = r.Status, r.Reason
+			reg.Taken++
+		}
+		reg.Students = append(reg.Students, e)
+	}
+	return reg
+}
+
+// records returns a student's records within p, ordered by date. A non-empty
+// classID limits them to that class.
+func (s *Service) records(studentID string, p Period, classID string) []store.Attendance {
+	all := s.store.AttendanceForStudent(studentID)
+	out := all[:0]
+	for _, r := range all {
+		if p.Contains(r.Date) && (classID == "" || r.ClassID == classID) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
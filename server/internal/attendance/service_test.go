@@ -0,0 +1,170 @@
+package attendance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+var monday = store.Date{Year: 2026, Month: time.January, Day: 5}
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Classes: []store.Class{
+			{ID: "1A", SchoolID: "s1", FormTeacherID: "form"},
+			{ID: "1B", SchoolID: "s1"},
+		},
+		Teaching: []store.Teaching{{TeacherID: "tamil", ClassID: "1A", Subject: "Tamil"}},
+		Students: []store.Student{
+			{ID: "a", SchoolID: "s1", ClassID: "1A", IndexNumber: 1, Name: "Arjun", Subjects: []string{"Tamil"}},
+			{ID: "b", SchoolID: "s1", ClassID: "1A", IndexNumber: 2, Name: "Bee Ling", Subjects: []string{"Chinese"}},
+			{ID: "c", SchoolID: "s1", ClassID: "1B", IndexNumber: 1, Name: "Chloe"},
+		},
+	})
+	now := func() time.Time { return time.Date(2026, time.January, 7, 8, 0, 0, 0, time.UTC) }
+	return New(s, now), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+func TestSubmit(t *testing.T) {
+	t.Run("records a whole class and reports the register", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		reg, err := svc.Submit(form, "1A", monday, []Mark{
+			{StudentID: "a", Status: store.AttendancePresent},
+			{StudentID: "b", Status: store.AttendanceMC, Reason: "  Fever "},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, reg.Taken)
+		require.Equal(t, store.AttendanceMC, reg.Students[1].Status)
+		require.Equal(t, "Fever", reg.Students[1].Reason)
+	})
+
+	t.Run("late edits are audited", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		leader := scopeOf(s, "leader", store.RoleSchoolLeader)
+		_, err := svc.Submit(form, "1A", monday, []Mark{{StudentID: "a", Status: store.AttendanceAbsent}})
+		require.NoError(t, err)
+
+		_, err = svc.Submit(leader, "1A", monday, []Mark{{StudentID: "a", Status: store.AttendanceLate, Reason: "Bus delay"}})
+		require.NoError(t, err)
+
+		history, err := svc.History(form, "1A", monday)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(history))
+		require.Equal(t, store.AttendanceAbsent, history[0].FromStatus)
+		require.Equal(t, store.AttendanceLate, history[0].ToStatus)
+		require.Equal(t, "leader", history[0].EditedBy)
+	})
+
+	t.Run("rejects invalid submissions as a whole", func(t *testing.T) {
+		cases := []struct {
+			name  string
+			date  store.Date
+			marks []Mark
+		}{
+			{name: "no marks", date: monday},
+			{name: "future date", date: monday.AddDays(3), marks: []Mark{{StudentID: "a", Status: store.AttendancePresent}}},
+			{name: "student in another class", date: monday, marks: []Mark{{StudentID: "c", Status: store.AttendancePresent}}},
+			{name: "unknown status", date: monday, marks: []Mark{{StudentID: "a", Status: "sick"}}},
+			{name: "duplicate student", date: monday, marks: []Mark{
+				{StudentID: "a", Status: store.AttendancePresent},
+				{StudentID: "a", Status: store.AttendanceAbsent},
+			}},
+			{name: "valid mark before an invalid one", date: monday, marks: []Mark{
+				{StudentID: "b", Status: store.AttendancePresent},
+				{StudentID: "c", Status: store.AttendancePresent},
+			}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				svc, s := newTestService(t)
+
+				_, err := svc.Submit(scopeOf(s, "form", store.RoleTeacher), "1A", tc.date, tc.marks)
+
+				require.True(t, errors.Is(err, ErrInvalid))
+				require.Equal(t, 0, len(s.AttendanceOn("1A", tc.date)))
+			})
+		}
+	})
+
+	t.Run("subject teachers may not take attendance", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.Submit(scopeOf(s, "tamil", store.RoleTeacher), "1A", monday, []Mark{{StudentID: "a", Status: store.AttendancePresent}})
+
+		require.True(t, errors.Is(err, ErrForbidden))
+	})
+
+	t.Run("classes outside scope are not found", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.Submit(scopeOf(s, "form", store.RoleTeacher), "1B", monday, []Mark{{StudentID: "c", Status: store.AttendancePresent}})
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestClassSummary(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+	days := [][2]store.AttendanceStatus{
+		{store.AttendancePresent, store.AttendanceAbsent},
+		{store.AttendanceLate, store.AttendanceAbsent},
+		{store.AttendancePresent, store.AttendanceMC},
+	}
+	for i, d := range days {
+		_, err := svc.Submit(form, "1A", monday.AddDays(i), []Mark{
+			{StudentID: "a", Status: d[0]},
+			{StudentID: "b", Status: d[1]},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("summarises each student and the class", func(t *testing.T) {
+		sum, err := svc.Class(form, "1A", Period{})
+
+		require.NoError(t, err)
+		require.Equal(t, 3, sum.Days)
+		require.Equal(t, 0.5, sum.Rate)
+		require.Equal(t, 1.0, sum.Students[0].Rate)
+		require.Equal(t, 3, sum.Students[1].ConsecutiveAbsences)
+	})
+
+	t.Run("limits to the period", func(t *testing.T) {
+		sum, err := svc.Class(form, "1A", Period{From: monday.AddDays(1), To: monday.AddDays(1)})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, sum.Days)
+		require.Equal(t, 1, sum.Students[1].Days)
+	})
+
+	t.Run("subject teachers see only their students", func(t *testing.T) {
+		sum, err := svc.Class(scopeOf(s, "tamil", store.RoleTeacher), "1A", Period{})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(sum.Students))
+		require.Equal(t, "a", sum.Students[0].StudentID)
+	})
+
+	t.Run("student attendance lists records in date order", func(t *testing.T) {
+		sa, err := svc.Student(form, "b", Period{})
+
+		require.NoError(t, err)
+		require.Equal(t, 3, len(sa.Records))
+		require.Equal(t, monday, sa.Records[0].Date)
+		require.Equal(t, 3, sa.Summary.LongestAbsence)
+	})
+}
@@ -0,0 +1,63 @@
+// Package attendance records the daily attendance form teachers take for their
+// classes and summarises it per student and per class.
+package attendance
+
+import (
+	"math"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Summary counts a student's attendance over a period.
+type Summary struct {
+	Days          int `json:"days"`
+	Present       int `json:"present"`
+	Late          int `json:"late"`
+	Absent        int `json:"absent"`
+	MC            int `json:"mc"`
+	OfficialLeave int `json:"official_leave"`
+	// Rate is the share of days the student was in school or away on
+	// official school business, rounded to three decimal places. It is 1
+	// when no days were recorded.
+	Rate float64 `json:"rate"`
+	// ConsecutiveAbsences is the number of school days, up to and including
+	// the last recorded one, that the student has been absent or on MC in a
+	// row. LongestAbsence is the longest such run in the period.
+	ConsecutiveAbsences int `json:"consecutive_absences"`
+	LongestAbsence      int `json:"longest_absence"`
+}
+
+// Summarize counts records, which must all belong to one student and be
+// ordered by date.
+func Summarize(records []store.Attendance) Summary {
+	var s Summary
+	for _, r := range records {
+		s.Days++
+		switch r.Status {
+		case store.AttendancePresent:
+			s.Present++
+		case store.AttendanceLate:
+			s.Late++
+		case store.AttendanceAbsent:
+			s.Absent++
+		case store.AttendanceMC:
+			s.MC++
+		case store.AttendanceOfficialLeave:
+			s.OfficialLeave++
+		}
+
+		if r.Status == store.AttendanceAbsent || r.Status == store.AttendanceMC {
+			s.ConsecutiveAbsences++
+			s.LongestAbsence = max(s.LongestAbsence, s.ConsecutiveAbsences)
+		} else {
+			s.ConsecutiveAbsences = 0
+		}
+	}
+
+	s.Rate = 1
+	if s.Days > 0 {
+		attended := float64(s.Present + s.Late + s.OfficialLeave)
+		s.Rate = math.Round(attended/float64(s.Days)*1000) / 1000
+	}
+	return s
+}
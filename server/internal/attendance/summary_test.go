@@ -0,0 +1,45 @@
+package attendance
+
+import (
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Run("counts each status and rates attended days", func(t *testing.T) {
+		statuses := []store.AttendanceStatus{
+			store.AttendancePresent, store.AttendancePresent, store.AttendanceLate,
+			store.AttendanceAbsent, store.AttendanceMC, store.AttendanceOfficialLeave,
+		}
+		var records []store.Attendance
+		for _, s := range statuses {
+			records = append(records, store.Attendance{Status: s})
+		}
+
+		got := Summarize(records)
+
+		require.Equal(t, Summary{Days: 6, Present: 2, Late: 1, Absent: 1, MC: 1, OfficialLeave: 1, Rate: 0.667, LongestAbsence: 2}, got)
+	})
+
+	t.Run("tracks current and longest runs of absence", func(t *testing.T) {
+		var records []store.Attendance
+		for _, c := range "AAMPLAAAPOMA" {
+			status := map[rune]store.AttendanceStatus{
+				'P': store.AttendancePresent, 'L': store.AttendanceLate, 'A': store.AttendanceAbsent,
+				'M': store.AttendanceMC, 'O': store.AttendanceOfficialLeave,
+			}[c]
+			records = append(records, store.Attendance{Status: status})
+		}
+
+		got := Summarize(records)
+
+		require.Equal(t, 2, got.ConsecutiveAbsences)
+		require.Equal(t, 3, got.LongestAbsence)
+	})
+
+	t.Run("rate is 1 with no records", func(t *testing.T) {
+		require.Equal(t, 1.0, Summarize(nil).Rate)
+	})
+}
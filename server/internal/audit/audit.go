@@ -0,0 +1,81 @@
+// Package audit records who accessed or changed which records, so that
+// access to sensitive information can be reviewed later.
+package audit
+
+import (
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Actions written to the log.
+const (
+	ActionNoteRead   = "note.read"
+	ActionNoteCreate = "note.create"
+	ActionNoteUpdate = "note.update"
+	ActionNoteDelete = "note.delete"
+
+	ActionRosterImport = "roster.import"
+
+	ActionExportClassList  = "export.class_list"
+	ActionExportAttendance = "export.attendance"
+	ActionExportGradebook  = "export.gradebook"
+	ActionExportNonReaders = "export.non_readers"
+
+	ActionReportStudent = "report.student"
+	ActionReportClass   = "report.class"
+
+	ActionPostPublish    = "post.publish"
+	ActionPostDelete     = "post.delete"
+	ActionPostSchedule   = "post.schedule"
+	ActionPostUnschedule = "post.unschedule"
+	ActionPostSubmit     = "post.submit"
+	ActionPostWithdraw   = "post.withdraw"
+	ActionPostApprove    = "post.approve"
+	ActionPostReject     = "post.reject"
+
+	ActionPostAttach         = "post.attach"
+	ActionPostAttachRejected = "post.attach_rejected"
+	ActionPostDetach         = "post.detach"
+)
+
+// Event is one action on one record.
+type Event struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Detail     string
+}
+
+// Log appends entries to the store's audit log.
+type Log struct {
+	store *store.Store
+	now   func() time.Time
+}
+
+// New returns a Log writing to s and timestamped by now.
+func New(s *store.Store, now func() time.Time) *Log {
+	return &Log{store: s, now: now}
+}
+
+// Record writes events performed by the teacher with ID actorID, all with
+// the same timestamp.
+func (l *Log) Record(actorID string, events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	at := l.now().UTC()
+	entries := make([]store.AuditEntry, len(events))
+	for i, e := range events {
+		entries[i] = store.AuditEntry{
+			At:         at,
+			ActorID:    actorID,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			Detail:     e.Detail,
+		}
+	}
+	l.store.AppendAudit(entries...)
+}
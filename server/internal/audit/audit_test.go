@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestRecord(t *testing.T) {
+	s := store.New(&store.Dataset{})
+	at := time.Date(2026, time.March, 2, 17, 0, 0, 0, time.FixedZone("SGT", 8*60*60))
+	log := New(s, func() time.Time { return at })
+
+	t.Run("writes every event with one UTC timestamp", func(t *testing.T) {
+		log.Record("t1",
+			Event{Action: ActionNoteRead, TargetType: "note", TargetID: "n1"},
+			Event{Action: ActionNoteRead, TargetType: "note", TargetID: "n2"},
+		)
+
+		entries := s.AuditLog()
+		require.Equal(t, 2, len(entries))
+		require.Equal(t, "n2", entries[1].TargetID)
+		require.Equal(t, "t1", entries[1].ActorID)
+		require.Equal(t, time.UTC, entries[0].At.Location())
+		require.True(t, entries[0].At.Equal(at))
+	})
+
+	t.Run("writes nothing without events", func(t *testing.T) {
+		log.Record("t1")
+
+		require.Equal(t, 2, len(s.AuditLog()))
+	})
+}
@@ -0,0 +1,118 @@
+// Package authz decides which classes and students a teacher may see.
+//
+// School leaders see every class in their school. Form teachers see every
+// student in their form class. Subject teachers see the students in the
+// classes they teach who take the subject they teach. Access never crosses
+// school boundaries.
+package authz
+
+import (
+	"slices"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Scope is the set of classes and students visible to one teacher. It is a
+// snapshot taken when the Scope is built.
+type Scope struct {
+	Teacher store.Teacher
+
+	schoolWide bool
+	form       map[string]bool
+	subjects   map[string][]string
+}
+
+// For returns the scope of teacher t.
+func For(s *store.Store, t store.Teacher) Scope {
+	sc := Scope{
+		Teacher:    t,
+		schoolWide: t.Role == store.RoleSchoolLeader,
+		form:       make(map[string]bool),
+		subjects:   make(map[string][]string),
+	}
+	for _, c := range s.Classes(t.SchoolID) {
+		if c.FormTeacherID == t.ID {
+			sc.form[c.ID] = true
+		}
+	}
+	for _, tg := range s.TeachingByTeacher(t.ID) {
+		sc.subjects[tg.ClassID] = append(sc.subjects[tg.ClassID], tg.Subject)
+	}
+	return sc
+}
+
+// SchoolWide reports whether the teacher may see every class in their school.
+func (sc Scope) SchoolWide() bool {
+	return sc.schoolWide
+}
+
+// IsFormTeacher reports whether the teacher is the form teacher of classID.
+func (sc Scope) IsFormTeacher(classID string) bool {
+	return sc.form[classID]
+}
+
+// Subjects returns the subjects the teacher teaches in classID.
+func (sc Scope) Subjects(classID string) []string {
+	return slices.Clone(sc.subjects[classID])
+}
+
+// CanViewClass reports whether the teacher may see class c and at least part
+// of its roster.
+func (sc Scope) CanViewClass(c store.Class) bool {
+	if c.SchoolID != sc.Teacher.SchoolID {
+		return false
+	}
+	return sc.schoolWide || sc.form[c.ID] || len(sc.subjects[c.ID]) > 0
+}
+
+// CanViewStudent reports whether the teacher may see student st.
+func (sc Scope) CanViewStudent(st store.Student) bool {
+	if st.SchoolID != sc.Teacher.SchoolID {
+		return false
+	}
+	if sc.schoolWide || sc.form[st.ClassID] {
+		return true
+	}
+	for _, subject := range sc.subjects[st.ClassID] {
+		if slices.Contains(st.Subjects, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTakeAttendance reports whether the teacher may record attendance for
+// class c: its form teacher, or a leader of its school.
+func (sc Scope) CanTakeAttendance(c store.Class) bool {
+	if c.SchoolID != sc.Teacher.SchoolID {
+		return false
+	}
+	return sc.schoolWide || sc.form[c.ID]
+}
+
+// CanReadNote reports whether the teacher may read note n about student st.
+// Authors always read their own notes; others need the visibility level
+// that includes them.
+func (sc Scope) CanReadNote(st store.Student, n store.Note) bool {
+	if n.StudentID != st.ID || !sc.CanViewStudent(st) {
+		return false
+	}
+	if n.AuthorID == sc.Teacher.ID {
+		return true
+	}
+	switch n.Visibility {
+	case store.NoteFormTeacher:
+		return sc.form[st.ClassID]
+	case store.NoteSchoolLeaders:
+		return sc.form[st.ClassID] || sc.schoolWide
+	default:
+		return false
+	}
+}
+
+// CanViewSubject reports whether the teacher may see marks and results for
+// subject in class classID: leaders and form teachers see every subject,
+// subject teachers only their own.
+func (sc Scope) CanViewSubject(classID, subject string) bool {
+	return sc.schoolWide || sc.form[classID] || slices.Contains(sc.subjects[classID], subject)
+}
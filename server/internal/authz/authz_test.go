@@ -0,0 +1,101 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestScope(t *testing.T) {
+	s := store.New(&store.Dataset{
+		Classes: []store.Class{
+			{ID: "1A", SchoolID: "s1", FormTeacherID: "form"},
+			{ID: "1B", SchoolID: "s1"},
+			{ID: "X", SchoolID: "s2"},
+		},
+		Teaching: []store.Teaching{
+			{TeacherID: "form", ClassID: "1A", Subject: "English"},
+			{TeacherID: "chinese", ClassID: "1A", Subject: "Chinese"},
+			{TeacherID: "chinese", ClassID: "1B", Subject: "Chinese"},
+		},
+	})
+
+	leader := For(s, store.Teacher{ID: "leader", SchoolID: "s1", Role: store.RoleSchoolLeader})
+	form := For(s, store.Teacher{ID: "form", SchoolID: "s1", Role: store.RoleTeacher})
+	chinese := For(s, store.Teacher{ID: "chinese", SchoolID: "s1", Role: store.RoleTeacher})
+
+	tamilIn1A := store.Student{SchoolID: "s1", ClassID: "1A", Subjects: []string{"English", "Tamil"}}
+	chineseIn1B := store.Student{SchoolID: "s1", ClassID: "1B", Subjects: []string{"English", "Chinese"}}
+	otherSchool := store.Student{SchoolID: "s2", ClassID: "X", Subjects: []string{"Chinese"}}
+
+	cases := []struct {
+		name    string
+		scope   Scope
+		student store.Student
+		want    bool
+	}{
+		{name: "leader sees any student in the school", scope: leader, student: chineseIn1B, want: true},
+		{name: "leader does not see another school", scope: leader, student: otherSchool, want: false},
+		{name: "form teacher sees every student in the form class", scope: form, student: tamilIn1A, want: true},
+		{name: "form teacher does not see other classes", scope: form, student: chineseIn1B, want: false},
+		{name: "subject teacher sees students taking the subject", scope: chinese, student: chineseIn1B, want: true},
+		{name: "subject teacher does not see students not taking the subject", scope: chinese, student: tamilIn1A, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.scope.CanViewStudent(tc.student))
+		})
+	}
+
+	t.Run("CanViewClass follows form and teaching assignments", func(t *testing.T) {
+		c1A, _ := s.Class("1A")
+		c1B, _ := s.Class("1B")
+
+		require.True(t, form.CanViewClass(c1A))
+		require.False(t, form.CanViewClass(c1B))
+		require.True(t, chinese.CanViewClass(c1B))
+		require.True(t, form.IsFormTeacher("1A"))
+		require.False(t, leader.IsFormTeacher("1A"))
+	})
+
+	t.Run("CanTakeAttendance is limited to form teachers and leaders", func(t *testing.T) {
+		c1A, _ := s.Class("1A")
+		x, _ := s.Class("X")
+
+		require.True(t, form.CanTakeAttendance(c1A))
+		require.True(t, leader.CanTakeAttendance(c1A))
+		require.False(t, leader.CanTakeAttendance(x))
+		require.False(t, chinese.CanTakeAttendance(c1A))
+	})
+
+	t.Run("CanReadNote widens with each visibility level", func(t *testing.T) {
+		st := store.Student{ID: "st", SchoolID: "s1", ClassID: "1A", Subjects: []string{"English", "Chinese"}}
+		note := func(v store.NoteVisibility) store.Note {
+			return store.Note{StudentID: "st", AuthorID: "chinese", Visibility: v}
+		}
+
+		require.True(t, chinese.CanReadNote(st, note(store.NotePrivate)))
+		require.False(t, form.CanReadNote(st, note(store.NotePrivate)))
+		require.False(t, leader.CanReadNote(st, note(store.NotePrivate)))
+
+		require.True(t, form.CanReadNote(st, note(store.NoteFormTeacher)))
+		require.False(t, leader.CanReadNote(st, note(store.NoteFormTeacher)))
+
+		require.True(t, form.CanReadNote(st, note(store.NoteSchoolLeaders)))
+		require.True(t, leader.CanReadNote(st, note(store.NoteSchoolLeaders)))
+	})
+
+	t.Run("CanReadNote requires access to the student", func(t *testing.T) {
+		moved := store.Student{ID: "st", SchoolID: "s1", ClassID: "1B", Subjects: []string{"English"}}
+
+		require.False(t, chinese.CanReadNote(moved, store.Note{StudentID: "st", AuthorID: "chinese", Visibility: store.NotePrivate}))
+	})
+
+	t.Run("CanViewSubject limits subject teachers to their subjects", func(t *testing.T) {
+		require.True(t, leader.CanViewSubject("1B", "Tamil"))
+		require.True(t, form.CanViewSubject("1A", "Tamil"))
+		require.True(t, chinese.CanViewSubject("1B", "Chinese"))
+		require.False(t, chinese.CanViewSubject("1B", "English"))
+	})
+}
@@ -0,0 +1,178 @@
+// Package export turns class lists, attendance registers, gradebooks and
+// the guardians who have not read a post into tables that download as CSV
+// or XLSX spreadsheets. Rows are produced as they are written, so a large
+// export is never held in memory, and every export is recorded in the
+// audit log.
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/xlsx"
+)
+
+var (
+	// ErrNotFound is returned for classes that do not exist or that the
+	// viewer may not see.
+	ErrNotFound = errors.New("export: not found")
+	// ErrInvalid is returned for unknown formats.
+	ErrInvalid = errors.New("export: invalid request")
+)
+
+// Format is a spreadsheet file format.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat validates s as a format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatCSV, FormatXLSX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format %q", ErrInvalid, s)
+	}
+}
+
+// FormatOf returns the format with the given media type.
+func FormatOf(mediaType string) (Format, bool) {
+	for _, f := range []Format{FormatCSV, FormatXLSX} {
+		if strings.EqualFold(mediaType, f.ContentType()) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// ContentType returns the media type of f.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+// Table is an export ready to be written.
+type Table struct {
+	// Name describes the export, as in "1A class list". It names the
+	// worksheet and the downloaded file.
+	Name   string
+	Header []string
+	// Rows yields the rows under Header, reading the store as it goes.
+	Rows iter.Seq[[]string]
+
+	event audit.Event
+}
+
+// Service builds exports from a Store, limited to what the viewing teacher
+// may see.
+type Service struct {
+	store  *store.Store
+	grades *gradebook.Service
+	posts  *posts.Service
+	log    *audit.Log
+}
+
+// New returns a Service over s that reads results from grades and post
+// receipts from ps, and records exports in log.
+func New(s *store.Store, grades *gradebook.Service, ps *posts.Service, log *audit.Log) *Service {
+	return &Service{store: s, grades: grades, posts: ps, log: log}
+}
+
+// Write records the export of t by the viewer and writes it to w in format
+// f. An error part way through leaves w holding a truncated file. No cell is
+// written as a formula: CSV cells that would read as one are escaped, and
+// XLSX stores text as inline strings.
+func (s *Service) Write(scope authz.Scope, w io.Writer, f Format, t Table) error {
+	var out interface {
+		Write(row []string) error
+		Close() error
+	}
+	switch f {
+	case FormatCSV:
+		out = csvWriter{csv.NewWriter(w)}
+	case FormatXLSX:
+		out = xlsx.NewWriter(w, t.Name)
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalid, f)
+	}
+
+	e := t.event
+	e.Detail = strings.TrimSuffix(string(f)+", "+e.Detail, ", ")
+	s.log.Record(scope.Teacher.ID, e)
+
+	if f == FormatCSV {
+		// Spreadsheet applications read CSV as UTF-8 only after a byte
+		// order mark; without one, names with accents come out garbled.
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+	}
+	if err := out.Write(t.Header); err != nil {
+		return err
+	}
+	for row := range t.Rows {
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// csvWriter writes rows as CSV. Spreadsheet applications run a cell that
+// starts with =, +, - or @ as a formula, so such cells, which may hold a
+// name or note anyone could have typed, are written behind an apostrophe to
+// be read as text. Numbers, such as negative marks, are left alone.
+type csvWriter struct{ *csv.Writer }
+
+func (w csvWriter) Write(row []string) error {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = v
+		if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				out[i] = "'" + v
+			}
+		}
+	}
+	return w.Writer.Write(out)
+}
+
+func (w csvWriter) Close() error {
+	w.Flush()
+	return w.Error()
+}
+
+func (s *Service) class(scope authz.Scope, id string) (store.Class, error) {
+	c, ok := s.store.Class(id)
+	if !ok || !scope.CanViewClass(c) {
+		return store.Class{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// students yields the students of class c the viewer may see, in index
+// number order.
+func (s *Service) students(scope authz.Scope, c store.Class) iter.Seq[store.Student] {
+	return func(yield func(store.Student) bool) {
+		for _, st := range s.store.StudentsInClass(c.ID) {
+			if scope.CanViewStudent(st) && !yield(st) {
+				return
+			}
+		}
+	}
+}
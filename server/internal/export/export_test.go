@@ -0,0 +1,283 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/xlsx"
+)
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Schools: []store.School{{ID: "s1"}},
+		Classes: []store.Class{{ID: "c1", SchoolID: "s1", Name: "1A", FormTeacherID: "form"}},
+		Teaching: []store.Teaching{
+			{TeacherID: "maths", ClassID: "c1", Subject: "Mathematics"},
+			{TeacherID: "english", ClassID: "c1", Subject: "English"},
+		},
+		Students: []store.Student{
+			{
+				ID: "a", SchoolID: "s1", ClassID: "c1", IndexNumber: 1, Name: "Tan Wei Ming", ExternalID: "T1",
+				Subjects:  []string{"English", "Mathematics"},
+				Guardians: []store.GuardianLink{{GuardianID: "g1", Relationship: "Father"}},
+			},
+			{ID: "b", SchoolID: "s1", ClassID: "c1", IndexNumber: 2, Name: "Chloe D'Souza", Subjects: []string{"English"}},
+		},
+		Guardians: []store.Guardian{{ID: "g1", Name: "Tan Ah Kow", Email: "ahkow@example.com", Phone: "91234567"}},
+		Assessments: []store.Assessment{
+			{ID: "m1", ClassID: "c1", Subject: "Mathematics", Name: "Test 1", Term: 1, MaxMarks: 50, Weight: 1},
+			{ID: "e1", ClassID: "c1", Subject: "English", Name: "Essay", Term: 1, MaxMarks: 40, Weight: 1},
+		},
+		Scores: []store.Score{
+			{AssessmentID: "m1", StudentID: "a", Marks: 40},
+			{AssessmentID: "e1", StudentID: "a", Marks: 30},
+			{AssessmentID: "e1", StudentID: "b", Status: store.ScoreAbsent},
+		},
+		Attendance: []store.Attendance{
+			{StudentID: "a", ClassID: "c1", Date: store.Date{Year: 2026, Month: time.January, Day: 5}, Status: store.AttendancePresent},
+			{StudentID: "a", ClassID: "c1", Date: store.Date{Year: 2026, Month: time.January, Day: 6}, Status: store.AttendanceMC},
+			{StudentID: "b", ClassID: "c1", Date: store.Date{Year: 2026, Month: time.January, Day: 6}, Status: store.AttendanceLate},
+		},
+	})
+	now := func() time.Time { return time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC) }
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	log := audit.New(s, now)
+	return New(s, gradebook.New(s, ids), posts.New(s, ids, now, log, nil, nil, nil, nil), log), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+// writeCSV writes t as CSV and reads it back.
+func writeCSV(t *testing.T, svc *Service, scope authz.Scope, table Table) [][]string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.Write(scope, &buf, FormatCSV, table))
+	require.True(t, strings.HasPrefix(buf.String(), "\ufeff"))
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestClassList(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+
+	t.Run("lists students with their first guardian", func(t *testing.T) {
+		table, err := svc.ClassList(form, "c1")
+		require.NoError(t, err)
+
+		rows := writeCSV(t, svc, form, table)
+
+		require.Equal(t, 3, len(rows))
+		require.Equal(t, "Tan Wei Ming", rows[1][1])
+		require.Equal(t, "English; Mathematics", rows[1][5])
+		require.Equal(t, "ahkow@example.com", rows[1][8])
+		require.Equal(t, "", rows[2][6])
+	})
+
+	t.Run("records the export", func(t *testing.T) {
+		log := s.AuditLog()
+		last := log[len(log)-1]
+
+		require.Equal(t, audit.ActionExportClassList, last.Action)
+		require.Equal(t, "form", last.ActorID)
+		require.Equal(t, "c1", last.TargetID)
+		require.Equal(t, "csv", last.Detail)
+	})
+
+	t.Run("hides classes the viewer may not see", func(t *testing.T) {
+		_, err := svc.ClassList(scopeOf(s, "stranger", store.RoleTeacher), "c1")
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestAttendance(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+
+	t.Run("writes a column per day taken", func(t *testing.T) {
+		table, err := svc.Attendance(form, "c1", attendance.Period{})
+		require.NoError(t, err)
+
+		rows := writeCSV(t, svc, form, table)
+
+		require.Equal(t, "2026-01-05", rows[0][2])
+		require.Equal(t, "2026-01-06", rows[0][3])
+		require.Equal(t, "Present", rows[1][2])
+		require.Equal(t, "MC", rows[1][3])
+		require.Equal(t, "", rows[2][2])
+		require.Equal(t, "Late", rows[2][3])
+		require.Equal(t, "1", rows[2][5])
+	})
+
+	t.Run("limits the register to the period", func(t *testing.T) {
+		jan6 := store.Date{Year: 2026, Month: time.January, Day: 6}
+		table, err := svc.Attendance(form, "c1", attendance.Period{From: jan6})
+		require.NoError(t, err)
+
+		rows := writeCSV(t, svc, form, table)
+
+		require.Equal(t, "2026-01-06", rows[0][2])
+		require.Equal(t, "Present", rows[0][3])
+		log := s.AuditLog()
+		require.Equal(t, "csv, from 2026-01-06", log[len(log)-1].Detail)
+	})
+}
+
+func TestGradebook(t *testing.T) {
+	svc, s := newTestService(t)
+
+	t.Run("writes marks and results by subject", func(t *testing.T) {
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		table, err := svc.Gradebook(leader, "c1", "")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.Write(leader, &buf, FormatXLSX, table))
+		r := bytes.NewReader(buf.Bytes())
+		rows, err := xlsx.ReadRows(r, r.Size())
+		require.NoError(t, err)
+
+		require.Equal(t, "English: Essay (Term 1, out of 40)", rows[0][2])
+		require.Equal(t, "Mathematics: Test 1 (Term 1, out of 50)", rows[0][3])
+		require.Equal(t, "English overall %", rows[0][4])
+		require.Equal(t, "30", rows[1][2])
+		require.Equal(t, "80", rows[1][6])
+		require.Equal(t, "absent", rows[2][2])
+		require.Equal(t, "", rows[2][3])
+		require.Equal(t, "0", rows[2][4])
+	})
+
+	t.Run("leaves out subjects the viewer does not teach", func(t *testing.T) {
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		table, err := svc.Gradebook(maths, "c1", "")
+		require.NoError(t, err)
+
+		require.Equal(t, 5, len(table.Header))
+		require.Equal(t, "Mathematics overall %", table.Header[3])
+	})
+}
+
+func TestWrite(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+	table, err := svc.ClassList(form, "c1")
+	require.NoError(t, err)
+
+	err = svc.Write(form, &bytes.Buffer{}, Format("pdf"), table)
+
+	require.True(t, errors.Is(err, ErrInvalid))
+	require.Equal(t, 0, len(s.AuditLog()))
+}
+
+func TestFormulas(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+	names := []string{"=HYPERLINK(\"http://example.com\")", "+1+2", "-2+3", "@SUM(A1)", "-5", "Tan Wei Ming"}
+	table := Table{Name: "Names", Header: []string{"Name"}, Rows: func(yield func([]string) bool) {
+		for _, n := range names {
+			if !yield([]string{n}) {
+				return
+			}
+		}
+	}}
+
+	t.Run("escapes CSV cells that would run as formulas", func(t *testing.T) {
+		rows := writeCSV(t, svc, form, table)
+
+		want := []string{"Name", "'=HYPERLINK(\"http://example.com\")", "'+1+2", "'-2+3", "'@SUM(A1)", "-5", "Tan Wei Ming"}
+		require.Equal(t, len(want), len(rows))
+		for i, w := range want {
+			require.Equal(t, w, rows[i][0])
+		}
+	})
+
+	t.Run("writes XLSX cells as text, not formulas", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, svc.Write(form, &buf, FormatXLSX, table))
+
+		r := bytes.NewReader(buf.Bytes())
+		zr, err := zip.NewReader(r, r.Size())
+		require.NoError(t, err)
+		f, err := zr.Open("xl/worksheets/sheet1.xml")
+		require.NoError(t, err)
+		sheet, err := io.ReadAll(f)
+		require.NoError(t, err)
+		require.False(t, bytes.Contains(sheet, []byte("<f>")))
+
+		rows, err := xlsx.ReadRows(r, r.Size())
+		require.NoError(t, err)
+		require.Equal(t, names[0], rows[1][0])
+		require.Equal(t, names[3], rows[4][0])
+	})
+}
+
+func TestNonReaders(t *testing.T) {
+	svc, s := newTestService(t)
+	form := scopeOf(s, "form", store.RoleTeacher)
+	v, err := svc.posts.Create(form, posts.Input{Title: "Sports Day", Body: "Bring a water bottle.", Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}}})
+	require.NoError(t, err)
+
+	t.Run("only covers published posts", func(t *testing.T) {
+		_, err := svc.NonReaders(form, v.ID)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("lists guardians who have not opened the post", func(t *testing.T) {
+		_, err := svc.posts.Publish(t.Context(), form, v.ID)
+		require.NoError(t, err)
+		_, err = svc.posts.Record(v.ID, []posts.ReceiptEvent{{GuardianID: "g1", Type: posts.EventDelivered}})
+		require.NoError(t, err)
+
+		table, err := svc.NonReaders(form, v.ID)
+		require.NoError(t, err)
+		rows := writeCSV(t, svc, form, table)
+
+		require.Equal(t, "Sports Day non-readers", table.Name)
+		require.Equal(t, 2, len(rows))
+		require.Equal(t, "Tan Ah Kow", rows[1][0])
+		require.Equal(t, "Father", rows[1][1])
+		require.Equal(t, "1A", rows[1][5])
+		require.Equal(t, "2026-03-02T09:00:00Z", rows[1][6])
+		last := s.AuditLog()[len(s.AuditLog())-1]
+		require.Equal(t, audit.ActionExportNonReaders, last.Action)
+	})
+
+	t.Run("drops guardians once they open it", func(t *testing.T) {
+		_, err := svc.posts.Record(v.ID, []posts.ReceiptEvent{{GuardianID: "g1", Type: posts.EventOpened}})
+		require.NoError(t, err)
+
+		table, err := svc.NonReaders(form, v.ID)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(writeCSV(t, svc, form, table)))
+	})
+
+	t.Run("hides posts the viewer may not see", func(t *testing.T) {
+		_, err := svc.NonReaders(scopeOf(s, "stranger", store.RoleTeacher), v.ID)
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
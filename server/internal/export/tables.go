@@ -0,0 +1,280 @@
+package export
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ClassList returns the students of class classID with their first
+// guardian's contact details.
+func (s *Service) ClassList(scope authz.Scope, classID string) (Table, error) {
+	c, err := s.class(scope, classID)
+	if err != nil {
+		return Table{}, err
+	}
+
+	return Table{
+		Name: c.Name + " class list",
+		Header: []string{
+			"Index", "Name", "Student ID", "Gender", "Date of birth", "Subjects",
+			"Guardian", "Relationship", "Guardian email", "Guardian phone",
+		},
+		Rows: func(yield func([]string) bool) {
+			for st := range s.students(scope, c) {
+				row := []string{
+					strconv.Itoa(st.IndexNumber), st.Name, st.ExternalID, st.Gender,
+					date(st.DateOfBirth), strings.Join(st.Subjects, "; "),
+					"", "", "", "",
+				}
+				if len(st.Guardians) > 0 {
+					link := st.Guardians[0]
+					g, _ := s.store.Guardian(link.GuardianID)
+					row[6], row[7], row[8], row[9] = g.Name, link.Relationship, g.Email, g.Phone
+				}
+				if !yield(row) {
+					return
+				}
+			}
+		},
+		event: audit.Event{Action: audit.ActionExportClassList, TargetType: "class", TargetID: c.ID},
+	}, nil
+}
+
+// statusLabels are how attendance statuses read in a register.
+var statusLabels = map[store.AttendanceStatus]string{
+	store.AttendancePresent:       "Present",
+	store.AttendanceLate:          "Late",
+	store.AttendanceAbsent:        "Absent",
+	store.AttendanceMC:            "MC",
+	store.AttendanceOfficialLeave: "Official leave",
+}
+
+// Attendance returns the attendance register of class classID over period
+// p: a column for each day attendance was taken, then each student's totals.
+func (s *Service) Attendance(scope authz.Scope, classID string, p attendance.Period) (Table, error) {
+	c, err := s.class(scope, classID)
+	if err != nil {
+		return Table{}, err
+	}
+
+	records := func(studentID string) []store.Attendance {
+		all := s.store.AttendanceForStudent(studentID)
+		return slices.DeleteFunc(all, func(r store.Attendance) bool {
+			return r.ClassID != c.ID || !p.Contains(r.Date)
+		})
+	}
+	var days []store.Date
+	for st := range s.students(scope, c) {
+		for _, r := range records(st.ID) {
+			days = append(days, r.Date)
+		}
+	}
+	slices.SortFunc(days, store.Date.Compare)
+	days = slices.Compact(days)
+
+	header := []string{"Index", "Name"}
+	for _, d := range days {
+		header = append(header, d.String())
+	}
+	header = append(header, "Present", "Late", "Absent", "MC", "Official leave", "Attendance rate")
+
+	return Table{
+		Name:   c.Name + " attendance",
+		Header: header,
+		Rows: func(yield func([]string) bool) {
+			for st := range s.students(scope, c) {
+				recs := records(st.ID)
+				row := make([]string, 0, len(header))
+				row = append(row, strconv.Itoa(st.IndexNumber), st.Name)
+				i := 0
+				for _, d := range days {
+					for i < len(recs) && recs[i].Date.Before(d) {
+						i++
+					}
+					if i < len(recs) && recs[i].Date == d {
+						row = append(row, statusLabels[recs[i].Status])
+					} else {
+						row = append(row, "")
+					}
+				}
+				sum := attendance.Summarize(recs)
+				row = append(row,
+					strconv.Itoa(sum.Present), strconv.Itoa(sum.Late), strconv.Itoa(sum.Absent),
+					strconv.Itoa(sum.MC), strconv.Itoa(sum.OfficialLeave), number(sum.Rate),
+				)
+				if !yield(row) {
+					return
+				}
+			}
+		},
+		event: audit.Event{Action: audit.ActionExportAttendance, TargetType: "class", TargetID: c.ID, Detail: period(p)},
+	}, nil
+}
+
+// Gradebook returns the marks of class classID in every assessment the
+// viewer may see, optionally limited to one subject, followed by each
+// subject's overall percentage and grade.
+func (s *Service) Gradebook(scope authz.Scope, classID, subject string) (Table, error) {
+	c, err := s.class(scope, classID)
+	if err != nil {
+		return Table{}, err
+	}
+
+	var assessments []store.Assessment
+	for _, a := range s.store.AssessmentsForClass(c.ID) {
+		if (subject == "" || a.Subject == subject) && scope.CanViewSubject(c.ID, a.Subject) {
+			assessments = append(assessments, a)
+		}
+	}
+	// AssessmentsForClass orders by date; the sort keeps that order within
+	// each subject.
+	slices.SortStableFunc(assessments, func(a, b store.Assessment) int {
+		return cmp.Or(strings.Compare(a.Subject, b.Subject), cmp.Compare(a.Term, b.Term))
+	})
+	var subjects []string
+	header := []string{"Index", "Name"}
+	for _, a := range assessments {
+		header = append(header, fmt.Sprintf("%s: %s (Term %d, out of %s)", a.Subject, a.Name, a.Term, number(a.MaxMarks)))
+		if len(subjects) == 0 || subjects[len(subjects)-1] != a.Subject {
+			subjects = append(subjects, a.Subject)
+		}
+	}
+	for _, sub := range subjects {
+		header = append(header, sub+" overall %", sub+" grade")
+	}
+
+	return Table{
+		Name:   strings.TrimSpace(c.Name + " " + subject + " gradebook"),
+		Header: header,
+		Rows: func(yield func([]string) bool) {
+			for st := range s.students(scope, c) {
+				if !yield(s.gradebookRow(st, assessments, subjects)) {
+					return
+				}
+			}
+		},
+		event: audit.Event{Action: audit.ActionExportGradebook, TargetType: "class", TargetID: c.ID, Detail: subject},
+	}, nil
+}
+
+func (s *Service) gradebookRow(st store.Student, assessments []store.Assessment, subjects []string) []string {
+	scores := make(map[string]store.Score)
+	for _, sc := range s.store.ScoresForStudent(st.ID) {
+		scores[sc.AssessmentID] = sc
+	}
+
+	row := []string{strconv.Itoa(st.IndexNumber), st.Name}
+	for _, a := range assessments {
+		sc, ok := scores[a.ID]
+		switch {
+		case !ok || !slices.Contains(st.Subjects, a.Subject):
+			row = append(row, "")
+		case sc.Status != "":
+			row = append(row, string(sc.Status))
+		default:
+			row = append(row, number(sc.Marks))
+		}
+	}
+
+	// Results fail only for an invalid grade scale, which cannot be saved;
+	// should one appear, the results are left blank rather than the export
+	// cut short.
+	results, _ := s.grades.ResultsOf(st)
+	for _, sub := range subjects {
+		i := slices.IndexFunc(results.Subjects, func(r gradebook.SubjectResult) bool { return r.Subject == sub })
+		if i < 0 || results.Subjects[i].Overall.Percentage == nil {
+			row = append(row, "", "")
+			continue
+		}
+		overall := results.Subjects[i].Overall
+		row = append(row, number(*overall.Percentage), overall.Grade)
+	}
+	return row
+}
+
+// NonReaders returns the guardians who have not opened published post
+// postID, a row for each child they receive it for, so that the school can
+// follow up with them another way.
+func (s *Service) NonReaders(scope authz.Scope, postID string) (Table, error) {
+	rs, err := s.posts.Receipts(scope, postID, posts.ReceiptFilter{Status: posts.ReceiptUnread})
+	switch {
+	case errors.Is(err, posts.ErrNotFound):
+		return Table{}, ErrNotFound
+	case errors.Is(err, posts.ErrInvalid):
+		return Table{}, fmt.Errorf("%w: only published posts have readers", ErrInvalid)
+	case err != nil:
+		return Table{}, err
+	}
+	p, _ := s.store.Post(postID)
+	classes := make(map[string]string)
+	for _, c := range s.store.Classes(p.SchoolID) {
+		classes[c.ID] = c.Name
+	}
+
+	return Table{
+		Name: p.Title + " non-readers",
+		Header: []string{
+			"Guardian", "Relationship", "Guardian email", "Guardian phone",
+			"Student", "Class", "Delivered",
+		},
+		Rows: func(yield func([]string) bool) {
+			for _, r := range rs.Items {
+				g, _ := s.store.Guardian(r.GuardianID)
+				for _, st := range r.Students {
+					row := []string{
+						g.Name, st.Relationship, g.Email, g.Phone,
+						st.StudentName, classes[st.ClassID], timestamp(r.DeliveredAt),
+					}
+					if !yield(row) {
+						return
+					}
+				}
+			}
+		},
+		event: audit.Event{Action: audit.ActionExportNonReaders, TargetType: "post", TargetID: p.ID},
+	}, nil
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func date(d store.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// period describes p for the audit log.
+func period(p attendance.Period) string {
+	switch {
+	case p.From.IsZero() && p.To.IsZero():
+		return ""
+	case p.To.IsZero():
+		return "from " + p.From.String()
+	case p.From.IsZero():
+		return "to " + p.To.String()
+	default:
+		return p.From.String() + " to " + p.To.String()
+	}
+}
@@ -0,0 +1,109 @@
+package gradebook
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Result is a weighted percentage and the grade it earns.
+type Result struct {
+	// Percentage is nil when no assessment counts towards the result yet.
+	Percentage *float64 `json:"percentage"`
+	Grade      string   `json:"grade,omitempty"`
+	// Complete is false while any assessment still has no score entered.
+	Complete bool `json:"complete"`
+}
+
+// TermResult is a subject's result for one term.
+type TermResult struct {
+	Term int `json:"term"`
+	Result
+}
+
+// SubjectResult is a student's results for one subject.
+type SubjectResult struct {
+	Subject string       `json:"subject"`
+	Terms   []TermResult `json:"terms"`
+	Overall Result       `json:"overall"`
+}
+
+// Compute returns a student's term and overall results per subject, ordered
+// by subject, from the assessments set for them and their scores keyed by
+// assessment ID.
+//
+// Each result is the weighted mean of the percentages scored. Absent scores
+// count as zero; exempt scores and assessments without a score are left out,
+// the latter marking the result incomplete.
+func Compute(assessments []store.Assessment, scores map[string]store.Score, scale store.GradeScale) []SubjectResult {
+	bySubject := make(map[string][]store.Assessment)
+	for _, a := range assessments {
+		bySubject[a.Subject] = append(bySubject[a.Subject], a)
+	}
+
+	out := make([]SubjectResult, 0, len(bySubject))
+	for subject, as := range bySubject {
+		slices.SortFunc(as, func(a, b store.Assessment) int { return cmp.Compare(a.Term, b.Term) })
+
+		sr := SubjectResult{Subject: subject, Terms: []TermResult{}}
+		overall := tally{}
+		for _, term := range terms(as) {
+			t := tally{}
+			for _, a := range as {
+				if a.Term == term {
+					sc, ok := scores[a.ID]
+					t.add(a, sc, ok)
+					overall.add(a, sc, ok)
+				}
+			}
+			sr.Terms = append(sr.Terms, TermResult{Term: term, Result: t.result(scale)})
+		}
+		sr.Overall = overall.result(scale)
+		out = append(out, sr)
+	}
+
+	slices.SortFunc(out, func(a, b SubjectResult) int { return cmp.Compare(a.Subject, b.Subject) })
+	return out
+}
+
+// terms returns the distinct terms of as, which is ordered by term.
+func terms(as []store.Assessment) []int {
+	var out []int
+	for _, a := range as {
+		if len(out) == 0 || out[len(out)-1] != a.Term {
+			out = append(out, a.Term)
+		}
+	}
+	return out
+}
+
+// tally accumulates weighted percentages towards a Result.
+type tally struct {
+	weighted, weights float64
+	missing           bool
+}
+
+func (t *tally) add(a store.Assessment, sc store.Score, scored bool) {
+	switch {
+	case !scored:
+		t.missing = true
+	case sc.Status == store.ScoreExempt || a.MaxMarks <= 0:
+	case sc.Status == store.ScoreAbsent:
+		t.weights += a.Weight
+	default:
+		t.weighted += a.Weight * sc.Marks / a.MaxMarks * 100
+		t.weights += a.Weight
+	}
+}
+
+func (t tally) result(scale store.GradeScale) Result {
+	r := Result{Complete: !t.missing}
+	if t.weights > 0 {
+		pct := math.Round(t.weighted/t.weights*10) / 10
+		r.Percentage = &pct
+		r.Grade = Grade(scale, pct)
+	}
+	return r
+}
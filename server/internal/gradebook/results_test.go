@@ -0,0 +1,101 @@
+package gradebook
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestValidateScale(t *testing.T) {
+	t.Run("accepts the built-in scales", func(t *testing.T) {
+		require.NoError(t, ValidateScale(OLevel))
+		require.NoError(t, ValidateScale(AchievementLevels))
+	})
+
+	cases := []struct {
+		name  string
+		bands []store.GradeBand
+	}{
+		{name: "no bands"},
+		{name: "empty grade", bands: []store.GradeBand{{Grade: "", Min: 0}}},
+		{name: "repeated grade", bands: []store.GradeBand{{Grade: "A", Min: 50}, {Grade: "A", Min: 0}}},
+		{name: "increasing minimums", bands: []store.GradeBand{{Grade: "B", Min: 50}, {Grade: "A", Min: 70}, {Grade: "F", Min: 0}}},
+		{name: "overlapping bands", bands: []store.GradeBand{{Grade: "A", Min: 50}, {Grade: "B", Min: 50}, {Grade: "F", Min: 0}}},
+		{name: "gap at the bottom", bands: []store.GradeBand{{Grade: "A", Min: 50}, {Grade: "B", Min: 10}}},
+		{name: "minimum above 100", bands: []store.GradeBand{{Grade: "A", Min: 101}, {Grade: "F", Min: 0}}},
+	}
+	for _, tc := range cases {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			err := ValidateScale(store.GradeScale{Name: tc.name, Bands: tc.bands})
+
+			require.True(t, errors.Is(err, ErrInvalid))
+		})
+	}
+}
+
+func TestGrade(t *testing.T) {
+	cases := []struct {
+		scale store.GradeScale
+		pct   float64
+		want  string
+	}{
+		{scale: OLevel, pct: 100, want: "A1"},
+		{scale: OLevel, pct: 75, want: "A1"},
+		{scale: OLevel, pct: 74.9, want: "A2"},
+		{scale: OLevel, pct: 39.9, want: "F9"},
+		{scale: AchievementLevels, pct: 90, want: "AL1"},
+		{scale: AchievementLevels, pct: 64.5, want: "AL6"},
+		{scale: AchievementLevels, pct: 0, want: "AL8"},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, Grade(tc.scale, tc.pct))
+	}
+}
+
+func TestCompute(t *testing.T) {
+	assessments := []store.Assessment{
+		{ID: "m1", Subject: "Mathematics", Term: 1, MaxMarks: 50, Weight: 1},
+		{ID: "m2", Subject: "Mathematics", Term: 1, MaxMarks: 20, Weight: 3},
+		{ID: "m3", Subject: "Mathematics", Term: 2, MaxMarks: 100, Weight: 2},
+		{ID: "e1", Subject: "English", Term: 1, MaxMarks: 40, Weight: 1},
+		{ID: "e2", Subject: "English", Term: 2, MaxMarks: 40, Weight: 1},
+	}
+
+	t.Run("weights percentages within terms and overall", func(t *testing.T) {
+		scores := map[string]store.Score{
+			"m1": {Marks: 40},                 // 80%
+			"m2": {Marks: 12},                 // 60%
+			"m3": {Status: store.ScoreAbsent}, // 0%
+			"e1": {Status: store.ScoreExempt}, // left out
+			"e2": {Marks: 30},                 // 75%
+		}
+
+		got := Compute(assessments, scores, OLevel)
+
+		require.Equal(t, 2, len(got))
+		english, maths := got[0], got[1]
+		require.Equal(t, "English", english.Subject)
+		require.True(t, english.Terms[0].Percentage == nil)
+		require.Equal(t, 75.0, *english.Overall.Percentage)
+		require.Equal(t, "A1", english.Overall.Grade)
+
+		require.Equal(t, 65.0, *maths.Terms[0].Percentage) // (80 + 3*60) / 4
+		require.Equal(t, "B3", maths.Terms[0].Grade)
+		require.Equal(t, 0.0, *maths.Terms[1].Percentage)
+		require.Equal(t, 43.3, *maths.Overall.Percentage) // (80 + 180 + 0) / 6
+		require.Equal(t, "E8", maths.Overall.Grade)
+		require.True(t, maths.Overall.Complete)
+	})
+
+	t.Run("missing scores mark results incomplete", func(t *testing.T) {
+		got := Compute(assessments, map[string]store.Score{"e1": {Marks: 20}}, OLevel)
+
+		english := got[0]
+		require.True(t, english.Terms[0].Complete)
+		require.False(t, english.Terms[1].Complete)
+		require.False(t, english.Overall.Complete)
+		require.Equal(t, 50.0, *english.Overall.Percentage)
+	})
+}
@@ -0,0 +1,82 @@
+package gradebook
+
+import (
+	"fmt"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+var (
+	// OLevel is the A1–F9 banding of the GCE O-Level examinations.
+	OLevel = store.GradeScale{
+		Name: "A1-F9",
+		Bands: []store.GradeBand{
+			{Grade: "A1", Min: 75},
+			{Grade: "A2", Min: 70},
+			{Grade: "B3", Min: 65},
+			{Grade: "B4", Min: 60},
+			{Grade: "C5", Min: 55},
+			{Grade: "C6", Min: 50},
+			{Grade: "D7", Min: 45},
+			{Grade: "E8", Min: 40},
+			{Grade: "F9", Min: 0},
+		},
+	}
+
+	// AchievementLevels is the AL1–AL8 banding of the PSLE.
+	AchievementLevels = store.GradeScale{
+		Name: "AL1-AL8",
+		Bands: []store.GradeBand{
+			{Grade: "AL1", Min: 90},
+			{Grade: "AL2", Min: 85},
+			{Grade: "AL3", Min: 80},
+			{Grade: "AL4", Min: 75},
+			{Grade: "AL5", Min: 65},
+			{Grade: "AL6", Min: 45},
+			{Grade: "AL7", Min: 20},
+			{Grade: "AL8", Min: 0},
+		},
+	}
+
+	// DefaultScale grades schools that do not configure their own.
+	DefaultScale = OLevel
+)
+
+// ValidateScale reports whether sc can grade every percentage: it needs at
+// least one band, distinct non-empty grades, strictly decreasing minimums
+// within 0–100, and a last band starting at 0. Strictly decreasing minimums
+// keep the bands from overlapping. Failures wrap ErrInvalid.
+func ValidateScale(sc store.GradeScale) error {
+	if len(sc.Bands) == 0 {
+		return fmt.Errorf("%w: grade scale has no bands", ErrInvalid)
+	}
+
+	seen := make(map[string]bool, len(sc.Bands))
+	for i, b := range sc.Bands {
+		switch {
+		case b.Grade == "":
+			return fmt.Errorf("%w: band %d of grade scale %q has no grade", ErrInvalid, i+1, sc.Name)
+		case seen[b.Grade]:
+			return fmt.Errorf("%w: grade scale %q repeats grade %q", ErrInvalid, sc.Name, b.Grade)
+		case b.Min < 0 || b.Min > 100:
+			return fmt.Errorf("%w: grade %q of scale %q starts outside 0-100", ErrInvalid, b.Grade, sc.Name)
+		case i > 0 && b.Min >= sc.Bands[i-1].Min:
+			return fmt.Errorf("%w: grade scale %q is not ordered from the highest band down", ErrInvalid, sc.Name)
+		}
+		seen[b.Grade] = true
+	}
+	if last := sc.Bands[len(sc.Bands)-1]; last.Min != 0 {
+		return fmt.Errorf("%w: grade scale %q does not grade percentages below %g", ErrInvalid, sc.Name, last.Min)
+	}
+	return nil
+}
+
+// Grade returns the grade pct earns on sc, which must be valid.
+func Grade(sc store.GradeScale, pct float64) string {
+	for _, b := range sc.Bands {
+		if pct >= b.Min {
+			return b.Grade
+		}
+	}
+	return sc.Bands[len(sc.Bands)-1].Grade
+}
@@ -0,0 +1,399 @@
+// Package gradebook holds the assessments set for subject classes and the
+// scores students earn in them, and computes term and overall grades from
+// each school's grade boundaries.
+package gradebook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+var (
+	// ErrNotFound is returned for classes, assessments and students that do
+	// not exist or that the viewer may not see.
+	ErrNotFound = errors.New("gradebook: not found")
+	// ErrForbidden is returned when the viewer may see an assessment but not
+	// change it, or changes the grade scale without being a school leader.
+	ErrForbidden = errors.New("gradebook: only the subject's teachers or a school leader may change assessments and scores, and only a school leader the grade scale")
+	// ErrInvalid wraps every validation failure of an assessment, scores or a
+	// grade scale.
+	ErrInvalid = errors.New("gradebook: invalid input")
+)
+
+const (
+	// MaxTerm is the number of terms in a school year.
+	MaxTerm = 4
+	// MaxNameLength bounds the name of an assessment or a grade scale, in
+	// characters.
+	MaxNameLength = 100
+)
+
+// AssessmentInput describes an assessment to create or update.
+type AssessmentInput struct {
+	Subject  string     `json:"subject"`
+	Name     string     `json:"name"`
+	Term     int        `json:"term"`
+	Date     store.Date `json:"date"`
+	MaxMarks float64    `json:"max_marks"`
+	Weight   float64    `json:"weight"`
+}
+
+// ScoreInput is one student's score in a score submission. Marks must be
+// set unless Status is, and must not be otherwise.
+type ScoreInput struct {
+	StudentID string            `json:"student_id"`
+	Marks     *float64          `json:"marks"`
+	Status    store.ScoreStatus `json:"status"`
+}
+
+// ScoreEntry is one student's line in an assessment's score sheet. Marks is
+// nil until a score is entered or when the score carries no marks.
+type ScoreEntry struct {
+	StudentID   string            `json:"student_id"`
+	Name        string            `json:"name"`
+	IndexNumber int               `json:"index_number"`
+	Marks       *float64          `json:"marks"`
+	Status      store.ScoreStatus `json:"status,omitempty"`
+}
+
+// ScoreSheet is an assessment with the scores of the students taking it.
+type ScoreSheet struct {
+	Assessment store.Assessment `json:"assessment"`
+	Scores     []ScoreEntry     `json:"scores"`
+}
+
+// StudentResults are a student's computed results. Scale names the grade
+// scale the grades were taken from.
+type StudentResults struct {
+	StudentID string          `json:"student_id"`
+	Scale     string          `json:"scale"`
+	Subjects  []SubjectResult `json:"subjects"`
+}
+
+// Service records assessments and scores and computes results, limited to
+// what the viewing teacher may see and change. Computed results are cached
+// per student until the store reports that their grades changed.
+type Service struct {
+	store *store.Store
+	ids   *random.IDGenerator
+
+	mu    sync.Mutex
+	cache map[string]cachedResults
+}
+
+type cachedResults struct {
+	revision uint64
+	results  StudentResults
+}
+
+// New returns a Service over s that names new assessments with ids.
+func New(s *store.Store, ids *random.IDGenerator) *Service {
+	return &Service{store: s, ids: ids, cache: make(map[string]cachedResults)}
+}
+
+// Assessments returns the assessments of class classID the viewer may see,
+// optionally limited to one subject.
+func (s *Service) Assessments(scope authz.Scope, classID, subject string) ([]store.Assessment, error) {
+	c, ok := s.store.Class(classID)
+	if !ok || !scope.CanViewClass(c) {
+		return nil, ErrNotFound
+	}
+
+	out := []store.Assessment{}
+	for _, a := range s.store.AssessmentsForClass(classID) {
+		if (subject == "" || a.Subject == subject) && scope.CanViewSubject(a.ClassID, a.Subject) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// CreateAssessment sets a new assessment for class classID.
+func (s *Service) CreateAssessment(scope authz.Scope, classID string, in AssessmentInput) (store.Assessment, error) {
+	c, ok := s.store.Class(classID)
+	if !ok || !scope.CanViewClass(c) {
+		return store.Assessment{}, ErrNotFound
+	}
+	a, err := s.assessment(classID, in)
+	if err != nil {
+		return store.Assessment{}, err
+	}
+	if !canEditSubject(scope, classID, a.Subject) {
+		return store.Assessment{}, ErrForbidden
+	}
+
+	id, err := s.ids.New()
+	if err != nil {
+		return store.Assessment{}, err
+	}
+	a.ID = id.String()
+	s.store.PutAssessment(a)
+	return a, nil
+}
+
+// UpdateAssessment replaces the details of assessment id. Its class and
+// subject cannot change.
+func (s *Service) UpdateAssessment(scope authz.Scope, id string, in AssessmentInput) (store.Assessment, error) {
+	old, err := s.visibleAssessment(scope, id)
+	if err != nil {
+		return store.Assessment{}, err
+	}
+	if !canEditSubject(scope, old.ClassID, old.Subject) {
+		return store.Assessment{}, ErrForbidden
+	}
+	if in.Subject != old.Subject {
+		return store.Assessment{}, fmt.Errorf("%w: subject cannot change", ErrInvalid)
+	}
+	a, err := s.assessment(old.ClassID, in)
+	if err != nil {
+		return store.Assessment{}, err
+	}
+
+	// Lowering the maximum must not leave scores above it.
+	for _, sc := range s.store.ScoresForAssessment(id) {
+		if sc.Status == "" && sc.Marks > a.MaxMarks {
+			return store.Assessment{}, fmt.Errorf("%w: max_marks is below an entered score of %g", ErrInvalid, sc.Marks)
+		}
+	}
+
+	a.ID = id
+	s.store.PutAssessment(a)
+	return a, nil
+}
+
+// Scores returns the score sheet of assessment id.
+func (s *Service) Scores(scope authz.Scope, id string) (ScoreSheet, error) {
+	a, err := s.visibleAssessment(scope, id)
+	if err != nil {
+		return ScoreSheet{}, err
+	}
+	return s.sheet(scope, a), nil
+}
+
+// PutScores enters or updates the scores of assessment id and returns the
+// updated score sheet. The submission is validated as a whole, so either
+// every score is recorded or none is.
+func (s *Service) PutScores(scope authz.Scope, id string, in []ScoreInput) (ScoreSheet, error) {
+	a, err := s.visibleAssessment(scope, id)
+	if err != nil {
+		return ScoreSheet{}, err
+	}
+	if !canEditSubject(scope, a.ClassID, a.Subject) {
+		return ScoreSheet{}, ErrForbidden
+	}
+	if len(in) == 0 {
+		return ScoreSheet{}, fmt.Errorf("%w: no scores", ErrInvalid)
+	}
+
+	scores := make([]store.Score, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, sc := range in {
+		st, ok := s.store.Student(sc.StudentID)
+		if !ok || st.ClassID != a.ClassID || !slices.Contains(st.Subjects, a.Subject) {
+			return ScoreSheet{}, fmt.Errorf("%w: student %q does not take this assessment", ErrInvalid, sc.StudentID)
+		}
+		if seen[sc.StudentID] {
+			return ScoreSheet{}, fmt.Errorf("%w: student %q is scored twice", ErrInvalid, sc.StudentID)
+		}
+		seen[sc.StudentID] = true
+
+		score := store.Score{AssessmentID: a.ID, StudentID: sc.StudentID, Status: sc.Status}
+		switch sc.Status {
+		case "":
+			if sc.Marks == nil || *sc.Marks < 0 || *sc.Marks > a.MaxMarks {
+				return ScoreSheet{}, fmt.Errorf("%w: marks for student %q must be between 0 and %g", ErrInvalid, sc.StudentID, a.MaxMarks)
+			}
+			score.Marks = *sc.Marks
+		case store.ScoreAbsent, store.ScoreExempt:
+			if sc.Marks != nil {
+				return ScoreSheet{}, fmt.Errorf("%w: student %q is %s and cannot have marks", ErrInvalid, sc.StudentID, sc.Status)
+			}
+		default:
+			return ScoreSheet{}, fmt.Errorf("%w: unknown status %q", ErrInvalid, sc.Status)
+		}
+		scores = append(scores, score)
+	}
+
+	s.store.PutScores(scores)
+	return s.sheet(scope, a), nil
+}
+
+// Student returns the results of student studentID in the subjects the
+// viewer may see.
+func (s *Service) Student(scope authz.Scope, studentID string) (StudentResults, error) {
+	st, ok := s.store.Student(studentID)
+	if !ok || !scope.CanViewStudent(st) {
+		return StudentResults{}, ErrNotFound
+	}
+
+	all, err := s.ResultsOf(st)
+	if err != nil {
+		return StudentResults{}, err
+	}
+	out := all
+	out.Subjects = []SubjectResult{}
+	for _, sr := range all.Subjects {
+		if scope.CanViewSubject(st.ClassID, sr.Subject) {
+			out.Subjects = append(out.Subjects, sr)
+		}
+	}
+	return out, nil
+}
+
+// Scale returns the grade scale in effect at the viewer's school.
+func (s *Service) Scale(scope authz.Scope) store.GradeScale {
+	if school, ok := s.store.School(scope.Teacher.SchoolID); ok && school.GradeScale != nil {
+		return *school.GradeScale
+	}
+	return DefaultScale
+}
+
+// SetScale replaces the grade scale of the viewer's school, which only school
+// leaders may do. A nil sc restores DefaultScale. Cached results graded on
+// the old scale are dropped.
+func (s *Service) SetScale(scope authz.Scope, sc *store.GradeScale) (store.GradeScale, error) {
+	if !scope.SchoolWide() {
+		return store.GradeScale{}, ErrForbidden
+	}
+	if sc != nil {
+		sc.Name = strings.TrimSpace(sc.Name)
+		if sc.Name == "" || len([]rune(sc.Name)) > MaxNameLength {
+			return store.GradeScale{}, fmt.Errorf("%w: grade scale name must be 1 to %d characters", ErrInvalid, MaxNameLength)
+		}
+		if err := ValidateScale(*sc); err != nil {
+			return store.GradeScale{}, err
+		}
+	}
+	if !s.store.SetGradeScale(scope.Teacher.SchoolID, sc) {
+		return store.GradeScale{}, ErrNotFound
+	}
+
+	s.mu.Lock()
+	clear(s.cache)
+	s.mu.Unlock()
+	return s.Scale(scope), nil
+}
+
+// ResultsOf returns every result of st, whoever may see them, for callers
+// that evaluate students in the background. Results come from the cache while
+// it is current.
+func (s *Service) ResultsOf(st store.Student) (StudentResults, error) {
+	// Read the revision first: a change that lands while computing bumps it
+	// again, so the entry is stale on the next read rather than wrong.
+	rev := s.store.GradesRevision(st.ID)
+
+	s.mu.Lock()
+	c, ok := s.cache[st.ID]
+	s.mu.Unlock()
+	if ok && c.revision == rev {
+		return c.results, nil
+	}
+
+	scale := DefaultScale
+	if school, ok := s.store.School(st.SchoolID); ok && school.GradeScale != nil {
+		scale = *school.GradeScale
+	}
+	if err := ValidateScale(scale); err != nil {
+		return StudentResults{}, err
+	}
+
+	scores := make(map[string]store.Score)
+	for _, sc := range s.store.ScoresForStudent(st.ID) {
+		scores[sc.AssessmentID] = sc
+	}
+	var assessments []store.Assessment
+	for _, a := range s.store.AssessmentsForClass(st.ClassID) {
+		if slices.Contains(st.Subjects, a.Subject) {
+			assessments = append(assessments, a)
+		}
+	}
+
+	results := StudentResults{StudentID: st.ID, Scale: scale.Name, Subjects: Compute(assessments, scores, scale)}
+	s.mu.Lock()
+	s.cache[st.ID] = cachedResults{revision: rev, results: results}
+	s.mu.Unlock()
+	return results, nil
+}
+
+func (s *Service) visibleAssessment(scope authz.Scope, id string) (store.Assessment, error) {
+	a, ok := s.store.Assessment(id)
+	if !ok {
+		return store.Assessment{}, ErrNotFound
+	}
+	c, ok := s.store.Class(a.ClassID)
+	if !ok || !scope.CanViewClass(c) || !scope.CanViewSubject(a.ClassID, a.Subject) {
+		return store.Assessment{}, ErrNotFound
+	}
+	return a, nil
+}
+
+// assessment validates in as an assessment of class classID.
+func (s *Service) assessment(classID string, in AssessmentInput) (store.Assessment, error) {
+	name := strings.TrimSpace(in.Name)
+	switch {
+	case !s.offers(classID, in.Subject):
+		return store.Assessment{}, fmt.Errorf("%w: subject %q is not taught in this class", ErrInvalid, in.Subject)
+	case name == "" || len([]rune(name)) > MaxNameLength:
+		return store.Assessment{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalid, MaxNameLength)
+	case in.Term < 1 || in.Term > MaxTerm:
+		return store.Assessment{}, fmt.Errorf("%w: term must be between 1 and %d", ErrInvalid, MaxTerm)
+	case in.Date.IsZero():
+		return store.Assessment{}, fmt.Errorf("%w: date is required", ErrInvalid)
+	case in.MaxMarks <= 0:
+		return store.Assessment{}, fmt.Errorf("%w: max_marks must be positive", ErrInvalid)
+	case in.Weight <= 0:
+		return store.Assessment{}, fmt.Errorf("%w: weight must be positive", ErrInvalid)
+	}
+	return store.Assessment{
+		ClassID:  classID,
+		Subject:  in.Subject,
+		Name:     name,
+		Term:     in.Term,
+		Date:     in.Date,
+		MaxMarks: in.MaxMarks,
+		Weight:   in.Weight,
+	}, nil
+}
+
+func (s *Service) offers(classID, subject string) bool {
+	return slices.ContainsFunc(s.store.TeachingByClass(classID), func(t store.Teaching) bool {
+		return t.Subject == subject
+	})
+}
+
+// sheet lists the students the viewer may see who take a's subject.
+func (s *Service) sheet(scope authz.Scope, a store.Assessment) ScoreSheet {
+	scores := make(map[string]store.Score)
+	for _, sc := range s.store.ScoresForAssessment(a.ID) {
+		scores[sc.StudentID] = sc
+	}
+
+	sheet := ScoreSheet{Assessment: a, Scores: []ScoreEntry{}}
+	for _, st := range s.store.StudentsInClass(a.ClassID) {
+		if !slices.Contains(st.Subjects, a.Subject) || !scope.CanViewStudent(st) {
+			continue
+		}
+		e := ScoreEntry{StudentID: st.ID, Name: st.Name, IndexNumber: st.IndexNumber}
+		if sc, ok := scores[st.ID]; ok {
+			e.Status = sc.Status
+			if sc.Status == "" {
+				e.Marks = &sc.Marks
+			}
+		}
+		sheet.Scores = append(sheet.Scores, e)
+	}
+	return sheet
+}
+
+// canEditSubject reports whether the viewer may set assessments and enter
+// scores for subject in classID.
+func canEditSubject(scope authz.Scope, classID, subject string) bool {
+	return scope.SchoolWide() || slices.Contains(scope.Subjects(classID), subject)
+}
@@ -0,0 +1,257 @@
+package gradebook
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Schools: []store.School{{ID: "s1", GradeScale: &AchievementLevels}},
+		Classes: []store.Class{{ID: "1A", SchoolID: "s1", FormTeacherID: "form"}},
+		Teaching: []store.Teaching{
+			{TeacherID: "maths", ClassID: "1A", Subject: "Mathematics"},
+			{TeacherID: "tamil", ClassID: "1A", Subject: "Tamil"},
+		},
+		Students: []store.Student{
+			{ID: "a", SchoolID: "s1", ClassID: "1A", IndexNumber: 1, Name: "Arjun", Subjects: []string{"Mathematics", "Tamil"}},
+			{ID: "b", SchoolID: "s1", ClassID: "1A", IndexNumber: 2, Name: "Bee Ling", Subjects: []string{"Mathematics"}},
+		},
+		Assessments: []store.Assessment{
+			{ID: "m1", ClassID: "1A", Subject: "Mathematics", Name: "Quiz", Term: 1, MaxMarks: 20, Weight: 1},
+			{ID: "t1", ClassID: "1A", Subject: "Tamil", Name: "Oral", Term: 1, MaxMarks: 10, Weight: 1},
+		},
+		Scores: []store.Score{
+			{AssessmentID: "m1", StudentID: "a", Marks: 18},
+			{AssessmentID: "t1", StudentID: "a", Marks: 5},
+		},
+	})
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), time.Now)
+	return New(s, ids), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+func marks(v float64) *float64 {
+	return &v
+}
+
+func TestAssessments(t *testing.T) {
+	svc, s := newTestService(t)
+	maths := scopeOf(s, "maths", store.RoleTeacher)
+	in := AssessmentInput{Subject: "Mathematics", Name: " Quiz 2 ", Term: 1, Date: store.Date{Year: 2026, Month: time.February, Day: 2}, MaxMarks: 30, Weight: 2}
+
+	t.Run("subject teachers see only their subjects", func(t *testing.T) {
+		got, err := svc.Assessments(maths, "1A", "")
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(got))
+		require.Equal(t, "m1", got[0].ID)
+	})
+
+	t.Run("subject teachers create assessments for their subject", func(t *testing.T) {
+		a, err := svc.CreateAssessment(maths, "1A", in)
+
+		require.NoError(t, err)
+		require.Equal(t, "Quiz 2", a.Name)
+		require.NoError(t, random.ValidateID(a.ID))
+		got, _ := s.Assessment(a.ID)
+		require.Equal(t, "1A", got.ClassID)
+	})
+
+	t.Run("form teachers may view but not create", func(t *testing.T) {
+		_, err := svc.CreateAssessment(scopeOf(s, "form", store.RoleTeacher), "1A", in)
+
+		require.True(t, errors.Is(err, ErrForbidden))
+	})
+
+	invalid := []struct {
+		name   string
+		change func(*AssessmentInput)
+	}{
+		{name: "subject not taught", change: func(in *AssessmentInput) { in.Subject = "Latin" }},
+		{name: "blank name", change: func(in *AssessmentInput) { in.Name = " " }},
+		{name: "term out of range", change: func(in *AssessmentInput) { in.Term = 5 }},
+		{name: "missing date", change: func(in *AssessmentInput) { in.Date = store.Date{} }},
+		{name: "zero max marks", change: func(in *AssessmentInput) { in.MaxMarks = 0 }},
+		{name: "negative weight", change: func(in *AssessmentInput) { in.Weight = -1 }},
+	}
+	for _, tc := range invalid {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			bad := in
+			tc.change(&bad)
+
+			_, err := svc.CreateAssessment(scopeOf(s, "leader", store.RoleSchoolLeader), "1A", bad)
+
+			require.True(t, errors.Is(err, ErrInvalid))
+		})
+	}
+
+	t.Run("max marks cannot drop below an entered score", func(t *testing.T) {
+		_, err := svc.UpdateAssessment(maths, "m1", AssessmentInput{Subject: "Mathematics", Name: "Quiz", Term: 1, Date: in.Date, MaxMarks: 10, Weight: 1})
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+}
+
+func TestPutScores(t *testing.T) {
+	t.Run("enters scores and markers", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		sheet, err := svc.PutScores(scopeOf(s, "maths", store.RoleTeacher), "m1", []ScoreInput{
+			{StudentID: "a", Marks: marks(19.5)},
+			{StudentID: "b", Status: store.ScoreAbsent},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, len(sheet.Scores))
+		require.Equal(t, 19.5, *sheet.Scores[0].Marks)
+		require.True(t, sheet.Scores[1].Marks == nil)
+		require.Equal(t, store.ScoreAbsent, sheet.Scores[1].Status)
+	})
+
+	cases := []struct {
+		name   string
+		scores []ScoreInput
+	}{
+		{name: "no scores"},
+		{name: "marks above maximum", scores: []ScoreInput{{StudentID: "a", Marks: marks(21)}}},
+		{name: "negative marks", scores: []ScoreInput{{StudentID: "a", Marks: marks(-1)}}},
+		{name: "missing marks", scores: []ScoreInput{{StudentID: "a"}}},
+		{name: "marks with a marker", scores: []ScoreInput{{StudentID: "a", Marks: marks(1), Status: store.ScoreExempt}}},
+		{name: "unknown marker", scores: []ScoreInput{{StudentID: "a", Status: "sick"}}},
+		{name: "student not taking the subject", scores: []ScoreInput{{StudentID: "nope", Marks: marks(1)}}},
+		{name: "duplicate student", scores: []ScoreInput{{StudentID: "a", Marks: marks(1)}, {StudentID: "a", Marks: marks(2)}}},
+	}
+	for _, tc := range cases {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			svc, s := newTestService(t)
+
+			_, err := svc.PutScores(scopeOf(s, "maths", store.RoleTeacher), "m1", tc.scores)
+
+			require.True(t, errors.Is(err, ErrInvalid))
+			require.Equal(t, 18.0, s.ScoresForStudent("a")[0].Marks)
+		})
+	}
+
+	t.Run("other subjects are not found", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.PutScores(scopeOf(s, "maths", store.RoleTeacher), "t1", []ScoreInput{{StudentID: "a", Marks: marks(1)}})
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestStudentResults(t *testing.T) {
+	t.Run("uses the school's grade scale", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		got, err := svc.Student(scopeOf(s, "form", store.RoleTeacher), "a")
+
+		require.NoError(t, err)
+		require.Equal(t, "AL1-AL8", got.Scale)
+		require.Equal(t, 2, len(got.Subjects))
+		require.Equal(t, "AL1", got.Subjects[0].Overall.Grade)
+	})
+
+	t.Run("subject teachers see only their subjects", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		got, err := svc.Student(scopeOf(s, "tamil", store.RoleTeacher), "a")
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(got.Subjects))
+		require.Equal(t, "Tamil", got.Subjects[0].Subject)
+	})
+
+	t.Run("cached results are recomputed when scores change", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		before, err := svc.Student(form, "a")
+		require.NoError(t, err)
+		require.Equal(t, 90.0, *before.Subjects[0].Overall.Percentage)
+
+		_, err = svc.PutScores(scopeOf(s, "maths", store.RoleTeacher), "m1", []ScoreInput{{StudentID: "a", Marks: marks(10)}})
+		require.NoError(t, err)
+
+		after, err := svc.Student(form, "a")
+		require.NoError(t, err)
+		require.Equal(t, 50.0, *after.Subjects[0].Overall.Percentage)
+	})
+
+	t.Run("cached results are recomputed when an assessment changes", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		_, err := svc.Student(form, "a")
+		require.NoError(t, err)
+
+		a, _ := s.Assessment("m1")
+		a.MaxMarks = 40
+		s.PutAssessment(a)
+
+		after, err := svc.Student(form, "a")
+		require.NoError(t, err)
+		require.Equal(t, 45.0, *after.Subjects[0].Overall.Percentage)
+	})
+}
+
+func TestScale(t *testing.T) {
+	custom := store.GradeScale{Name: "Pass/Fail", Bands: []store.GradeBand{{Grade: "P", Min: 50}, {Grade: "F", Min: 0}}}
+
+	t.Run("only school leaders change the scale", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.SetScale(scopeOf(s, "form", store.RoleTeacher), &custom)
+
+		require.True(t, errors.Is(err, ErrForbidden))
+		require.Equal(t, "AL1-AL8", svc.Scale(scopeOf(s, "form", store.RoleTeacher)).Name)
+	})
+
+	t.Run("rejects overlapping bands", func(t *testing.T) {
+		svc, s := newTestService(t)
+		sc := store.GradeScale{Name: "Overlap", Bands: []store.GradeBand{{Grade: "A", Min: 60}, {Grade: "B", Min: 70}, {Grade: "F", Min: 0}}}
+
+		_, err := svc.SetScale(scopeOf(s, "lead", store.RoleSchoolLeader), &sc)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("cached results are regraded on the new scale", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form, lead := scopeOf(s, "form", store.RoleTeacher), scopeOf(s, "lead", store.RoleSchoolLeader)
+		before, err := svc.Student(form, "a")
+		require.NoError(t, err)
+		require.Equal(t, "AL1", before.Subjects[0].Overall.Grade)
+
+		sc := custom
+		got, err := svc.SetScale(lead, &sc)
+		require.NoError(t, err)
+		require.Equal(t, "Pass/Fail", got.Name)
+
+		after, err := svc.Student(form, "a")
+		require.NoError(t, err)
+		require.Equal(t, "Pass/Fail", after.Scale)
+		require.Equal(t, "P", after.Subjects[0].Overall.Grade)
+	})
+
+	t.Run("nil restores the default", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		got, err := svc.SetScale(scopeOf(s, "lead", store.RoleSchoolLeader), nil)
+
+		require.NoError(t, err)
+		require.Equal(t, DefaultScale.Name, got.Name)
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// registerRequest is the body of PUT /api/students/classes/{id}/attendance/{date}.
+type registerRequest struct {
+	Students []attendance.Mark `json:"students"`
+}
+
+// historyResponse is the body of a register's audit trail.
+type historyResponse struct {
+	Items []store.AttendanceEdit `json:"items"`
+}
+
+// getRegister serves GET /api/students/classes/{id}/attendance/{date}, the
+// class register for one day.
+func (h *handler) getRegister(w http.ResponseWriter, r *http.Request) {
+	date, err := store.ParseDate(r.PathValue("date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "date must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	reg, err := h.attendance.Register(h.scope(r), r.PathValue("id"), date)
+	if err != nil {
+		writeAttendanceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reg)
+}
+
+// putRegister serves PUT /api/students/classes/{id}/attendance/{date}, which
+// records the attendance of some or all of a class in one submission.
+// Changes to attendance already taken are kept in the audit trail.
+func (h *handler) putRegister(w http.ResponseWriter, r *http.Request) {
+	date, err := store.ParseDate(r.PathValue("date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "date must be formatted as YYYY-MM-DD")
+		return
+	}
+	var req registerRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	reg, err := h.attendance.Submit(h.scope(r), r.PathValue("id"), date, req.Students)
+	if err != nil {
+		writeAttendanceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reg)
+}
+
+// getRegisterHistory serves
+// GET /api/students/classes/{id}/attendance/{date}/history, the audit trail
+// of late edits to a class register.
+func (h *handler) getRegisterHistory(w http.ResponseWriter, r *http.Request) {
+	date, err := store.ParseDate(r.PathValue("date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "date must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	edits, err := h.attendance.History(h.scope(r), r.PathValue("id"), date)
+	if err != nil {
+		writeAttendanceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, historyResponse{Items: edits})
+}
+
+// getClassAttendanceSummary serves
+// GET /api/students/classes/{id}/attendance/summary, per-student summaries
+// between the optional "from" and "to" dates.
+func (h *handler) getClassAttendanceSummary(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePeriod(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	sum, err := h.attendance.Class(h.scope(r), r.PathValue("id"), p)
+	if err != nil {
+		writeAttendanceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sum)
+}
+
+// getStudentAttendance serves GET /api/students/{id}/attendance, a student's
+// records and summary between the optional "from" and "to" dates.
+func (h *handler) getStudentAttendance(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePeriod(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	sa, err := h.attendance.Student(h.scope(r), r.PathValue("id"), p)
+	if err != nil {
+		writeAttendanceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sa)
+}
+
+// parsePeriod reads the optional "from" and "to" query parameters.
+func parsePeriod(q url.Values) (attendance.Period, error) {
+	var p attendance.Period
+	for name, d := range map[string]*store.Date{"from": &p.From, "to": &p.To} {
+		if s := q.Get(name); s != "" {
+			v, err := store.ParseDate(s)
+			if err != nil {
+				return attendance.Period{}, fmt.Errorf("%s must be formatted as YYYY-MM-DD", name)
+			}
+			*d = v
+		}
+	}
+	if !p.From.IsZero() && !p.To.IsZero() && p.To.Before(p.From) {
+		return attendance.Period{}, errors.New("to must not be before from")
+	}
+	return p, nil
+}
+
+func writeAttendanceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, attendance.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, attendance.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, attendance.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
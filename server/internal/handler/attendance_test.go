@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestRegister(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	class := f.ds.Classes[0]
+	students := f.store.StudentsInClass(class.ID)
+	path := "/api/students/classes/" + class.ID + "/attendance/2026-03-02"
+
+	body := func(status string) string {
+		var marks []string
+		for _, st := range students {
+			marks = append(marks, `{"student_id":"`+st.ID+`","status":"`+status+`"}`)
+		}
+		return `{"students":[` + strings.Join(marks, ",") + `]}`
+	}
+
+	t.Run("register is empty before attendance is taken", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, path, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		reg := decode[attendance.Register](t, rec)
+		require.Equal(t, 0, reg.Taken)
+		require.Equal(t, len(students), len(reg.Students))
+	})
+
+	t.Run("form teacher submits the whole class", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPut, path, strings.NewReader(body("present")))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, len(students), decode[attendance.Register](t, rec).Taken)
+	})
+
+	t.Run("late edits appear in the history", func(t *testing.T) {
+		edit := `{"students":[{"student_id":"` + students[0].ID + `","status":"late","reason":"Bus delay"}]}`
+		rec := f.do(&leader, http.MethodPut, path, strings.NewReader(edit))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = f.do(&form, http.MethodGet, path+"/history", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		items := decode[struct{ Items []store.AttendanceEdit }](t, rec).Items
+		require.Equal(t, 1, len(items))
+		require.Equal(t, store.AttendanceLate, items[0].ToStatus)
+		require.Equal(t, leader.ID, items[0].EditedBy)
+	})
+
+	cases := []struct {
+		name       string
+		teacher    store.Teacher
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "subject teacher may not submit", teacher: f.subjectTeacher("Chinese"), method: http.MethodPut, path: path, body: body("present"), wantStatus: http.StatusForbidden},
+		{name: "rejects malformed dates", teacher: form, method: http.MethodGet, path: "/api/students/classes/" + class.ID + "/attendance/monday", wantStatus: http.StatusBadRequest},
+		{name: "rejects unknown statuses", teacher: form, method: http.MethodPut, path: path, body: body("sick"), wantStatus: http.StatusBadRequest},
+		{name: "rejects unknown fields", teacher: form, method: http.MethodPut, path: path, body: `{"marks":[]}`, wantStatus: http.StatusBadRequest},
+		{name: "rejects future dates", teacher: form, method: http.MethodPut, path: "/api/students/classes/" + class.ID + "/attendance/2999-01-01", body: body("present"), wantStatus: http.StatusBadRequest},
+		{name: "other classes are not found", teacher: form, method: http.MethodGet, path: "/api/students/classes/" + f.ds.Classes[1].ID + "/attendance/2026-03-02", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(&tc.teacher, tc.method, tc.path, strings.NewReader(tc.body))
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAttendanceSummaries(t *testing.T) {
+	f := newFixture(t)
+	form := f.formTeacher()
+	class := f.ds.Classes[0]
+
+	t.Run("class summary covers the seeded school days", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/classes/"+class.ID+"/attendance/summary", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		sum := decode[attendance.ClassSummary](t, rec)
+		require.Equal(t, 10, sum.Days)
+		require.Equal(t, 8, len(sum.Students))
+	})
+
+	t.Run("student summary honours the period", func(t *testing.T) {
+		st := f.store.StudentsInClass(class.ID)[0]
+
+		rec := f.do(&form, http.MethodGet, "/api/students/"+st.ID+"/attendance?from=2026-01-05&to=2026-01-09", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 5, decode[attendance.StudentAttendance](t, rec).Summary.Days)
+	})
+
+	t.Run("rejects inverted periods", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/classes/"+class.ID+"/attendance/summary?from=2026-02-01&to=2026-01-01", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/token"
+)
+
+type ctxKeyTeacher struct{}
+
+// authenticate wraps next so it only runs for requests carrying a valid API
+// key in an "Authorization: Bearer" header. The key's owner is stored in the
+// request context; see teacherFromContext. Malformed keys are rejected by
+// their checksum before any lookup.
+func (h *handler) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token.Validate(tok, token.KindAPIKey) != nil {
+			unauthorized(w)
+			return
+		}
+
+		teacher, ok := h.store.TeacherByAPIKey(token.Hash(tok))
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxKeyTeacher{}, teacher)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticateGateway wraps next so it only runs for requests from Parents
+// Gateway, which carry the gateway key in an "Authorization: Bearer" header.
+// Every request is rejected when no gateway key is configured.
+func (h *handler) authenticateGateway(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || h.gatewayKeyHash == "" || token.Validate(tok, token.KindGateway) != nil || !token.Equal(tok, h.gatewayKeyHash) {
+			unauthorized(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="tw"`)
+	writeError(w, http.StatusUnauthorized, codeUnauthorized, "a valid API key is required")
+}
+
+// teacherFromContext returns the authenticated teacher. It must only be
+// called from handlers wrapped by authenticate.
+func teacherFromContext(ctx context.Context) store.Teacher {
+	return ctx.Value(ctxKeyTeacher{}).(store.Teacher)
+}
+
+// scope returns the access scope of the authenticated teacher.
+func (h *handler) scope(r *http.Request) authz.Scope {
+	return authz.For(h.store, teacherFromContext(r.Context()))
+}
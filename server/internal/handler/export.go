@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/export"
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+)
+
+// exportClassList serves GET /api/students/classes/{id}/export, the class
+// list as a spreadsheet.
+func (h *handler) exportClassList(w http.ResponseWriter, r *http.Request) {
+	h.writeExport(w, r, func() (export.Table, error) {
+		return h.export.ClassList(h.scope(r), r.PathValue("id"))
+	})
+}
+
+// exportAttendance serves GET /api/students/classes/{id}/attendance/export,
+// the class's attendance register between the optional "from" and "to"
+// dates as a spreadsheet.
+func (h *handler) exportAttendance(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePeriod(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	h.writeExport(w, r, func() (export.Table, error) {
+		return h.export.Attendance(h.scope(r), r.PathValue("id"), p)
+	})
+}
+
+// exportGradebook serves GET /api/students/classes/{id}/gradebook/export,
+// the class's marks and results as a spreadsheet, optionally limited to the
+// "subject" query parameter.
+func (h *handler) exportGradebook(w http.ResponseWriter, r *http.Request) {
+	h.writeExport(w, r, func() (export.Table, error) {
+		return h.export.Gradebook(h.scope(r), r.PathValue("id"), r.URL.Query().Get("subject"))
+	})
+}
+
+// writeExport streams the table made by table in the format the request
+// asks for.
+func (h *handler) writeExport(w http.ResponseWriter, r *http.Request, table func() (export.Table, error)) {
+	f, err := exportFormat(r)
+	if err != nil {
+		writeExportError(w, r, err)
+		return
+	}
+	if f == "" {
+		writeError(w, http.StatusNotAcceptable, codeNotAcceptable, "Accept must allow text/csv or "+export.FormatXLSX.ContentType())
+		return
+	}
+	t, err := table()
+	if err != nil {
+		writeExportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", f.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": t.Name + "." + string(f),
+	}))
+	w.WriteHeader(http.StatusOK)
+	if err := h.export.Write(h.scope(r), w, f, t); err != nil {
+		// The status line has gone; all that is left is to note the cut.
+		middleware.LoggerFromContext(r.Context()).ErrorContext(r.Context(), "export failed", "err", err)
+	}
+}
+
+// exportFormat returns the format named by the "format" query parameter or,
+// failing that, the one the Accept header prefers. It returns "" when the
+// Accept header allows neither, and CSV when there is no preference.
+func exportFormat(r *http.Request) (export.Format, error) {
+	if s := r.URL.Query().Get("format"); s != "" {
+		return export.ParseFormat(s)
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return export.FormatCSV, nil
+	}
+
+	var best export.Format
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		f, ok := export.FormatOf(mediaType)
+		if !ok && (mediaType == "*/*" || mediaType == "text/*") {
+			f, ok = export.FormatCSV, true
+		}
+		if ok && q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best, nil
+}
+
+func writeExportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, export.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, export.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
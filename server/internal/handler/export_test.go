@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/export"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/xlsx"
+)
+
+// export requests path as teacher with the given Accept header.
+func (f *fixture) export(teacher store.Teacher, path, accept string) *httptest.ResponseRecorder {
+	f.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+f.keys[teacher.ID])
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	f.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestExport(t *testing.T) {
+	f := newFixture(t)
+	form := f.formTeacher()
+	class := f.ds.Classes[0]
+	base := "/api/students/classes/" + class.ID
+
+	t.Run("exports a class list as CSV by default", func(t *testing.T) {
+		rec := f.export(form, base+"/export", "")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename="`+class.Name+` class list.csv"`, rec.Header().Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Equal(t, len(f.store.StudentsInClass(class.ID))+1, len(lines))
+		log := f.store.AuditLog()
+		require.Equal(t, audit.ActionExportClassList, log[len(log)-1].Action)
+	})
+
+	t.Run("chooses XLSX from the Accept header", func(t *testing.T) {
+		rec := f.export(form, base+"/attendance/export?from=2026-01-05", "text/csv;q=0.5, "+export.FormatXLSX.ContentType())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, export.FormatXLSX.ContentType(), rec.Header().Get("Content-Type"))
+		r := bytes.NewReader(rec.Body.Bytes())
+		rows, err := xlsx.ReadRows(r, r.Size())
+		require.NoError(t, err)
+		require.Equal(t, "2026-01-05", rows[0][2])
+	})
+
+	t.Run("lets the format parameter override Accept", func(t *testing.T) {
+		rec := f.export(form, base+"/gradebook/export?format=csv", export.FormatXLSX.ContentType())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, f.export(form, base+"/export?format=pdf", "").Code)
+		require.Equal(t, http.StatusNotAcceptable, f.export(form, base+"/export", "application/json").Code)
+	})
+
+	t.Run("hides classes the teacher may not see", func(t *testing.T) {
+		rec := f.export(form, "/api/students/classes/"+f.ds.Classes[1].ID+"/export", "")
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// scoresRequest is the body of PUT /api/students/assessments/{id}/scores.
+type scoresRequest struct {
+	Scores []gradebook.ScoreInput `json:"scores"`
+}
+
+// assessmentsResponse is the body of an assessment listing.
+type assessmentsResponse struct {
+	Items []store.Assessment `json:"items"`
+}
+
+// listAssessments serves GET /api/students/classes/{id}/assessments, filtered
+// by the optional "subject" query parameter.
+func (h *handler) listAssessments(w http.ResponseWriter, r *http.Request) {
+	as, err := h.gradebook.Assessments(h.scope(r), r.PathValue("id"), r.URL.Query().Get("subject"))
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, assessmentsResponse{Items: as})
+}
+
+// createAssessment serves POST /api/students/classes/{id}/assessments.
+func (h *handler) createAssessment(w http.ResponseWriter, r *http.Request) {
+	var in gradebook.AssessmentInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	a, err := h.gradebook.CreateAssessment(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, a)
+}
+
+// updateAssessment serves PUT /api/students/assessments/{id}.
+func (h *handler) updateAssessment(w http.ResponseWriter, r *http.Request) {
+	var in gradebook.AssessmentInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	a, err := h.gradebook.UpdateAssessment(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+// getScores serves GET /api/students/assessments/{id}/scores, the score
+// sheet of an assessment.
+func (h *handler) getScores(w http.ResponseWriter, r *http.Request) {
+	sheet, err := h.gradebook.Scores(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sheet)
+}
+
+// putScores serves PUT /api/students/assessments/{id}/scores, which enters
+// or updates the scores of some or all students taking an assessment.
+func (h *handler) putScores(w http.ResponseWriter, r *http.Request) {
+	var req scoresRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	sheet, err := h.gradebook.PutScores(h.scope(r), r.PathValue("id"), req.Scores)
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sheet)
+}
+
+// getStudentResults serves GET /api/students/{id}/results, a student's term
+// and overall results per subject.
+func (h *handler) getStudentResults(w http.ResponseWriter, r *http.Request) {
+	results, err := h.gradebook.Student(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// getGradeScale serves GET /api/students/gradebook/scale, the grade scale in
+// effect at the teacher's school.
+func (h *handler) getGradeScale(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.gradebook.Scale(h.scope(r)))
+}
+
+// putGradeScale serves PUT /api/students/gradebook/scale. Only school leaders
+// may change it; a null body restores the default scale.
+func (h *handler) putGradeScale(w http.ResponseWriter, r *http.Request) {
+	var in *store.GradeScale
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	sc, err := h.gradebook.SetScale(h.scope(r), in)
+	if err != nil {
+		writeGradebookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sc)
+}
+
+func writeGradebookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, gradebook.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, gradebook.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, gradebook.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
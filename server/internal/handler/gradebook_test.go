@@ -0,0 +1,146 @@
+package handler_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestGradebook(t *testing.T) {
+	f := newFixture(t)
+	class := f.ds.Classes[0]
+	teacher, leader := f.subjectTeacher("Mathematics"), f.leader()
+	path := "/api/students/classes/" + class.ID + "/assessments"
+
+	var created store.Assessment
+	t.Run("subject teacher creates an assessment", func(t *testing.T) {
+		body := `{"subject":"Mathematics","name":"Term 2 WA3","term":2,"date":"2026-05-04","max_marks":40,"weight":1}`
+
+		rec := f.do(&teacher, http.MethodPost, path, strings.NewReader(body))
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		created = decode[store.Assessment](t, rec)
+		require.Equal(t, class.ID, created.ClassID)
+	})
+
+	t.Run("lists assessments by subject", func(t *testing.T) {
+		rec := f.do(&teacher, http.MethodGet, path+"?subject=Mathematics", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		items := decode[struct{ Items []store.Assessment }](t, rec).Items
+		require.True(t, len(items) > 1)
+		for _, a := range items {
+			require.Equal(t, "Mathematics", a.Subject)
+		}
+	})
+
+	var student string
+	t.Run("enters scores and returns the sheet", func(t *testing.T) {
+		rec := f.do(&teacher, http.MethodGet, "/api/students/assessments/"+created.ID+"/scores", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		sheet := decode[gradebook.ScoreSheet](t, rec)
+		require.True(t, len(sheet.Scores) > 0)
+		require.True(t, sheet.Scores[0].Marks == nil)
+		student = sheet.Scores[0].StudentID
+
+		body := `{"scores":[{"student_id":"` + student + `","marks":36}]}`
+		rec = f.do(&teacher, http.MethodPut, "/api/students/assessments/"+created.ID+"/scores", strings.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 36.0, *decode[gradebook.ScoreSheet](t, rec).Scores[0].Marks)
+	})
+
+	t.Run("results reflect new scores", func(t *testing.T) {
+		get := func() gradebook.StudentResults {
+			rec := f.do(&leader, http.MethodGet, "/api/students/"+student+"/results", nil)
+			require.Equal(t, http.StatusOK, rec.Code)
+			return decode[gradebook.StudentResults](t, rec)
+		}
+		maths := func(r gradebook.StudentResults) gradebook.SubjectResult {
+			for _, s := range r.Subjects {
+				if s.Subject == "Mathematics" {
+					return s
+				}
+			}
+			t.Fatal("no Mathematics results")
+			return gradebook.SubjectResult{}
+		}
+		before := maths(get())
+		require.Equal(t, "A1-F9", get().Scale)
+
+		body := `{"scores":[{"student_id":"` + student + `","status":"absent"}]}`
+		rec := f.do(&teacher, http.MethodPut, "/api/students/assessments/"+created.ID+"/scores", strings.NewReader(body))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		after := maths(get())
+		require.True(t, *after.Overall.Percentage < *before.Overall.Percentage)
+	})
+
+	cases := []struct {
+		name       string
+		teacher    store.Teacher
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "form teacher may not enter scores", teacher: f.formTeacher(), method: http.MethodPut, path: "/api/students/assessments/" + created.ID + "/scores", body: `{"scores":[]}`, wantStatus: http.StatusForbidden},
+		{name: "rejects marks above the maximum", teacher: teacher, method: http.MethodPut, path: "/api/students/assessments/" + created.ID + "/scores", body: `{"scores":[{"student_id":"` + student + `","marks":41}]}`, wantStatus: http.StatusBadRequest},
+		{name: "rejects invalid assessments", teacher: teacher, method: http.MethodPost, path: path, body: `{"subject":"Mathematics","name":"","term":1,"date":"2026-05-04","max_marks":40,"weight":1}`, wantStatus: http.StatusBadRequest},
+		{name: "rejects malformed dates", teacher: teacher, method: http.MethodPost, path: path, body: `{"subject":"Mathematics","name":"Quiz","term":1,"date":"May 4","max_marks":40,"weight":1}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown assessments are not found", teacher: teacher, method: http.MethodGet, path: "/api/students/assessments/nope/scores", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(&tc.teacher, tc.method, tc.path, strings.NewReader(tc.body))
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestGradeScale(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	student := f.ds.Students[0].ID
+	body := `{"name":"Pass/Fail","bands":[{"grade":"P","min":50},{"grade":"F","min":0}]}`
+
+	t.Run("only school leaders change the scale", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPut, "/api/students/gradebook/scale", strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodPut, "/api/students/gradebook/scale", strings.NewReader(body))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "Pass/Fail", decode[store.GradeScale](t, rec).Name)
+
+		rec = f.do(&form, http.MethodGet, "/api/students/gradebook/scale", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 2, len(decode[store.GradeScale](t, rec).Bands))
+	})
+
+	t.Run("results are graded on the new scale", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/"+student+"/results", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "Pass/Fail", decode[gradebook.StudentResults](t, rec).Scale)
+	})
+
+	t.Run("rejects overlapping bands", func(t *testing.T) {
+		overlap := `{"name":"Overlap","bands":[{"grade":"A","min":60},{"grade":"B","min":60},{"grade":"F","min":0}]}`
+
+		rec := f.do(&leader, http.MethodPut, "/api/students/gradebook/scale", strings.NewReader(overlap))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("null restores the default", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodPut, "/api/students/gradebook/scale", strings.NewReader(`null`))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, gradebook.DefaultScale.Name, decode[store.GradeScale](t, rec).Name)
+	})
+}
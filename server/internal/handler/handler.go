@@ -1,11 +1,190 @@
 package handler
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/export"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/insights"
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
+	"github.com/String-sg/teacher-workspace/server/internal/notes"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/profile"
+	"github.com/String-sg/teacher-workspace/server/internal/reports"
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/rosterimport"
+	"github.com/String-sg/teacher-workspace/server/internal/search"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+// Options holds the dependencies of the application routes.
+type Options struct {
+	Store *store.Store
+	// ProfileSources are added to the default sections of the student
+	// profile.
+	ProfileSources []profile.Source
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+	// IDs names new records. It defaults to random.DefaultIDs.
+	IDs *random.IDGenerator
+	// Insights flags students who need attention. NewMux creates one over
+	// Store when it is nil; pass one in to share it with a nightly run.
+	Insights *insights.Engine
+	// Jobs runs background work such as a class's reports. NewMux creates
+	// one when it is nil; pass one in to close it on shutdown.
+	Jobs *jobs.Runner
+	// Posts keeps Parents Gateway posts. NewMux creates one over Store,
+	// which does not save scheduled posts, when it is nil; pass one in to
+	// run its scheduler.
+	Posts *posts.Service
+	// GatewayKeyHash is the token.Hash of the key Parents Gateway calls the
+	// gateway routes with. They reject every request when it is empty.
+	GatewayKeyHash string
+}
+
+// handler holds the services the route handlers share.
+type handler struct {
+	store      *store.Store
+	roster     *roster.Service
+	profile    *profile.Service
+	attendance *attendance.Service
+	gradebook  *gradebook.Service
+	notes      *notes.Service
+	insights   *insights.Engine
+	importer   *rosterimport.Service
+	search     *search.Index
+	export     *export.Service
+	reports    *reports.Service
+	posts      *posts.Service
+
+	gatewayKeyHash string
+}
 
 // NewMux returns a ServeMux with all application routes registered.
-func NewMux() *http.ServeMux {
+func NewMux(opts Options) *http.ServeMux {
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	ids := opts.IDs
+	if ids == nil {
+		ids = random.DefaultIDs
+	}
+	log := audit.New(opts.Store, now)
+	h := &handler{
+		store:      opts.Store,
+		roster:     roster.New(opts.Store),
+		attendance: attendance.New(opts.Store, now),
+		gradebook:  gradebook.New(opts.Store, ids),
+		notes:      notes.New(opts.Store, log, ids, now),
+		importer:   rosterimport.New(opts.Store, log, ids),
+		search:     search.New(opts.Store),
+		posts:      opts.Posts,
+
+		gatewayKeyHash: opts.GatewayKeyHash,
+	}
+	runner := opts.Jobs
+	if runner == nil {
+		runner = jobs.New(ids, now, jobs.DefaultWorkers)
+	}
+	h.reports = reports.New(opts.Store, h.attendance, h.gradebook, h.notes, runner, log)
+	if h.posts == nil {
+		h.posts = posts.New(opts.Store, ids, now, log, nil, nil, nil, nil)
+	}
+	h.export = export.New(opts.Store, h.gradebook, h.posts, log)
+	h.insights = opts.Insights
+	if h.insights == nil {
+		h.insights = insights.New(opts.Store, h.gradebook, h.posts, now)
+	}
+	sources := append(profile.DefaultSources(opts.Store), h.notes.ProfileSource())
+	h.profile = profile.New(opts.Store, append(sources, opts.ProfileSources...)...)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /{$}", root)
+
+	mux.Handle("GET /api/students", h.authenticate(h.listStudents))
+	mux.Handle("GET /api/students/{id}", h.authenticate(h.getStudent))
+	mux.Handle("GET /api/students/classes", h.authenticate(h.listClasses))
+	mux.Handle("GET /api/students/search", h.authenticate(h.searchStudents))
+	mux.Handle("POST /api/students/import", h.authenticate(h.importRoster))
+	mux.Handle("GET /api/students/insights", h.authenticate(h.listInsights))
+	mux.Handle("GET /api/students/insights/thresholds", h.authenticate(h.getInsightThresholds))
+	mux.Handle("PUT /api/students/insights/thresholds", h.authenticate(h.putInsightThresholds))
+	mux.Handle("GET /api/students/gradebook/scale", h.authenticate(h.getGradeScale))
+	mux.Handle("PUT /api/students/gradebook/scale", h.authenticate(h.putGradeScale))
+	mux.Handle("GET /api/students/reports/branding", h.authenticate(h.getReportBranding))
+	mux.Handle("PUT /api/students/reports/branding", h.authenticate(h.putReportBranding))
+	mux.Handle("GET /api/students/reports/jobs/{id}", h.authenticate(h.getReportJob))
+	mux.Handle("GET /api/students/reports/jobs/{id}/download", h.authenticate(h.downloadReportJob))
+	// "/api/students/classes/{id}" and "/api/students/{id}/profile" both match
+	// "/api/students/classes/profile", which ServeMux rejects as a conflict,
+	// so GET paths of the form /api/students/{id}/{sub} are dispatched by hand.
+	mux.Handle("GET /api/students/{id}/{sub}", h.authenticate(h.studentsSubtree))
+	mux.Handle("POST /api/students/{id}/notes", h.authenticate(h.createNote))
+	mux.Handle("GET /api/students/notes/{id}", h.authenticate(h.getNote))
+	mux.Handle("PUT /api/students/notes/{id}", h.authenticate(h.updateNote))
+	mux.Handle("DELETE /api/students/notes/{id}", h.authenticate(h.deleteNote))
+	mux.Handle("GET /api/students/notes/{id}/history", h.authenticate(h.getNoteHistory))
+	mux.Handle("GET /api/students/classes/{id}/students", h.authenticate(h.listClassStudents))
+	mux.Handle("GET /api/students/classes/{id}/export", h.authenticate(h.exportClassList))
+	mux.Handle("GET /api/students/classes/{id}/attendance/export", h.authenticate(h.exportAttendance))
+	mux.Handle("GET /api/students/classes/{id}/gradebook/export", h.authenticate(h.exportGradebook))
+	mux.Handle("POST /api/students/classes/{id}/reports", h.authenticate(h.startClassReports))
+	mux.Handle("GET /api/students/classes/{id}/attendance/summary", h.authenticate(h.getClassAttendanceSummary))
+	mux.Handle("GET /api/students/classes/{id}/attendance/{date}", h.authenticate(h.getRegister))
+	mux.Handle("PUT /api/students/classes/{id}/attendance/{date}", h.authenticate(h.putRegister))
+	mux.Handle("GET /api/students/classes/{id}/attendance/{date}/history", h.authenticate(h.getRegisterHistory))
+	mux.Handle("GET /api/students/classes/{id}/assessments", h.authenticate(h.listAssessments))
+	mux.Handle("POST /api/students/classes/{id}/assessments", h.authenticate(h.createAssessment))
+	mux.Handle("PUT /api/students/assessments/{id}", h.authenticate(h.updateAssessment))
+	mux.Handle("GET /api/students/assessments/{id}/scores", h.authenticate(h.getScores))
+	mux.Handle("PUT /api/students/assessments/{id}/scores", h.authenticate(h.putScores))
+
+	mux.Handle("GET /api/posts", h.authenticate(h.listPosts))
+	mux.Handle("POST /api/posts", h.authenticate(h.createPost))
+	mux.Handle("POST /api/posts/audience", h.authenticate(h.previewAudience))
+	mux.Handle("GET /api/posts/{id}", h.authenticate(h.getPost))
+	mux.Handle("PUT /api/posts/{id}", h.authenticate(h.updatePost))
+	mux.Handle("DELETE /api/posts/{id}", h.authenticate(h.deletePost))
+	mux.Handle("POST /api/posts/{id}/publish", h.authenticate(h.publishPost))
+	mux.Handle("PUT /api/posts/{id}/schedule", h.authenticate(h.schedulePost))
+	mux.Handle("DELETE /api/posts/{id}/schedule", h.authenticate(h.cancelPost))
+	mux.Handle("POST /api/posts/{id}/submit", h.authenticate(h.submitPost))
+	mux.Handle("POST /api/posts/{id}/withdraw", h.authenticate(h.withdrawPost))
+	mux.Handle("POST /api/posts/{id}/approve", h.authenticate(h.approvePost))
+	mux.Handle("POST /api/posts/{id}/reject", h.authenticate(h.rejectPost))
+	mux.Handle("POST /api/posts/{id}/attachments", h.authenticate(h.attach))
+	mux.Handle("GET /api/posts/{id}/attachments/{attachment}", h.authenticate(h.getAttachment))
+	mux.Handle("DELETE /api/posts/{id}/attachments/{attachment}", h.authenticate(h.detach))
+	mux.Handle("GET /api/posts/{id}/preview", h.authenticate(h.previewPost))
+	mux.Handle("GET /api/posts/{id}/recipients", h.authenticate(h.listRecipients))
+	mux.Handle("GET /api/posts/{id}/responses", h.authenticate(h.listConsentResponses))
+	mux.Handle("GET /api/posts/{id}/receipts", h.authenticate(h.listReceipts))
+	mux.Handle("GET /api/posts/{id}/receipts/export", h.authenticate(h.exportNonReaders))
+	mux.Handle("GET /api/posts/{id}/notifications", h.authenticate(h.listDeliveries))
+	mux.Handle("GET /api/approvals", h.authenticate(h.listApprovals))
+	mux.Handle("GET /api/approvals/rules", h.authenticate(h.getApprovalRules))
+	mux.Handle("PUT /api/approvals/rules", h.authenticate(h.putApprovalRules))
+	mux.Handle("GET /api/groups", h.authenticate(h.listGroups))
+	mux.Handle("POST /api/groups", h.authenticate(h.createGroup))
+	mux.Handle("GET /api/groups/{id}", h.authenticate(h.getGroup))
+	mux.Handle("PUT /api/groups/{id}", h.authenticate(h.updateGroup))
+	mux.Handle("DELETE /api/groups/{id}", h.authenticate(h.deleteGroup))
+	mux.Handle("GET /api/templates", h.authenticate(h.listTemplates))
+	mux.Handle("POST /api/templates", h.authenticate(h.createTemplate))
+	mux.Handle("GET /api/templates/{id}", h.authenticate(h.getTemplate))
+	mux.Handle("PUT /api/templates/{id}", h.authenticate(h.updateTemplate))
+	mux.Handle("DELETE /api/templates/{id}", h.authenticate(h.deleteTemplate))
+
+	mux.Handle("POST /api/gateway/posts/{id}/responses", h.authenticateGateway(h.respondToConsent))
+	mux.Handle("POST /api/gateway/posts/{id}/receipts", h.authenticateGateway(h.recordReceipts))
+	mux.Handle("PUT /api/gateway/guardians/{id}/opt-out", h.authenticateGateway(h.putOptOut))
+	mux.Handle("PUT /api/gateway/guardians/{id}/language", h.authenticateGateway(h.putLanguage))
+	mux.Handle("GET /api/gateway/posts/{id}/attachments/{attachment}", h.authenticateGateway(h.getPublishedAttachment))
 	return mux
 }
 
@@ -6,10 +6,11 @@ import (
 	"testing"
 
 	"github.com/String-sg/teacher-workspace/server/internal/handler"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
 )
 
 func TestNewMux(t *testing.T) {
-	mux := handler.NewMux()
+	mux := handler.NewMux(handler.Options{Store: store.New(&store.Dataset{})})
 
 	t.Run("GET / returns 200 with body OK", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -0,0 +1,101 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/handler"
+	"github.com/String-sg/teacher-workspace/server/internal/seed"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// fixture is a seeded two-school dataset served by a fresh mux.
+type fixture struct {
+	t     *testing.T
+	ds    store.Dataset
+	store *store.Store
+	mux   *http.ServeMux
+	keys  map[string]string // teacher ID to plaintext API key
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	cfg := seed.DefaultConfig()
+	cfg.Schools = 2
+	cfg.LevelsPerSchool = 2
+	cfg.ClassesPerLevel = 2
+	cfg.StudentsPerClass = 8
+	cfg.SchoolDays = 10
+
+	ds, err := seed.Generate(cfg)
+	require.NoError(t, err)
+	creds, err := seed.MintAPIKeys(ds, cfg)
+	require.NoError(t, err)
+
+	f := &fixture{t: t, ds: *ds, keys: make(map[string]string)}
+	for _, c := range creds {
+		f.keys[c.TeacherID] = c.Token
+	}
+	f.store = store.New(ds)
+	f.mux = handler.NewMux(handler.Options{Store: f.store})
+	return f
+}
+
+// leader returns the school leader of the first school.
+func (f *fixture) leader() store.Teacher {
+	for _, t := range f.ds.Teachers {
+		if t.SchoolID == f.ds.Schools[0].ID && t.Role == store.RoleSchoolLeader {
+			return t
+		}
+	}
+	f.t.Fatal("fixture has no school leader")
+	return store.Teacher{}
+}
+
+// formTeacher returns the form teacher of the first class.
+func (f *fixture) formTeacher() store.Teacher {
+	t, _ := f.store.Teacher(f.ds.Classes[0].FormTeacherID)
+	return t
+}
+
+// subjectTeacher returns a teacher who teaches subject to the first class
+// without being its form teacher.
+func (f *fixture) subjectTeacher(subject string) store.Teacher {
+	for _, tg := range f.store.TeachingByClass(f.ds.Classes[0].ID) {
+		if tg.Subject == subject && tg.TeacherID != f.ds.Classes[0].FormTeacherID {
+			t, _ := f.store.Teacher(tg.TeacherID)
+			return t
+		}
+	}
+	f.t.Fatalf("fixture has no %s teacher", subject)
+	return store.Teacher{}
+}
+
+// do serves a request as teacher, or anonymously when teacher is nil.
+func (f *fixture) do(teacher *store.Teacher, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	f.t.Helper()
+
+	req := httptest.NewRequest(method, path, body)
+	if teacher != nil {
+		req.Header.Set("Authorization", "Bearer "+f.keys[teacher.ID])
+	}
+	rec := httptest.NewRecorder()
+	f.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals a JSON response body into a value of type T.
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("failed to decode %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
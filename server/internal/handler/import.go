@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/String-sg/teacher-workspace/server/internal/rosterimport"
+)
+
+// maxUploadMemory is how much of a multipart upload is held in memory before
+// the rest spills to disk.
+const maxUploadMemory = 1 << 20
+
+// importRoster serves POST /api/students/import, which imports a class list
+// into the teacher's school. The multipart form carries the CSV or XLSX
+// "file", an optional "mapping" of field names to column headers as a JSON
+// object, and "dry_run". Rows with errors are reported in a 200 response;
+// nothing is written unless every row is valid.
+func (h *handler) importRoster(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, rosterimport.MaxFileSize+maxBodyBytes)
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, codeInvalidRequest, fmt.Sprintf("upload must not exceed %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "body must be a multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var o rosterimport.Options
+	if v := r.FormValue("dry_run"); v != "" {
+		var err error
+		if o.DryRun, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, "dry_run must be true or false")
+			return
+		}
+	}
+	if v := r.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &o.Mapping); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, "mapping must be a JSON object of field names to column headers")
+			return
+		}
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(file, head)
+	format, err := rosterimport.FormatOf(header.Filename, head[:n])
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	rows, err := rosterimport.Read(format, file)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	report, err := h.importer.Import(h.scope(r), rows, o)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func writeImportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, rosterimport.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, rosterimport.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, rosterimport.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
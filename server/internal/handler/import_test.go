@@ -0,0 +1,88 @@
+package handler_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/rosterimport"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// upload posts a class list named name to the import endpoint as teacher,
+// with the given extra form fields.
+func (f *fixture) upload(teacher store.Teacher, name, content string, fields map[string]string) *httptest.ResponseRecorder {
+	f.t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", name)
+	require.NoError(f.t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(f.t, err)
+	for k, v := range fields {
+		require.NoError(f.t, mw.WriteField(k, v))
+	}
+	require.NoError(f.t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/students/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+f.keys[teacher.ID])
+	rec := httptest.NewRecorder()
+	f.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportRoster(t *testing.T) {
+	f := newFixture(t)
+	leader := f.leader()
+	class := f.ds.Classes[0]
+	list := "Admission No,Name,Class,Index,Guardian Name,Guardian Email\n" +
+		"A100,Tan Wei Ming," + class.Name + ",90,Tan Ah Kow,ahkow@example.com\n"
+
+	t.Run("dry run validates without writing", func(t *testing.T) {
+		rec := f.upload(leader, "list.csv", list, map[string]string{"dry_run": "true"})
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		report := decode[rosterimport.Report](t, rec)
+		require.False(t, report.Applied)
+		require.Equal(t, 1, report.Created)
+		_, ok := f.store.StudentByExternalID(leader.SchoolID, "A100")
+		require.False(t, ok)
+	})
+
+	t.Run("imports a class list", func(t *testing.T) {
+		rec := f.upload(leader, "list.csv", list, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.True(t, decode[rosterimport.Report](t, rec).Applied)
+		st, ok := f.store.StudentByExternalID(leader.SchoolID, "A100")
+		require.True(t, ok)
+		require.Equal(t, class.ID, st.ClassID)
+	})
+
+	t.Run("reports row errors", func(t *testing.T) {
+		rec := f.upload(leader, "list.csv", "student_id,name,class,index_number,guardian_name\nA101,Lim Jia Hui,9Z,1,\n", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		report := decode[rosterimport.Report](t, rec)
+		require.False(t, report.Applied)
+		require.Equal(t, 2, len(report.Errors))
+		require.Equal(t, 2, report.Errors[0].Row)
+	})
+
+	t.Run("rejects files without the required columns", func(t *testing.T) {
+		rec := f.upload(leader, "list.csv", "name\nTan Wei Ming\n", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("only school leaders import", func(t *testing.T) {
+		rec := f.upload(f.formTeacher(), "list.csv", list, nil)
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
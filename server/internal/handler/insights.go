@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/String-sg/teacher-workspace/server/internal/insights"
+)
+
+// listInsights serves GET /api/students/insights, the flagged students
+// visible to the teacher with the reasons they were flagged, filtered by the
+// optional "level", "class" and "rule" query parameters.
+func (h *handler) listInsights(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	flags, err := h.insights.Flagged(h.scope(r), insights.Filter{
+		LevelID: q.Get("level"),
+		ClassID: q.Get("class"),
+		Rule:    q.Get("rule"),
+	})
+	if err != nil {
+		writeInsightsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, paginate(flags, limit, offset))
+}
+
+// getStudentInsights serves GET /api/students/{id}/insights. A student who is
+// not flagged has no reasons.
+func (h *handler) getStudentInsights(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.insights.Student(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeInsightsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, flag)
+}
+
+// getInsightThresholds serves GET /api/students/insights/thresholds, the
+// thresholds in effect at the teacher's school.
+func (h *handler) getInsightThresholds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.insights.Thresholds(h.scope(r)))
+}
+
+// putInsightThresholds serves PUT /api/students/insights/thresholds. Only
+// school leaders may change them; fields left out keep their current
+// values.
+func (h *handler) putInsightThresholds(w http.ResponseWriter, r *http.Request) {
+	in := h.insights.Thresholds(h.scope(r))
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	th, err := h.insights.SetThresholds(h.scope(r), in)
+	if err != nil {
+		writeInsightsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, th)
+}
+
+func writeInsightsError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, insights.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, insights.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, insights.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,87 @@
+package handler_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/insights"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestInsights(t *testing.T) {
+	f := newFixture(t)
+	class := f.ds.Classes[0]
+	form, leader := f.formTeacher(), f.leader()
+	student := f.store.StudentsInClass(class.ID)[0]
+
+	var records []store.Attendance
+	for day := range 4 {
+		date := store.Date{Year: 2026, Month: time.December, Day: 1 + day}
+		records = append(records, store.Attendance{StudentID: student.ID, ClassID: class.ID, Date: date, Status: store.AttendanceAbsent})
+	}
+	f.store.PutAttendance(records, form.ID, time.Now())
+
+	t.Run("lists flagged students with their reasons", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/insights?class="+class.ID+"&rule=consecutive_absences", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := decode[listResponse[insights.Flag]](t, rec)
+		require.Equal(t, 1, body.Total)
+		require.Equal(t, student.ID, body.Items[0].Student.ID)
+		require.Equal(t, insights.RuleConsecutiveAbsences, body.Items[0].Reasons[0].Rule)
+		require.Equal(t, 4.0, body.Items[0].Reasons[0].Value)
+	})
+
+	t.Run("returns one student's reasons", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/"+student.ID+"/insights", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.True(t, len(decode[insights.Flag](t, rec).Reasons) > 0)
+	})
+
+	t.Run("rejects unknown rules", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/insights?rule=horoscope", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("only school leaders change thresholds", func(t *testing.T) {
+		body := `{"consecutive_absences":5}`
+
+		rec := f.do(&form, http.MethodPut, "/api/students/insights/thresholds", strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodPut, "/api/students/insights/thresholds", strings.NewReader(body))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 5, decode[store.InsightThresholds](t, rec).ConsecutiveAbsences)
+
+		rec = f.do(&form, http.MethodGet, "/api/students/insights/thresholds", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		th := decode[store.InsightThresholds](t, rec)
+		require.Equal(t, 5, th.ConsecutiveAbsences)
+		require.Equal(t, insights.DefaultThresholds.MinAttendanceRate, th.MinAttendanceRate)
+
+		rec = f.do(&form, http.MethodGet, "/api/students/insights?class="+class.ID+"&rule=consecutive_absences", nil)
+		require.Equal(t, 0, decode[listResponse[insights.Flag]](t, rec).Total)
+	})
+
+	t.Run("rejects out of range thresholds", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodPut, "/api/students/insights/thresholds", strings.NewReader(`{"min_attendance_rate":150}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("rejects a zero rather than taking the default", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodPut, "/api/students/insights/thresholds", strings.NewReader(`{"min_attendance_rate":0}`))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = f.do(&leader, http.MethodPut, "/api/students/insights/thresholds", strings.NewReader(`{"grade_drop_points":15}`))
+		require.Equal(t, http.StatusOK, rec.Code)
+		th := decode[store.InsightThresholds](t, rec)
+		require.Equal(t, 15.0, th.GradeDropPoints)
+		require.Equal(t, 5, th.ConsecutiveAbsences)
+	})
+}
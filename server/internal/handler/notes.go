@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/notes"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// notesResponse is the body of a note listing.
+type notesResponse struct {
+	Items []store.Note `json:"items"`
+}
+
+// noteHistoryResponse is the body of a note's history.
+type noteHistoryResponse struct {
+	Items []store.NoteRevision `json:"items"`
+}
+
+// listNotes serves GET /api/students/{id}/notes, the notes about a student
+// the teacher may read, filtered by the optional "category" and "tag" query
+// parameters.
+func (h *handler) listNotes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ns, err := h.notes.List(h.scope(r), r.PathValue("id"), notes.Filter{
+		Category: q.Get("category"),
+		Tag:      q.Get("tag"),
+	})
+	if err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, notesResponse{Items: ns})
+}
+
+// createNote serves POST /api/students/{id}/notes.
+func (h *handler) createNote(w http.ResponseWriter, r *http.Request) {
+	var in notes.Input
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	n, err := h.notes.Create(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	writeNote(w, http.StatusCreated, n)
+}
+
+// getNote serves GET /api/students/notes/{id}.
+func (h *handler) getNote(w http.ResponseWriter, r *http.Request) {
+	n, err := h.notes.Get(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	writeNote(w, http.StatusOK, n)
+}
+
+// updateNote serves PUT /api/students/notes/{id}. An If-Match header
+// holding the ETag of the version the change was made to makes it fail
+// once the note has moved on.
+func (h *handler) updateNote(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	var in notes.Input
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	n, err := h.notes.Update(h.scope(r), r.PathValue("id"), version, in)
+	if err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	writeNote(w, http.StatusOK, n)
+}
+
+// deleteNote serves DELETE /api/students/notes/{id}. Notes are soft-deleted
+// and keep their history. If-Match is as for updateNote.
+func (h *handler) deleteNote(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if err := h.notes.Delete(h.scope(r), r.PathValue("id"), version); err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getNoteHistory serves GET /api/students/notes/{id}/history.
+func (h *handler) getNoteHistory(w http.ResponseWriter, r *http.Request) {
+	revs, err := h.notes.History(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeNotesError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, noteHistoryResponse{Items: revs})
+}
+
+// writeNote writes note n with its version as its ETag.
+func writeNote(w http.ResponseWriter, status int, n store.Note) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(n.Version)))
+	writeJSON(w, status, n)
+}
+
+// ifMatch returns the note version in the request's If-Match header, or 0
+// if it has none.
+func ifMatch(r *http.Request) (int, error) {
+	etag := r.Header.Get("If-Match")
+	if etag == "" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`))
+	if err != nil || version < 1 {
+		return 0, errors.New("If-Match must be the ETag of a version of the note")
+	}
+	return version, nil
+}
+
+func writeNotesError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, notes.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, notes.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, notes.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	case errors.Is(err, notes.ErrConflict):
+		writeError(w, http.StatusPreconditionFailed, codeConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
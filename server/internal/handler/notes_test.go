@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestNotes(t *testing.T) {
+	f := newFixture(t)
+	author, form, leader := f.subjectTeacher("Mathematics"), f.formTeacher(), f.leader()
+	// Mathematics is a core subject, so every student in the class takes it.
+	student := f.store.StudentsInClass(f.ds.Classes[0].ID)[0].ID
+	body := `{"visibility":"form_teacher","category":"wellbeing","tags":["follow-up"],"body":"Appeared tired in class.","sensitive":true}`
+
+	var note store.Note
+	t.Run("author creates a note", func(t *testing.T) {
+		rec := f.do(&author, http.MethodPost, "/api/students/"+student+"/notes", strings.NewReader(body))
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		note = decode[store.Note](t, rec)
+		require.Equal(t, author.ID, note.AuthorID)
+	})
+
+	t.Run("form teacher reads it and the read is audited", func(t *testing.T) {
+		before := len(f.store.AuditLog())
+
+		rec := f.do(&form, http.MethodGet, "/api/students/"+student+"/notes?tag=follow-up", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, len(decode[listResponse[store.Note]](t, rec).Items))
+		log := f.store.AuditLog()
+		require.Equal(t, before+1, len(log))
+		require.Equal(t, form.ID, log[len(log)-1].ActorID)
+	})
+
+	t.Run("leader cannot read a form teacher note", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/notes/"+note.ID, nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("form teacher cannot edit another teacher's note", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPut, "/api/students/notes/"+note.ID, strings.NewReader(body))
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("author's edit to a stale version is refused", func(t *testing.T) {
+		put := func(etag string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/api/students/notes/"+note.ID, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+f.keys[author.ID])
+			req.Header.Set("If-Match", etag)
+			rec := httptest.NewRecorder()
+			f.mux.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec := put(`"1"`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+		rec = put(`"1"`)
+		require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		rec = put("latest")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("author edits, deletes and reviews history", func(t *testing.T) {
+		edit := strings.Replace(body, "Appeared tired", "Appeared tired again", 1)
+		rec := f.do(&author, http.MethodPut, "/api/students/notes/"+note.ID, strings.NewReader(edit))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = f.do(&author, http.MethodDelete, "/api/students/notes/"+note.ID, nil)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = f.do(&author, http.MethodGet, "/api/students/notes/"+note.ID, nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects invalid notes", func(t *testing.T) {
+		rec := f.do(&author, http.MethodPost, "/api/students/"+student+"/notes", strings.NewReader(`{"visibility":"public","category":"academic","body":"x"}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
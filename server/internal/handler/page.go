@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// page is one page of a listing. NextOffset is set when more items follow.
+type page[T any] struct {
+	Items      []T  `json:"items"`
+	Total      int  `json:"total"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+// parsePage reads the "limit" and "offset" query parameters.
+func parsePage(q url.Values) (limit, offset int, err error) {
+	limit, offset = defaultPageLimit, 0
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, errors.New("limit must be between 1 and 200")
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+// paginate returns the page of items starting at offset.
+func paginate[T any](items []T, limit, offset int) page[T] {
+	p := page[T]{Items: []T{}, Total: len(items), Limit: limit, Offset: offset}
+	if offset >= len(items) {
+		return p
+	}
+
+	end := min(offset+limit, len(items))
+	p.Items = items[offset:end]
+	if end < len(items) {
+		p.NextOffset = &end
+	}
+	return p
+}
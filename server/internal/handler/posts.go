@@ -0,0 +1,679 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/export"
+	"github.com/String-sg/teacher-workspace/server/internal/lang"
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// groupsResponse is the body of a group listing.
+type groupsResponse struct {
+	Items []store.Group `json:"items"`
+}
+
+// templatesResponse is the body of a post template listing.
+type templatesResponse struct {
+	Items []store.PostTemplate `json:"items"`
+}
+
+// approvalsResponse is the body of an approver's queue.
+type approvalsResponse struct {
+	Items []posts.View `json:"items"`
+}
+
+// listedPost is a post in a listing, with its version in the language the
+// request prefers.
+type listedPost struct {
+	store.Post
+	Version posts.Version `json:"version"`
+}
+
+// reviewRequest is the body of an approver's decision on a post.
+type reviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// scheduleRequest is the body of a post's schedule.
+type scheduleRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// audienceRequest is the body of an audience preview.
+type audienceRequest struct {
+	Targets []store.Target `json:"targets"`
+}
+
+// listPosts serves GET /api/posts, the posts the teacher may see, newest
+// first, filtered by the optional "status" and "author" query parameters.
+// Each carries a version in the language Accept-Language prefers.
+func (h *handler) listPosts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := store.PostStatus(q.Get("status"))
+	switch status {
+	case "", store.PostDraft, store.PostPendingApproval, store.PostApproved, store.PostRejected, store.PostScheduled, store.PostPublished:
+	default:
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "status must be draft, pending_approval, approved, rejected, scheduled or published")
+		return
+	}
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	ps := h.posts.List(h.scope(r), posts.Filter{Status: status, AuthorID: q.Get("author")})
+	items := make([]listedPost, len(ps))
+	for i, p := range ps {
+		items[i] = listedPost{Post: p, Version: localize(r, p)}
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	writeJSON(w, http.StatusOK, paginate(items, limit, offset))
+}
+
+// createPost serves POST /api/posts, which saves a draft.
+func (h *handler) createPost(w http.ResponseWriter, r *http.Request) {
+	var in posts.Input
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	v, err := h.posts.Create(h.scope(r), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusCreated, v)
+}
+
+// previewAudience serves POST /api/posts/audience, the number of students
+// and guardians a set of targets reaches, for showing while a post is
+// written.
+func (h *handler) previewAudience(w http.ResponseWriter, r *http.Request) {
+	var in audienceRequest
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	a, err := h.posts.Audience(h.scope(r), in.Targets)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+// getPost serves GET /api/posts/{id}.
+func (h *handler) getPost(w http.ResponseWriter, r *http.Request) {
+	v, err := h.posts.Get(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// updatePost serves PUT /api/posts/{id}. Published posts may not be
+// changed.
+func (h *handler) updatePost(w http.ResponseWriter, r *http.Request) {
+	var in posts.Input
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	v, err := h.posts.Update(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// deletePost serves DELETE /api/posts/{id}. Published posts may not be
+// deleted.
+func (h *handler) deletePost(w http.ResponseWriter, r *http.Request) {
+	if err := h.posts.Delete(h.scope(r), r.PathValue("id")); err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishPost serves POST /api/posts/{id}/publish, which publishes a draft
+// or scheduled post straight away.
+func (h *handler) publishPost(w http.ResponseWriter, r *http.Request) {
+	v, err := h.posts.Publish(r.Context(), h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// schedulePost serves PUT /api/posts/{id}/schedule, which schedules a draft
+// or reschedules a scheduled post to be published at "publish_at".
+func (h *handler) schedulePost(w http.ResponseWriter, r *http.Request) {
+	var in scheduleRequest
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if in.PublishAt == nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "publish_at is required")
+		return
+	}
+
+	v, err := h.posts.Schedule(h.scope(r), r.PathValue("id"), *in.PublishAt)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// cancelPost serves DELETE /api/posts/{id}/schedule, which returns a
+// scheduled post to being a draft.
+func (h *handler) cancelPost(w http.ResponseWriter, r *http.Request) {
+	v, err := h.posts.Cancel(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// submitPost serves POST /api/posts/{id}/submit, which sends a draft or
+// rejected post that needs approval to the school's approvers.
+func (h *handler) submitPost(w http.ResponseWriter, r *http.Request) {
+	v, err := h.posts.Submit(r.Context(), h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// withdrawPost serves POST /api/posts/{id}/withdraw, which returns a post
+// waiting for approval to being a draft.
+func (h *handler) withdrawPost(w http.ResponseWriter, r *http.Request) {
+	v, err := h.posts.Withdraw(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// approvePost serves POST /api/posts/{id}/approve, with an optional
+// "comment".
+func (h *handler) approvePost(w http.ResponseWriter, r *http.Request) {
+	h.reviewPost(w, r, h.posts.Approve)
+}
+
+// rejectPost serves POST /api/posts/{id}/reject, whose "comment" says why.
+func (h *handler) rejectPost(w http.ResponseWriter, r *http.Request) {
+	h.reviewPost(w, r, h.posts.Reject)
+}
+
+func (h *handler) reviewPost(w http.ResponseWriter, r *http.Request, review func(context.Context, authz.Scope, string, string) (posts.View, error)) {
+	// An approval needs no comment, so it needs no body either.
+	var in reviewRequest
+	if r.ContentLength != 0 {
+		if err := readJSON(w, r, &in); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	v, err := review(r.Context(), h.scope(r), r.PathValue("id"), in.Comment)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeView(w, r, http.StatusOK, v)
+}
+
+// listApprovals serves GET /api/approvals, the posts waiting for the
+// teacher's review, submitted longest ago first.
+func (h *handler) listApprovals(w http.ResponseWriter, r *http.Request) {
+	vs, err := h.posts.Approvals(h.scope(r))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	for i := range vs {
+		vs[i].Version = localize(r, vs[i].Post)
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	writeJSON(w, http.StatusOK, approvalsResponse{Items: vs})
+}
+
+// getApprovalRules serves GET /api/approvals/rules, which posts at the
+// teacher's school need approving.
+func (h *handler) getApprovalRules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.posts.ApprovalRules(h.scope(r)))
+}
+
+// putApprovalRules serves PUT /api/approvals/rules, through which school
+// leaders set which posts need approving and who may approve them.
+func (h *handler) putApprovalRules(w http.ResponseWriter, r *http.Request) {
+	var in store.ApprovalRules
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	rules, err := h.posts.SetApprovalRules(h.scope(r), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// attach serves POST /api/posts/{id}/attachments, which attaches the
+// multipart form's "file" to a post.
+func (h *handler) attach(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, posts.MaxAttachmentSize+maxBodyBytes)
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, codeInvalidRequest, fmt.Sprintf("upload must not exceed %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "body must be a multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, posts.MaxAttachmentSize+1))
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if len(data) > posts.MaxAttachmentSize {
+		writeError(w, http.StatusRequestEntityTooLarge, codeInvalidRequest, fmt.Sprintf("attachments must not exceed %d bytes", posts.MaxAttachmentSize))
+		return
+	}
+
+	a, err := h.posts.Attach(r.Context(), h.scope(r), r.PathValue("id"), header.Filename, data)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, a)
+}
+
+// getAttachment serves GET /api/posts/{id}/attachments/{attachment}, the
+// attached file.
+func (h *handler) getAttachment(w http.ResponseWriter, r *http.Request) {
+	a, body, err := h.posts.OpenAttachment(r.Context(), h.scope(r), r.PathValue("id"), r.PathValue("attachment"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeAttachment(w, r, a, body)
+}
+
+// getPublishedAttachment serves
+// GET /api/gateway/posts/{id}/attachments/{attachment}, through which
+// Parents Gateway fetches the files attached to published posts.
+func (h *handler) getPublishedAttachment(w http.ResponseWriter, r *http.Request) {
+	a, body, err := h.posts.OpenPublishedAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeAttachment(w, r, a, body)
+}
+
+// writeAttachment streams body, the content of a, as a download. The
+// content type is the sniffed one, and browsers are told not to sniff
+// again.
+func writeAttachment(w http.ResponseWriter, r *http.Request, a store.Attachment, body io.ReadCloser) {
+	defer body.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		middleware.LoggerFromContext(r.Context()).ErrorContext(r.Context(), "sending attachment failed", "err", err)
+	}
+}
+
+// detach serves DELETE /api/posts/{id}/attachments/{attachment}.
+func (h *handler) detach(w http.ResponseWriter, r *http.Request) {
+	if err := h.posts.Detach(r.Context(), h.scope(r), r.PathValue("id"), r.PathValue("attachment")); err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listRecipients serves GET /api/posts/{id}/recipients, the guardians a post
+// is sent to and why, and those left out.
+func (h *handler) listRecipients(w http.ResponseWriter, r *http.Request) {
+	res, err := h.posts.Recipients(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// listDeliveries serves GET /api/posts/{id}/notifications, how the emails
+// about a post are getting on, guardian by guardian.
+func (h *handler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	d, err := h.posts.Deliveries(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// previewPost serves GET /api/posts/{id}/preview, the post with its merge
+// fields filled for the guardian in the optional "guardian_id" query
+// parameter, or for its first recipient, and the fields that cannot be. It
+// is in the guardian's language unless the "language" query parameter
+// names another.
+func (h *handler) previewPost(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p, err := h.posts.Preview(h.scope(r), r.PathValue("id"), q.Get("guardian_id"), store.Language(q.Get("language")))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// listConsentResponses serves GET /api/posts/{id}/responses, where the
+// responses to a consent form stand, listing the students filtered by the
+// optional "status" query parameter: responded or outstanding.
+func (h *handler) listConsentResponses(w http.ResponseWriter, r *http.Request) {
+	rs, err := h.posts.Responses(h.scope(r), r.PathValue("id"), posts.ResponseStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rs)
+}
+
+// respondToConsent serves POST /api/gateway/posts/{id}/responses, through
+// which Parents Gateway passes on a guardian's consent form response.
+func (h *handler) respondToConsent(w http.ResponseWriter, r *http.Request) {
+	var in posts.ResponseInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	res, err := h.posts.Respond(r.PathValue("id"), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// listReceipts serves GET /api/posts/{id}/receipts, how far a published post
+// has reached its recipients overall and by class, listing the guardians
+// filtered by the optional "status" query parameter, unread or
+// unacknowledged, and "class_id".
+func (h *handler) listReceipts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rs, err := h.posts.Receipts(h.scope(r), r.PathValue("id"), posts.ReceiptFilter{
+		Status:  posts.ReceiptStatus(q.Get("status")),
+		ClassID: q.Get("class_id"),
+	})
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rs)
+}
+
+// exportNonReaders serves GET /api/posts/{id}/receipts/export, the guardians
+// who have not opened a published post as a spreadsheet.
+func (h *handler) exportNonReaders(w http.ResponseWriter, r *http.Request) {
+	h.writeExport(w, r, func() (export.Table, error) {
+		return h.export.NonReaders(h.scope(r), r.PathValue("id"))
+	})
+}
+
+type receiptsRequest struct {
+	Events []posts.ReceiptEvent `json:"events"`
+}
+
+// recordReceipts serves POST /api/gateway/posts/{id}/receipts, through which
+// Parents Gateway passes on a batch of deliveries, opens and
+// acknowledgements of a post. A batch may be sent again safely.
+func (h *handler) recordReceipts(w http.ResponseWriter, r *http.Request) {
+	var in receiptsRequest
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	n, err := h.posts.Record(r.PathValue("id"), in.Events)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+type optOutRequest struct {
+	OptedOut *bool `json:"opted_out"`
+}
+
+// putOptOut serves PUT /api/gateway/guardians/{id}/opt-out, through which
+// Parents Gateway passes on a guardian opting out of posts or back in.
+func (h *handler) putOptOut(w http.ResponseWriter, r *http.Request) {
+	var in optOutRequest
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if in.OptedOut == nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "opted_out is required")
+		return
+	}
+
+	g, err := h.posts.SetOptOut(r.PathValue("id"), *in.OptedOut)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+type languageRequest struct {
+	Language store.Language `json:"language"`
+}
+
+// putLanguage serves PUT /api/gateway/guardians/{id}/language, through
+// which Parents Gateway passes on the language a guardian prefers posts in.
+func (h *handler) putLanguage(w http.ResponseWriter, r *http.Request) {
+	var in languageRequest
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	g, err := h.posts.SetLanguage(r.PathValue("id"), in.Language)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// listGroups serves GET /api/groups, the teacher's own groups.
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, groupsResponse{Items: h.posts.Groups(h.scope(r))})
+}
+
+// createGroup serves POST /api/groups.
+func (h *handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var in posts.GroupInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	g, err := h.posts.CreateGroup(h.scope(r), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, g)
+}
+
+// getGroup serves GET /api/groups/{id}.
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	g, err := h.posts.Group(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// updateGroup serves PUT /api/groups/{id}.
+func (h *handler) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var in posts.GroupInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	g, err := h.posts.UpdateGroup(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// deleteGroup serves DELETE /api/groups/{id}.
+func (h *handler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.posts.DeleteGroup(h.scope(r), r.PathValue("id")); err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listTemplates serves GET /api/templates, the post templates the teacher
+// may use: their own and their school's.
+func (h *handler) listTemplates(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, templatesResponse{Items: h.posts.Templates(h.scope(r))})
+}
+
+// createTemplate serves POST /api/templates.
+func (h *handler) createTemplate(w http.ResponseWriter, r *http.Request) {
+	var in posts.TemplateInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	t, err := h.posts.CreateTemplate(h.scope(r), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// getTemplate serves GET /api/templates/{id}.
+func (h *handler) getTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := h.posts.Template(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// updateTemplate serves PUT /api/templates/{id}.
+func (h *handler) updateTemplate(w http.ResponseWriter, r *http.Request) {
+	var in posts.TemplateInput
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	t, err := h.posts.UpdateTemplate(h.scope(r), r.PathValue("id"), in)
+	if err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// deleteTemplate serves DELETE /api/templates/{id}.
+func (h *handler) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.posts.DeleteTemplate(h.scope(r), r.PathValue("id")); err != nil {
+		writePostsError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeView writes post view v, its version in the language the request's
+// Accept-Language header prefers.
+func writeView(w http.ResponseWriter, r *http.Request, status int, v posts.View) {
+	v.Version = localize(r, v.Post)
+	w.Header().Set("Content-Language", string(v.Version.Language))
+	w.Header().Add("Vary", "Accept-Language")
+	writeJSON(w, status, v)
+}
+
+// localize returns post p in the language of those it is available in that
+// the request's Accept-Language header prefers, or in English.
+func localize(r *http.Request, p store.Post) posts.Version {
+	l, ok := lang.Negotiate(r.Header.Get("Accept-Language"), lang.Available(p))
+	if !ok {
+		l = store.LanguageEnglish
+	}
+	return posts.Localize(p, l)
+}
+
+func writePostsError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, posts.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, posts.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, posts.ErrConflict):
+		writeError(w, http.StatusConflict, codeConflict, err.Error())
+	case errors.Is(err, posts.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,595 @@
+package handler_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/handler"
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/notify"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/blob"
+	"github.com/String-sg/teacher-workspace/server/pkg/email"
+	"github.com/String-sg/teacher-workspace/server/pkg/email/emailtest"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/scan"
+	"github.com/String-sg/teacher-workspace/server/pkg/token"
+)
+
+func TestPosts(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	class := f.ds.Classes[0].ID
+	body := `{"title":"Learning Journey","body":"<p>Meet at the <b>school hall</b>.</p><img src=x onerror=alert(1)>","targets":[{"type":"class","id":"` + class + `"}]}`
+
+	var post posts.View
+	t.Run("form teacher saves a draft for their class", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(body))
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		post = decode[posts.View](t, rec)
+		require.Equal(t, store.PostDraft, post.Status)
+		require.Equal(t, "<p>Meet at the <b>school hall</b>.</p>", post.Body)
+		require.Equal(t, len(f.store.StudentsInClass(class)), post.Audience.Students)
+		require.True(t, post.Audience.Guardians > 0)
+	})
+
+	t.Run("previews the audience of targets", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts/audience", strings.NewReader(`{"targets":[{"type":"class","id":"`+class+`"}]}`))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, post.Audience, decode[posts.Audience](t, rec))
+	})
+
+	t.Run("rejects classes the teacher does not teach", func(t *testing.T) {
+		other := strings.Replace(body, class, f.ds.Classes[1].ID, 1)
+
+		rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(other))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("leader sees drafts but may not edit them", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/posts?status=draft&author="+form.ID, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, decode[listResponse[store.Post]](t, rec).Total)
+
+		rec = f.do(&leader, http.MethodPut, "/api/posts/"+post.ID, strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("author schedules and cancels", func(t *testing.T) {
+		at := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+		rec := f.do(&form, http.MethodPut, "/api/posts/"+post.ID+"/schedule", strings.NewReader(`{"publish_at":"`+at+`"}`))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostScheduled, decode[posts.View](t, rec).Status)
+
+		rec = f.do(&form, http.MethodGet, "/api/posts?status=scheduled", nil)
+		require.Equal(t, 1, decode[listResponse[store.Post]](t, rec).Total)
+
+		rec = f.do(&form, http.MethodDelete, "/api/posts/"+post.ID+"/schedule", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostDraft, decode[posts.View](t, rec).Status)
+	})
+
+	t.Run("rejects schedules without a time", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPut, "/api/posts/"+post.ID+"/schedule", strings.NewReader(`{}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("author publishes and can no longer edit", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostPublished, decode[posts.View](t, rec).Status)
+
+		rec = f.do(&form, http.MethodPut, "/api/posts/"+post.ID, strings.NewReader(body))
+		require.Equal(t, http.StatusConflict, rec.Code)
+		rec = f.do(&form, http.MethodDelete, "/api/posts/"+post.ID, nil)
+		require.Equal(t, http.StatusConflict, rec.Code)
+		rec = f.do(&form, http.MethodDelete, "/api/posts/"+post.ID+"/schedule", nil)
+		require.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("rejects unknown statuses", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts?status=sent", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGroups(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	students := f.store.StudentsInClass(f.ds.Classes[0].ID)
+
+	var group store.Group
+	t.Run("teacher creates a group and posts to it", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/groups", strings.NewReader(`{"name":"Choir","student_ids":["`+students[0].ID+`","`+students[1].ID+`"]}`))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		group = decode[store.Group](t, rec)
+
+		rec = f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Practice","body":"Friday at 3pm.","targets":[{"type":"group","id":"`+group.ID+`"}]}`))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, 2, decode[posts.View](t, rec).Audience.Students)
+	})
+
+	t.Run("groups are private to their owner", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/groups/"+group.ID, nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = f.do(&leader, http.MethodGet, "/api/groups", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 0, len(decode[listResponse[store.Group]](t, rec).Items))
+	})
+
+	t.Run("owner renames and deletes the group", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPut, "/api/groups/"+group.ID, strings.NewReader(`{"name":"Senior Choir","student_ids":["`+students[0].ID+`"]}`))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "Senior Choir", decode[store.Group](t, rec).Name)
+
+		rec = f.do(&form, http.MethodDelete, "/api/groups/"+group.ID, nil)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
+
+func TestConsentResponses(t *testing.T) {
+	f := newFixture(t)
+	key, err := token.New(token.KindGateway)
+	require.NoError(t, err)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, GatewayKeyHash: token.Hash(key)})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+	student := f.store.StudentsInClass(class)[0]
+	due := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"type":"consent","title":"Zoo trip","body":"Please respond.","targets":[{"type":"class","id":"`+class+`"}],"consent":{"questions":["May your child attend?"],"due_at":"`+due+`","remind_every_days":2}}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+	rec = f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	respond := func(key string) *httptest.ResponseRecorder {
+		body := `{"student_id":"` + student.ID + `","guardian_id":"` + student.Guardians[0].GuardianID + `","answers":{"q1":true}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/gateway/posts/"+post.ID+"/responses", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("gateway routes need the gateway key", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, respond(f.keys[form.ID]).Code)
+	})
+
+	t.Run("gateway passes on a guardian's response", func(t *testing.T) {
+		rec := respond(key)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, student.ID, decode[store.ConsentResponse](t, rec).StudentID)
+	})
+
+	t.Run("teacher lists who has responded", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/responses?status=responded", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		rs := decode[posts.Responses](t, rec)
+		require.Equal(t, 1, rs.Responded)
+		require.Equal(t, len(f.store.StudentsInClass(class))-1, rs.Outstanding)
+		require.Equal(t, student.ID, rs.Items[0].StudentID)
+	})
+}
+
+func TestReceipts(t *testing.T) {
+	f := newFixture(t)
+	key, err := token.New(token.KindGateway)
+	require.NoError(t, err)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, GatewayKeyHash: token.Hash(key)})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+	guardian := f.store.StudentsInClass(class)[0].Guardians[0].GuardianID
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Sports Day","body":"Bring a water bottle.","targets":[{"type":"class","id":"`+class+`"}]}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+	rec = f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	record := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/gateway/posts/"+post.ID+"/receipts", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("gateway records a batch of events once", func(t *testing.T) {
+		body := `{"events":[{"guardian_id":"` + guardian + `","type":"delivered"},{"guardian_id":"` + guardian + `","type":"opened"}]}`
+
+		rec := record(body)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, posts.Ingested{Applied: 2}, decode[posts.Ingested](t, rec))
+		rec = record(body)
+		require.Equal(t, posts.Ingested{Ignored: 2}, decode[posts.Ingested](t, rec))
+	})
+
+	t.Run("rejects unknown event types", func(t *testing.T) {
+		rec := record(`{"events":[{"guardian_id":"` + guardian + `","type":"read"}]}`)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("author sees read stats and who has not read", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/receipts?status=unread&class_id="+class, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		rs := decode[posts.Receipts](t, rec)
+		require.Equal(t, 1, rs.Opened)
+		require.Equal(t, rs.Recipients-1, len(rs.Items))
+		require.Equal(t, 1, len(rs.Classes))
+	})
+
+	t.Run("exports the non-readers", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/receipts/export?format=csv", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		require.True(t, strings.Contains(rec.Body.String(), "Guardian,Relationship"))
+	})
+}
+
+func TestRecipients(t *testing.T) {
+	f := newFixture(t)
+	key, err := token.New(token.KindGateway)
+	require.NoError(t, err)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, GatewayKeyHash: token.Hash(key)})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+	guardian := f.store.StudentsInClass(class)[0].Guardians[0].GuardianID
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Sports Day","body":"Bring a water bottle.","targets":[{"type":"class","id":"`+class+`"}]}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+
+	optOut := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/gateway/guardians/"+guardian+"/opt-out", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("gateway passes on an opt-out", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, optOut(`{}`).Code)
+
+		rec := optOut(`{"opted_out":true}`)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.True(t, decode[store.Guardian](t, rec).OptedOut)
+	})
+
+	t.Run("author sees who is sent the post and who is left out", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/recipients", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		res := decode[posts.Resolution](t, rec)
+		require.Equal(t, guardian, res.Excluded[0].GuardianID)
+		require.Equal(t, posts.ExcludedOptedOut, res.Excluded[0].Reason)
+		require.Equal(t, class, res.Recipients[0].Students[0].Via[0].ID)
+	})
+}
+
+func TestAttachments(t *testing.T) {
+	f := newFixture(t)
+	blobs, err := blob.NewDir(t.TempDir())
+	require.NoError(t, err)
+	svc := posts.New(f.store, random.DefaultIDs, time.Now, audit.New(f.store, time.Now), nil, blobs, scan.Fake{}, nil)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, Posts: svc})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+	pdf := "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n"
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Zoo trip","body":"Details attached.","targets":[{"type":"class","id":"`+class+`"}]}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+
+	attach := func(name, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/"+post.ID+"/attachments", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+f.keys[form.ID])
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var a store.Attachment
+	t.Run("author attaches a PDF", func(t *testing.T) {
+		rec := attach("Itinerary.pdf", pdf)
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		a = decode[store.Attachment](t, rec)
+		require.Equal(t, "application/pdf", a.ContentType)
+	})
+
+	t.Run("rejects files that are not what they are named", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, attach("photo.png", pdf).Code)
+		require.Equal(t, http.StatusBadRequest, attach("virus.pdf", pdf+scan.EICAR).Code)
+		require.Equal(t, http.StatusRequestEntityTooLarge, attach("big.pdf", pdf+strings.Repeat("x", posts.MaxAttachmentSize)).Code)
+	})
+
+	t.Run("downloads the file as an attachment", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/attachments/"+a.ID, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, pdf, rec.Body.String())
+		require.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+		require.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, `attachment; filename=Itinerary.pdf`, rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("author detaches the file", func(t *testing.T) {
+		rec := f.do(&form, http.MethodDelete, "/api/posts/"+post.ID+"/attachments/"+a.ID, nil)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/attachments/"+a.ID, nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestNotifications(t *testing.T) {
+	f := newFixture(t)
+	srv := emailtest.NewServer()
+	defer srv.Close()
+	relay, err := email.NewSMTP(email.SMTPConfig{Addr: srv.Addr})
+	require.NoError(t, err)
+	d := notify.New(f.store, relay, mail.Address{Name: "Teacher Workspace", Address: "noreply@schools.example.sg"}, time.Now, nil)
+	svc := posts.New(f.store, random.DefaultIDs, time.Now, audit.New(f.store, time.Now), nil, nil, nil, d.Release)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, Posts: svc})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Sports Day","body":"Bring a water bottle.","targets":[{"type":"class","id":"`+class+`"}]}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+	req := httptest.NewRequest(http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+	req.Header.Set("Authorization", "Bearer "+f.keys[form.ID])
+	rec = httptest.NewRecorder()
+	middleware.RequestID(f.mux).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	requestID := rec.Header().Get("X-Request-ID")
+
+	t.Run("emails each guardian once published", func(t *testing.T) {
+		sent, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		require.Equal(t, post.Audience.Guardians, sent)
+		require.Equal(t, sent, len(srv.Messages()))
+	})
+
+	t.Run("author sees each guardian's delivery, traced to the request", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/notifications", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		res := decode[posts.Deliveries](t, rec)
+		require.Equal(t, post.Audience.Guardians, res.Sent)
+		require.Equal(t, 0, res.Pending+res.Failed)
+		require.Equal(t, requestID, res.Items[0].RequestID)
+		require.NotEqual(t, "", res.Items[0].GuardianName)
+	})
+
+	t.Run("other teachers cannot see them", func(t *testing.T) {
+		other := f.subjectTeacher("Mathematics")
+		rec := f.do(&other, http.MethodGet, "/api/posts/"+post.ID+"/notifications", nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestTemplates(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	class := f.ds.Classes[0].ID
+
+	var tpl store.PostTemplate
+	t.Run("leader shares a school template", func(t *testing.T) {
+		body := `{"scope":"school","name":"Excursion","title":"Excursion for {{class}}","body":"<p>Dear {{guardian_name}}, {{student_name}} leaves at 8am.</p>"}`
+
+		rec := f.do(&form, http.MethodPost, "/api/templates", strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodPost, "/api/templates", strings.NewReader(body))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		tpl = decode[store.PostTemplate](t, rec)
+
+		rec = f.do(&form, http.MethodGet, "/api/templates", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, len(decode[listResponse[store.PostTemplate]](t, rec).Items))
+	})
+
+	t.Run("rejects unknown merge fields", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/templates", strings.NewReader(`{"name":"Oops","title":"Hi {{parent}}","body":"Hello."}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.True(t, strings.Contains(rec.Body.String(), "{{parent}}"))
+	})
+
+	t.Run("previews a post started from it for one guardian", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"`+tpl.Title+`","body":"`+tpl.Body+`","targets":[{"type":"class","id":"`+class+`"}]}`))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		post := decode[posts.View](t, rec)
+
+		rec = f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/preview", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		p := decode[posts.Preview](t, rec)
+		g, _ := f.store.Guardian(p.GuardianID)
+		require.True(t, strings.Contains(p.Body, "Dear "+g.Name+","))
+		require.False(t, strings.Contains(p.Title, "{{"))
+		require.Equal(t, 0, len(p.Missing)+len(p.Unknown)+len(p.Gaps))
+	})
+
+	t.Run("leader deletes it", func(t *testing.T) {
+		rec := f.do(&form, http.MethodDelete, "/api/templates/"+tpl.ID, nil)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodDelete, "/api/templates/"+tpl.ID, nil)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
+
+func TestApprovals(t *testing.T) {
+	f := newFixture(t)
+	form, leader := f.formTeacher(), f.leader()
+	class := f.ds.Classes[0].ID
+
+	t.Run("leader makes announcements need approval", func(t *testing.T) {
+		body := `{"types":["announcement"]}`
+
+		rec := f.do(&form, http.MethodPut, "/api/approvals/rules", strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodPut, "/api/approvals/rules", strings.NewReader(body))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = f.do(&form, http.MethodGet, "/api/approvals/rules", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostAnnouncement, decode[store.ApprovalRules](t, rec).Types[0])
+	})
+
+	var post posts.View
+	t.Run("teacher submits a post instead of publishing it", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(`{"title":"Sports Day","body":"Bring water.","targets":[{"type":"class","id":"`+class+`"}]}`))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		post = decode[posts.View](t, rec)
+		require.True(t, post.NeedsApproval)
+
+		rec = f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+		require.Equal(t, http.StatusConflict, rec.Code)
+
+		rec = f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/submit", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostPendingApproval, decode[posts.View](t, rec).Status)
+
+		rec = f.do(&form, http.MethodGet, "/api/posts?status=pending_approval", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, len(decode[listResponse[posts.View]](t, rec).Items))
+	})
+
+	t.Run("leader reviews it from the queue", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/approvals", nil)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodGet, "/api/approvals", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		queue := decode[listResponse[posts.View]](t, rec).Items
+		require.Equal(t, 1, len(queue))
+		require.Equal(t, post.ID, queue[0].ID)
+
+		rec = f.do(&leader, http.MethodPost, "/api/posts/"+post.ID+"/reject", nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = f.do(&leader, http.MethodPost, "/api/posts/"+post.ID+"/approve", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostApproved, decode[posts.View](t, rec).Status)
+	})
+
+	t.Run("teacher publishes it once approved", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/posts/"+post.ID+"/publish", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.PostPublished, decode[posts.View](t, rec).Status)
+	})
+}
+
+func TestTranslations(t *testing.T) {
+	f := newFixture(t)
+	key, err := token.New(token.KindGateway)
+	require.NoError(t, err)
+	f.mux = handler.NewMux(handler.Options{Store: f.store, GatewayKeyHash: token.Hash(key)})
+	form := f.formTeacher()
+	class := f.ds.Classes[0].ID
+	guardian := f.store.StudentsInClass(class)[0].Guardians[0].GuardianID
+	body := `{"title":"Sports Day","body":"Bring a water bottle.","translations":{"zh":{"title":"运动会","body":"请带水壶。"}},"targets":[{"type":"class","id":"` + class + `"}]}`
+
+	rec := f.do(&form, http.MethodPost, "/api/posts", strings.NewReader(body))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	post := decode[posts.View](t, rec)
+
+	setLanguage := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/gateway/guardians/"+guardian+"/language", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("lists the missing translations", func(t *testing.T) {
+		require.Equal(t, 2, len(post.MissingTranslations))
+		require.Equal(t, store.LanguageMalay, post.MissingTranslations[0].Language)
+	})
+
+	t.Run("negotiates the version with Accept-Language", func(t *testing.T) {
+		for header, want := range map[string]store.Language{
+			"zh-SG, en;q=0.5":  store.LanguageChinese,
+			"ms;q=1, zh;q=0.8": store.LanguageChinese,
+			"ta":               store.LanguageEnglish,
+			"":                 store.LanguageEnglish,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/posts/"+post.ID, nil)
+			req.Header.Set("Authorization", "Bearer "+f.keys[form.ID])
+			req.Header.Set("Accept-Language", header)
+			rec := httptest.NewRecorder()
+			f.mux.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, string(want), rec.Header().Get("Content-Language"))
+			v := decode[posts.View](t, rec)
+			require.Equal(t, want, v.Version.Language)
+			require.Equal(t, "Sports Day", v.Title)
+		}
+	})
+
+	t.Run("localizes post listings too", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+		req.Header.Set("Authorization", "Bearer "+f.keys[form.ID])
+		req.Header.Set("Accept-Language", "zh")
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		items := decode[listResponse[posts.View]](t, rec).Items
+		i := slices.IndexFunc(items, func(v posts.View) bool { return v.ID == post.ID })
+		require.Equal(t, "运动会", items[i].Version.Title)
+	})
+
+	t.Run("gateway passes on a guardian's language", func(t *testing.T) {
+		rec := setLanguage(`{"language":"ta"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/preview?guardian_id="+guardian, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, store.LanguageEnglish, decode[posts.Preview](t, rec).Language)
+
+		require.Equal(t, http.StatusOK, setLanguage(`{"language":"zh"}`).Code)
+		rec = f.do(&form, http.MethodGet, "/api/posts/"+post.ID+"/preview?guardian_id="+guardian, nil)
+		require.Equal(t, "请带水壶。", decode[posts.Preview](t, rec).Body)
+
+		require.Equal(t, http.StatusBadRequest, setLanguage(`{"language":"fr"}`).Code)
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+)
+
+// getProfile serves GET /api/students/{id}/profile. Sections that could not
+// be fetched in time are logged and marked, and the profile is still served.
+func (h *handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	p, err := h.profile.Build(r.Context(), h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeRosterError(w, r, err)
+		return
+	}
+
+	logger := middleware.LoggerFromContext(r.Context())
+	for name, section := range p.Sections {
+		if err := section.Err(); err != nil {
+			logger.WarnContext(r.Context(), "profile section unavailable", "section", name, "status", section.Status, "err", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,75 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/handler"
+	"github.com/String-sg/teacher-workspace/server/internal/profile"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+type profileResponse struct {
+	Student struct {
+		ID          string `json:"id"`
+		DateOfBirth string `json:"date_of_birth"`
+	} `json:"student"`
+	Sections map[string]struct {
+		Status string `json:"status"`
+		Data   any    `json:"data"`
+	} `json:"sections"`
+	Partial bool `json:"partial"`
+}
+
+func TestGetProfile(t *testing.T) {
+	f := newFixture(t)
+	form := f.formTeacher()
+	student := f.store.StudentsInClass(f.ds.Classes[0].ID)[0]
+
+	t.Run("combines every section", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/"+student.ID+"/profile", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := decode[profileResponse](t, rec)
+		require.Equal(t, student.ID, body.Student.ID)
+		require.Equal(t, student.DateOfBirth.String(), body.Student.DateOfBirth)
+		require.False(t, body.Partial)
+		for _, name := range []string{"guardians", "attendance", "assessments", "cca", "notes"} {
+			require.Equal(t, "ok", body.Sections[name].Status)
+		}
+	})
+
+	t.Run("student outside scope is not found", func(t *testing.T) {
+		other := f.store.StudentsInClass(f.ds.Classes[1].ID)[0]
+
+		rec := f.do(&form, http.MethodGet, "/api/students/"+other.ID+"/profile", nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("slow source is marked partial", func(t *testing.T) {
+		f.mux = handler.NewMux(handler.Options{
+			Store: f.store,
+			ProfileSources: []profile.Source{{
+				Name:    "slow",
+				Timeout: 10 * time.Millisecond,
+				Fetch: func(ctx context.Context, _ authz.Scope, _ store.Student) (any, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			}},
+		})
+
+		rec := f.do(&form, http.MethodGet, "/api/students/"+student.ID+"/profile", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := decode[profileResponse](t, rec)
+		require.True(t, body.Partial)
+		require.Equal(t, "timeout", body.Sections["slow"].Status)
+		require.Equal(t, "ok", body.Sections["guardians"].Status)
+	})
+}
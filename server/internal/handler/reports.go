@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
+	"github.com/String-sg/teacher-workspace/server/internal/reports"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// getStudentReport serves GET /api/students/{id}/report, the student's
+// progress report as a PDF.
+func (h *handler) getStudentReport(w http.ResponseWriter, r *http.Request) {
+	res, err := h.reports.Student(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeReportsError(w, r, err)
+		return
+	}
+	writeFile(w, res)
+}
+
+// startClassReports serves POST /api/students/classes/{id}/reports, which
+// starts generating the reports of the class's students as one PDF, or as a
+// ZIP of one PDF per student when the "format" query parameter is "zip".
+// It responds 202 with the job to poll.
+func (h *handler) startClassReports(w http.ResponseWriter, r *http.Request) {
+	f := reports.Format(r.URL.Query().Get("format"))
+	if f == "" {
+		f = reports.FormatPDF
+	}
+	job, err := h.reports.Class(h.scope(r), r.PathValue("id"), f)
+	if err != nil {
+		writeReportsError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/students/reports/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// getReportJob serves GET /api/students/reports/jobs/{id}, the status and
+// progress of a class report job the teacher started.
+func (h *handler) getReportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.reports.Job(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeReportsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// downloadReportJob serves GET /api/students/reports/jobs/{id}/download,
+// the file made by a class report job once it has succeeded.
+func (h *handler) downloadReportJob(w http.ResponseWriter, r *http.Request) {
+	res, err := h.reports.Download(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeReportsError(w, r, err)
+		return
+	}
+	writeFile(w, res)
+}
+
+// getReportBranding serves GET /api/students/reports/branding, how the
+// teacher's school styles its reports.
+func (h *handler) getReportBranding(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.reports.Branding(h.scope(r)))
+}
+
+// putReportBranding serves PUT /api/students/reports/branding. Only school
+// leaders may change it; the logo is sent base64-encoded.
+func (h *handler) putReportBranding(w http.ResponseWriter, r *http.Request) {
+	var in store.ReportBranding
+	if err := readJSON(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	b, err := h.reports.SetBranding(h.scope(r), in)
+	if err != nil {
+		writeReportsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// writeFile writes res as a download.
+func writeFile(w http.ResponseWriter, res jobs.Result) {
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": res.Filename,
+	}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(res.Data)
+}
+
+func writeReportsError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, reports.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	case errors.Is(err, reports.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, reports.ErrNotReady):
+		writeError(w, http.StatusConflict, codeConflict, err.Error())
+	case errors.Is(err, reports.ErrInvalid):
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// waitForJob polls job id as teacher until it finishes.
+func (f *fixture) waitForJob(teacher store.Teacher, id string) jobs.Job {
+	f.t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		rec := f.do(&teacher, http.MethodGet, "/api/students/reports/jobs/"+id, nil)
+		require.Equal(f.t, http.StatusOK, rec.Code)
+		job := decode[jobs.Job](f.t, rec)
+		if job.Status == jobs.StatusSucceeded || job.Status == jobs.StatusFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			f.t.Fatalf("job %s still %s", id, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReports(t *testing.T) {
+	f := newFixture(t)
+	form := f.formTeacher()
+	class := f.ds.Classes[0]
+	students := f.store.StudentsInClass(class.ID)
+
+	t.Run("prints a student's report", func(t *testing.T) {
+		rec := f.do(&form, http.MethodGet, "/api/students/"+students[0].ID+"/report", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+		require.True(t, strings.HasPrefix(rec.Body.String(), "%PDF-"))
+		require.True(t, strings.Contains(rec.Header().Get("Content-Disposition"), "report.pdf"))
+	})
+
+	t.Run("hides students of other classes", func(t *testing.T) {
+		other := f.store.StudentsInClass(f.ds.Classes[1].ID)[0]
+
+		rec := f.do(&form, http.MethodGet, "/api/students/"+other.ID+"/report", nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("generates a class's reports in the background", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/students/classes/"+class.ID+"/reports?format=zip", nil)
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		job := decode[jobs.Job](t, rec)
+		require.Equal(t, "/api/students/reports/jobs/"+job.ID, rec.Header().Get("Location"))
+		require.Equal(t, len(students), job.Total)
+
+		job = f.waitForJob(form, job.ID)
+		require.Equal(t, jobs.StatusSucceeded, job.Status)
+		require.Equal(t, len(students), job.Done)
+
+		rec = f.do(&form, http.MethodGet, "/api/students/reports/jobs/"+job.ID+"/download", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		require.NoError(t, err)
+		require.Equal(t, len(students), len(zr.File))
+	})
+
+	t.Run("keeps jobs to the teacher who started them", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/students/classes/"+class.ID+"/reports", nil)
+		job := decode[jobs.Job](t, rec)
+		f.waitForJob(form, job.ID)
+		leader := f.leader()
+
+		rec = f.do(&leader, http.MethodGet, "/api/students/reports/jobs/"+job.ID+"/download", nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		rec := f.do(&form, http.MethodPost, "/api/students/classes/"+class.ID+"/reports?format=docx", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("only school leaders change branding", func(t *testing.T) {
+		leader := f.leader()
+		body := `{"accent_colour":"#204080","footer":"Printed for parent-teacher meetings"}`
+
+		rec := f.do(&form, http.MethodPut, "/api/students/reports/branding", strings.NewReader(body))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = f.do(&leader, http.MethodPut, "/api/students/reports/branding", strings.NewReader(body))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = f.do(&form, http.MethodGet, "/api/students/reports/branding", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "#204080", decode[store.ReportBranding](t, rec).AccentColour)
+	})
+
+	t.Run("rejects logos that are not images", func(t *testing.T) {
+		leader := f.leader()
+
+		rec := f.do(&leader, http.MethodPut, "/api/students/reports/branding", strings.NewReader(`{"logo":"bm90IGFuIGltYWdl"}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
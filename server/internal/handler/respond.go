@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+)
+
+// Error codes returned in the "code" field of an error response.
+const (
+	codeInvalidRequest = "invalid_request"
+	codeUnauthorized   = "unauthorized"
+	codeForbidden      = "forbidden"
+	codeNotFound       = "not_found"
+	codeNotAcceptable  = "not_acceptable"
+	codeConflict       = "conflict"
+	codeInternal       = "internal"
+)
+
+// errorResponse is the body of every non-2xx JSON response.
+type errorResponse struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeJSON writes v as the JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// maxBodyBytes bounds JSON request bodies.
+const maxBodyBytes = 1 << 20
+
+// readJSON decodes the JSON request body into v, rejecting unknown fields,
+// trailing data and bodies over maxBodyBytes. Its errors are safe to return
+// to the client.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("request body must not exceed %d bytes", tooLarge.Limit)
+		}
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if dec.More() {
+		return errors.New("invalid JSON body: unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorResponse{Error: errorDetail{Code: code, Message: message}})
+}
+
+// writeInternalError logs err with the request-scoped logger and writes a
+// generic 500 response, so internal details never reach the client.
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	middleware.LoggerFromContext(r.Context()).ErrorContext(r.Context(), "request failed", "err", err)
+	writeError(w, http.StatusInternalServerError, codeInternal, "internal server error")
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/search"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+type searchResponse struct {
+	Items []roster.StudentSummary `json:"items"`
+}
+
+// searchStudents serves GET /api/students/search, the students visible to
+// the teacher that match the "q" query parameter, best match first. "limit"
+// caps the number returned.
+func (h *handler) searchStudents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultSearchLimit
+	if s := q.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxSearchLimit {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, "limit must be between 1 and 50")
+			return
+		}
+	}
+
+	students, err := h.search.Search(h.scope(r), q.Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalid) {
+			writeError(w, http.StatusBadRequest, codeInvalidRequest, "q must contain letters or digits")
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, searchResponse{Items: students})
+}
@@ -0,0 +1,44 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestSearchStudents(t *testing.T) {
+	f := newFixture(t)
+	leader := f.leader()
+	st := f.store.StudentsInClass(f.ds.Classes[0].ID)[0]
+
+	t.Run("finds a student by name", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/search?q="+url.QueryEscape(st.Name), nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := decode[listResponse[roster.StudentSummary]](t, rec)
+		require.True(t, len(body.Items) > 0)
+		require.Equal(t, st.ID, body.Items[0].ID)
+	})
+
+	t.Run("caps the results at limit", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/search?limit=1&q="+url.QueryEscape(f.ds.Classes[0].Name), nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, len(decode[listResponse[roster.StudentSummary]](t, rec).Items))
+	})
+
+	t.Run("rejects an empty query", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/search?q=", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("rejects a bad limit", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students/search?q=tan&limit=500", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
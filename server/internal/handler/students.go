@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+)
+
+// listClasses serves GET /api/students/classes, the classes visible to the
+// teacher, filtered by the optional "level" and "subject" query parameters.
+func (h *handler) listClasses(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	classes := h.roster.Classes(h.scope(r), roster.ClassFilter{
+		LevelID: q.Get("level"),
+		Subject: q.Get("subject"),
+	})
+
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, paginate(classes, limit, offset))
+}
+
+// getClass serves GET /api/students/classes/{id}.
+func (h *handler) getClass(w http.ResponseWriter, r *http.Request) {
+	class, err := h.roster.Class(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeRosterError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, class)
+}
+
+// listClassStudents serves GET /api/students/classes/{id}/students, the
+// roster of one class.
+func (h *handler) listClassStudents(w http.ResponseWriter, r *http.Request) {
+	h.writeStudents(w, r, r.PathValue("id"))
+}
+
+// listStudents serves GET /api/students, the students visible to the
+// teacher, filtered by the optional "level", "class" and "subject" query
+// parameters and ordered by "sort".
+func (h *handler) listStudents(w http.ResponseWriter, r *http.Request) {
+	h.writeStudents(w, r, r.URL.Query().Get("class"))
+}
+
+func (h *handler) writeStudents(w http.ResponseWriter, r *http.Request, classID string) {
+	q := r.URL.Query()
+
+	sort, err := roster.ParseSort(q.Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "sort must be one of class, -class, name, -name")
+		return
+	}
+	limit, offset, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	students, err := h.roster.Students(h.scope(r), roster.StudentFilter{
+		LevelID: q.Get("level"),
+		ClassID: classID,
+		Subject: q.Get("subject"),
+		Sort:    sort,
+	})
+	if err != nil {
+		writeRosterError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, paginate(students, limit, offset))
+}
+
+// getStudent serves GET /api/students/{id}.
+func (h *handler) getStudent(w http.ResponseWriter, r *http.Request) {
+	student, err := h.roster.Student(h.scope(r), r.PathValue("id"))
+	if err != nil {
+		writeRosterError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, student)
+}
+
+func writeRosterError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, roster.ErrNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+		return
+	}
+	writeInternalError(w, r, err)
+}
+
+// studentsSubtree serves "/api/students/classes/{id}" and
+// "/api/students/{id}/{section}".
+func (h *handler) studentsSubtree(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") == "classes" {
+		r.SetPathValue("id", r.PathValue("sub"))
+		h.getClass(w, r)
+		return
+	}
+
+	switch r.PathValue("sub") {
+	case "profile":
+		h.getProfile(w, r)
+	case "attendance":
+		h.getStudentAttendance(w, r)
+	case "results":
+		h.getStudentResults(w, r)
+	case "notes":
+		h.listNotes(w, r)
+	case "insights":
+		h.getStudentInsights(w, r)
+	case "report":
+		h.getStudentReport(w, r)
+	default:
+		writeError(w, http.StatusNotFound, codeNotFound, "not found")
+	}
+}
@@ -0,0 +1,208 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/token"
+)
+
+type listResponse[T any] struct {
+	Items      []T  `json:"items"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"next_offset"`
+}
+
+func TestStudentsAuthentication(t *testing.T) {
+	f := newFixture(t)
+	unknown, err := token.New(token.KindAPIKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{name: "missing header", header: ""},
+		{name: "not a bearer token", header: "Basic dXNlcjpwYXNz"},
+		{name: "malformed token", header: "Bearer tw_api_nope"},
+		{name: "well-formed but unknown token", header: "Bearer " + unknown},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name+" returns 401", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			f.mux.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+			require.True(t, strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer"))
+		})
+	}
+}
+
+func TestListClasses(t *testing.T) {
+	f := newFixture(t)
+	leader, form := f.leader(), f.formTeacher()
+	levelID := f.ds.Levels[0].ID
+
+	cases := []struct {
+		name      string
+		teacher   store.Teacher
+		path      string
+		wantTotal int
+		wantForm  bool
+	}{
+		{name: "school leader sees every class in the school", teacher: leader, path: "/api/students/classes", wantTotal: 4},
+		{name: "filters by level", teacher: leader, path: "/api/students/classes?level=" + levelID, wantTotal: 2},
+		{name: "filters by subject", teacher: leader, path: "/api/students/classes?subject=Tamil", wantTotal: 4},
+		{name: "unknown subject matches nothing", teacher: leader, path: "/api/students/classes?subject=Latin", wantTotal: 0},
+		{name: "form teacher sees their form class", teacher: form, path: "/api/students/classes", wantTotal: 1, wantForm: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(&tc.teacher, http.MethodGet, tc.path, nil)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			body := decode[listResponse[roster.ClassSummary]](t, rec)
+			require.Equal(t, tc.wantTotal, body.Total)
+			if tc.wantForm {
+				require.True(t, body.Items[0].IsFormClass)
+				require.Equal(t, f.ds.Classes[0].ID, body.Items[0].ID)
+			}
+		})
+	}
+
+	t.Run("classes of another school are not found", func(t *testing.T) {
+		var other string
+		for _, c := range f.ds.Classes {
+			if c.SchoolID != leader.SchoolID {
+				other = c.ID
+				break
+			}
+		}
+
+		rec := f.do(&leader, http.MethodGet, "/api/students/classes/"+other, nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestListStudents(t *testing.T) {
+	f := newFixture(t)
+	leader, form := f.leader(), f.formTeacher()
+	class := f.ds.Classes[0]
+
+	cases := []struct {
+		name       string
+		teacher    store.Teacher
+		path       string
+		wantStatus int
+		wantTotal  int
+	}{
+		{name: "school leader lists the whole school", teacher: leader, path: "/api/students", wantStatus: http.StatusOK, wantTotal: 32},
+		{name: "filters by class", teacher: leader, path: "/api/students?class=" + class.ID, wantStatus: http.StatusOK, wantTotal: 8},
+		{name: "filters by level", teacher: leader, path: "/api/students?level=" + class.LevelID, wantStatus: http.StatusOK, wantTotal: 16},
+		{name: "form teacher lists their form class", teacher: form, path: "/api/students", wantStatus: http.StatusOK, wantTotal: 8},
+		{name: "class roster", teacher: form, path: "/api/students/classes/" + class.ID + "/students", wantStatus: http.StatusOK, wantTotal: 8},
+		{name: "rejects unknown sort", teacher: leader, path: "/api/students?sort=age", wantStatus: http.StatusBadRequest},
+		{name: "rejects limit above maximum", teacher: leader, path: "/api/students?limit=1000", wantStatus: http.StatusBadRequest},
+		{name: "rejects negative offset", teacher: leader, path: "/api/students?offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "roster of an unknown class is not found", teacher: leader, path: "/api/students/classes/nope/students", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(&tc.teacher, http.MethodGet, tc.path, nil)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantStatus == http.StatusOK {
+				require.Equal(t, tc.wantTotal, decode[listResponse[roster.StudentSummary]](t, rec).Total)
+			}
+		})
+	}
+
+	t.Run("subject teacher sees only students taking their subject", func(t *testing.T) {
+		teacher := f.subjectTeacher("Chinese")
+
+		rec := f.do(&teacher, http.MethodGet, "/api/students/classes/"+class.ID+"/students?limit=200", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		for _, s := range decode[listResponse[roster.StudentSummary]](t, rec).Items {
+			require.True(t, slices.Contains(s.Subjects, "Chinese"))
+		}
+	})
+
+	t.Run("pages are stable and cover every student once", func(t *testing.T) {
+		var ids []string
+		path := "/api/students?sort=name&limit=5"
+		for offset := 0; ; {
+			rec := f.do(&leader, http.MethodGet, path+"&offset="+strconv.Itoa(offset), nil)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			body := decode[listResponse[roster.StudentSummary]](t, rec)
+			for _, s := range body.Items {
+				ids = append(ids, s.ID)
+			}
+			if body.NextOffset == nil {
+				break
+			}
+			offset = *body.NextOffset
+		}
+
+		require.Equal(t, 32, len(ids))
+		slices.Sort(ids)
+		require.Equal(t, 32, len(slices.Compact(ids)))
+	})
+
+	t.Run("sorts by name descending", func(t *testing.T) {
+		rec := f.do(&leader, http.MethodGet, "/api/students?sort=-name&limit=200", nil)
+
+		items := decode[listResponse[roster.StudentSummary]](t, rec).Items
+		for i := 1; i < len(items); i++ {
+			require.True(t, strings.ToLower(items[i-1].Name) >= strings.ToLower(items[i].Name))
+		}
+	})
+}
+
+func TestGetStudent(t *testing.T) {
+	f := newFixture(t)
+	form := f.formTeacher()
+
+	var inClass, outOfClass store.Student
+	for _, s := range f.ds.Students {
+		switch {
+		case s.ClassID == f.ds.Classes[0].ID:
+			inClass = s
+		case s.SchoolID == form.SchoolID:
+			outOfClass = s
+		}
+	}
+
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "student in form class", id: inClass.ID, wantStatus: http.StatusOK},
+		{name: "student outside scope is not found", id: outOfClass.ID, wantStatus: http.StatusNotFound},
+		{name: "unknown student is not found", id: "nope", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(&form, http.MethodGet, "/api/students/"+tc.id, nil)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantStatus == http.StatusOK {
+				require.Equal(t, tc.id, decode[roster.StudentSummary](t, rec).ID)
+			}
+		})
+	}
+}
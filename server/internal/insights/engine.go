@@ -0,0 +1,266 @@
+package insights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+var (
+	// ErrNotFound is returned for students and classes that do not exist or
+	// that the viewer may not see.
+	ErrNotFound = errors.New("insights: not found")
+	// ErrForbidden is returned when a teacher who is not a school leader
+	// changes the school's thresholds.
+	ErrForbidden = errors.New("insights: only school leaders may change thresholds")
+)
+
+// Flag is a student and the reasons they were flagged, empty when none.
+type Flag struct {
+	Student roster.StudentSummary `json:"student"`
+	Reasons []Reason              `json:"reasons"`
+}
+
+// Filter narrows a listing of flagged students. Empty fields match
+// everything.
+type Filter struct {
+	LevelID string
+	ClassID string
+	Rule    string
+}
+
+// Engine evaluates students against the rules. Reasons are cached per
+// student and re-evaluated only once the student's attendance, results or
+// consent forms change, the school's thresholds change, or the day turns.
+type Engine struct {
+	store  *store.Store
+	roster *roster.Service
+	grades *gradebook.Service
+	posts  *posts.Service
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedReasons
+}
+
+type cachedReasons struct {
+	key     cacheKey
+	reasons []Reason
+}
+
+// cacheKey holds everything a student's reasons depend on.
+type cacheKey struct {
+	attendance uint64
+	grades     uint64
+	consents   uint64
+	today      store.Date
+	thresholds store.InsightThresholds
+}
+
+// New returns an Engine over s that reads results from grades, consent
+// forms from ps, and takes the current day from now.
+func New(s *store.Store, grades *gradebook.Service, ps *posts.Service, now func() time.Time) *Engine {
+	return &Engine{
+		store:  s,
+		roster: roster.New(s),
+		grades: grades,
+		posts:  ps,
+		now:    now,
+		cache:  make(map[string]cachedReasons),
+	}
+}
+
+// Student returns the flag of student studentID, with only the reasons about
+// subjects the viewer may see.
+func (e *Engine) Student(scope authz.Scope, studentID string) (Flag, error) {
+	summary, err := e.roster.Student(scope, studentID)
+	if err != nil {
+		return Flag{}, ErrNotFound
+	}
+	st, _ := e.store.Student(studentID)
+	reasons, err := e.reasons(st)
+	if err != nil {
+		return Flag{}, err
+	}
+	return Flag{Student: summary, Reasons: visible(scope, st, reasons, "")}, nil
+}
+
+// Flagged returns the students visible to the viewer that match f and have
+// at least one reason the viewer may see, ordered by level, class and index number.
+func (e *Engine) Flagged(scope authz.Scope, f Filter) ([]Flag, error) {
+	if f.Rule != "" && !slices.ContainsFunc(Rules, func(r Rule) bool { return r.ID == f.Rule }) {
+		return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalid, f.Rule)
+	}
+	students, err := e.roster.Students(scope, roster.StudentFilter{LevelID: f.LevelID, ClassID: f.ClassID, Sort: roster.SortClass})
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	out := []Flag{}
+	for _, summary := range students {
+		st, ok := e.store.Student(summary.ID)
+		if !ok {
+			continue
+		}
+		reasons, err := e.reasons(st)
+		if err != nil {
+			return nil, err
+		}
+		if reasons = visible(scope, st, reasons, f.Rule); len(reasons) > 0 {
+			out = append(out, Flag{Student: summary, Reasons: reasons})
+		}
+	}
+	return out, nil
+}
+
+// Thresholds returns the thresholds in effect at the viewer's school.
+func (e *Engine) Thresholds(scope authz.Scope) store.InsightThresholds {
+	school, _ := e.store.School(scope.Teacher.SchoolID)
+	return Effective(school.InsightThresholds)
+}
+
+// SetThresholds replaces the thresholds of the viewer's school and returns
+// those now in effect. Every threshold must be set; see Validate.
+func (e *Engine) SetThresholds(scope authz.Scope, th store.InsightThresholds) (store.InsightThresholds, error) {
+	if !scope.SchoolWide() {
+		return store.InsightThresholds{}, ErrForbidden
+	}
+	if err := Validate(th); err != nil {
+		return store.InsightThresholds{}, err
+	}
+	if !e.store.SetInsightThresholds(scope.Teacher.SchoolID, &th) {
+		return store.InsightThresholds{}, ErrNotFound
+	}
+	return Effective(&th), nil
+}
+
+// Run evaluates every student in every school and returns how many were
+// flagged. Students whose data has not changed since the last run today are
+// taken from the cache.
+func (e *Engine) Run() (int, error) {
+	flagged := 0
+	for _, school := range e.store.Schools() {
+		for _, st := range e.store.StudentsInSchool(school.ID) {
+			reasons, err := e.reasons(st)
+			if err != nil {
+				return flagged, err
+			}
+			if len(reasons) > 0 {
+				flagged++
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// RunNightly calls Run every day at hour o'clock local time until ctx is
+// done, so that rules that depend on the date, such as overdue consent
+// forms, are brought up to date before school starts.
+func (e *Engine) RunNightly(ctx context.Context, hour int) {
+	for {
+		t := time.NewTimer(nextRun(e.now(), hour).Sub(e.now()))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		start := time.Now()
+		n, err := e.Run()
+		if err != nil {
+			slog.ErrorContext(ctx, "insights run failed", "err", err)
+			continue
+		}
+		slog.InfoContext(ctx, "insights run finished", "flagged", n, "duration", time.Since(start))
+	}
+}
+
+// nextRun returns the first time at hour o'clock strictly after now.
+func nextRun(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// reasons returns every reason st is flagged for, from the cache while it is
+// current.
+func (e *Engine) reasons(st store.Student) ([]Reason, error) {
+	school, _ := e.store.School(st.SchoolID)
+	// Read the revisions first: a change that lands while evaluating bumps
+	// them again, so the entry is stale on the next read rather than wrong.
+	key := cacheKey{
+		attendance: e.store.AttendanceRevision(st.ID),
+		grades:     e.store.GradesRevision(st.ID),
+		consents:   e.store.ConsentRevision(),
+		today:      store.DateOf(e.now()),
+		thresholds: Effective(school.InsightThresholds),
+	}
+
+	e.mu.Lock()
+	c, ok := e.cache[st.ID]
+	e.mu.Unlock()
+	if ok && c.key == key {
+		return c.reasons, nil
+	}
+
+	results, err := e.grades.ResultsOf(st)
+	if err != nil {
+		return nil, err
+	}
+	in := Input{
+		Today:      key.today,
+		Attendance: e.store.AttendanceForStudent(st.ID),
+		Results:    results.Subjects,
+		Consents:   e.consents(st),
+	}
+	reasons := Evaluate(in, key.thresholds)
+
+	e.mu.Lock()
+	e.cache[st.ID] = cachedReasons{key: key, reasons: reasons}
+	e.mu.Unlock()
+	return reasons, nil
+}
+
+// consents returns the consent forms sent about st, each due on the day it
+// closes, in the same time zone as today.
+func (e *Engine) consents(st store.Student) []Consent {
+	out := []Consent{}
+	for _, c := range e.posts.ConsentsOf(st) {
+		out = append(out, Consent{
+			FormID:    c.Post.ID,
+			Title:     c.Post.Title,
+			Due:       store.DateOf(c.Post.Consent.DueAt.In(e.now().Location())),
+			Responded: c.Responded,
+		})
+	}
+	return out
+}
+
+// visible returns the reasons matching rule, or all of them when rule is
+// empty, that the viewer may see.
+func visible(scope authz.Scope, st store.Student, reasons []Reason, rule string) []Reason {
+	out := []Reason{}
+	for _, r := range reasons {
+		if rule != "" && r.Rule != rule {
+			continue
+		}
+		if r.Subject != "" && !scope.CanViewSubject(st.ClassID, r.Subject) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
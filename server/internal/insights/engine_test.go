@@ -0,0 +1,176 @@
+package insights
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/posts"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestEngine(t *testing.T) (*Engine, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Schools: []store.School{{ID: "s1"}},
+		Classes: []store.Class{{ID: "1A", SchoolID: "s1", FormTeacherID: "form"}},
+		Teaching: []store.Teaching{
+			{TeacherID: "maths", ClassID: "1A", Subject: "Mathematics"},
+			{TeacherID: "english", ClassID: "1A", Subject: "English"},
+		},
+		Students: []store.Student{
+			{ID: "a", SchoolID: "s1", ClassID: "1A", IndexNumber: 1, Subjects: []string{"English", "Mathematics"}},
+			{ID: "b", SchoolID: "s1", ClassID: "1A", IndexNumber: 2, Subjects: []string{"English", "Mathematics"}},
+		},
+	})
+	now := func() time.Time { return time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC) }
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	svc := posts.New(s, ids, now, audit.New(s, now), nil, nil, nil, nil)
+	return New(s, gradebook.New(s, ids), svc, now), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+// absent records student id absent on the n school days before 2 March.
+func absent(s *store.Store, id string, n int) {
+	var records []store.Attendance
+	for day := range n {
+		date := store.Date{Year: 2026, Month: time.February, Day: 23 + day}
+		records = append(records, store.Attendance{StudentID: id, ClassID: "1A", Date: date, Status: store.AttendanceAbsent})
+	}
+	s.PutAttendance(records, "form", time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC))
+}
+
+// decline gives student id a Mathematics result of 80% in term 1 and 50% in
+// term 2.
+func decline(s *store.Store, id string) {
+	s.PutAssessment(store.Assessment{ID: "t1", ClassID: "1A", Subject: "Mathematics", Name: "Test 1", Term: 1, MaxMarks: 100, Weight: 1})
+	s.PutAssessment(store.Assessment{ID: "t2", ClassID: "1A", Subject: "Mathematics", Name: "Test 2", Term: 2, MaxMarks: 100, Weight: 1})
+	s.PutScores([]store.Score{
+		{AssessmentID: "t1", StudentID: id, Marks: 80},
+		{AssessmentID: "t2", StudentID: id, Marks: 50},
+	})
+}
+
+func TestEngine(t *testing.T) {
+	t.Run("re-evaluates a student when new data arrives", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		flags, err := e.Flagged(form, Filter{})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(flags))
+
+		absent(s, "b", 3)
+		flags, err = e.Flagged(form, Filter{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(flags))
+		require.Equal(t, "b", flags[0].Student.ID)
+		require.Equal(t, RuleAttendanceDrop, flags[0].Reasons[0].Rule)
+		require.Equal(t, RuleConsecutiveAbsences, flags[0].Reasons[1].Rule)
+	})
+
+	t.Run("filters by rule", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		absent(s, "b", 3)
+		decline(s, "a")
+
+		flags, err := e.Flagged(form, Filter{Rule: RuleGradeDecline})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(flags))
+		require.Equal(t, "a", flags[0].Student.ID)
+		require.Equal(t, 1, len(flags[0].Reasons))
+
+		_, err = e.Flagged(form, Filter{Rule: "horoscope"})
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("hides reasons about subjects the viewer does not teach", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		decline(s, "a")
+
+		flag, err := e.Student(scopeOf(s, "maths", store.RoleTeacher), "a")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(flag.Reasons))
+		require.Equal(t, "Mathematics", flag.Reasons[0].Subject)
+
+		flag, err = e.Student(scopeOf(s, "english", store.RoleTeacher), "a")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(flag.Reasons))
+	})
+
+	t.Run("applies the school's thresholds", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		absent(s, "b", 3)
+
+		th := DefaultThresholds
+		th.ConsecutiveAbsences = 4
+		_, err := e.SetThresholds(scopeOf(s, "form", store.RoleTeacher), th)
+		require.True(t, errors.Is(err, ErrForbidden))
+		th, err = e.SetThresholds(scopeOf(s, "leader", store.RoleSchoolLeader), th)
+		require.NoError(t, err)
+		require.Equal(t, 4, th.ConsecutiveAbsences)
+
+		flags, err := e.Flagged(scopeOf(s, "leader", store.RoleSchoolLeader), Filter{Rule: RuleConsecutiveAbsences})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(flags))
+	})
+
+	t.Run("flags consent forms left unanswered past their due date", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		published := time.Date(2026, time.February, 20, 9, 0, 0, 0, time.UTC)
+		s.PutPost(store.Post{
+			ID: "p1", SchoolID: "s1", Status: store.PostPublished, Type: store.PostConsent, PublishedAt: &published,
+			Title: "Excursion", Targets: []store.Target{{Type: store.TargetClass, ID: "1A"}},
+			Consent: &store.ConsentForm{DueAt: time.Date(2026, time.February, 27, 9, 0, 0, 0, time.UTC)},
+		})
+		s.AppendConsentResponse(store.ConsentResponse{PostID: "p1", StudentID: "a", RespondedAt: published})
+
+		flags, err := e.Flagged(form, Filter{Rule: RuleMissingConsents})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(flags))
+		require.Equal(t, "b", flags[0].Student.ID)
+
+		s.AppendConsentResponse(store.ConsentResponse{PostID: "p1", StudentID: "b", RespondedAt: published})
+		flags, err = e.Flagged(form, Filter{Rule: RuleMissingConsents})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(flags))
+	})
+
+	t.Run("Run counts flagged students", func(t *testing.T) {
+		e, s := newTestEngine(t)
+		absent(s, "a", 4)
+
+		n, err := e.Run()
+
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	})
+}
+
+func TestNextRun(t *testing.T) {
+	sgt := time.FixedZone("SGT", 8*60*60)
+
+	t.Run("later today", func(t *testing.T) {
+		now := time.Date(2026, time.March, 2, 1, 30, 0, 0, sgt)
+		require.Equal(t, time.Date(2026, time.March, 2, 2, 0, 0, 0, sgt), nextRun(now, 2))
+	})
+
+	t.Run("tomorrow once the hour has passed", func(t *testing.T) {
+		now := time.Date(2026, time.March, 2, 2, 0, 0, 0, sgt)
+		require.Equal(t, time.Date(2026, time.March, 3, 2, 0, 0, 0, sgt), nextRun(now, 2))
+	})
+}
@@ -0,0 +1,265 @@
+// Package insights flags students who may need attention. A set of rules
+// looks at each student's attendance, results and consent responses against
+// thresholds each school may tune, and every flag comes with the reasons
+// that raised it.
+package insights
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ErrInvalid wraps every validation failure of thresholds and filters.
+var ErrInvalid = errors.New("insights: invalid input")
+
+// Rule IDs, as reported in Reason.Rule.
+const (
+	RuleAttendanceDrop      = "attendance_drop"
+	RuleConsecutiveAbsences = "consecutive_absences"
+	RuleGradeDecline        = "grade_decline"
+	RuleMissingConsents     = "missing_consents"
+)
+
+// Severity ranks how urgently a reason calls for attention.
+type Severity string
+
+const (
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// DefaultThresholds apply wherever a school leaves a threshold unset.
+var DefaultThresholds = store.InsightThresholds{
+	AttendanceWindowDays: 10,
+	MinAttendanceRate:    90,
+	AttendanceDropPoints: 10,
+	ConsecutiveAbsences:  3,
+	GradeDropPoints:      10,
+	MissingConsents:      1,
+	ConsentGraceDays:     0,
+}
+
+// Effective returns th with every unset field taken from DefaultThresholds.
+// A nil th is all defaults.
+func Effective(th *store.InsightThresholds) store.InsightThresholds {
+	if th == nil {
+		return DefaultThresholds
+	}
+	d := DefaultThresholds
+	return store.InsightThresholds{
+		AttendanceWindowDays: cmp.Or(th.AttendanceWindowDays, d.AttendanceWindowDays),
+		MinAttendanceRate:    cmp.Or(th.MinAttendanceRate, d.MinAttendanceRate),
+		AttendanceDropPoints: cmp.Or(th.AttendanceDropPoints, d.AttendanceDropPoints),
+		ConsecutiveAbsences:  cmp.Or(th.ConsecutiveAbsences, d.ConsecutiveAbsences),
+		GradeDropPoints:      cmp.Or(th.GradeDropPoints, d.GradeDropPoints),
+		MissingConsents:      cmp.Or(th.MissingConsents, d.MissingConsents),
+		ConsentGraceDays:     cmp.Or(th.ConsentGraceDays, d.ConsentGraceDays),
+	}
+}
+
+// Validate checks that every threshold in th is set and usable. Effective
+// would take a zero for the default, so only ConsentGraceDays, whose
+// default is zero, may be zero.
+func Validate(th store.InsightThresholds) error {
+	switch {
+	case th.AttendanceWindowDays < 1 || th.AttendanceWindowDays > 200:
+		return fmt.Errorf("%w: attendance_window_days must be 1 to 200", ErrInvalid)
+	case th.MinAttendanceRate <= 0 || th.MinAttendanceRate > 100:
+		return fmt.Errorf("%w: min_attendance_rate must be above 0 and at most 100", ErrInvalid)
+	case th.AttendanceDropPoints <= 0 || th.AttendanceDropPoints > 100:
+		return fmt.Errorf("%w: attendance_drop_points must be above 0 and at most 100", ErrInvalid)
+	case th.ConsecutiveAbsences < 1 || th.ConsecutiveAbsences > 60:
+		return fmt.Errorf("%w: consecutive_absences must be 1 to 60", ErrInvalid)
+	case th.GradeDropPoints <= 0 || th.GradeDropPoints > 100:
+		return fmt.Errorf("%w: grade_drop_points must be above 0 and at most 100", ErrInvalid)
+	case th.MissingConsents < 1 || th.MissingConsents > 50:
+		return fmt.Errorf("%w: missing_consents must be 1 to 50", ErrInvalid)
+	case th.ConsentGraceDays < 0 || th.ConsentGraceDays > 60:
+		return fmt.Errorf("%w: consent_grace_days must be 0 to 60", ErrInvalid)
+	}
+	return nil
+}
+
+// Consent is a consent form sent to a student's guardians and whether they
+// have responded.
+type Consent struct {
+	FormID    string     `json:"form_id"`
+	Title     string     `json:"title"`
+	Due       store.Date `json:"due"`
+	Responded bool       `json:"responded"`
+}
+
+// Input is everything the rules know about one student.
+type Input struct {
+	Today store.Date `json:"today"`
+	// Attendance is ordered by date.
+	Attendance []store.Attendance        `json:"attendance"`
+	Results    []gradebook.SubjectResult `json:"results"`
+	Consents   []Consent                 `json:"consents"`
+}
+
+// Reason explains why a rule flagged a student. Value is what the rule
+// measured and Threshold the limit it crossed, in the same unit. Subject is
+// set for reasons about one subject.
+type Reason struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Subject   string   `json:"subject,omitempty"`
+	Message   string   `json:"message"`
+	Value     float64  `json:"value"`
+	Threshold float64  `json:"threshold"`
+}
+
+// Rule is one early-warning signal.
+type Rule struct {
+	ID       string
+	Evaluate func(Input, store.InsightThresholds) []Reason
+}
+
+// Rules are the signals every student is evaluated against, in the order
+// their reasons are reported.
+var Rules = []Rule{
+	{ID: RuleAttendanceDrop, Evaluate: attendanceDrop},
+	{ID: RuleConsecutiveAbsences, Evaluate: consecutiveAbsences},
+	{ID: RuleGradeDecline, Evaluate: gradeDecline},
+	{ID: RuleMissingConsents, Evaluate: missingConsents},
+}
+
+// Evaluate runs every rule over in and returns the reasons raised.
+func Evaluate(in Input, th store.InsightThresholds) []Reason {
+	reasons := []Reason{}
+	for _, r := range Rules {
+		reasons = append(reasons, r.Evaluate(in, th)...)
+	}
+	return reasons
+}
+
+// attendanceDrop flags recent attendance that is below the minimum rate, or
+// that has fallen from the student's earlier attendance by more than the
+// allowed points.
+func attendanceDrop(in Input, th store.InsightThresholds) []Reason {
+	if len(in.Attendance) == 0 {
+		return nil
+	}
+	split := max(len(in.Attendance)-th.AttendanceWindowDays, 0)
+	recent := percent(attendance.Summarize(in.Attendance[split:]).Rate)
+
+	if recent < th.MinAttendanceRate {
+		return []Reason{{
+			Rule:      RuleAttendanceDrop,
+			Severity:  SeverityHigh,
+			Message:   fmt.Sprintf("Attended %g%% of the last %d school days", recent, len(in.Attendance)-split),
+			Value:     recent,
+			Threshold: th.MinAttendanceRate,
+		}}
+	}
+	// Compare like with like: a shorter earlier period says too little.
+	if split < th.AttendanceWindowDays {
+		return nil
+	}
+	earlier := percent(attendance.Summarize(in.Attendance[:split]).Rate)
+	if drop := round(earlier - recent); drop >= th.AttendanceDropPoints {
+		return []Reason{{
+			Rule:      RuleAttendanceDrop,
+			Severity:  SeverityMedium,
+			Message:   fmt.Sprintf("Attendance fell from %g%% to %g%% over the last %d school days", earlier, recent, th.AttendanceWindowDays),
+			Value:     drop,
+			Threshold: th.AttendanceDropPoints,
+		}}
+	}
+	return nil
+}
+
+// consecutiveAbsences flags a run of absences up to the latest school day.
+func consecutiveAbsences(in Input, th store.InsightThresholds) []Reason {
+	run := attendance.Summarize(in.Attendance).ConsecutiveAbsences
+	if run < th.ConsecutiveAbsences {
+		return nil
+	}
+	severity := SeverityMedium
+	if run >= 2*th.ConsecutiveAbsences {
+		severity = SeverityHigh
+	}
+	return []Reason{{
+		Rule:      RuleConsecutiveAbsences,
+		Severity:  severity,
+		Message:   fmt.Sprintf("Absent for the last %d school days in a row", run),
+		Value:     float64(run),
+		Threshold: float64(th.ConsecutiveAbsences),
+	}}
+}
+
+// gradeDecline flags subjects whose latest term result fell from the term
+// before by more than the allowed points. Terms without a result yet are
+// skipped.
+func gradeDecline(in Input, th store.InsightThresholds) []Reason {
+	var out []Reason
+	for _, sr := range in.Results {
+		var terms []gradebook.TermResult
+		for _, t := range sr.Terms {
+			if t.Percentage != nil {
+				terms = append(terms, t)
+			}
+		}
+		if len(terms) < 2 {
+			continue
+		}
+		prev, last := terms[len(terms)-2], terms[len(terms)-1]
+		drop := round(*prev.Percentage - *last.Percentage)
+		if drop < th.GradeDropPoints {
+			continue
+		}
+		severity := SeverityMedium
+		if drop >= 2*th.GradeDropPoints {
+			severity = SeverityHigh
+		}
+		out = append(out, Reason{
+			Rule:      RuleGradeDecline,
+			Severity:  severity,
+			Subject:   sr.Subject,
+			Message:   fmt.Sprintf("%s fell from %g%% in term %d to %g%% in term %d", sr.Subject, *prev.Percentage, prev.Term, *last.Percentage, last.Term),
+			Value:     drop,
+			Threshold: th.GradeDropPoints,
+		})
+	}
+	return out
+}
+
+// missingConsents flags consent forms still unanswered past their due date
+// and grace period.
+func missingConsents(in Input, th store.InsightThresholds) []Reason {
+	var titles []string
+	for _, c := range in.Consents {
+		if !c.Responded && in.Today.After(c.Due.AddDays(th.ConsentGraceDays)) {
+			titles = append(titles, c.Title)
+		}
+	}
+	if len(titles) < th.MissingConsents || len(titles) == 0 {
+		return nil
+	}
+	slices.Sort(titles)
+	return []Reason{{
+		Rule:      RuleMissingConsents,
+		Severity:  SeverityMedium,
+		Message:   "No response to overdue consent forms: " + strings.Join(titles, "; "),
+		Value:     float64(len(titles)),
+		Threshold: float64(th.MissingConsents),
+	}}
+}
+
+// percent converts a rate to a percentage with one decimal place.
+func percent(rate float64) float64 {
+	return round(rate * 100)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,99 @@
+package insights
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// ruleCase is one case of a rule's testdata/<rule>.input.json. Thresholds
+// are applied over the defaults.
+type ruleCase struct {
+	Name       string                   `json:"name"`
+	Thresholds *store.InsightThresholds `json:"thresholds"`
+	Input      Input                    `json:"input"`
+}
+
+// ruleResult is one case of a rule's testdata/<rule>.golden.json.
+type ruleResult struct {
+	Name    string   `json:"name"`
+	Reasons []Reason `json:"reasons"`
+}
+
+// TestRulesGolden evaluates each rule over its input cases and compares the
+// reasons with the rule's golden file. Run with -update to rewrite them.
+func TestRulesGolden(t *testing.T) {
+	for _, rule := range Rules {
+		t.Run(rule.ID, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", rule.ID+".input.json"))
+			require.NoError(t, err)
+			var cases []ruleCase
+			require.NoError(t, json.Unmarshal(b, &cases))
+
+			got := []ruleResult{}
+			for _, c := range cases {
+				reasons := rule.Evaluate(c.Input, Effective(c.Thresholds))
+				if reasons == nil {
+					reasons = []Reason{}
+				}
+				got = append(got, ruleResult{Name: c.Name, Reasons: reasons})
+			}
+			out, err := json.MarshalIndent(got, "", "  ")
+			require.NoError(t, err)
+			out = append(out, '\n')
+
+			golden := filepath.Join("testdata", rule.ID+".golden.json")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, out, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			if !bytes.Equal(want, out) {
+				t.Errorf("reasons differ from %s; run with -update and review the diff\ngot:\n%s", golden, out)
+			}
+		})
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	t.Run("unset fields take their defaults", func(t *testing.T) {
+		th := Effective(&store.InsightThresholds{ConsecutiveAbsences: 5})
+
+		require.Equal(t, 5, th.ConsecutiveAbsences)
+		require.Equal(t, DefaultThresholds.MinAttendanceRate, th.MinAttendanceRate)
+		require.Equal(t, DefaultThresholds, Effective(nil))
+	})
+
+	t.Run("rejects out of range values", func(t *testing.T) {
+		th := DefaultThresholds
+		th.MinAttendanceRate = 85
+		require.NoError(t, Validate(th))
+		th.MinAttendanceRate = 120
+		require.Error(t, Validate(th))
+		th = DefaultThresholds
+		th.ConsecutiveAbsences = -1
+		require.Error(t, Validate(th))
+	})
+
+	t.Run("rejects zeros that would be taken for the default", func(t *testing.T) {
+		th := DefaultThresholds
+		th.MinAttendanceRate = 0
+		require.Error(t, Validate(th))
+		th = DefaultThresholds
+		th.MissingConsents = 0
+		require.Error(t, Validate(th))
+		require.Error(t, Validate(store.InsightThresholds{ConsecutiveAbsences: 4}))
+
+		th = DefaultThresholds
+		th.ConsentGraceDays = 0
+		require.NoError(t, Validate(th))
+	})
+}
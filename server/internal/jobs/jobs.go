@@ -0,0 +1,232 @@
+// Package jobs runs slow work, such as generating a class's reports, in the
+// background so that a request can return at once and the teacher can poll
+// for progress. Jobs and their results are kept in memory for Retention after
+// they finish.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+var (
+	// ErrNotFound is returned for jobs that do not exist, have expired or
+	// were started by another teacher.
+	ErrNotFound = errors.New("jobs: not found")
+	// ErrNotReady is returned for the result of a job that has not
+	// succeeded.
+	ErrNotReady = errors.New("jobs: job has not finished successfully")
+)
+
+const (
+	// DefaultWorkers is how many jobs run at once unless told otherwise.
+	DefaultWorkers = 2
+	// Retention is how long a finished job and its result are kept.
+	Retention = time.Hour
+)
+
+// Status is where a job is in its life.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job describes a background job and its progress.
+type Job struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	OwnerID string `json:"owner_id"`
+	Status  Status `json:"status"`
+	// Done and Total count the job's steps, such as the students whose
+	// reports have been generated out of the class's.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// Error says why a failed job failed.
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Result is the file a job produces.
+type Result struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Func is the work of a job. It calls progress as each step is done, and
+// should stop early once ctx is done.
+type Func func(ctx context.Context, progress func(done int)) (Result, error)
+
+// Runner runs jobs, a few at a time.
+type Runner struct {
+	ids *random.IDGenerator
+	now func() time.Time
+	sem chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*entry
+}
+
+type entry struct {
+	job    Job
+	result Result
+	err    error
+}
+
+// New returns a Runner that runs up to workers jobs at once, names them with
+// ids and timestamps them with now.
+func New(ids *random.IDGenerator, now func() time.Time, workers int) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		ids:    ids,
+		now:    now,
+		sem:    make(chan struct{}, max(workers, 1)),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*entry),
+	}
+}
+
+// Start queues fn as a job of the given kind for the teacher with ID
+// ownerID, made up of total steps, and returns it as queued.
+func (r *Runner) Start(ownerID, kind string, total int, fn Func) (Job, error) {
+	id, err := r.ids.New()
+	if err != nil {
+		return Job{}, err
+	}
+
+	e := &entry{job: Job{
+		ID:        id.String(),
+		Kind:      kind,
+		OwnerID:   ownerID,
+		Status:    StatusQueued,
+		Total:     total,
+		CreatedAt: r.now().UTC(),
+	}}
+	r.mu.Lock()
+	r.expire()
+	r.jobs[e.job.ID] = e
+	job := e.job
+	r.mu.Unlock()
+
+	r.wg.Go(func() { r.run(e, fn) })
+	return job, nil
+}
+
+func (r *Runner) run(e *entry, fn Func) {
+	select {
+	case r.sem <- struct{}{}:
+		defer func() { <-r.sem }()
+	case <-r.ctx.Done():
+		r.finish(e, Result{}, r.ctx.Err())
+		return
+	}
+
+	r.mu.Lock()
+	e.job.Status = StatusRunning
+	r.mu.Unlock()
+
+	res, err := fn(r.ctx, func(done int) {
+		r.mu.Lock()
+		e.job.Done = done
+		r.mu.Unlock()
+	})
+	r.finish(e, res, err)
+}
+
+func (r *Runner) finish(e *entry, res Result, err error) {
+	now := r.now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.job.FinishedAt = &now
+	if err != nil {
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+		e.err = err
+		return
+	}
+	e.job.Status = StatusSucceeded
+	e.job.Done = e.job.Total
+	e.result = res
+}
+
+// Get returns job id, which must have been started by the teacher with ID
+// ownerID.
+func (r *Runner) Get(ownerID, id string) (Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.owned(ownerID, id)
+	if err != nil {
+		return Job{}, err
+	}
+	return e.job, nil
+}
+
+// Result returns the result of job id, which must have been started by the
+// teacher with ID ownerID and have succeeded. The error for a failed job
+// also wraps the error it failed with.
+func (r *Runner) Result(ownerID, id string) (Job, Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.owned(ownerID, id)
+	if err != nil {
+		return Job{}, Result{}, err
+	}
+	if e.err != nil {
+		return e.job, Result{}, fmt.Errorf("%w: job is %s: %w", ErrNotReady, e.job.Status, e.err)
+	}
+	if e.job.Status != StatusSucceeded {
+		return e.job, Result{}, fmt.Errorf("%w: job is %s", ErrNotReady, e.job.Status)
+	}
+	return e.job, e.result, nil
+}
+
+// Wait blocks until every job started so far has finished.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Close cancels every job still queued or running and waits for them to
+// stop.
+func (r *Runner) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// owned returns job id if it belongs to ownerID. r.mu must be held.
+func (r *Runner) owned(ownerID, id string) (*entry, error) {
+	r.expire()
+	e, ok := r.jobs[id]
+	if !ok || e.job.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// expire forgets jobs that finished more than Retention ago. r.mu must be
+// held.
+func (r *Runner) expire() {
+	cutoff := r.now().Add(-Retention)
+	maps.DeleteFunc(r.jobs, func(_ string, e *entry) bool {
+		return e.job.FinishedAt != nil && e.job.FinishedAt.Before(cutoff)
+	})
+}
@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestRunner(t *testing.T, workers int) (*Runner, *time.Time) {
+	t.Helper()
+
+	clock := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	r := New(ids, now, workers)
+	t.Cleanup(r.Close)
+	return r, &clock
+}
+
+func TestRunner(t *testing.T) {
+	t.Run("runs a job and keeps its result", func(t *testing.T) {
+		r, _ := newTestRunner(t, 1)
+		step := make(chan struct{})
+		job, err := r.Start("t1", "test", 2, func(_ context.Context, progress func(int)) (Result, error) {
+			progress(1)
+			<-step
+			return Result{Filename: "out.txt", Data: []byte("done")}, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, StatusQueued, job.Status)
+
+		for {
+			job, err = r.Get("t1", job.ID)
+			require.NoError(t, err)
+			if job.Done == 1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, StatusRunning, job.Status)
+		_, _, err = r.Result("t1", job.ID)
+		require.True(t, errors.Is(err, ErrNotReady))
+
+		close(step)
+		r.Wait()
+		job, res, err := r.Result("t1", job.ID)
+		require.NoError(t, err)
+		require.Equal(t, StatusSucceeded, job.Status)
+		require.Equal(t, 2, job.Done)
+		require.Equal(t, "done", string(res.Data))
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		r, _ := newTestRunner(t, 1)
+		errNoStudents := errors.New("no students")
+		job, err := r.Start("t1", "test", 1, func(context.Context, func(int)) (Result, error) {
+			return Result{}, errNoStudents
+		})
+		require.NoError(t, err)
+
+		r.Wait()
+		job, err = r.Get("t1", job.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, StatusFailed, job.Status)
+		require.Equal(t, "no students", job.Error)
+		_, _, err = r.Result("t1", job.ID)
+		require.True(t, errors.Is(err, ErrNotReady))
+		require.True(t, errors.Is(err, errNoStudents))
+	})
+
+	t.Run("shows jobs only to their owner", func(t *testing.T) {
+		r, _ := newTestRunner(t, 1)
+		job, err := r.Start("t1", "test", 0, func(context.Context, func(int)) (Result, error) { return Result{}, nil })
+		require.NoError(t, err)
+
+		_, err = r.Get("t2", job.ID)
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("limits how many jobs run at once", func(t *testing.T) {
+		r, _ := newTestRunner(t, 2)
+		var mu sync.Mutex
+		running, most := 0, 0
+		for range 6 {
+			_, err := r.Start("t1", "test", 0, func(context.Context, func(int)) (Result, error) {
+				mu.Lock()
+				running++
+				most = max(most, running)
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return Result{}, nil
+			})
+			require.NoError(t, err)
+		}
+
+		r.Wait()
+
+		require.True(t, most <= 2)
+	})
+
+	t.Run("forgets finished jobs after Retention", func(t *testing.T) {
+		r, clock := newTestRunner(t, 1)
+		job, err := r.Start("t1", "test", 0, func(context.Context, func(int)) (Result, error) { return Result{}, nil })
+		require.NoError(t, err)
+		r.Wait()
+
+		*clock = clock.Add(Retention + time.Second)
+		_, err = r.Get("t1", job.ID)
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Close cancels running jobs", func(t *testing.T) {
+		r, _ := newTestRunner(t, 1)
+		job, err := r.Start("t1", "test", 0, func(ctx context.Context, _ func(int)) (Result, error) {
+			<-ctx.Done()
+			return Result{}, ctx.Err()
+		})
+		require.NoError(t, err)
+
+		r.Close()
+		job, err = r.Get("t1", job.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, StatusFailed, job.Status)
+	})
+}
@@ -0,0 +1,92 @@
+// Package lang picks which of the official languages a post is read in.
+// A post is written in English and may be translated into Chinese, Malay
+// and Tamil; a reader whose language it has not been translated into is
+// given the English.
+package lang
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Parse returns the official language of a language tag, such as "zh-SG"
+// or "ta", ignoring its region and script.
+func Parse(tag string) (store.Language, bool) {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	l := store.Language(strings.ToLower(primary))
+	if !slices.Contains(store.Languages, l) {
+		return "", false
+	}
+	return l, true
+}
+
+// Negotiate returns the language of available most preferred in an
+// Accept-Language header, reporting false if it names none of them.
+func Negotiate(header string, available []store.Language) (store.Language, bool) {
+	type weighted struct {
+		lang store.Language
+		q    float64
+	}
+	var prefs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		l, ok := Parse(tag)
+		if ok && q > 0 && slices.Contains(available, l) {
+			prefs = append(prefs, weighted{l, q})
+		}
+	}
+	if len(prefs) == 0 {
+		return "", false
+	}
+	slices.SortStableFunc(prefs, func(a, b weighted) int { return cmp.Compare(b.q, a.q) })
+	return prefs[0].lang, true
+}
+
+// Post returns post p's title and body in language l, or in English if p
+// has no translation into l, and the language they are in.
+func Post(p store.Post, l store.Language) (store.Translation, store.Language) {
+	if t, ok := p.Translations[l]; ok {
+		return t, l
+	}
+	return store.Translation{Title: p.Title, Body: p.Body}, store.LanguageEnglish
+}
+
+// Available returns the languages post p may be read in: English and those
+// it has been translated into.
+func Available(p store.Post) []store.Language {
+	out := []store.Language{store.LanguageEnglish}
+	for _, l := range store.Languages[1:] {
+		if _, ok := p.Translations[l]; ok {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Of returns the language guardian g prefers posts in.
+func Of(g store.Guardian) store.Language {
+	return cmp.Or(g.Language, store.LanguageEnglish)
+}
+
+// Missing returns the official languages post p has not been translated
+// into.
+func Missing(p store.Post) []store.Language {
+	var out []store.Language
+	for _, l := range store.Languages[1:] {
+		if _, ok := p.Translations[l]; !ok {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]store.Language{
+		"zh-SG":                        store.LanguageChinese,
+		"fr, ms;q=0.8, en;q=0.9":       store.LanguageEnglish,
+		"ta-SG;q=0.5, zh-Hans-SG;q=.7": store.LanguageChinese,
+		"en-GB, ta":                    store.LanguageEnglish,
+		"TA":                           store.LanguageTamil,
+	} {
+		got, ok := Negotiate(header, store.Languages)
+		require.True(t, ok)
+		require.Equal(t, want, got)
+	}
+
+	for _, header := range []string{"", "fr-FR, de", "zh;q=0", "*"} {
+		_, ok := Negotiate(header, store.Languages)
+		require.False(t, ok)
+	}
+
+	t.Run("picks among the languages available", func(t *testing.T) {
+		available := []store.Language{store.LanguageEnglish, store.LanguageChinese}
+
+		got, ok := Negotiate("ms;q=1, zh;q=0.8", available)
+		require.True(t, ok)
+		require.Equal(t, store.LanguageChinese, got)
+
+		_, ok = Negotiate("ms, ta", available)
+		require.False(t, ok)
+	})
+}
+
+func TestPost(t *testing.T) {
+	p := store.Post{
+		Title:        "Sports Day",
+		Body:         "<p>Bring water.</p>",
+		Translations: map[store.Language]store.Translation{store.LanguageMalay: {Title: "Hari Sukan", Body: "<p>Bawa air.</p>"}},
+	}
+
+	t.Run("gives the translation into a language", func(t *testing.T) {
+		got, l := Post(p, store.LanguageMalay)
+
+		require.Equal(t, store.LanguageMalay, l)
+		require.Equal(t, "Hari Sukan", got.Title)
+	})
+
+	t.Run("falls back to English", func(t *testing.T) {
+		got, l := Post(p, store.LanguageTamil)
+
+		require.Equal(t, store.LanguageEnglish, l)
+		require.Equal(t, "Sports Day", got.Title)
+		require.Equal(t, store.LanguageEnglish, Of(store.Guardian{}))
+	})
+
+	t.Run("lists the missing translations", func(t *testing.T) {
+		missing := Missing(p)
+
+		require.Equal(t, 2, len(missing))
+		require.Equal(t, store.LanguageChinese, missing[0])
+		require.Equal(t, store.LanguageTamil, missing[1])
+	})
+
+	t.Run("lists the languages it may be read in", func(t *testing.T) {
+		available := Available(p)
+
+		require.Equal(t, 2, len(available))
+		require.Equal(t, store.LanguageEnglish, available[0])
+		require.Equal(t, store.LanguageMalay, available[1])
+	})
+}
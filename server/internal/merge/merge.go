@@ -0,0 +1,132 @@
+// Package merge fills the merge fields of a post, such as {{student_name}},
+// for each guardian it is sent to.
+package merge
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Merge fields a post's title and body may use.
+const (
+	GuardianName = "guardian_name"
+	StudentName  = "student_name"
+	Class        = "class"
+	School       = "school"
+	TeacherName  = "teacher_name"
+	// DueDate is when a consent form is due; announcements have none.
+	DueDate = "due_date"
+)
+
+// Fields lists every merge field.
+var Fields = []string{GuardianName, StudentName, Class, School, TeacherName, DueDate}
+
+// field matches a merge field, as in "{{student_name}}" or "{{ class }}".
+var field = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// sgt is Singapore Standard Time, which times are given to guardians in.
+var sgt = time.FixedZone("SGT", 8*60*60)
+
+// Values are what the merge fields stand for for one guardian. A field with
+// no value is missing.
+type Values map[string]string
+
+// Used returns the fields texts use, known or not, each once in the order
+// they first appear.
+func Used(texts ...string) []string {
+	var out []string
+	for _, s := range texts {
+		for _, m := range field.FindAllStringSubmatch(s, -1) {
+			if !slices.Contains(out, m[1]) {
+				out = append(out, m[1])
+			}
+		}
+	}
+	return out
+}
+
+// Unknown returns the fields texts use that are not merge fields.
+func Unknown(texts ...string) []string {
+	var out []string
+	for _, f := range Used(texts...) {
+		if !slices.Contains(Fields, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Missing returns the merge fields texts use that have no value in v.
+func (v Values) Missing(texts ...string) []string {
+	var out []string
+	for _, f := range Used(texts...) {
+		if slices.Contains(Fields, f) && v[f] == "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// For returns the values of the merge fields for guardian g, sent post p
+// about students.
+func For(s *store.Store, p store.Post, g store.Guardian, students []store.Student) Values {
+	school, _ := s.School(p.SchoolID)
+	author, _ := s.Teacher(p.AuthorID)
+	var names, classes []string
+	for _, st := range students {
+		names = append(names, st.Name)
+		if c, ok := s.Class(st.ClassID); ok && !slices.Contains(classes, c.Name) {
+			classes = append(classes, c.Name)
+		}
+	}
+	v := Values{
+		GuardianName: g.Name,
+		StudentName:  List(names),
+		Class:        List(classes),
+		School:       school.Name,
+		TeacherName:  author.Name,
+	}
+	if p.Consent != nil {
+		v[DueDate] = FormatTime(p.Consent.DueAt)
+	}
+	return v
+}
+
+// Text returns plain text s with its merge fields filled from v. Missing
+// fields are left blank and fields that are not merge fields as they are.
+func Text(s string, v Values) string {
+	return fill(s, v, func(s string) string { return s })
+}
+
+// HTML is Text for rich text, whose values are escaped.
+func HTML(s string, v Values) string {
+	return fill(s, v, html.EscapeString)
+}
+
+func fill(s string, v Values, escape func(string) string) string {
+	return field.ReplaceAllStringFunc(s, func(m string) string {
+		f := field.FindStringSubmatch(m)[1]
+		if !slices.Contains(Fields, f) {
+			return m
+		}
+		return escape(v[f])
+	})
+}
+
+// List lists names as in "Ali, Bala and Chen".
+func List(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// FormatTime formats t as guardians in Singapore read it.
+func FormatTime(t time.Time) string {
+	return t.In(sgt).Format("Mon 2 Jan 2006, 3:04pm")
+}
@@ -0,0 +1,56 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestMerge(t *testing.T) {
+	s := store.New(&store.Dataset{
+		Schools:  []store.School{{ID: "s1", Name: "Rivervale Primary"}},
+		Classes:  []store.Class{{ID: "c1", SchoolID: "s1", Name: "3A"}, {ID: "c2", SchoolID: "s1", Name: "5B"}},
+		Teachers: []store.Teacher{{ID: "t1", SchoolID: "s1", Name: "Mdm Lim"}},
+		Students: []store.Student{
+			{ID: "a", ClassID: "c1", Name: "Tan Wei Ming"},
+			{ID: "b", ClassID: "c2", Name: "Tan Wei Ling"},
+		},
+	})
+	g := store.Guardian{ID: "g1", Name: "Betty <Tan>"}
+	p := store.Post{ID: "p1", SchoolID: "s1", AuthorID: "t1"}
+	a, _ := s.Student("a")
+	b, _ := s.Student("b")
+
+	t.Run("fills the fields for a guardian", func(t *testing.T) {
+		v := For(s, p, g, []store.Student{a, b})
+
+		got := Text("Dear {{guardian_name}}, {{ student_name }} of {{class}} at {{school}} from {{teacher_name}}", v)
+
+		require.Equal(t, "Dear Betty <Tan>, Tan Wei Ming and Tan Wei Ling of 3A and 5B at Rivervale Primary from Mdm Lim", got)
+	})
+
+	t.Run("escapes values in rich text", func(t *testing.T) {
+		got := HTML("<p>Dear {{guardian_name}},</p>", For(s, p, g, []store.Student{a}))
+
+		require.Equal(t, "<p>Dear Betty &lt;Tan&gt;,</p>", got)
+	})
+
+	t.Run("reports unknown and missing fields", func(t *testing.T) {
+		title, body := "{{student_name}}: reply by {{due_date}}", "<p>{{ student_nmae }} {{ class }}</p>"
+
+		require.Equal(t, "student_nmae", strings.Join(Unknown(title, body), ","))
+		v := For(s, p, g, nil)
+		require.Equal(t, "student_name,due_date,class", strings.Join(v.Missing(title, body), ","))
+		require.Equal(t, "<p>{{ student_nmae }} </p>", HTML(body, v))
+	})
+
+	t.Run("gives consent forms a due date", func(t *testing.T) {
+		p := p
+		p.Consent = &store.ConsentForm{DueAt: time.Date(2026, time.March, 6, 15, 59, 0, 0, time.UTC)}
+
+		require.Equal(t, "Fri 6 Mar 2026, 11:59pm", For(s, p, g, []store.Student{a})[DueDate])
+	})
+}
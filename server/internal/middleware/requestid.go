@@ -18,17 +18,32 @@ const requestIDHeader = "X-Request-ID"
 // containing it are stored in the request context. The ID is also written to
 // the response headers to support log correlation and request tracing.
 func RequestID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id := random.Base58(32)
-		ctx := context.WithValue(r.Context(), ctxKeyRequestID{}, id)
-
-		logger := slog.Default().With("request_id", id)
-		ctx = context.WithValue(ctx, ctxKeyLogger{}, logger)
-
-		w.Header().Set(requestIDHeader, id)
+	return RequestIDFrom(random.Default)(next)
+}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// RequestIDFrom returns a RequestID middleware that draws identifiers from g
+// instead of random.Default, which lets tests supply a deterministic source.
+// If g fails to produce an identifier, the request is rejected with 500
+// Internal Server Error rather than served without one.
+func RequestIDFrom(g *random.Generator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := g.Base58(32)
+			if err != nil {
+				slog.Default().ErrorContext(r.Context(), "generate request id", "err", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			ctx := context.WithValue(r.Context(), ctxKeyRequestID{}, id)
+
+			logger := slog.Default().With("request_id", id)
+			ctx = context.WithValue(ctx, ctxKeyLogger{}, logger)
+
+			w.Header().Set(requestIDHeader, id)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // RequestIDFromContext retrieves the request ID from the provided context.
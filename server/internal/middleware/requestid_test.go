@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"testing/iotest"
 
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
 	"github.com/String-sg/teacher-workspace/server/pkg/require"
 )
 
@@ -98,6 +102,44 @@ func TestRequestID(t *testing.T) {
 	})
 }
 
+func TestRequestIDFrom(t *testing.T) {
+	t.Run("uses the supplied generator", func(t *testing.T) {
+		entropy := bytes.Repeat([]byte{0x5a}, 64)
+		want, err := random.New(bytes.NewReader(entropy)).Base58(32)
+		require.NoError(t, err)
+
+		var ctxID string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctxID, _ = RequestIDFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		RequestIDFrom(random.New(bytes.NewReader(entropy)))(next).ServeHTTP(rec, req)
+
+		require.Equal(t, want, rec.Result().Header.Get(requestIDHeader))
+		require.Equal(t, want, ctxID)
+	})
+
+	t.Run("returns 500 without calling next when the generator fails", func(t *testing.T) {
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		RequestIDFrom(random.New(iotest.ErrReader(errors.New("boom"))))(next).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, "", rec.Result().Header.Get(requestIDHeader))
+		require.False(t, called)
+	})
+}
+
 func TestRequestIDFromContext(t *testing.T) {
 	t.Run("returns empty string and false when not set", func(t *testing.T) {
 		id, ok := RequestIDFromContext(context.Background())
@@ -0,0 +1,319 @@
+// Package notes keeps teachers' pastoral and academic observations about
+// students. Each note has a visibility level that decides who besides its
+// author may read it, a full edit history, and is only ever soft-deleted.
+// Every access to a sensitive note is written to the audit log.
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+var (
+	// ErrNotFound is returned for students and notes that do not exist, that
+	// were deleted, or that the viewer may not read.
+	ErrNotFound = errors.New("notes: not found")
+	// ErrForbidden is returned when the viewer may read a note but not
+	// change it or see its history.
+	ErrForbidden = errors.New("notes: only a note's author may change it or see its history")
+	// ErrInvalid wraps every validation failure of a note.
+	ErrInvalid = errors.New("notes: invalid note")
+	// ErrConflict is returned when a note is changed on the strength of a
+	// version that is no longer its latest.
+	ErrConflict = errors.New("notes: the note has changed since that version")
+)
+
+// Categories are the kinds of observation a note may record.
+var Categories = []string{"academic", "behaviour", "wellbeing", "family", "health", "other"}
+
+const (
+	// MaxBodyLength bounds a note's text, in characters.
+	MaxBodyLength = 5000
+	// MaxTags bounds the tags on one note.
+	MaxTags = 10
+)
+
+// tagPattern matches a normalised tag.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Input is the content of a note to create or update.
+type Input struct {
+	Visibility store.NoteVisibility `json:"visibility"`
+	Category   string               `json:"category"`
+	Tags       []string             `json:"tags"`
+	Body       string               `json:"body"`
+	Sensitive  bool                 `json:"sensitive"`
+}
+
+// Filter narrows a note listing. Empty fields match everything.
+type Filter struct {
+	Category string
+	Tag      string
+}
+
+// Service reads and writes notes, limited to what the viewing teacher may
+// see and change.
+type Service struct {
+	store *store.Store
+	audit *audit.Log
+	ids   *random.IDGenerator
+	now   func() time.Time
+
+	// mu serializes changes, so that each gets its own version.
+	mu sync.Mutex
+}
+
+// New returns a Service over s that writes sensitive accesses to log, names
+// new notes with ids and timestamps changes with now.
+func New(s *store.Store, log *audit.Log, ids *random.IDGenerator, now func() time.Time) *Service {
+	return &Service{store: s, audit: log, ids: ids, now: now}
+}
+
+// List returns the notes about student studentID that the viewer may read,
+// newest first.
+func (s *Service) List(scope authz.Scope, studentID string, f Filter) ([]store.Note, error) {
+	notes, err := s.list(scope, studentID, f)
+	if err != nil {
+		return nil, err
+	}
+	s.auditReads(scope, notes)
+	return notes, nil
+}
+
+// list is List without auditing, for callers that show only some of the
+// notes and audit those themselves.
+func (s *Service) list(scope authz.Scope, studentID string, f Filter) ([]store.Note, error) {
+	st, ok := s.store.Student(studentID)
+	if !ok || !scope.CanViewStudent(st) {
+		return nil, ErrNotFound
+	}
+
+	out := []store.Note{}
+	for _, n := range s.store.NotesForStudent(studentID) {
+		if n.DeletedAt != nil || !scope.CanReadNote(st, n) {
+			continue
+		}
+		if f.Category != "" && n.Category != f.Category || f.Tag != "" && !slices.Contains(n.Tags, f.Tag) {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// Get returns the note with the given ID.
+func (s *Service) Get(scope authz.Scope, id string) (store.Note, error) {
+	n, err := s.readable(scope, id)
+	if err != nil {
+		return store.Note{}, err
+	}
+	s.auditReads(scope, []store.Note{n})
+	return n, nil
+}
+
+// Create records a new note about student studentID by the viewer.
+func (s *Service) Create(scope authz.Scope, studentID string, in Input) (store.Note, error) {
+	st, ok := s.store.Student(studentID)
+	if !ok || !scope.CanViewStudent(st) {
+		return store.Note{}, ErrNotFound
+	}
+	in, err := validate(in)
+	if err != nil {
+		return store.Note{}, err
+	}
+	id, err := s.ids.New()
+	if err != nil {
+		return store.Note{}, err
+	}
+
+	now := s.now().UTC()
+	n := store.Note{
+		ID:        id.String(),
+		StudentID: studentID,
+		AuthorID:  scope.Teacher.ID,
+		CreatedAt: now,
+	}
+	return s.put(scope, n, in, now, audit.ActionNoteCreate), nil
+}
+
+// Update replaces the content of note id, which must be the viewer's own.
+// If version is not 0, it must be the note's latest version, so that a
+// change made meanwhile is not overwritten unseen.
+func (s *Service) Update(scope authz.Scope, id string, version int, in Input) (store.Note, error) {
+	in, err := validate(in)
+	if err != nil {
+		return store.Note{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.latest(scope, id, version)
+	if err != nil {
+		return store.Note{}, err
+	}
+	return s.put(scope, n, in, s.now().UTC(), audit.ActionNoteUpdate), nil
+}
+
+// Delete soft-deletes note id, which must be the viewer's own. The note and
+// its history are kept but no longer listed. version is as for Update.
+func (s *Service) Delete(scope authz.Scope, id string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.latest(scope, id, version)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	n.DeletedAt = &now
+	n.UpdatedAt = now
+	n.Version++
+	rev := revision(n, scope.Teacher.ID, now)
+	rev.Deleted = true
+	s.store.PutNote(n, rev)
+	if n.Sensitive {
+		s.audit.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionNoteDelete, TargetType: "note", TargetID: n.ID})
+	}
+	return nil
+}
+
+// History returns every revision of note id, oldest first. Only the note's
+// author may see it, since earlier revisions may have had a narrower
+// visibility than the note has now.
+func (s *Service) History(scope authz.Scope, id string) ([]store.NoteRevision, error) {
+	n, err := s.own(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sensitive {
+		s.audit.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionNoteRead, TargetType: "note", TargetID: n.ID, Detail: "history"})
+	}
+	return s.store.NoteRevisions(id), nil
+}
+
+// put saves n with the content of in as a new version and records the
+// revision.
+func (s *Service) put(scope authz.Scope, n store.Note, in Input, now time.Time, action string) store.Note {
+	n.Visibility = in.Visibility
+	n.Category = in.Category
+	n.Tags = in.Tags
+	n.Body = in.Body
+	// A note once marked sensitive keeps being audited: its earlier
+	// revisions are still sensitive.
+	n.Sensitive = n.Sensitive || in.Sensitive
+	n.UpdatedAt = now
+	n.Version++
+
+	s.store.PutNote(n, revision(n, scope.Teacher.ID, now))
+	if n.Sensitive {
+		s.audit.Record(scope.Teacher.ID, audit.Event{Action: action, TargetType: "note", TargetID: n.ID})
+	}
+	return n
+}
+
+func revision(n store.Note, editorID string, at time.Time) store.NoteRevision {
+	return store.NoteRevision{
+		NoteID:     n.ID,
+		Version:    n.Version,
+		EditorID:   editorID,
+		EditedAt:   at,
+		Visibility: n.Visibility,
+		Category:   n.Category,
+		Tags:       n.Tags,
+		Body:       n.Body,
+		Sensitive:  n.Sensitive,
+	}
+}
+
+// readable returns note id if the viewer may read it.
+func (s *Service) readable(scope authz.Scope, id string) (store.Note, error) {
+	n, ok := s.store.Note(id)
+	if !ok || n.DeletedAt != nil {
+		return store.Note{}, ErrNotFound
+	}
+	st, ok := s.store.Student(n.StudentID)
+	if !ok || !scope.CanReadNote(st, n) {
+		return store.Note{}, ErrNotFound
+	}
+	return n, nil
+}
+
+// latest returns note id if the viewer wrote it and, unless version is 0,
+// version is its latest.
+func (s *Service) latest(scope authz.Scope, id string, version int) (store.Note, error) {
+	n, err := s.own(scope, id)
+	if err != nil {
+		return store.Note{}, err
+	}
+	if version != 0 && n.Version != version {
+		return store.Note{}, fmt.Errorf("%w: note is at version %d, not %d", ErrConflict, n.Version, version)
+	}
+	return n, nil
+}
+
+// own returns note id if the viewer wrote it.
+func (s *Service) own(scope authz.Scope, id string) (store.Note, error) {
+	n, err := s.readable(scope, id)
+	if err != nil {
+		return store.Note{}, err
+	}
+	if n.AuthorID != scope.Teacher.ID {
+		return store.Note{}, ErrForbidden
+	}
+	return n, nil
+}
+
+func (s *Service) auditReads(scope authz.Scope, notes []store.Note) {
+	var events []audit.Event
+	for _, n := range notes {
+		if n.Sensitive {
+			events = append(events, audit.Event{Action: audit.ActionNoteRead, TargetType: "note", TargetID: n.ID})
+		}
+	}
+	s.audit.Record(scope.Teacher.ID, events...)
+}
+
+// validate normalises in and checks it.
+func validate(in Input) (Input, error) {
+	switch in.Visibility {
+	case store.NotePrivate, store.NoteFormTeacher, store.NoteSchoolLeaders:
+	default:
+		return Input{}, fmt.Errorf("%w: visibility must be one of private, form_teacher, school_leaders", ErrInvalid)
+	}
+	if !slices.Contains(Categories, in.Category) {
+		return Input{}, fmt.Errorf("%w: category must be one of %s", ErrInvalid, strings.Join(Categories, ", "))
+	}
+
+	in.Body = strings.TrimSpace(in.Body)
+	if in.Body == "" || len([]rune(in.Body)) > MaxBodyLength {
+		return Input{}, fmt.Errorf("%w: body must be 1 to %d characters", ErrInvalid, MaxBodyLength)
+	}
+
+	tags := []string{}
+	for _, t := range in.Tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !tagPattern.MatchString(t) {
+			return Input{}, fmt.Errorf("%w: tag %q must be up to 32 lowercase letters, digits and hyphens", ErrInvalid, t)
+		}
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > MaxTags {
+		return Input{}, fmt.Errorf("%w: at most %d tags", ErrInvalid, MaxTags)
+	}
+	in.Tags = tags
+	return in, nil
+}
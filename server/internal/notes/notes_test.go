@@ -0,0 +1,279 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Classes:  []store.Class{{ID: "1A", SchoolID: "s1", FormTeacherID: "form"}},
+		Teaching: []store.Teaching{{TeacherID: "maths", ClassID: "1A", Subject: "Mathematics"}},
+		Students: []store.Student{{ID: "a", SchoolID: "s1", ClassID: "1A", Subjects: []string{"Mathematics"}}},
+	})
+	clock := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	now := func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	return New(s, audit.New(s, now), ids, now), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+func TestNotes(t *testing.T) {
+	in := Input{Visibility: store.NoteFormTeacher, Category: "academic", Tags: []string{" Homework ", "homework", "sec1"}, Body: " Missing homework three times this week. "}
+
+	t.Run("creates notes with normalised content", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		n, err := svc.Create(scopeOf(s, "maths", store.RoleTeacher), "a", in)
+
+		require.NoError(t, err)
+		require.Equal(t, "maths", n.AuthorID)
+		require.Equal(t, 1, n.Version)
+		require.Equal(t, "Missing homework three times this week.", n.Body)
+		require.Equal(t, 2, len(n.Tags))
+		require.Equal(t, "homework", n.Tags[0])
+	})
+
+	t.Run("visibility decides who reads a note", func(t *testing.T) {
+		svc, s := newTestService(t)
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		leader := scopeOf(s, "leader", store.RoleSchoolLeader)
+		for _, v := range []store.NoteVisibility{store.NotePrivate, store.NoteFormTeacher, store.NoteSchoolLeaders} {
+			in := in
+			in.Visibility = v
+			_, err := svc.Create(maths, "a", in)
+			require.NoError(t, err)
+		}
+
+		count := func(scope authz.Scope) int {
+			notes, err := svc.List(scope, "a", Filter{})
+			require.NoError(t, err)
+			return len(notes)
+		}
+		require.Equal(t, 3, count(maths))
+		require.Equal(t, 2, count(form))
+		require.Equal(t, 1, count(leader))
+	})
+
+	t.Run("filters by category and tag", func(t *testing.T) {
+		svc, s := newTestService(t)
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		_, err := svc.Create(maths, "a", in)
+		require.NoError(t, err)
+		other := Input{Visibility: store.NotePrivate, Category: "wellbeing", Body: "Seemed withdrawn."}
+		_, err = svc.Create(maths, "a", other)
+		require.NoError(t, err)
+
+		byCategory, err := svc.List(maths, "a", Filter{Category: "wellbeing"})
+		require.NoError(t, err)
+		byTag, err := svc.List(maths, "a", Filter{Tag: "sec1"})
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(byCategory))
+		require.Equal(t, "wellbeing", byCategory[0].Category)
+		require.Equal(t, 1, len(byTag))
+		require.Equal(t, "academic", byTag[0].Category)
+	})
+
+	t.Run("edits and deletes keep full history", func(t *testing.T) {
+		svc, s := newTestService(t)
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		n, err := svc.Create(maths, "a", in)
+		require.NoError(t, err)
+
+		edit := in
+		edit.Body = "Homework handed in after reminder."
+		n, err = svc.Update(maths, n.ID, 0, edit)
+		require.NoError(t, err)
+		require.Equal(t, 2, n.Version)
+		require.NoError(t, svc.Delete(maths, n.ID, 0))
+
+		_, err = svc.Get(maths, n.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+		notes, err := svc.List(maths, "a", Filter{})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(notes))
+
+		stored, ok := s.Note(n.ID)
+		require.True(t, ok)
+		require.True(t, stored.DeletedAt != nil)
+		history := s.NoteRevisions(n.ID)
+		require.Equal(t, 3, len(history))
+		require.Equal(t, "Missing homework three times this week.", history[0].Body)
+		require.Equal(t, "Homework handed in after reminder.", history[1].Body)
+		require.True(t, history[2].Deleted)
+	})
+
+	t.Run("refuses changes to a version that is no longer the latest", func(t *testing.T) {
+		svc, s := newTestService(t)
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		n, err := svc.Create(maths, "a", in)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Go(func() {
+				edit := in
+				edit.Body = fmt.Sprintf("Edit %d.", i)
+				_, errs[i] = svc.Update(maths, n.ID, n.Version, edit)
+			})
+		}
+		wg.Wait()
+
+		conflicts := 0
+		for _, err := range errs {
+			if errors.Is(err, ErrConflict) {
+				conflicts++
+			} else {
+				require.NoError(t, err)
+			}
+		}
+		require.Equal(t, len(errs)-1, conflicts)
+		require.Equal(t, 2, len(s.NoteRevisions(n.ID)))
+		require.True(t, errors.Is(svc.Delete(maths, n.ID, n.Version), ErrConflict))
+		require.NoError(t, svc.Delete(maths, n.ID, n.Version+1))
+	})
+
+	t.Run("gives concurrent changes versions of their own", func(t *testing.T) {
+		svc, s := newTestService(t)
+		maths := scopeOf(s, "maths", store.RoleTeacher)
+		n, err := svc.Create(maths, "a", in)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Go(func() { _, errs[i] = svc.Update(maths, n.ID, 0, in) })
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		stored, _ := s.Note(n.ID)
+		require.Equal(t, 9, stored.Version)
+		for i, rev := range s.NoteRevisions(n.ID) {
+			require.Equal(t, i+1, rev.Version)
+		}
+	})
+
+	t.Run("only the author changes a note or reads its history", func(t *testing.T) {
+		svc, s := newTestService(t)
+		n, err := svc.Create(scopeOf(s, "maths", store.RoleTeacher), "a", in)
+		require.NoError(t, err)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		_, err = svc.Update(form, n.ID, 0, in)
+		require.True(t, errors.Is(err, ErrForbidden))
+		require.True(t, errors.Is(svc.Delete(form, n.ID, 0), ErrForbidden))
+		_, err = svc.History(form, n.ID)
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		_, err = svc.Update(scopeOf(s, "leader", store.RoleSchoolLeader), n.ID, 0, in)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	invalid := []struct {
+		name   string
+		change func(*Input)
+	}{
+		{name: "unknown visibility", change: func(in *Input) { in.Visibility = "everyone" }},
+		{name: "unknown category", change: func(in *Input) { in.Category = "gossip" }},
+		{name: "blank body", change: func(in *Input) { in.Body = "  " }},
+		{name: "malformed tag", change: func(in *Input) { in.Tags = []string{"two words"} }},
+		{name: "too many tags", change: func(in *Input) {
+			in.Tags = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+		}},
+	}
+	for _, tc := range invalid {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			svc, s := newTestService(t)
+			bad := in
+			tc.change(&bad)
+
+			_, err := svc.Create(scopeOf(s, "maths", store.RoleTeacher), "a", bad)
+
+			require.True(t, errors.Is(err, ErrInvalid))
+		})
+	}
+}
+
+func TestSensitiveNotesAreAudited(t *testing.T) {
+	svc, s := newTestService(t)
+	maths := scopeOf(s, "maths", store.RoleTeacher)
+	form := scopeOf(s, "form", store.RoleTeacher)
+
+	sensitive, err := svc.Create(maths, "a", Input{Visibility: store.NoteFormTeacher, Category: "family", Body: "Parents separating.", Sensitive: true})
+	require.NoError(t, err)
+	_, err = svc.Create(maths, "a", Input{Visibility: store.NoteFormTeacher, Category: "academic", Body: "Strong in algebra."})
+	require.NoError(t, err)
+
+	_, err = svc.List(form, "a", Filter{})
+	require.NoError(t, err)
+	_, err = svc.Get(form, sensitive.ID)
+	require.NoError(t, err)
+
+	entries := s.AuditLog()
+	require.Equal(t, 3, len(entries))
+	require.Equal(t, audit.ActionNoteCreate, entries[0].Action)
+	require.Equal(t, "maths", entries[0].ActorID)
+	for _, e := range entries[1:] {
+		require.Equal(t, audit.ActionNoteRead, e.Action)
+		require.Equal(t, "form", e.ActorID)
+		require.Equal(t, sensitive.ID, e.TargetID)
+	}
+
+	t.Run("profile section audits only the notes it shows", func(t *testing.T) {
+		st, _ := s.Student("a")
+		before := len(s.AuditLog())
+
+		data, err := svc.ProfileSource().Fetch(t.Context(), form, st)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, len(data.([]store.Note)))
+		require.Equal(t, before+1, len(s.AuditLog()))
+	})
+}
+
+func TestReportComments(t *testing.T) {
+	svc, s := newTestService(t)
+	maths := scopeOf(s, "maths", store.RoleTeacher)
+	for _, in := range []Input{
+		{Visibility: store.NoteFormTeacher, Category: "academic", Tags: []string{"report"}, Body: "Works steadily."},
+		{Visibility: store.NoteFormTeacher, Category: "academic", Body: "Left out: not tagged."},
+		{Visibility: store.NoteFormTeacher, Category: "wellbeing", Tags: []string{"report"}, Body: "Left out: sensitive.", Sensitive: true},
+		{Visibility: store.NoteFormTeacher, Category: "academic", Tags: []string{"Report"}, Body: "Has improved since Term 1."},
+	} {
+		_, err := svc.Create(maths, "a", in)
+		require.NoError(t, err)
+	}
+	logged := len(s.AuditLog())
+
+	notes, err := svc.ReportComments(scopeOf(s, "form", store.RoleTeacher), "a")
+
+	require.NoError(t, err)
+	require.Equal(t, 2, len(notes))
+	require.Equal(t, "Works steadily.", notes[0].Body)
+	require.Equal(t, "Has improved since Term 1.", notes[1].Body)
+	require.Equal(t, logged, len(s.AuditLog()))
+}
@@ -0,0 +1,30 @@
+package notes
+
+import (
+	"context"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/profile"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ProfileNotes is how many recent notes the student profile shows.
+const ProfileNotes = 5
+
+// ProfileSource returns the "notes" section of the student profile: the
+// most recent notes the viewer may read.
+func (s *Service) ProfileSource() profile.Source {
+	return profile.Source{
+		Name:    "notes",
+		Timeout: profile.DefaultTimeout,
+		Fetch: func(_ context.Context, scope authz.Scope, st store.Student) (any, error) {
+			notes, err := s.list(scope, st.ID, Filter{})
+			if err != nil {
+				return nil, err
+			}
+			notes = notes[:min(len(notes), ProfileNotes)]
+			s.auditReads(scope, notes)
+			return notes, nil
+		},
+	}
+}
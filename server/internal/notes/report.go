@@ -0,0 +1,25 @@
+package notes
+
+import (
+	"slices"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ReportTag marks the notes a teacher writes to be printed as comments in a
+// student's progress report.
+const ReportTag = "report"
+
+// ReportComments returns the notes about student studentID tagged ReportTag
+// that the viewer may read, oldest first. Sensitive notes are never printed,
+// so none are returned and nothing is audited.
+func (s *Service) ReportComments(scope authz.Scope, studentID string) ([]store.Note, error) {
+	notes, err := s.list(scope, studentID, Filter{Tag: ReportTag})
+	if err != nil {
+		return nil, err
+	}
+	notes = slices.DeleteFunc(notes, func(n store.Note) bool { return n.Sensitive })
+	slices.Reverse(notes)
+	return notes, nil
+}
@@ -0,0 +1,337 @@
+// Package notify emails guardians the notifications queued when a post is
+// published or a consent form reminder is due.
+//
+// Notifications are kept in the store, and a Dispatcher sends each one only
+// once it has been saved, so that none is lost if tw stops. A notification
+// the relay fails to take is retried with backoff while the failure is
+// transient, and each attempt is recorded on it. If tw stops after sending
+// one but before saving that it did, it is sent again when tw starts.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/lang"
+	"github.com/String-sg/teacher-workspace/server/internal/merge"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/email"
+	"github.com/String-sg/teacher-workspace/server/pkg/richtext"
+)
+
+const (
+	// MaxAttempts bounds the attempts to send one notification.
+	MaxAttempts = 6
+	// RetryBackoff is the wait after a notification's first failed attempt.
+	// It doubles after each later one, up to MaxBackoff.
+	RetryBackoff = 30 * time.Second
+	MaxBackoff   = 30 * time.Minute
+)
+
+// errUndeliverable is returned for notifications that no attempt would
+// send, such as those to a guardian with no email address.
+var errUndeliverable = errors.New("notify: undeliverable")
+
+// Dispatcher sends notifications through a relay.
+type Dispatcher struct {
+	store  *store.Store
+	sender email.Sender
+	from   mail.Address
+	now    func() time.Time
+	save   func() error
+	wake   chan struct{}
+
+	mu sync.Mutex
+	// ready holds the IDs of saved notifications that may still need
+	// sending, in the order they were released.
+	ready []string
+}
+
+// New returns a Dispatcher that sends the notifications in s through sender
+// from the address from, timestamping attempts with now. save, if not nil,
+// writes s durably after attempts are recorded. Notifications pending in s
+// when New is called are taken to be saved already.
+func New(s *store.Store, sender email.Sender, from mail.Address, now func() time.Time, save func() error) *Dispatcher {
+	d := &Dispatcher{store: s, sender: sender, from: from, now: now, save: save, wake: make(chan struct{}, 1)}
+	for _, n := range s.PendingNotifications() {
+		d.ready = append(d.ready, n.ID)
+	}
+	return d
+}
+
+// Release hands the Dispatcher notifications ids, which have been saved, to
+// send.
+func (d *Dispatcher) Release(ids ...string) {
+	d.mu.Lock()
+	d.ready = append(d.ready, ids...)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SendDue attempts each released notification that is due and returns how
+// many it sent. The attempts are saved before SendDue returns.
+func (d *Dispatcher) SendDue(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	ids := slices.Clone(d.ready)
+	d.mu.Unlock()
+
+	now := d.now().UTC()
+	done := make(map[string]bool)
+	attempted, sent := 0, 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		n, ok := d.store.Notification(id)
+		if !ok || n.Status != store.NotificationPending {
+			done[id] = true
+			continue
+		}
+		if n.NextAttemptAt != nil && n.NextAttemptAt.After(now) {
+			continue
+		}
+		n, ok = d.attempt(ctx, n, now)
+		if !ok {
+			continue
+		}
+		attempted++
+		if !d.store.UpdateNotification(n) {
+			// Its post was deleted while it was being sent.
+			done[id] = true
+			continue
+		}
+		switch n.Status {
+		case store.NotificationSent:
+			sent++
+			done[id] = true
+		case store.NotificationFailed:
+			done[id] = true
+		}
+	}
+
+	d.mu.Lock()
+	d.ready = slices.DeleteFunc(d.ready, func(id string) bool { return done[id] })
+	d.mu.Unlock()
+	if attempted == 0 || d.save == nil {
+		return sent, nil
+	}
+	if err := d.save(); err != nil {
+		return sent, fmt.Errorf("notify: saving: %w", err)
+	}
+	return sent, nil
+}
+
+// attempt tries to send notification n at now and returns it with the
+// attempt recorded. It reports false if ctx was done before the attempt
+// finished, which then does not count.
+func (d *Dispatcher) attempt(ctx context.Context, n store.Notification, now time.Time) (store.Notification, bool) {
+	m, err := d.message(n)
+	if err == nil {
+		err = d.sender.Send(ctx, m)
+	}
+	if err != nil && ctx.Err() != nil {
+		return n, false
+	}
+
+	n.Attempts++
+	log := slog.With("notification_id", n.ID, "post_id", n.PostID, "kind", n.Kind, "attempt", n.Attempts)
+	if n.TeacherID != "" {
+		log = log.With("teacher_id", n.TeacherID)
+	} else {
+		log = log.With("guardian_id", n.GuardianID)
+	}
+	if n.RequestID != "" {
+		log = log.With("request_id", n.RequestID)
+	}
+	switch {
+	case err == nil:
+		n.Status = store.NotificationSent
+		n.SentAt = &now
+		n.NextAttemptAt = nil
+		n.LastError = ""
+		log.InfoContext(ctx, "notification sent")
+	case errors.Is(err, errUndeliverable) || email.Permanent(err) || n.Attempts >= MaxAttempts:
+		n.Status = store.NotificationFailed
+		n.NextAttemptAt = nil
+		n.LastError = err.Error()
+		log.WarnContext(ctx, "notification failed", "err", err)
+	default:
+		next := now.Add(backoff(n.Attempts))
+		n.NextAttemptAt = &next
+		n.LastError = err.Error()
+		log.InfoContext(ctx, "notification will be retried", "err", err, "next_attempt_at", next)
+	}
+	return n, true
+}
+
+// backoff returns the wait after a notification's attempts-th failed
+// attempt.
+func backoff(attempts int) time.Duration {
+	wait := RetryBackoff
+	for range attempts - 1 {
+		if wait *= 2; wait >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return wait
+}
+
+// message renders the email of notification n.
+func (d *Dispatcher) message(n store.Notification) (email.Message, error) {
+	p, ok := d.store.Post(n.PostID)
+	if !ok {
+		return email.Message{}, fmt.Errorf("%w: post %s no longer exists", errUndeliverable, n.PostID)
+	}
+	if n.TeacherID != "" {
+		return d.teacherMessage(n, p)
+	}
+	g, ok := d.store.Guardian(n.GuardianID)
+	switch {
+	case !ok:
+		return email.Message{}, fmt.Errorf("%w: guardian %s no longer exists", errUndeliverable, n.GuardianID)
+	case g.OptedOut:
+		return email.Message{}, fmt.Errorf("%w: guardian has opted out", errUndeliverable)
+	case g.Email == "":
+		return email.Message{}, fmt.Errorf("%w: guardian has no email address", errUndeliverable)
+	}
+
+	var students []store.Student
+	for _, id := range n.StudentIDs {
+		if st, ok := d.store.Student(id); ok {
+			students = append(students, st)
+		}
+	}
+	// Guardians are sent the post in the language they prefer, if it has
+	// been translated into it. Posts are checked for missing fields when
+	// they are published, but the school's records may have changed since.
+	version, _ := lang.Post(p, g.Language)
+	v := merge.For(d.store, p, g, students)
+	if missing := v.Missing(version.Title, version.Body); len(missing) > 0 {
+		slog.Warn("merge fields have no value", "notification_id", n.ID, "post_id", n.PostID, "guardian_id", n.GuardianID, "fields", missing)
+	}
+	body := merge.HTML(version.Body, v)
+
+	school, _ := d.store.School(p.SchoolID)
+	c := content{
+		Guardian:    g.Name,
+		School:      school.Name,
+		Title:       merge.Text(version.Title, v),
+		Text:        richtext.Text(body),
+		HTML:        htmltemplate.HTML(body),
+		Attachments: len(d.store.Attachments(p.ID)),
+	}
+	for _, st := range students {
+		class, _ := d.store.Class(st.ClassID)
+		c.Students = append(c.Students, fmt.Sprintf("%s (%s)", st.Name, class.Name))
+	}
+	if p.Consent != nil {
+		c.DueAt = v[merge.DueDate]
+	}
+	r, err := render(n.Kind, c)
+	if err != nil {
+		return email.Message{}, err
+	}
+	return email.Message{
+		From:    d.from,
+		To:      mail.Address{Name: g.Name, Address: g.Email},
+		Subject: r.subject,
+		Text:    r.text,
+		HTML:    r.html,
+		Date:    d.now(),
+		ID:      n.ID,
+	}, nil
+}
+
+// teacherMessage returns the email of notification n to a teacher about
+// post p being approved.
+func (d *Dispatcher) teacherMessage(n store.Notification, p store.Post) (email.Message, error) {
+	t, ok := d.store.Teacher(n.TeacherID)
+	switch {
+	case !ok:
+		return email.Message{}, fmt.Errorf("%w: teacher %s no longer exists", errUndeliverable, n.TeacherID)
+	case t.Email == "":
+		return email.Message{}, fmt.Errorf("%w: teacher has no email address", errUndeliverable)
+	}
+
+	school, _ := d.store.School(p.SchoolID)
+	author, _ := d.store.Teacher(p.AuthorID)
+	c := content{
+		Teacher: t.Name,
+		School:  school.Name,
+		Title:   p.Title,
+		Text:    richtext.Text(p.Body),
+		HTML:    htmltemplate.HTML(p.Body),
+		Author:  author.Name,
+	}
+	if len(p.Reviews) > 0 {
+		review := p.Reviews[len(p.Reviews)-1]
+		reviewer, _ := d.store.Teacher(review.ReviewerID)
+		c.Reviewer, c.Decision, c.Comment = reviewer.Name, string(review.Decision), review.Comment
+	}
+	r, err := render(n.Kind, c)
+	if err != nil {
+		return email.Message{}, err
+	}
+	return email.Message{
+		From:    d.from,
+		To:      mail.Address{Name: t.Name, Address: t.Email},
+		Subject: r.subject,
+		Text:    r.text,
+		HTML:    r.html,
+		Date:    d.now(),
+		ID:      n.ID,
+	}, nil
+}
+
+// Run sends notifications as they are released, and retries failed ones
+// when they are due, until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		n, err := d.SendDue(ctx)
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "sending notifications failed", "err", err)
+		case n > 0:
+			slog.InfoContext(ctx, "sent notifications", "count", n)
+		}
+
+		t.Reset(d.untilNext())
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// untilNext returns the wait until the next released notification is due
+// to be retried, or MaxBackoff if none is.
+func (d *Dispatcher) untilNext() time.Duration {
+	d.mu.Lock()
+	ids := slices.Clone(d.ready)
+	d.mu.Unlock()
+
+	now := d.now()
+	wait := MaxBackoff
+	for _, id := range ids {
+		n, ok := d.store.Notification(id)
+		if ok && n.Status == store.NotificationPending && n.NextAttemptAt != nil {
+			wait = min(wait, max(n.NextAttemptAt.Sub(now), 0))
+		}
+	}
+	return wait
+}
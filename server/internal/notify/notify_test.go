@@ -0,0 +1,331 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/email"
+	"github.com/String-sg/teacher-workspace/server/pkg/email/emailtest"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+var from = mail.Address{Name: "Rivervale Primary", Address: "noreply@rivervale.example"}
+
+func testStore(at time.Time) *store.Store {
+	published := at.Add(-time.Minute)
+	return store.New(&store.Dataset{
+		Schools:  []store.School{{ID: "s1", Name: "Rivervale Primary"}},
+		Classes:  []store.Class{{ID: "c1", SchoolID: "s1", Name: "3A"}},
+		Students: []store.Student{{ID: "st1", SchoolID: "s1", ClassID: "c1", Name: "Tan Wei Ming"}, {ID: "st2", SchoolID: "s1", ClassID: "c1", Name: "Tan Wei Ling"}},
+		Guardians: []store.Guardian{
+			{ID: "g1", Name: "Betty Tan", Email: "betty@example.com"},
+			{ID: "g2", Name: "Ahmad bin Ali"},
+			{ID: "g3", Name: "Zhang Wei", Email: "zhang@example.com", OptedOut: true},
+		},
+		Teachers: []store.Teacher{
+			{ID: "t1", SchoolID: "s1", Name: "Siti Rahman", Email: "siti@rivervale.example"},
+			{ID: "t2", SchoolID: "s1", Name: "Lim Mei Ling", Email: "lim@rivervale.example"},
+		},
+		Posts: []store.Post{{
+			ID: "p1", SchoolID: "s1", Type: store.PostAnnouncement, Status: store.PostPublished, PublishedAt: &published,
+			Title: "Excursion", Body: "<p>Meet at <b>7.30am</b> &amp; bring water.</p>",
+		}},
+		Notifications: []store.Notification{
+			{ID: "n1", PostID: "p1", GuardianID: "g1", Kind: store.NotifyPost, StudentIDs: []string{"st1", "st2"}, Status: store.NotificationPending, RequestID: "req-1"},
+			{ID: "n2", PostID: "p1", GuardianID: "g2", Kind: store.NotifyPost, Status: store.NotificationPending},
+			{ID: "n3", PostID: "p1", GuardianID: "g3", Kind: store.NotifyPost, Status: store.NotificationPending},
+		},
+	})
+}
+
+// sendFunc is an email.Sender that calls itself.
+type sendFunc func(ctx context.Context, m email.Message) error
+
+func (f sendFunc) Send(ctx context.Context, m email.Message) error { return f(ctx, m) }
+
+// bodies returns the text and HTML parts of m.
+func bodies(t *testing.T, m emailtest.Message) (subject, text, html string) {
+	t.Helper()
+
+	msg, err := m.Parse()
+	require.NoError(t, err)
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for range 2 {
+		p, err := r.NextPart()
+		require.NoError(t, err)
+		b, err := io.ReadAll(quotedprintable.NewReader(p))
+		require.NoError(t, err)
+		parts = append(parts, string(b))
+	}
+	return msg.Header.Get("Subject"), parts[0], parts[1]
+}
+
+func TestDispatcher(t *testing.T) {
+	at := time.Date(2026, time.March, 2, 1, 0, 0, 0, time.UTC)
+
+	newDispatcher := func(t *testing.T) (*Dispatcher, *store.Store, *emailtest.Server, *time.Time) {
+		t.Helper()
+
+		srv := emailtest.NewServer()
+		t.Cleanup(srv.Close)
+		relay, err := email.NewSMTP(email.SMTPConfig{Addr: srv.Addr})
+		require.NoError(t, err)
+		s := testStore(at)
+		clock := at
+		return New(s, relay, from, func() time.Time { return clock }, nil), s, srv, &clock
+	}
+
+	t.Run("sends each guardian the post, rendered for them", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+
+		n, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		require.Equal(t, 1, n)
+		got := srv.Messages()
+		require.Equal(t, 1, len(got))
+		require.Equal(t, "betty@example.com", got[0].To[0])
+		subject, text, html := bodies(t, got[0])
+		require.Equal(t, "Rivervale Primary: Excursion", subject)
+		require.True(t, strings.Contains(text, "Dear Betty Tan,"))
+		require.True(t, strings.Contains(text, "Tan Wei Ming (3A) and Tan Wei Ling (3A)"))
+		require.True(t, strings.Contains(text, "Meet at 7.30am & bring water."))
+		require.True(t, strings.Contains(html, "<p>Meet at <b>7.30am</b> &amp; bring water.</p>"))
+
+		sent, _ := s.Notification("n1")
+		require.Equal(t, store.NotificationSent, sent.Status)
+		require.Equal(t, at, *sent.SentAt)
+	})
+
+	t.Run("gives up on guardians who cannot be emailed", func(t *testing.T) {
+		d, s, _, _ := newDispatcher(t)
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		for id, reason := range map[string]string{"n2": "no email address", "n3": "opted out"} {
+			n, _ := s.Notification(id)
+			require.Equal(t, store.NotificationFailed, n.Status)
+			require.True(t, strings.Contains(n.LastError, reason))
+		}
+	})
+
+	t.Run("escapes what it does not trust", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		g, _ := s.Guardian("g1")
+		g.Name = "<script>alert(1)</script>"
+		s.PutRoster(nil, []store.Guardian{g})
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		_, _, html := bodies(t, srv.Messages()[0])
+		require.False(t, strings.Contains(html, "<script>"))
+	})
+
+	t.Run("fills merge fields for each guardian", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		p, _ := s.Post("p1")
+		p.Title = "Excursion for {{class}}"
+		p.Body = "<p>Dear {{guardian_name}}, {{student_name}} will be back by noon.</p>"
+		s.PutPost(p)
+		g, _ := s.Guardian("g1")
+		g.Name = "Betty <Tan>"
+		s.PutRoster(nil, []store.Guardian{g})
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		subject, text, html := bodies(t, srv.Messages()[0])
+		require.Equal(t, "Rivervale Primary: Excursion for 3A", subject)
+		require.True(t, strings.Contains(text, "Dear Betty <Tan>, Tan Wei Ming and Tan Wei Ling will be back by noon."))
+		require.True(t, strings.Contains(html, "<p>Dear Betty &lt;Tan&gt;, Tan Wei Ming and Tan Wei Ling will"))
+	})
+
+	t.Run("sends the post in the guardian's language", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		p, _ := s.Post("p1")
+		p.Translations = map[store.Language]store.Translation{store.LanguageMalay: {Title: "Lawatan", Body: "<p>Jumpa {{guardian_name}} pukul 7.30 pagi.</p>"}}
+		s.PutPost(p)
+		g, _ := s.Guardian("g1")
+		g.Language = store.LanguageMalay
+		s.PutRoster(nil, []store.Guardian{g})
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		subject, text, _ := bodies(t, srv.Messages()[0])
+		require.Equal(t, "Rivervale Primary: Lawatan", subject)
+		require.True(t, strings.Contains(text, "Jumpa Betty Tan pukul 7.30 pagi."))
+	})
+
+	t.Run("tells the author what an approver decided", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		p, _ := s.Post("p1")
+		p.AuthorID = "t1"
+		p.Reviews = []store.PostReview{{ReviewerID: "t2", Decision: store.ReviewRejected, Comment: "Say when the bus leaves.", At: at}}
+		s.PutPost(p)
+		s.PutNotifications(store.Notification{ID: "n4", PostID: "p1", TeacherID: "t1", Kind: store.NotifyApprovalDecision, Status: store.NotificationPending})
+		d.Release("n4")
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		got := srv.Messages()
+		require.Equal(t, 2, len(got))
+		require.Equal(t, "siti@rivervale.example", got[1].To[0])
+		subject, text, _ := bodies(t, got[1])
+		require.Equal(t, "Post rejected: Excursion", subject)
+		require.True(t, strings.Contains(text, "Lim Mei Ling has rejected \"Excursion\"."))
+		require.True(t, strings.Contains(text, "Say when the bus leaves."))
+		n, _ := s.Notification("n4")
+		require.Equal(t, store.NotificationSent, n.Status)
+	})
+
+	t.Run("sends only notifications that have been released", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+		s.PutNotifications(store.Notification{ID: "n4", PostID: "p1", GuardianID: "g1", Kind: store.NotifyPost, Status: store.NotificationPending})
+
+		n, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		d.Release("n4")
+		n, err = d.SendDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, 2, len(srv.Messages()))
+	})
+
+	t.Run("retries transient failures with backoff", func(t *testing.T) {
+		d, s, srv, clock := newDispatcher(t)
+		srv.Fail(451, 421)
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+		n, _ := s.Notification("n1")
+		require.Equal(t, store.NotificationPending, n.Status)
+		require.Equal(t, 1, n.Attempts)
+		require.Equal(t, at.Add(RetryBackoff), *n.NextAttemptAt)
+		require.Equal(t, RetryBackoff, d.untilNext())
+
+		_, err = d.SendDue(t.Context())
+		require.NoError(t, err)
+		n, _ = s.Notification("n1")
+		require.Equal(t, 1, n.Attempts)
+
+		*clock = clock.Add(RetryBackoff)
+		_, err = d.SendDue(t.Context())
+		require.NoError(t, err)
+		n, _ = s.Notification("n1")
+		require.Equal(t, 2, n.Attempts)
+		require.Equal(t, clock.Add(2*RetryBackoff), *n.NextAttemptAt)
+
+		*clock = clock.Add(2 * RetryBackoff)
+		sent, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, sent)
+		n, _ = s.Notification("n1")
+		require.Equal(t, store.NotificationSent, n.Status)
+		require.Equal(t, 3, n.Attempts)
+		require.Equal(t, "", n.LastError)
+	})
+
+	t.Run("does not bring back notifications of a post deleted while sending", func(t *testing.T) {
+		s := testStore(at)
+		d := New(s, sendFunc(func(context.Context, email.Message) error {
+			s.DeletePost("p1")
+			return nil
+		}), from, func() time.Time { return at }, nil)
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		_, ok := s.Notification("n1")
+		require.False(t, ok)
+		require.Equal(t, 0, len(s.PendingNotifications()))
+	})
+
+	t.Run("gives up on permanent failures at once", func(t *testing.T) {
+		d, s, srv, _ := newDispatcher(t)
+		srv.Fail(550)
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		n, _ := s.Notification("n1")
+		require.Equal(t, store.NotificationFailed, n.Status)
+		require.Equal(t, 1, n.Attempts)
+		require.True(t, strings.Contains(n.LastError, "550"))
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		d, s, srv, clock := newDispatcher(t)
+		for range MaxAttempts {
+			srv.Fail(451)
+		}
+
+		for range MaxAttempts {
+			_, err := d.SendDue(t.Context())
+			require.NoError(t, err)
+			*clock = clock.Add(MaxBackoff)
+		}
+
+		n, _ := s.Notification("n1")
+		require.Equal(t, store.NotificationFailed, n.Status)
+		require.Equal(t, MaxAttempts, n.Attempts)
+		require.True(t, n.NextAttemptAt == nil)
+	})
+
+	t.Run("saves each round of attempts", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		defer srv.Close()
+		relay, err := email.NewSMTP(email.SMTPConfig{Addr: srv.Addr})
+		require.NoError(t, err)
+		saves := 0
+		d := New(testStore(at), relay, from, func() time.Time { return at }, func() error { saves++; return nil })
+
+		_, err = d.SendDue(t.Context())
+		require.NoError(t, err)
+		_, err = d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		require.Equal(t, 1, saves)
+	})
+
+	t.Run("logs each send with the request that queued it", func(t *testing.T) {
+		var buf bytes.Buffer
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+		d, _, _, _ := newDispatcher(t)
+
+		_, err := d.SendDue(t.Context())
+		require.NoError(t, err)
+
+		line, _, _ := strings.Cut(buf.String(), "\n")
+		require.True(t, strings.Contains(line, `"msg":"notification sent"`))
+		require.True(t, strings.Contains(line, `"request_id":"req-1"`))
+		require.True(t, strings.Contains(line, `"notification_id":"n1"`))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, RetryBackoff, backoff(1))
+	require.Equal(t, 4*RetryBackoff, backoff(3))
+	require.Equal(t, MaxBackoff, backoff(20))
+}
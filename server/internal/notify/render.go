@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+
+	"github.com/String-sg/teacher-workspace/server/internal/merge"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// content is what a notification's templates are rendered with.
+type content struct {
+	Guardian string
+	School   string
+	Title    string
+	// Students names the students the guardian is told about, with their
+	// classes.
+	Students []string
+	// Text and HTML are the post's body. HTML has been sanitized.
+	Text string
+	HTML htmltemplate.HTML
+	// DueAt is when a consent form is due, or empty.
+	DueAt       string
+	Attachments int
+
+	// Teacher is the teacher a notification about approving a post is
+	// for, and Author the post's author. Reviewer, Decision and Comment
+	// are the approver's latest review of it.
+	Teacher  string
+	Author   string
+	Reviewer string
+	Decision string
+	Comment  string
+}
+
+// rendered is a notification's email.
+type rendered struct {
+	subject, text, html string
+}
+
+var funcs = map[string]any{"join": merge.List}
+
+// Each kind of notification has a text template, which also defines its
+// subject, and an HTML one.
+var (
+	textTemplates = map[store.NotificationKind]*template.Template{}
+	htmlTemplates = map[store.NotificationKind]*htmltemplate.Template{}
+)
+
+func init() {
+	for _, kind := range []store.NotificationKind{store.NotifyPost, store.NotifyReminder, store.NotifyApprovalRequest, store.NotifyApprovalDecision} {
+		textTemplates[kind] = template.Must(template.New("").Funcs(funcs).ParseFS(templateFiles, "templates/"+string(kind)+".txt.tmpl")).Lookup(string(kind) + ".txt.tmpl")
+		htmlTemplates[kind] = htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(templateFiles, "templates/"+string(kind)+".html.tmpl")).Lookup(string(kind) + ".html.tmpl")
+	}
+}
+
+// render renders the email of a notification of kind.
+func render(kind store.NotificationKind, c content) (rendered, error) {
+	var subject, text, html bytes.Buffer
+	t := textTemplates[kind]
+	if err := t.ExecuteTemplate(&subject, "subject", c); err != nil {
+		return rendered{}, err
+	}
+	if err := t.Execute(&text, c); err != nil {
+		return rendered{}, err
+	}
+	if err := htmlTemplates[kind].Execute(&html, c); err != nil {
+		return rendered{}, err
+	}
+	return rendered{subject: subject.String(), text: strings.TrimSpace(text.String()) + "\n", html: html.String()}, nil
+}
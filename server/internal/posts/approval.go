@@ -0,0 +1,259 @@
+package posts
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// MaxReviewCommentLength bounds an approver's comment, in characters.
+const MaxReviewCommentLength = 1000
+
+// ApprovalRules returns the post approval rules of the viewer's school.
+func (s *Service) ApprovalRules(scope authz.Scope) store.ApprovalRules {
+	school, _ := s.store.School(scope.Teacher.SchoolID)
+	if school.PostApproval == nil {
+		return store.ApprovalRules{}
+	}
+	return *school.PostApproval
+}
+
+// SetApprovalRules replaces the post approval rules of the viewer's school,
+// which only its leaders may do. Posts already approved, scheduled or
+// published are not affected, but scheduled posts that now need approval
+// go back to being drafts when they fall due.
+func (s *Service) SetApprovalRules(scope authz.Scope, rules store.ApprovalRules) (store.ApprovalRules, error) {
+	if !scope.SchoolWide() {
+		return store.ApprovalRules{}, ErrForbidden
+	}
+	if rules.MinStudents < 0 {
+		return store.ApprovalRules{}, fmt.Errorf("%w: min_students must not be negative", ErrInvalid)
+	}
+	var types []store.PostType
+	for _, t := range rules.Types {
+		if t != store.PostAnnouncement && t != store.PostConsent {
+			return store.ApprovalRules{}, fmt.Errorf("%w: types must be announcement or consent", ErrInvalid)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	var approvers []string
+	for _, id := range rules.ApproverIDs {
+		t, ok := s.store.Teacher(id)
+		if !ok || t.SchoolID != scope.Teacher.SchoolID {
+			return store.ApprovalRules{}, fmt.Errorf("%w: no teacher %q", ErrInvalid, id)
+		}
+		if !slices.Contains(approvers, id) {
+			approvers = append(approvers, id)
+		}
+	}
+	rules.Types, rules.ApproverIDs = types, approvers
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.store.SetPostApproval(scope.Teacher.SchoolID, &rules) {
+		return store.ApprovalRules{}, ErrNotFound
+	}
+	s.commit()
+	return rules, nil
+}
+
+// Approvals returns the posts waiting for the viewer's review, submitted
+// longest ago first. Only approvers have a queue.
+func (s *Service) Approvals(scope authz.Scope) ([]View, error) {
+	if !s.isApprover(scope) {
+		return nil, ErrForbidden
+	}
+	var pending []store.Post
+	for _, p := range s.store.PostsInSchool(scope.Teacher.SchoolID) {
+		if p.Status == store.PostPendingApproval && s.canReview(scope, p) {
+			pending = append(pending, p)
+		}
+	}
+	slices.SortFunc(pending, func(a, b store.Post) int {
+		return cmp.Or(a.SubmittedAt.Compare(*b.SubmittedAt), cmp.Compare(a.ID, b.ID))
+	})
+	out := []View{}
+	for _, p := range pending {
+		out = append(out, s.view(scope, p))
+	}
+	return out, nil
+}
+
+// Submit sends post id, a draft or rejected post of the viewer's that needs
+// approval, to be reviewed. It must be publishable as it stands. The
+// school's approvers are notified, traced to the request in ctx.
+func (s *Service) Submit(ctx context.Context, scope authz.Scope, id string) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionSubmit)
+	if err != nil {
+		return View{}, err
+	}
+	if p.Status != store.PostDraft && p.Status != store.PostRejected {
+		return View{}, fmt.Errorf("%w: only drafts and rejected posts may be submitted", ErrConflict)
+	}
+	if !s.needsApproval(p) {
+		return View{}, fmt.Errorf("%w: the post does not need approval", ErrConflict)
+	}
+	now := s.now().UTC()
+	if err := s.ready(scope, p, now); err != nil {
+		return View{}, err
+	}
+	requestID, _ := middleware.RequestIDFromContext(ctx)
+	ns, err := s.teacherNotifications(p, store.NotifyApprovalRequest, s.approvers(p), requestID)
+	if err != nil {
+		return View{}, err
+	}
+
+	p.Status = store.PostPendingApproval
+	p.SubmittedAt = &now
+	p.UpdatedAt = now
+	s.store.PutPost(p)
+	s.enqueue(ns)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostSubmit, TargetType: "post", TargetID: p.ID})
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// Withdraw returns post id, which the viewer submitted for approval, to
+// being a draft before it is reviewed.
+func (s *Service) Withdraw(scope authz.Scope, id string) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionWithdraw)
+	if err != nil {
+		return View{}, err
+	}
+	if p.Status != store.PostPendingApproval {
+		return View{}, fmt.Errorf("%w: post is not waiting for approval", ErrConflict)
+	}
+
+	s.withdraw(scope, &p, "")
+	s.store.PutPost(p)
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// withdraw returns post p to being a draft that needs submitting again,
+// recording why in detail.
+func (s *Service) withdraw(scope authz.Scope, p *store.Post, detail string) {
+	p.Status = store.PostDraft
+	p.SubmittedAt = nil
+	p.UpdatedAt = s.now().UTC()
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostWithdraw, TargetType: "post", TargetID: p.ID, Detail: detail})
+}
+
+// Approve approves post id, which is waiting for the viewer's review, so
+// that its author may publish it. comment is optional.
+func (s *Service) Approve(ctx context.Context, scope authz.Scope, id, comment string) (View, error) {
+	return s.review(ctx, scope, id, store.ReviewApproved, comment)
+}
+
+// Reject sends post id, which is waiting for the viewer's review, back to
+// its author, saying why in comment.
+func (s *Service) Reject(ctx context.Context, scope authz.Scope, id, comment string) (View, error) {
+	return s.review(ctx, scope, id, store.ReviewRejected, comment)
+}
+
+func (s *Service) review(ctx context.Context, scope authz.Scope, id string, decision store.ReviewDecision, comment string) (View, error) {
+	comment = strings.TrimSpace(comment)
+	if utf8.RuneCountInString(comment) > MaxReviewCommentLength {
+		return View{}, fmt.Errorf("%w: comment must be at most %d characters", ErrInvalid, MaxReviewCommentLength)
+	}
+	if decision == store.ReviewRejected && comment == "" {
+		return View{}, fmt.Errorf("%w: say why the post is rejected in a comment", ErrInvalid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.store.Post(id)
+	if !ok || !(canView(scope, p) || s.canReview(scope, p)) {
+		return View{}, ErrNotFound
+	}
+	if !s.canReview(scope, p) {
+		return View{}, ErrForbidden
+	}
+	if p.Status != store.PostPendingApproval {
+		return View{}, fmt.Errorf("%w: post is not waiting for approval", ErrConflict)
+	}
+	requestID, _ := middleware.RequestIDFromContext(ctx)
+	ns, err := s.teacherNotifications(p, store.NotifyApprovalDecision, []string{p.AuthorID}, requestID)
+	if err != nil {
+		return View{}, err
+	}
+
+	now := s.now().UTC()
+	p.Reviews = append(slices.Clone(p.Reviews), store.PostReview{ReviewerID: scope.Teacher.ID, Decision: decision, Comment: comment, At: now})
+	action := audit.ActionPostApprove
+	p.Status = store.PostApproved
+	if decision == store.ReviewRejected {
+		action = audit.ActionPostReject
+		p.Status = store.PostRejected
+	}
+	p.UpdatedAt = now
+	s.store.PutPost(p)
+	s.enqueue(ns)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: action, TargetType: "post", TargetID: p.ID})
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// needsApproval reports whether post p must be approved before it is
+// published, under the rules of its school.
+func (s *Service) needsApproval(p store.Post) bool {
+	school, _ := s.store.School(p.SchoolID)
+	rules := school.PostApproval
+	if rules == nil {
+		return false
+	}
+	if slices.Contains(rules.Types, cmp.Or(p.Type, store.PostAnnouncement)) {
+		return true
+	}
+	return rules.MinStudents > 0 && s.audience(p).Students >= rules.MinStudents
+}
+
+// approved reports whether post p has been approved since it was last
+// submitted, and not changed since.
+func approved(p store.Post) bool {
+	if p.SubmittedAt == nil || len(p.Reviews) == 0 {
+		return false
+	}
+	last := p.Reviews[len(p.Reviews)-1]
+	return last.Decision == store.ReviewApproved && !last.At.Before(*p.SubmittedAt)
+}
+
+// isApprover reports whether the viewer may approve posts at their school.
+func (s *Service) isApprover(scope authz.Scope) bool {
+	return scope.SchoolWide() || slices.Contains(s.ApprovalRules(scope).ApproverIDs, scope.Teacher.ID)
+}
+
+// canReview reports whether the viewer may approve or reject post p: an
+// approver of its school other than its author may.
+func (s *Service) canReview(scope authz.Scope, p store.Post) bool {
+	return p.SchoolID == scope.Teacher.SchoolID && p.AuthorID != scope.Teacher.ID && s.isApprover(scope)
+}
+
+// approvers returns the IDs of the teachers who may review post p.
+func (s *Service) approvers(p store.Post) []string {
+	var out []string
+	for _, t := range s.store.TeachersInSchool(p.SchoolID) {
+		if s.canReview(authz.For(s.store, t), p) {
+			out = append(out, t.ID)
+		}
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package posts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestApproval(t *testing.T) {
+	in := Input{Title: "Sports Day", Body: "Bring water.", Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}}}
+
+	// newApproval returns a scheduler whose school needs posts reaching
+	// three or more students approved, and a draft of form's that does.
+	newApproval := func(t *testing.T) (*scheduler, string) {
+		t.Helper()
+
+		sc := newScheduler(t)
+		_, err := sc.svc.SetApprovalRules(scopeOf(sc.store, "lead", store.RoleSchoolLeader), store.ApprovalRules{MinStudents: 3})
+		require.NoError(t, err)
+		v, err := sc.svc.Create(scopeOf(sc.store, "form", store.RoleTeacher), in)
+		require.NoError(t, err)
+		return sc, v.ID
+	}
+
+	t.Run("lets only leaders set the rules", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		lead := scopeOf(s, "lead", store.RoleSchoolLeader)
+
+		_, err := svc.SetApprovalRules(form, store.ApprovalRules{MinStudents: 3})
+		require.True(t, errors.Is(err, ErrForbidden))
+		_, err = svc.SetApprovalRules(lead, store.ApprovalRules{Types: []store.PostType{"memo"}})
+		require.True(t, errors.Is(err, ErrInvalid))
+		_, err = svc.SetApprovalRules(lead, store.ApprovalRules{ApproverIDs: []string{"nobody"}})
+		require.True(t, errors.Is(err, ErrInvalid))
+
+		rules, err := svc.SetApprovalRules(lead, store.ApprovalRules{
+			Types:       []store.PostType{store.PostConsent, store.PostConsent},
+			ApproverIDs: []string{"form", "form"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(rules.Types))
+		require.Equal(t, 1, len(rules.ApproverIDs))
+		require.Equal(t, 1, len(svc.ApprovalRules(form).ApproverIDs))
+	})
+
+	t.Run("asks for approval by target size", func(t *testing.T) {
+		sc, id := newApproval(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+
+		v, err := sc.svc.Get(form, id)
+		require.NoError(t, err)
+		require.True(t, v.NeedsApproval)
+		require.Equal(t, TransitionSubmit, v.Transitions[len(v.Transitions)-1])
+
+		_, err = sc.svc.Publish(t.Context(), form, id)
+		require.True(t, errors.Is(err, ErrConflict))
+
+		small, err := sc.svc.Create(form, Input{Title: "Quiz", Body: "On Friday.", Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}}})
+		require.NoError(t, err)
+		require.False(t, small.NeedsApproval)
+		_, err = sc.svc.Submit(t.Context(), form, small.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+	})
+
+	t.Run("goes through review to publishing", func(t *testing.T) {
+		sc, id := newApproval(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		lead := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+
+		v, err := sc.svc.Submit(t.Context(), form, id)
+		require.NoError(t, err)
+		require.Equal(t, store.PostPendingApproval, v.Status)
+		require.Equal(t, 1, len(sc.released))
+		n, _ := sc.store.Notification(sc.released[0])
+		require.Equal(t, "lead", n.TeacherID)
+		require.Equal(t, store.NotifyApprovalRequest, n.Kind)
+
+		queue, err := sc.svc.Approvals(lead)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(queue))
+		require.Equal(t, id, queue[0].ID)
+		_, err = sc.svc.Approvals(form)
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		_, err = sc.svc.Reject(t.Context(), lead, id, " ")
+		require.True(t, errors.Is(err, ErrInvalid))
+		v, err = sc.svc.Reject(t.Context(), lead, id, "Say when it ends.")
+		require.NoError(t, err)
+		require.Equal(t, store.PostRejected, v.Status)
+		require.Equal(t, "Say when it ends.", v.Reviews[0].Comment)
+		n, _ = sc.store.Notification(sc.released[1])
+		require.Equal(t, "form", n.TeacherID)
+		require.Equal(t, store.NotifyApprovalDecision, n.Kind)
+
+		_, err = sc.svc.Submit(t.Context(), form, id)
+		require.NoError(t, err)
+		v, err = sc.svc.Approve(t.Context(), lead, id, "")
+		require.NoError(t, err)
+		require.Equal(t, store.PostApproved, v.Status)
+		_, err = sc.svc.Approve(t.Context(), lead, id, "")
+		require.True(t, errors.Is(err, ErrConflict))
+
+		v, err = sc.svc.Publish(t.Context(), form, id)
+		require.NoError(t, err)
+		require.Equal(t, store.PostPublished, v.Status)
+
+		var actions []string
+		for _, e := range sc.store.AuditLog() {
+			if e.TargetID == id {
+				actions = append(actions, e.Action)
+			}
+		}
+		require.Equal(t, strings.Join([]string{
+			audit.ActionPostSubmit, audit.ActionPostReject,
+			audit.ActionPostSubmit, audit.ActionPostApprove, audit.ActionPostPublish,
+		}, " "), strings.Join(actions, " "))
+	})
+
+	t.Run("lets approvers named in the rules review others' posts", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		lead := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		_, err := sc.svc.SetApprovalRules(lead, store.ApprovalRules{Types: []store.PostType{store.PostAnnouncement}, ApproverIDs: []string{"form"}})
+		require.NoError(t, err)
+		mine, err := sc.svc.Create(lead, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Submit(t.Context(), lead, mine.ID)
+		require.NoError(t, err)
+
+		_, err = sc.svc.Approve(t.Context(), lead, mine.ID, "")
+		require.True(t, errors.Is(err, ErrForbidden))
+		v, err := sc.svc.Get(form, mine.ID)
+		require.NoError(t, err)
+		require.Equal(t, "approve reject", strings.Join(v.Transitions, " "))
+		_, err = sc.svc.Approve(t.Context(), form, mine.ID, "Looks good.")
+		require.NoError(t, err)
+	})
+
+	t.Run("needs approving again once changed", func(t *testing.T) {
+		sc, id := newApproval(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		lead := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		_, err := sc.svc.Submit(t.Context(), form, id)
+		require.NoError(t, err)
+		_, err = sc.svc.Approve(t.Context(), lead, id, "")
+		require.NoError(t, err)
+
+		in := in
+		in.Title = "Sports Day (updated)"
+		v, err := sc.svc.Update(form, id, in)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		_, err = sc.svc.Publish(t.Context(), form, id)
+		require.True(t, errors.Is(err, ErrConflict))
+
+		_, err = sc.svc.Submit(t.Context(), form, id)
+		require.NoError(t, err)
+		v, err = sc.svc.Withdraw(form, id)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		queue, err := sc.svc.Approvals(lead)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(queue))
+	})
+
+	t.Run("needs approving again once its files change", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		lead := scopeOf(s, "lead", store.RoleSchoolLeader)
+		_, err := svc.SetApprovalRules(lead, store.ApprovalRules{Types: []store.PostType{store.PostAnnouncement}})
+		require.NoError(t, err)
+		approve := func(id string) {
+			t.Helper()
+			_, err := svc.Submit(t.Context(), form, id)
+			require.NoError(t, err)
+			_, err = svc.Approve(t.Context(), lead, id, "")
+			require.NoError(t, err)
+		}
+		pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+		approve(v.ID)
+		a, err := svc.Attach(t.Context(), form, v.ID, "form.pdf", pdf)
+		require.NoError(t, err)
+		v, err = svc.Get(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+
+		approve(v.ID)
+		_, err = svc.Schedule(form, v.ID, time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.NoError(t, svc.Detach(t.Context(), form, v.ID, a.ID))
+		v, err = svc.Get(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		require.True(t, v.PublishAt == nil)
+		require.Equal(t, TransitionSubmit, v.Transitions[len(v.Transitions)-1])
+	})
+
+	t.Run("returns a cancelled post to approved", func(t *testing.T) {
+		sc, id := newApproval(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		_, err := sc.svc.Submit(t.Context(), form, id)
+		require.NoError(t, err)
+		_, err = sc.svc.Approve(t.Context(), scopeOf(sc.store, "lead", store.RoleSchoolLeader), id, "")
+		require.NoError(t, err)
+
+		_, err = sc.svc.Schedule(form, id, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		v, err := sc.svc.Cancel(form, id)
+		require.NoError(t, err)
+		require.Equal(t, store.PostApproved, v.Status)
+	})
+}
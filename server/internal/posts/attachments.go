@@ -0,0 +1,239 @@
+package posts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+const (
+	// MaxAttachments bounds the attachments of one post.
+	MaxAttachments = 5
+	// MaxAttachmentSize bounds an attachment, in bytes.
+	MaxAttachmentSize = 10 << 20
+	// MaxAttachmentNameLength bounds an attachment's file name, in
+	// characters.
+	MaxAttachmentNameLength = 200
+)
+
+// attachmentTypes are the content types that may be attached, with the file
+// extensions each may be named with.
+var attachmentTypes = map[string][]string{
+	"application/pdf": {".pdf"},
+	"image/jpeg":      {".jpg", ".jpeg"},
+	"image/png":       {".png"},
+	"image/gif":       {".gif"},
+	"image/webp":      {".webp"},
+}
+
+// errNoAttachments is returned when the Service was given nowhere to keep
+// attachments.
+var errNoAttachments = errors.New("posts: attachments are not configured")
+
+// Attach attaches the file data named name to post id, which must be the
+// viewer's own and not yet published.
+//
+// The file's type is sniffed from its content; it must be a PDF or an image
+// and be named for what it is, so that a file named "form.pdf" is a PDF. It
+// is scanned, if the Service has a scanner, before it is stored. A post
+// that was submitted for approval goes back to being a draft, to be
+// submitted again.
+func (s *Service) Attach(ctx context.Context, scope authz.Scope, id, name string, data []byte) (store.Attachment, error) {
+	if s.blobs == nil {
+		return store.Attachment{}, errNoAttachments
+	}
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || utf8.RuneCountInString(name) > MaxAttachmentNameLength {
+		return store.Attachment{}, fmt.Errorf("%w: file name must be 1 to %d characters", ErrInvalid, MaxAttachmentNameLength)
+	}
+	if len(data) == 0 || len(data) > MaxAttachmentSize {
+		return store.Attachment{}, fmt.Errorf("%w: attachments must be 1 to %d bytes", ErrInvalid, MaxAttachmentSize)
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	exts, ok := attachmentTypes[contentType]
+	if !ok {
+		return store.Attachment{}, fmt.Errorf("%w: only PDF, JPEG, PNG, GIF and WebP files may be attached", ErrInvalid)
+	}
+	if !slices.Contains(exts, strings.ToLower(path.Ext(name))) {
+		return store.Attachment{}, fmt.Errorf("%w: %q holds %s content and must be named %s", ErrInvalid, name, contentType, strings.Join(exts, " or "))
+	}
+
+	// The post is checked before the slow work of scanning and storing the
+	// file, and again after, since it may have been published meanwhile.
+	s.mu.Lock()
+	_, err := s.attachable(scope, id)
+	s.mu.Unlock()
+	if err != nil {
+		return store.Attachment{}, err
+	}
+	if s.scanner != nil {
+		v, err := s.scanner.Scan(ctx, bytes.NewReader(data))
+		if err != nil {
+			return store.Attachment{}, fmt.Errorf("posts: scanning attachment: %w", err)
+		}
+		if !v.Clean {
+			s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostAttachRejected, TargetType: "post", TargetID: id, Detail: strings.TrimSpace(name + ": " + v.Threat)})
+			return store.Attachment{}, fmt.Errorf("%w: %q did not pass the virus scan", ErrInvalid, name)
+		}
+	}
+
+	attachmentID, err := s.ids.New()
+	if err != nil {
+		return store.Attachment{}, err
+	}
+	sum := sha256.Sum256(data)
+	a := store.Attachment{
+		ID:          attachmentID.String(),
+		PostID:      id,
+		UploaderID:  scope.Teacher.ID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Key:         "attachments/" + attachmentID.String(),
+		CreatedAt:   s.now().UTC(),
+	}
+	if err := s.blobs.Put(ctx, a.Key, bytes.NewReader(data), a.Size, a.ContentType); err != nil {
+		return store.Attachment{}, fmt.Errorf("posts: storing attachment: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.attachable(scope, id)
+	if err != nil {
+		s.deleteBlobs(ctx, a)
+		return store.Attachment{}, err
+	}
+	s.store.AppendAttachment(a)
+	if s.unapprove(scope, &p) {
+		s.store.PutPost(p)
+	}
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostAttach, TargetType: "post", TargetID: id, Detail: a.ID})
+	s.commit()
+	return a, nil
+}
+
+// attachable returns post id if the viewer may attach another file to it.
+func (s *Service) attachable(scope authz.Scope, id string) (store.Post, error) {
+	p, err := s.editable(scope, id, TransitionEdit)
+	if err != nil {
+		return store.Post{}, err
+	}
+	if len(s.store.Attachments(p.ID)) >= MaxAttachments {
+		return store.Post{}, fmt.Errorf("%w: a post may have at most %d attachments", ErrInvalid, MaxAttachments)
+	}
+	return p, nil
+}
+
+// Detach removes attachment attachmentID from post id, which must be the
+// viewer's own and not yet published. Like attaching a file, it withdraws
+// the post's approval.
+func (s *Service) Detach(ctx context.Context, scope authz.Scope, id, attachmentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionEdit)
+	if err != nil {
+		return err
+	}
+	a, ok := attachment(s.store.Attachments(p.ID), attachmentID)
+	if !ok {
+		return ErrNotFound
+	}
+	s.store.DeleteAttachment(a.ID)
+	if s.unapprove(scope, &p) {
+		s.store.PutPost(p)
+	}
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostDetach, TargetType: "post", TargetID: p.ID, Detail: a.ID})
+	// The content goes only once nothing saved refers to it.
+	if s.commit() {
+		s.deleteBlobs(ctx, a)
+	}
+	return nil
+}
+
+// unapprove withdraws any approval of post p, whose attachments the viewer
+// has just changed, so that no one publishes files an approver has not
+// seen. A scheduled post that needs approval is unscheduled. It reports
+// whether p changed.
+func (s *Service) unapprove(scope authz.Scope, p *store.Post) bool {
+	if p.Status == store.PostDraft || p.Status == store.PostScheduled && !s.needsApproval(*p) {
+		if p.SubmittedAt == nil {
+			return false
+		}
+		p.SubmittedAt = nil
+		p.UpdatedAt = s.now().UTC()
+		return true
+	}
+	p.PublishAt = nil
+	s.withdraw(scope, p, "changed")
+	return true
+}
+
+// OpenAttachment returns attachment attachmentID of post id, which the
+// viewer must be able to see, and opens its content. The caller must close
+// it.
+func (s *Service) OpenAttachment(ctx context.Context, scope authz.Scope, id, attachmentID string) (store.Attachment, io.ReadCloser, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !canView(scope, p) {
+		return store.Attachment{}, nil, ErrNotFound
+	}
+	return s.open(ctx, p, attachmentID)
+}
+
+// OpenPublishedAttachment is OpenAttachment for Parents Gateway, which may
+// open the attachments of published posts.
+func (s *Service) OpenPublishedAttachment(ctx context.Context, id, attachmentID string) (store.Attachment, io.ReadCloser, error) {
+	p, ok := s.store.Post(id)
+	if !ok || p.Status != store.PostPublished {
+		return store.Attachment{}, nil, ErrNotFound
+	}
+	return s.open(ctx, p, attachmentID)
+}
+
+func (s *Service) open(ctx context.Context, p store.Post, attachmentID string) (store.Attachment, io.ReadCloser, error) {
+	a, ok := attachment(s.store.Attachments(p.ID), attachmentID)
+	if !ok {
+		return store.Attachment{}, nil, ErrNotFound
+	}
+	if s.blobs == nil {
+		return store.Attachment{}, nil, errNoAttachments
+	}
+	r, err := s.blobs.Get(ctx, a.Key)
+	if err != nil {
+		return store.Attachment{}, nil, fmt.Errorf("posts: opening attachment %s: %w", a.ID, err)
+	}
+	return a, r, nil
+}
+
+func attachment(as []store.Attachment, id string) (store.Attachment, bool) {
+	i := slices.IndexFunc(as, func(a store.Attachment) bool { return a.ID == id })
+	if i < 0 {
+		return store.Attachment{}, false
+	}
+	return as[i], true
+}
+
+// deleteBlobs deletes the content of attachments whose records are gone.
+// Content that cannot be deleted is left behind, unreachable.
+func (s *Service) deleteBlobs(ctx context.Context, as ...store.Attachment) {
+	for _, a := range as {
+		if err := s.blobs.Delete(ctx, a.Key); err != nil {
+			slog.ErrorContext(ctx, "deleting attachment content failed", "key", a.Key, "err", err)
+		}
+	}
+}
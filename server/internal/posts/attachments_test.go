@@ -0,0 +1,143 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/scan"
+)
+
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	in := Input{Title: "Excursion", Body: "Meet at 7.30am.", Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}}}
+
+	t.Run("attaches files and serves them back", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		a, err := svc.Attach(ctx, form, v.ID, `C:\Users\form\Consent form.PDF`, pdf)
+		require.NoError(t, err)
+		require.Equal(t, "Consent form.PDF", a.Name)
+		require.Equal(t, "application/pdf", a.ContentType)
+		require.Equal(t, int64(len(pdf)), a.Size)
+
+		got, body, err := svc.OpenAttachment(ctx, scopeOf(s, "lead", store.RoleSchoolLeader), v.ID, a.ID)
+		require.NoError(t, err)
+		b, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		require.Equal(t, string(pdf), string(b))
+		require.Equal(t, a, got)
+
+		v, err = svc.Get(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(v.Attachments))
+	})
+
+	t.Run("trusts the content, not the name", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		for name, data := range map[string][]byte{
+			"map.pdf":    png,
+			"notes.pdf":  []byte("just some text"),
+			"page.html":  []byte("<html><script>alert(1)</script></html>"),
+			"empty.pdf":  nil,
+			"  ":         pdf,
+			"report.exe": []byte("MZ\x90\x00"),
+		} {
+			_, err := svc.Attach(ctx, form, v.ID, name, data)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+		_, err = svc.Attach(ctx, form, v.ID, "map.png", png)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects files that fail the scan", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		_, err = svc.Attach(ctx, form, v.ID, "form.pdf", append(pdf, scan.EICAR...))
+
+		require.True(t, errors.Is(err, ErrInvalid))
+		require.Equal(t, 0, len(s.Attachments(v.ID)))
+		log := s.AuditLog()
+		require.Equal(t, audit.ActionPostAttachRejected, log[len(log)-1].Action)
+		require.True(t, strings.Contains(log[len(log)-1].Detail, "EICAR"))
+	})
+
+	t.Run("limits attachments to drafts and scheduled posts", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+		for range MaxAttachments {
+			_, err = svc.Attach(ctx, form, v.ID, "form.pdf", pdf)
+			require.NoError(t, err)
+		}
+		_, err = svc.Attach(ctx, form, v.ID, "form.pdf", pdf)
+		require.True(t, errors.Is(err, ErrInvalid))
+
+		_, err = svc.Attach(ctx, scopeOf(s, "lead", store.RoleSchoolLeader), v.ID, "form.pdf", pdf)
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		first := s.Attachments(v.ID)[0]
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.NoError(t, err)
+		err = svc.Detach(ctx, form, v.ID, first.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+	})
+
+	t.Run("detaching and deleting the post remove the content", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+		a, err := svc.Attach(ctx, form, v.ID, "form.pdf", pdf)
+		require.NoError(t, err)
+		b, err := svc.Attach(ctx, form, v.ID, "map.png", png)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Detach(ctx, form, v.ID, a.ID))
+		_, err = svc.blobs.Get(ctx, a.Key)
+		require.Error(t, err)
+		_, _, err = svc.OpenAttachment(ctx, form, v.ID, a.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		require.NoError(t, svc.Delete(form, v.ID))
+		_, err = svc.blobs.Get(ctx, b.Key)
+		require.Error(t, err)
+	})
+
+	t.Run("gateway opens only published posts' attachments", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+		a, err := svc.Attach(ctx, form, v.ID, "form.pdf", pdf)
+		require.NoError(t, err)
+
+		_, _, err = svc.OpenPublishedAttachment(ctx, v.ID, a.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.NoError(t, err)
+		_, body, err := svc.OpenPublishedAttachment(ctx, v.ID, a.ID)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+	})
+}
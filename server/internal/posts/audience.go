@@ -0,0 +1,325 @@
+package posts
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/lang"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Audience counts whom a post's targets reach. A student targeted more than
+// once, or a guardian of several targeted students, counts once. Guardians
+// who are not sent the post, as Resolution explains, do not count.
+type Audience struct {
+	Students  int `json:"students"`
+	Guardians int `json:"guardians"`
+}
+
+// Audience returns whom targets would reach if the viewer posted to them,
+// so that the count can be checked before a draft is saved.
+func (s *Service) Audience(scope authz.Scope, targets []store.Target) (Audience, error) {
+	for _, t := range targets {
+		if err := s.checkTarget(scope, t); err != nil {
+			return Audience{}, err
+		}
+	}
+	return s.audience(store.Post{SchoolID: scope.Teacher.SchoolID, Targets: targets}), nil
+}
+
+func (s *Service) audience(p store.Post) Audience {
+	res := s.resolve(p)
+	students := make(map[string]bool)
+	for _, r := range res.Recipients {
+		for _, st := range r.Students {
+			students[st.StudentID] = true
+		}
+	}
+	return Audience{Students: len(students) + len(res.Unreached), Guardians: len(res.Recipients)}
+}
+
+// Recipient is one guardian a post reaches, the children they receive it
+// for and, once it is published, how far it has got with them.
+type Recipient struct {
+	GuardianID   string `json:"guardian_id"`
+	GuardianName string `json:"guardian_name"`
+	// Language is the language the guardian prefers posts in.
+	Language       store.Language     `json:"language"`
+	Students       []RecipientStudent `json:"students"`
+	DeliveredAt    *time.Time         `json:"delivered_at,omitempty"`
+	OpenedAt       *time.Time         `json:"opened_at,omitempty"`
+	AcknowledgedAt *time.Time         `json:"acknowledged_at,omitempty"`
+}
+
+// RecipientStudent is a child a guardian receives a post for. Via lists the
+// post's targets that select the child, which is why the guardian receives
+// it.
+type RecipientStudent struct {
+	StudentID    string         `json:"student_id"`
+	StudentName  string         `json:"student_name"`
+	ClassID      string         `json:"class_id"`
+	Relationship string         `json:"relationship"`
+	Via          []store.Target `json:"via"`
+}
+
+// ExclusionReason is why a guardian of a targeted student is not sent a
+// post about them.
+type ExclusionReason string
+
+const (
+	// ExcludedOptedOut is a guardian who has asked not to be sent posts.
+	ExcludedOptedOut ExclusionReason = "opted_out"
+	// ExcludedRestricted is a guardian who may not be sent posts about the
+	// student, as under a custody order.
+	ExcludedRestricted ExclusionReason = "restricted"
+)
+
+// Exclusion is a guardian not sent a post about one targeted student.
+type Exclusion struct {
+	GuardianID   string          `json:"guardian_id"`
+	GuardianName string          `json:"guardian_name"`
+	StudentID    string          `json:"student_id"`
+	Reason       ExclusionReason `json:"reason"`
+}
+
+// Resolution is whom a post's targets reach. Each guardian is a recipient
+// once, however many of their children are targeted, and an excluded
+// guardian may still receive the post for another child.
+type Resolution struct {
+	// Recipients lists the guardians sent the post, by name.
+	Recipients []Recipient `json:"recipients"`
+	Excluded   []Exclusion `json:"excluded"`
+	// Unreached lists the targeted students none of whose guardians are
+	// sent the post, in the order targeted.
+	Unreached []RecipientStudent `json:"unreached"`
+}
+
+// Recipients returns whom post id reaches now and why.
+func (s *Service) Recipients(scope authz.Scope, id string) (Resolution, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !canView(scope, p) {
+		return Resolution{}, ErrNotFound
+	}
+	return s.resolve(p), nil
+}
+
+// resolve expands post p's audience into the guardians of the students it
+// reaches, leaving out guardians who have opted out and those restricted
+// from hearing about a student.
+func (s *Service) resolve(p store.Post) Resolution {
+	return s.resolveReached(s.reach(p))
+}
+
+// resolveReached is resolve for the students rs.
+func (s *Service) resolveReached(rs []reached) Resolution {
+	out := Resolution{Recipients: []Recipient{}, Excluded: []Exclusion{}, Unreached: []RecipientStudent{}}
+	byGuardian := make(map[string]*Recipient)
+	for _, r := range rs {
+		reached := false
+		for _, link := range r.student.Guardians {
+			g, ok := s.store.Guardian(link.GuardianID)
+			if !ok {
+				continue
+			}
+			reason := ExclusionReason("")
+			switch {
+			case link.Restricted:
+				reason = ExcludedRestricted
+			case g.OptedOut:
+				reason = ExcludedOptedOut
+			}
+			if reason != "" {
+				out.Excluded = append(out.Excluded, Exclusion{GuardianID: g.ID, GuardianName: g.Name, StudentID: r.student.ID, Reason: reason})
+				continue
+			}
+
+			reached = true
+			rc, ok := byGuardian[g.ID]
+			if !ok {
+				rc = &Recipient{GuardianID: g.ID, GuardianName: g.Name, Language: lang.Of(g)}
+				byGuardian[g.ID] = rc
+			}
+			rc.Students = append(rc.Students, r.context(link.Relationship))
+		}
+		if !reached {
+			out.Unreached = append(out.Unreached, r.context(""))
+		}
+	}
+
+	for _, rc := range byGuardian {
+		out.Recipients = append(out.Recipients, *rc)
+	}
+	slices.SortFunc(out.Recipients, func(a, b Recipient) int {
+		return cmp.Or(cmp.Compare(a.GuardianName, b.GuardianName), cmp.Compare(a.GuardianID, b.GuardianID))
+	})
+	return out
+}
+
+// reached is a student selected by a post's targets, and the targets that
+// select them.
+type reached struct {
+	student store.Student
+	via     []store.Target
+}
+
+func (r reached) context(relationship string) RecipientStudent {
+	return RecipientStudent{
+		StudentID:    r.student.ID,
+		StudentName:  r.student.Name,
+		ClassID:      r.student.ClassID,
+		Relationship: relationship,
+		Via:          r.via,
+	}
+}
+
+// students returns the students post p reaches, each once, in the order
+// first reached.
+func (s *Service) students(p store.Post) []store.Student {
+	rs := s.reach(p)
+	out := make([]store.Student, len(rs))
+	for i, r := range rs {
+		out[i] = r.student
+	}
+	return out
+}
+
+// reach returns the students post p reaches and the targets that select
+// them. A published post reaches the students it was sent about, with the
+// guardians they had then, whatever has since become of its groups and
+// classes; opt-outs and custody restrictions still apply as they change.
+// Posts published before recipients were recorded, and posts not yet
+// published, reach whom their targets select now.
+func (s *Service) reach(p store.Post) []reached {
+	recorded := s.store.PostRecipients(p.ID)
+	if p.Status != store.PostPublished || len(recorded) == 0 {
+		return s.expand(p.SchoolID, p.Targets)
+	}
+	out := make([]reached, 0, len(recorded))
+	for _, rc := range recorded {
+		if r, ok := s.recorded(rc); ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// recorded returns the student rc records a post as sent about, with the
+// guardians they had then. A guardian restricted from hearing about them
+// since stays restricted.
+func (s *Service) recorded(rc store.PostRecipient) (reached, bool) {
+	st, ok := s.store.Student(rc.StudentID)
+	if !ok {
+		return reached{}, false
+	}
+	links := slices.Clone(rc.Guardians)
+	for i, l := range links {
+		if j := slices.IndexFunc(st.Guardians, func(v store.GuardianLink) bool { return v.GuardianID == l.GuardianID }); j >= 0 {
+			links[i].Restricted = l.Restricted || st.Guardians[j].Restricted
+		}
+	}
+	st.Guardians = links
+	return reached{student: st, via: rc.Via}, true
+}
+
+// record returns whom post p's targets select now, to be kept as the
+// students it was sent about when it is published.
+func (s *Service) record(p store.Post) []store.PostRecipient {
+	rs := s.expand(p.SchoolID, p.Targets)
+	out := make([]store.PostRecipient, len(rs))
+	for i, r := range rs {
+		out[i] = store.PostRecipient{PostID: p.ID, StudentID: r.student.ID, Via: r.via, Guardians: slices.Clone(r.student.Guardians)}
+	}
+	return out
+}
+
+// expand expands targets into the students of school schoolID they select,
+// each once, in the order first reached.
+func (s *Service) expand(schoolID string, targets []store.Target) []reached {
+	var out []reached
+	index := make(map[string]int)
+	add := func(t store.Target, sts ...store.Student) {
+		for _, st := range sts {
+			if st.SchoolID != schoolID {
+				continue
+			}
+			if i, ok := index[st.ID]; ok {
+				out[i].via = append(out[i].via, t)
+				continue
+			}
+			index[st.ID] = len(out)
+			out = append(out, reached{student: st, via: []store.Target{t}})
+		}
+	}
+
+	for _, t := range targets {
+		switch t.Type {
+		case store.TargetStudent:
+			if st, ok := s.store.Student(t.ID); ok {
+				add(t, st)
+			}
+		case store.TargetClass:
+			add(t, s.store.StudentsInClass(t.ID)...)
+		case store.TargetLevel:
+			for _, c := range s.store.Classes(schoolID) {
+				if c.LevelID == t.ID {
+					add(t, s.store.StudentsInClass(c.ID)...)
+				}
+			}
+		case store.TargetGroup:
+			g, _ := s.store.Group(t.ID)
+			for _, id := range g.StudentIDs {
+				if st, ok := s.store.Student(id); ok {
+					add(t, st)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SetOptOut records whether guardian guardianID has asked not to be sent
+// posts. It changes whom every post reaches from then on, including those
+// already published.
+func (s *Service) SetOptOut(guardianID string, optedOut bool) (store.Guardian, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.store.LockRoster()()
+
+	g, ok := s.store.Guardian(guardianID)
+	if !ok {
+		return store.Guardian{}, ErrNotFound
+	}
+	if g.OptedOut == optedOut {
+		return g, nil
+	}
+	g.OptedOut = optedOut
+	s.store.PutRoster(nil, []store.Guardian{g})
+	s.commit()
+	return g, nil
+}
+
+// SetLanguage records the language guardian guardianID prefers posts in.
+func (s *Service) SetLanguage(guardianID string, l store.Language) (store.Guardian, error) {
+	if !slices.Contains(store.Languages, l) {
+		return store.Guardian{}, fmt.Errorf("%w: language must be en, zh, ms or ta", ErrInvalid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.store.LockRoster()()
+
+	g, ok := s.store.Guardian(guardianID)
+	if !ok {
+		return store.Guardian{}, ErrNotFound
+	}
+	if lang.Of(g) == l {
+		return g, nil
+	}
+	g.Language = l
+	s.store.PutRoster(nil, []store.Guardian{g})
+	s.commit()
+	return g, nil
+}
@@ -0,0 +1,369 @@
+package posts
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+const (
+	// MaxConsentQuestions bounds the questions on one consent form.
+	MaxConsentQuestions = 10
+	// MaxQuestionLength bounds a consent question, in characters.
+	MaxQuestionLength = 300
+	// MaxRemindEveryDays bounds the days between consent form reminders.
+	MaxRemindEveryDays = 14
+)
+
+// ConsentInput is what a consent form asks and until when.
+type ConsentInput struct {
+	Questions       []string  `json:"questions"`
+	DueAt           time.Time `json:"due_at"`
+	RemindEveryDays int       `json:"remind_every_days"`
+}
+
+// ResponseInput is a guardian's answers to a consent form for one student,
+// keyed by question ID.
+type ResponseInput struct {
+	StudentID  string          `json:"student_id"`
+	GuardianID string          `json:"guardian_id"`
+	Answers    map[string]bool `json:"answers"`
+}
+
+// ResponseStatus filters a consent form's students by whether they have
+// responded.
+type ResponseStatus string
+
+const (
+	ResponseResponded   ResponseStatus = "responded"
+	ResponseOutstanding ResponseStatus = "outstanding"
+)
+
+// Responses is where a consent form's responses stand.
+type Responses struct {
+	DueAt       time.Time `json:"due_at"`
+	Closed      bool      `json:"closed"`
+	Students    int       `json:"students"`
+	Responded   int       `json:"responded"`
+	Outstanding int       `json:"outstanding"`
+	Reminders   int       `json:"reminders"`
+	// Items lists the students matching the filter, by class and index
+	// number.
+	Items []StudentResponse `json:"items"`
+}
+
+// StudentResponse is where one student's response to a consent form
+// stands.
+type StudentResponse struct {
+	StudentID   string         `json:"student_id"`
+	StudentName string         `json:"student_name"`
+	ClassID     string         `json:"class_id"`
+	Status      ResponseStatus `json:"status"`
+	// Response is the student's current response; History holds every
+	// response, oldest first.
+	Response *store.ConsentResponse  `json:"response,omitempty"`
+	History  []store.ConsentResponse `json:"history"`
+}
+
+// validateConsent normalises in and checks it.
+func (s *Service) validateConsent(in ConsentInput) (ConsentInput, error) {
+	questions, err := validateQuestions(in.Questions)
+	if err != nil {
+		return ConsentInput{}, err
+	}
+	in.Questions = questions
+	if !in.DueAt.After(s.now()) {
+		return ConsentInput{}, fmt.Errorf("%w: due_at must be in the future", ErrInvalid)
+	}
+	in.DueAt = in.DueAt.UTC()
+	if in.RemindEveryDays < 0 || in.RemindEveryDays > MaxRemindEveryDays {
+		return ConsentInput{}, fmt.Errorf("%w: remind_every_days must be 0 to %d", ErrInvalid, MaxRemindEveryDays)
+	}
+	return in, nil
+}
+
+// validateQuestions returns a consent form's questions trimmed, checking
+// them.
+func validateQuestions(qs []string) ([]string, error) {
+	if len(qs) == 0 || len(qs) > MaxConsentQuestions {
+		return nil, fmt.Errorf("%w: consent forms need 1 to %d questions", ErrInvalid, MaxConsentQuestions)
+	}
+	out := make([]string, len(qs))
+	for i, q := range qs {
+		q = strings.TrimSpace(q)
+		if q == "" || utf8.RuneCountInString(q) > MaxQuestionLength {
+			return nil, fmt.Errorf("%w: questions must be 1 to %d characters", ErrInvalid, MaxQuestionLength)
+		}
+		out[i] = q
+	}
+	return out, nil
+}
+
+// Respond records a guardian's response to consent form postID for one of
+// their children, returning the student's current response.
+//
+// Responses are kept per student. The first guardian to respond for a
+// student answers for them: that guardian may change their answers until the
+// form is due, each change kept in the student's history, but other
+// guardians may not. Repeating the current answers changes nothing, so a
+// response delivered twice is recorded once.
+func (s *Service) Respond(postID string, in ResponseInput) (store.ConsentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.store.Post(postID)
+	if !ok || p.Status != store.PostPublished || p.Consent == nil {
+		return store.ConsentResponse{}, ErrNotFound
+	}
+	now := s.now().UTC()
+	if !p.Consent.DueAt.After(now) {
+		return store.ConsentResponse{}, fmt.Errorf("%w: the consent form closed at %s", ErrConflict, p.Consent.DueAt.Format(time.RFC3339))
+	}
+	st, ok := s.recipient(p, in.StudentID, in.GuardianID)
+	if !ok {
+		return store.ConsentResponse{}, fmt.Errorf("%w: guardian %q did not receive the form for student %q", ErrInvalid, in.GuardianID, in.StudentID)
+	}
+	answers, err := consentAnswers(p.Consent, in.Answers)
+	if err != nil {
+		return store.ConsentResponse{}, err
+	}
+
+	history := responsesByStudent(s.store.ConsentResponses(p.ID))[st.StudentID]
+	if len(history) > 0 {
+		current := history[len(history)-1]
+		if current.GuardianID != in.GuardianID {
+			return store.ConsentResponse{}, fmt.Errorf("%w: another guardian has already responded for this student", ErrConflict)
+		}
+		if slices.Equal(current.Answers, answers) {
+			return current, nil
+		}
+	}
+
+	r := store.ConsentResponse{PostID: p.ID, StudentID: st.StudentID, GuardianID: in.GuardianID, Answers: answers, RespondedAt: now}
+	s.store.AppendConsentResponse(r)
+	s.commit()
+	return r, nil
+}
+
+// recipient returns student studentID if post p was sent to guardian
+// guardianID about them. Only the student's own record is resolved, unless
+// p was published before recipients were recorded.
+func (s *Service) recipient(p store.Post, studentID, guardianID string) (RecipientStudent, bool) {
+	var res Resolution
+	if rc, ok := s.store.PostRecipient(p.ID, studentID); ok {
+		r, ok := s.recorded(rc)
+		if !ok {
+			return RecipientStudent{}, false
+		}
+		res = s.resolveReached([]reached{r})
+	} else if !s.store.HasPostRecipients(p.ID) {
+		res = s.resolve(p)
+	}
+	for _, r := range res.Recipients {
+		if r.GuardianID != guardianID {
+			continue
+		}
+		for _, st := range r.Students {
+			if st.StudentID == studentID {
+				return st, true
+			}
+		}
+	}
+	return RecipientStudent{}, false
+}
+
+// consentAnswers returns answers in question order, checking there is one
+// for every question of form and no other.
+func consentAnswers(form *store.ConsentForm, answers map[string]bool) ([]store.ConsentAnswer, error) {
+	if len(answers) != len(form.Questions) {
+		return nil, fmt.Errorf("%w: answer each of the %d questions once", ErrInvalid, len(form.Questions))
+	}
+	out := make([]store.ConsentAnswer, len(form.Questions))
+	for i, q := range form.Questions {
+		yes, ok := answers[q.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: question %s is not answered", ErrInvalid, q.ID)
+		}
+		out[i] = store.ConsentAnswer{QuestionID: q.ID, Yes: yes}
+	}
+	return out, nil
+}
+
+// responsesByStudent groups responses by student, keeping their order.
+func responsesByStudent(responses []store.ConsentResponse) map[string][]store.ConsentResponse {
+	m := make(map[string][]store.ConsentResponse)
+	for _, r := range responses {
+		m[r.StudentID] = append(m[r.StudentID], r)
+	}
+	return m
+}
+
+// Responses returns where the responses to consent form postID stand, with
+// the students whose status is status, or every student if it is empty. The
+// students are those the form was sent about, and any who have responded
+// since leaving its audience.
+func (s *Service) Responses(scope authz.Scope, postID string, status ResponseStatus) (Responses, error) {
+	p, ok := s.store.Post(postID)
+	if !ok || !canView(scope, p) {
+		return Responses{}, ErrNotFound
+	}
+	if p.Consent == nil {
+		return Responses{}, fmt.Errorf("%w: post is not a consent form", ErrInvalid)
+	}
+	if status != "" && status != ResponseResponded && status != ResponseOutstanding {
+		return Responses{}, fmt.Errorf("%w: status must be responded or outstanding", ErrInvalid)
+	}
+
+	byStudent := responsesByStudent(s.store.ConsentResponses(p.ID))
+	students := s.students(p)
+	for id := range byStudent {
+		if !slices.ContainsFunc(students, func(st store.Student) bool { return st.ID == id }) {
+			if st, ok := s.store.Student(id); ok {
+				students = append(students, st)
+			}
+		}
+	}
+	classes := make(map[string]string)
+	for _, c := range s.store.Classes(p.SchoolID) {
+		classes[c.ID] = c.Name
+	}
+	slices.SortFunc(students, func(a, b store.Student) int {
+		return cmp.Or(cmp.Compare(classes[a.ClassID], classes[b.ClassID]), cmp.Compare(a.IndexNumber, b.IndexNumber), cmp.Compare(a.ID, b.ID))
+	})
+
+	out := Responses{
+		DueAt:     p.Consent.DueAt,
+		Closed:    !p.Consent.DueAt.After(s.now()),
+		Students:  len(students),
+		Reminders: len(s.store.ConsentReminders(p.ID)),
+		Items:     []StudentResponse{},
+	}
+	for _, st := range students {
+		row := StudentResponse{
+			StudentID:   st.ID,
+			StudentName: st.Name,
+			ClassID:     st.ClassID,
+			Status:      ResponseOutstanding,
+			History:     byStudent[st.ID],
+		}
+		if n := len(row.History); n > 0 {
+			row.Status = ResponseResponded
+			row.Response = &row.History[n-1]
+			out.Responded++
+		} else {
+			row.History = []store.ConsentResponse{}
+			out.Outstanding++
+		}
+		if status == "" || row.Status == status {
+			out.Items = append(out.Items, row)
+		}
+	}
+	return out, nil
+}
+
+// StudentConsent is a published consent form sent about a student, and
+// whether it has been answered for them.
+type StudentConsent struct {
+	Post      store.Post
+	Responded bool
+}
+
+// ConsentsOf returns the published consent forms sent about student st, or
+// answered for them, due soonest first. Forms are looked up by their
+// recorded recipients; only forms published before recipients were
+// recorded have their audience resolved, once each.
+func (s *Service) ConsentsOf(st store.Student) []StudentConsent {
+	var out []StudentConsent
+	for _, p := range s.store.PostsInSchool(st.SchoolID) {
+		if p.Status != store.PostPublished || p.Consent == nil {
+			continue
+		}
+		responded := s.store.HasConsentResponse(p.ID, st.ID)
+		_, reached := s.store.PostRecipient(p.ID, st.ID)
+		if !reached && !responded && !s.store.HasPostRecipients(p.ID) {
+			reached = slices.ContainsFunc(s.students(p), func(v store.Student) bool { return v.ID == st.ID })
+		}
+		if responded || reached {
+			out = append(out, StudentConsent{Post: p, Responded: responded})
+		}
+	}
+	slices.SortFunc(out, func(a, b StudentConsent) int {
+		return cmp.Or(a.Post.Consent.DueAt.Compare(b.Post.Consent.DueAt), cmp.Compare(a.Post.ID, b.Post.ID))
+	})
+	return out
+}
+
+// SendReminders reminds the guardians of students who have not responded
+// to an open consent form, for each form whose reminder is due, and returns
+// how many reminders it sent. A form's first reminder is due
+// RemindEveryDays after it was published, and each later one that long
+// after the last.
+func (s *Service) SendReminders() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	n := 0
+	for _, p := range s.store.OpenConsentForms(now) {
+		if p.Consent.RemindEveryDays == 0 || p.PublishedAt == nil {
+			continue
+		}
+		last := *p.PublishedAt
+		if p.Consent.LastRemindedAt != nil {
+			last = *p.Consent.LastRemindedAt
+		}
+		if now.Before(last.AddDate(0, 0, p.Consent.RemindEveryDays)) {
+			continue
+		}
+
+		responded := responsesByStudent(s.store.ConsentResponses(p.ID))
+		r := store.ConsentReminder{PostID: p.ID, SentAt: now}
+		seen := make(map[string]bool)
+		var reminded []Recipient
+		for _, rc := range s.resolve(p).Recipients {
+			var outstanding []RecipientStudent
+			for _, st := range rc.Students {
+				if len(responded[st.StudentID]) > 0 {
+					continue
+				}
+				outstanding = append(outstanding, st)
+				if !seen[st.StudentID] {
+					seen[st.StudentID] = true
+					r.StudentIDs = append(r.StudentIDs, st.StudentID)
+				}
+			}
+			if len(outstanding) > 0 {
+				r.GuardianIDs = append(r.GuardianIDs, rc.GuardianID)
+				rc.Students = outstanding
+				reminded = append(reminded, rc)
+			}
+		}
+		ns, err := s.notifications(p, store.NotifyReminder, reminded, "")
+		if err != nil {
+			// The reminder stays due, to be sent by the next run.
+			slog.Error("queueing consent form reminders failed", "post_id", p.ID, "err", err)
+			continue
+		}
+
+		form := *p.Consent
+		form.LastRemindedAt = &now
+		p.Consent = &form
+		s.store.PutPost(p)
+		s.enqueue(ns)
+		if len(r.StudentIDs) > 0 {
+			s.store.AppendConsentReminder(r)
+			n++
+		}
+	}
+	if n == 0 && !s.unsaved {
+		return 0, nil
+	}
+	return n, s.persist()
+}
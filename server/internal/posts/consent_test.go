@@ -0,0 +1,189 @@
+package posts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// publishConsent publishes a consent form to level l1, due in a week with
+// reminders every two days.
+func (sc *scheduler) publishConsent() View {
+	sc.t.Helper()
+
+	leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+	v, err := sc.svc.Create(leader, Input{
+		Type:    store.PostConsent,
+		Title:   "Zoo trip",
+		Body:    "Please give consent by Friday.",
+		Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}},
+		Consent: &ConsentInput{Questions: []string{"May your child attend?", " May we take photos? "}, DueAt: sc.clock.AddDate(0, 0, 7), RemindEveryDays: 2},
+	})
+	require.NoError(sc.t, err)
+	v, err = sc.svc.Publish(sc.t.Context(), leader, v.ID)
+	require.NoError(sc.t, err)
+	return v
+}
+
+func TestConsent(t *testing.T) {
+	yes := map[string]bool{"q1": true, "q2": true}
+
+	t.Run("numbers the questions", func(t *testing.T) {
+		sc := newScheduler(t)
+
+		v := sc.publishConsent()
+
+		require.Equal(t, store.PostConsent, v.Type)
+		require.Equal(t, 2, len(v.Consent.Questions))
+		require.Equal(t, store.ConsentQuestion{ID: "q2", Text: "May we take photos?"}, v.Consent.Questions[1])
+	})
+
+	t.Run("rejects forms without questions and consent on announcements", func(t *testing.T) {
+		sc := newScheduler(t)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		in := Input{Title: "Trip", Body: "Details.", Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}}}
+
+		for _, c := range []struct {
+			typ     store.PostType
+			consent *ConsentInput
+		}{
+			{store.PostConsent, nil},
+			{store.PostConsent, &ConsentInput{DueAt: sc.clock.Add(time.Hour)}},
+			{store.PostConsent, &ConsentInput{Questions: []string{"Attend?"}, DueAt: sc.clock}},
+			{store.PostAnnouncement, &ConsentInput{Questions: []string{"Attend?"}, DueAt: sc.clock.Add(time.Hour)}},
+		} {
+			in.Type, in.Consent = c.typ, c.consent
+			_, err := sc.svc.Create(leader, in)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+
+	t.Run("may not be scheduled past its due date", func(t *testing.T) {
+		sc := newScheduler(t)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		v, err := sc.svc.Create(leader, Input{
+			Type: store.PostConsent, Title: "Trip", Body: "Details.",
+			Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}},
+			Consent: &ConsentInput{Questions: []string{"Attend?"}, DueAt: sc.clock.Add(time.Hour)},
+		})
+		require.NoError(t, err)
+
+		_, err = sc.svc.Schedule(leader, v.ID, sc.clock.Add(2*time.Hour))
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("the first guardian to respond answers for the student", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g2", Answers: yes})
+		require.NoError(t, err)
+		_, err = sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g1", Answers: yes})
+		require.True(t, errors.Is(err, ErrConflict))
+
+		sc.clock = sc.clock.Add(time.Hour)
+		_, err = sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g2", Answers: map[string]bool{"q1": true, "q2": false}})
+		require.NoError(t, err)
+		_, err = sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g2", Answers: map[string]bool{"q1": true, "q2": false}})
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(sc.store.ConsentResponses(v.ID)))
+	})
+
+	t.Run("rejects invalid responses", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+
+		for _, in := range []ResponseInput{
+			{StudentID: "a", GuardianID: "g9", Answers: yes},
+			{StudentID: "z", GuardianID: "g9", Answers: yes},
+			{StudentID: "a", GuardianID: "g1", Answers: map[string]bool{"q1": true}},
+			{StudentID: "a", GuardianID: "g1", Answers: map[string]bool{"q1": true, "q3": true}},
+		} {
+			_, err := sc.svc.Respond(v.ID, in)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+
+		sc.clock = sc.clock.AddDate(0, 0, 7)
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g1", Answers: yes})
+		require.True(t, errors.Is(err, ErrConflict))
+	})
+
+	t.Run("guardians sent the form may respond after the roster changes", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+		a, _ := sc.store.Student("a")
+		a.ClassID = "c9"
+		sc.store.PutRoster([]store.Student{a}, nil)
+
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g2", Answers: yes})
+		require.NoError(t, err)
+
+		rs, err := sc.svc.Responses(scopeOf(sc.store, "lead", store.RoleSchoolLeader), v.ID, "")
+		require.NoError(t, err)
+		require.Equal(t, 3, rs.Students)
+		require.Equal(t, 1, rs.Responded)
+		a, _ = sc.store.Student("a")
+		consents := sc.svc.ConsentsOf(a)
+		require.Equal(t, 1, len(consents))
+		require.True(t, consents[0].Responded)
+	})
+
+	t.Run("lists who has and has not responded", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "b", GuardianID: "g1", Answers: yes})
+		require.NoError(t, err)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+
+		rs, err := sc.svc.Responses(leader, v.ID, "")
+		require.NoError(t, err)
+		require.Equal(t, 3, rs.Students)
+		require.Equal(t, 1, rs.Responded)
+		require.Equal(t, 2, rs.Outstanding)
+
+		rs, err = sc.svc.Responses(leader, v.ID, ResponseOutstanding)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(rs.Items))
+		require.Equal(t, "a", rs.Items[0].StudentID)
+		require.Equal(t, 0, len(rs.Items[0].History))
+
+		_, err = sc.svc.Responses(scopeOf(sc.store, "form", store.RoleTeacher), v.ID, "")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("reminds guardians of students without a response", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "b", GuardianID: "g1", Answers: yes})
+		require.NoError(t, err)
+
+		sc.clock = sc.clock.AddDate(0, 0, 1)
+		n, err := sc.svc.SendReminders()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		sc.clock = sc.clock.AddDate(0, 0, 1)
+		n, err = sc.svc.SendReminders()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		n, err = sc.svc.SendReminders()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		reminders := sc.store.ConsentReminders(v.ID)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, 1, len(reminders[0].StudentIDs))
+		require.Equal(t, 2, len(reminders[0].GuardianIDs))
+
+		// Reminders stop once the form is due.
+		sc.clock = sc.clock.AddDate(0, 0, 6)
+		n, err = sc.svc.SendReminders()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+	})
+}
@@ -0,0 +1,119 @@
+package posts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+const (
+	// MaxGroupNameLength bounds a group's name, in characters.
+	MaxGroupNameLength = 80
+	// MaxGroupSize bounds the students in one group.
+	MaxGroupSize = 500
+)
+
+// GroupInput is the content of a group to create or update.
+type GroupInput struct {
+	Name       string   `json:"name"`
+	StudentIDs []string `json:"student_ids"`
+}
+
+// Groups returns the viewer's groups, ordered by name.
+func (s *Service) Groups(scope authz.Scope) []store.Group {
+	return append([]store.Group{}, s.store.GroupsByOwner(scope.Teacher.ID)...)
+}
+
+// Group returns group id, which must be the viewer's own.
+func (s *Service) Group(scope authz.Scope, id string) (store.Group, error) {
+	g, ok := s.store.Group(id)
+	if !ok || g.OwnerID != scope.Teacher.ID {
+		return store.Group{}, ErrNotFound
+	}
+	return g, nil
+}
+
+// CreateGroup saves a new group owned by the viewer.
+func (s *Service) CreateGroup(scope authz.Scope, in GroupInput) (store.Group, error) {
+	in, err := s.validateGroup(scope, in)
+	if err != nil {
+		return store.Group{}, err
+	}
+	id, err := s.ids.New()
+	if err != nil {
+		return store.Group{}, err
+	}
+
+	now := s.now().UTC()
+	g := store.Group{
+		ID:         id.String(),
+		SchoolID:   scope.Teacher.SchoolID,
+		OwnerID:    scope.Teacher.ID,
+		Name:       in.Name,
+		StudentIDs: in.StudentIDs,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	s.store.PutGroup(g)
+	return g, nil
+}
+
+// UpdateGroup replaces the name and students of group id, which must be the
+// viewer's own. Posts already published to the group are not affected: they
+// stay with the students they were sent about.
+func (s *Service) UpdateGroup(scope authz.Scope, id string, in GroupInput) (store.Group, error) {
+	g, err := s.Group(scope, id)
+	if err != nil {
+		return store.Group{}, err
+	}
+	in, err = s.validateGroup(scope, in)
+	if err != nil {
+		return store.Group{}, err
+	}
+
+	g.Name, g.StudentIDs = in.Name, in.StudentIDs
+	g.UpdatedAt = s.now().UTC()
+	s.store.PutGroup(g)
+	return g, nil
+}
+
+// DeleteGroup removes group id, which must be the viewer's own. Drafts that
+// target it can no longer be published until the target is removed; posts
+// already published to it keep the students they were sent about.
+func (s *Service) DeleteGroup(scope authz.Scope, id string) error {
+	g, err := s.Group(scope, id)
+	if err != nil {
+		return err
+	}
+	s.store.DeleteGroup(g.ID)
+	return nil
+}
+
+// validateGroup normalises in and checks it. Every student must be one the
+// viewer may see.
+func (s *Service) validateGroup(scope authz.Scope, in GroupInput) (GroupInput, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || utf8.RuneCountInString(in.Name) > MaxGroupNameLength {
+		return GroupInput{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalid, MaxGroupNameLength)
+	}
+
+	ids := []string{}
+	for _, id := range in.StudentIDs {
+		if slices.Contains(ids, id) {
+			continue
+		}
+		st, ok := s.store.Student(id)
+		if !ok || !scope.CanViewStudent(st) {
+			return GroupInput{}, fmt.Errorf("%w: no student %q", ErrInvalid, id)
+		}
+		if ids = append(ids, id); len(ids) > MaxGroupSize {
+			return GroupInput{}, fmt.Errorf("%w: at most %d students", ErrInvalid, MaxGroupSize)
+		}
+	}
+	in.StudentIDs = ids
+	return in, nil
+}
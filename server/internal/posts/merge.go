@@ -0,0 +1,178 @@
+package posts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/merge"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Preview is a post as one guardian receives it, in their language and with
+// its merge fields filled.
+type Preview struct {
+	// GuardianID is empty when the post reaches no one yet, and the fields
+	// that depend on the guardian are missing.
+	GuardianID   string   `json:"guardian_id,omitempty"`
+	GuardianName string   `json:"guardian_name,omitempty"`
+	StudentIDs   []string `json:"student_ids"`
+	// Language is the language the post is shown in, which is English when
+	// it has not been translated into the one asked for.
+	Language store.Language `json:"language"`
+	Title    string         `json:"title"`
+	Body     string         `json:"body"`
+	// Missing lists the merge fields the post uses that have no value for
+	// this guardian.
+	Missing []string `json:"missing"`
+	// Unknown lists the fields the post uses that are not merge fields.
+	Unknown []string `json:"unknown"`
+	// Gaps counts, for each merge field missing for any recipient, the
+	// guardians it is missing for. A post with gaps cannot be published.
+	Gaps []MergeGap `json:"gaps"`
+}
+
+// MergeGap is a merge field that has no value for some of a post's
+// guardians.
+type MergeGap struct {
+	Field     string `json:"field"`
+	Guardians int    `json:"guardians"`
+}
+
+// Preview renders post id, which the viewer must be able to see, for
+// guardian guardianID, or for its first recipient if guardianID is empty.
+// The post is in language l, or in the guardian's if l is empty.
+func (s *Service) Preview(scope authz.Scope, id, guardianID string, l store.Language) (Preview, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !canView(scope, p) {
+		return Preview{}, ErrNotFound
+	}
+
+	recipients := s.resolve(p).Recipients
+	var sample Recipient
+	switch {
+	case guardianID != "":
+		i := indexRecipient(recipients, guardianID)
+		if i < 0 {
+			return Preview{}, fmt.Errorf("%w: guardian %q does not receive the post", ErrInvalid, guardianID)
+		}
+		sample = recipients[i]
+	case len(recipients) > 0:
+		sample = recipients[0]
+	}
+
+	switch {
+	case l == "":
+		l = sample.Language
+	case !slices.Contains(store.Languages, l):
+		return Preview{}, fmt.Errorf("%w: language must be en, zh, ms or ta", ErrInvalid)
+	}
+
+	version := Localize(p, l)
+	v := s.mergeValues(p, sample)
+	out := Preview{
+		GuardianID:   sample.GuardianID,
+		GuardianName: sample.GuardianName,
+		StudentIDs:   []string{},
+		Language:     version.Language,
+		Title:        merge.Text(version.Title, v),
+		Body:         merge.HTML(version.Body, v),
+		Missing:      v.Missing(version.Title, version.Body),
+		Unknown:      merge.Unknown(version.Title, version.Body),
+		Gaps:         s.gaps(p, recipients),
+	}
+	for _, st := range sample.Students {
+		out.StudentIDs = append(out.StudentIDs, st.StudentID)
+	}
+	if out.Missing == nil {
+		out.Missing = []string{}
+	}
+	if out.Unknown == nil {
+		out.Unknown = []string{}
+	}
+	return out, nil
+}
+
+func indexRecipient(rs []Recipient, guardianID string) int {
+	for i, rc := range rs {
+		if rc.GuardianID == guardianID {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeValues returns the values of the merge fields of post p for
+// recipient rc.
+func (s *Service) mergeValues(p store.Post, rc Recipient) merge.Values {
+	g, _ := s.store.Guardian(rc.GuardianID)
+	var students []store.Student
+	for _, st := range rc.Students {
+		if v, ok := s.store.Student(st.StudentID); ok {
+			students = append(students, v)
+		}
+	}
+	return merge.For(s.store, p, g, students)
+}
+
+// gaps returns the merge fields post p uses that are missing for any of
+// recipients in the version they are sent, in the order the post uses
+// them.
+func (s *Service) gaps(p store.Post, recipients []Recipient) []MergeGap {
+	out := []MergeGap{}
+	used := merge.Used(texts(p)...)
+	if len(used) == 0 {
+		return out
+	}
+	counts := make(map[string]int)
+	for _, rc := range recipients {
+		version := Localize(p, rc.Language)
+		for _, f := range s.mergeValues(p, rc).Missing(version.Title, version.Body) {
+			counts[f]++
+		}
+	}
+	for _, f := range used {
+		if counts[f] > 0 {
+			out = append(out, MergeGap{Field: f, Guardians: counts[f]})
+		}
+	}
+	return out
+}
+
+// mergeable reports whether every merge field post p uses has a value for
+// each of its recipients, so that none is sent a blank.
+func (s *Service) mergeable(p store.Post) error {
+	if err := checkFields(texts(p)...); err != nil {
+		return err
+	}
+	gaps := s.gaps(p, s.resolve(p).Recipients)
+	if len(gaps) == 0 {
+		return nil
+	}
+	var parts []string
+	for _, g := range gaps {
+		parts = append(parts, fmt.Sprintf("{{%s}} for %d", g.Field, g.Guardians))
+	}
+	return fmt.Errorf("%w: merge fields have no value for some guardians: %s", ErrInvalid, strings.Join(parts, ", "))
+}
+
+// checkFields reports fields in texts that are not merge fields.
+func checkFields(texts ...string) error {
+	unknown := merge.Unknown(texts...)
+	if len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: unknown merge fields {{%s}}; the fields are {{%s}}", ErrInvalid, strings.Join(unknown, "}}, {{"), strings.Join(merge.Fields, "}}, {{"))
+}
+
+// texts returns post p's titles and bodies in every language.
+func texts(p store.Post) []string {
+	out := []string{p.Title, p.Body}
+	for _, l := range store.Languages {
+		if t, ok := p.Translations[l]; ok {
+			out = append(out, t.Title, t.Body)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package posts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestMergeFields(t *testing.T) {
+	in := Input{
+		Title:   "Excursion for {{student_name}}",
+		Body:    "<p>Dear {{ guardian_name }}, {{student_name}} leaves at 8am.</p>",
+		Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}},
+	}
+
+	t.Run("rejects fields that are not merge fields", func(t *testing.T) {
+		svc, s := newTestService(t)
+		in := in
+		in.Body = "<p>Dear {{parent_name}},</p>"
+
+		_, err := svc.Create(scopeOf(s, "form", store.RoleTeacher), in)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+		require.True(t, strings.Contains(err.Error(), "{{parent_name}}"))
+		require.True(t, strings.Contains(err.Error(), "{{guardian_name}}"))
+	})
+
+	t.Run("previews a post as a guardian receives it", func(t *testing.T) {
+		svc, s := newTestService(t)
+		a, _ := s.Student("a")
+		a.Name = "Tan <Wei Ming>"
+		s.PutRoster([]store.Student{a}, nil)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		p, err := svc.Preview(form, v.ID, "g2", "")
+
+		require.NoError(t, err)
+		require.Equal(t, "g2", p.GuardianID)
+		require.Equal(t, "Excursion for Tan <Wei Ming>", p.Title)
+		require.Equal(t, "<p>Dear Betty Tan, Tan &lt;Wei Ming&gt; leaves at 8am.</p>", p.Body)
+		require.Equal(t, 0, len(p.Missing))
+		require.Equal(t, 0, len(p.Gaps))
+
+		_, err = svc.Preview(form, v.ID, "g9", "")
+		require.True(t, errors.Is(err, ErrInvalid))
+		_, err = svc.Preview(scopeOf(s, "other", store.RoleTeacher), v.ID, "", "")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("reports missing fields and refuses to publish with them", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		p, err := svc.Preview(form, v.ID, "", "")
+		require.NoError(t, err)
+		require.Equal(t, "student_name", strings.Join(p.Missing, ","))
+		require.Equal(t, 1, len(p.Gaps))
+		require.Equal(t, MergeGap{Field: "student_name", Guardians: 2}, p.Gaps[0])
+
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.True(t, errors.Is(err, ErrInvalid))
+		require.True(t, strings.Contains(err.Error(), "{{student_name}} for 2"))
+
+		a, _ := s.Student("a")
+		a.Name = "Tan Wei Ming"
+		s.PutRoster([]store.Student{a}, nil)
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package posts
+
+import (
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Delivery is a notification about a post and the guardian it is for.
+type Delivery struct {
+	store.Notification
+	GuardianName string `json:"guardian_name"`
+}
+
+// Deliveries is how the emails about a post are getting on, one per
+// guardian for the post and one per reminder.
+type Deliveries struct {
+	Pending int        `json:"pending"`
+	Sent    int        `json:"sent"`
+	Failed  int        `json:"failed"`
+	Items   []Delivery `json:"items"`
+}
+
+// Deliveries returns the notifications to guardians about post id, which the
+// viewer must be able to see, oldest first.
+func (s *Service) Deliveries(scope authz.Scope, id string) (Deliveries, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !canView(scope, p) {
+		return Deliveries{}, ErrNotFound
+	}
+
+	out := Deliveries{Items: []Delivery{}}
+	for _, n := range s.store.Notifications(p.ID) {
+		if n.GuardianID == "" {
+			continue
+		}
+		switch n.Status {
+		case store.NotificationPending:
+			out.Pending++
+		case store.NotificationSent:
+			out.Sent++
+		case store.NotificationFailed:
+			out.Failed++
+		}
+		g, _ := s.store.Guardian(n.GuardianID)
+		out.Items = append(out.Items, Delivery{Notification: n, GuardianName: g.Name})
+	}
+	return out, nil
+}
+
+// notifications returns a pending notification of kind about post p for
+// each of recipients, about their students, or none if guardians are not
+// notified. requestID traces them to the request that queued them.
+func (s *Service) notifications(p store.Post, kind store.NotificationKind, recipients []Recipient, requestID string) ([]store.Notification, error) {
+	if s.notify == nil {
+		return nil, nil
+	}
+	now := s.now().UTC()
+	out := make([]store.Notification, 0, len(recipients))
+	for _, rc := range recipients {
+		id, err := s.ids.New()
+		if err != nil {
+			return nil, err
+		}
+		n := store.Notification{
+			ID:         id.String(),
+			PostID:     p.ID,
+			GuardianID: rc.GuardianID,
+			Kind:       kind,
+			Status:     store.NotificationPending,
+			RequestID:  requestID,
+			CreatedAt:  now,
+		}
+		for _, st := range rc.Students {
+			n.StudentIDs = append(n.StudentIDs, st.StudentID)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// teacherNotifications returns a pending notification of kind about post p
+// for each of teacherIDs, or none if no one is notified.
+func (s *Service) teacherNotifications(p store.Post, kind store.NotificationKind, teacherIDs []string, requestID string) ([]store.Notification, error) {
+	if s.notify == nil {
+		return nil, nil
+	}
+	now := s.now().UTC()
+	out := make([]store.Notification, 0, len(teacherIDs))
+	for _, teacherID := range teacherIDs {
+		id, err := s.ids.New()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, store.Notification{
+			ID:        id.String(),
+			PostID:    p.ID,
+			TeacherID: teacherID,
+			Kind:      kind,
+			Status:    store.NotificationPending,
+			RequestID: requestID,
+			CreatedAt: now,
+		})
+	}
+	return out, nil
+}
+
+// enqueue adds notifications ns, to be handed to notify once they are
+// saved.
+func (s *Service) enqueue(ns []store.Notification) {
+	if len(ns) == 0 {
+		return
+	}
+	s.store.PutNotifications(ns...)
+	for _, n := range ns {
+		s.queued = append(s.queued, n.ID)
+	}
+}
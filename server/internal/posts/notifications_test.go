@@ -0,0 +1,120 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// requestContext returns the context of a request given an ID by the
+// RequestID middleware, and the ID.
+func requestContext() (context.Context, string) {
+	var ctx context.Context
+	middleware.RequestID(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	id, _ := middleware.RequestIDFromContext(ctx)
+	return ctx, id
+}
+
+func TestNotifications(t *testing.T) {
+	in := Input{Title: "Sports Day", Body: "Bring a water bottle.", Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}}}
+
+	t.Run("queues one per guardian, handed over only once saved", func(t *testing.T) {
+		sc := newScheduler(t)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		v, err := sc.svc.Create(leader, in)
+		require.NoError(t, err)
+		ctx, requestID := requestContext()
+		sc.saveErr = errors.New("disk full")
+
+		_, err = sc.svc.Publish(ctx, leader, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(sc.released))
+
+		sc.saveErr = nil
+		_, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 2, len(sc.released))
+
+		sc.restart()
+		ns := sc.store.Notifications(v.ID)
+		require.Equal(t, 2, len(ns))
+		i := slices.IndexFunc(ns, func(n store.Notification) bool { return n.GuardianID == "g1" })
+		require.Equal(t, store.NotificationPending, ns[i].Status)
+		require.Equal(t, store.NotifyPost, ns[i].Kind)
+		require.Equal(t, "a,b", strings.Join(ns[i].StudentIDs, ","))
+		require.Equal(t, requestID, ns[i].RequestID)
+	})
+
+	t.Run("queues them for scheduled posts", func(t *testing.T) {
+		sc := newScheduler(t)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		v, err := sc.svc.Create(leader, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(leader, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 0, len(sc.released))
+
+		sc.clock = sc.clock.Add(time.Hour)
+		_, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(sc.released))
+		require.Equal(t, "", sc.store.Notifications(v.ID)[0].RequestID)
+	})
+
+	t.Run("queues reminders for guardians yet to respond", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := sc.publishConsent()
+		_, err := sc.svc.Respond(v.ID, ResponseInput{StudentID: "a", GuardianID: "g2", Answers: map[string]bool{"q1": true, "q2": true}})
+		require.NoError(t, err)
+
+		sc.clock = sc.clock.AddDate(0, 0, 2)
+		_, err = sc.svc.SendReminders()
+		require.NoError(t, err)
+
+		var reminders []store.Notification
+		for _, n := range sc.store.Notifications(v.ID) {
+			if n.Kind == store.NotifyReminder {
+				reminders = append(reminders, n)
+			}
+		}
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, "g1", reminders[0].GuardianID)
+		require.Equal(t, 1, len(reminders[0].StudentIDs))
+		require.Equal(t, "b", reminders[0].StudentIDs[0])
+		require.True(t, slices.Contains(sc.released, reminders[0].ID))
+	})
+
+	t.Run("reports how delivery is going", func(t *testing.T) {
+		sc := newScheduler(t)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		v, err := sc.svc.Create(leader, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Publish(t.Context(), leader, v.ID)
+		require.NoError(t, err)
+		n := sc.store.Notifications(v.ID)[0]
+		n.Status, n.Attempts = store.NotificationSent, 1
+		sc.store.PutNotifications(n)
+
+		d, err := sc.svc.Deliveries(leader, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, d.Pending)
+		require.Equal(t, 1, d.Sent)
+		require.Equal(t, 2, len(d.Items))
+		require.NotEqual(t, "", d.Items[0].GuardianName)
+
+		_, err = sc.svc.Deliveries(scopeOf(sc.store, "form", store.RoleTeacher), v.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
@@ -0,0 +1,537 @@
+// Package posts keeps the announcements teachers send to parents through
+// Parents Gateway. A post is written as a draft, targeted at students,
+// classes, levels or a teacher's own groups, and then published, either
+// straight away or by the scheduler at a time the author chose.
+//
+// Giving a draft a publish time schedules it; a scheduled post may be
+// rescheduled, or cancelled back to a draft. Drafts and scheduled posts may
+// be changed, deleted or published; published posts may not. Files attached
+// to a post are checked and scanned, and kept in blob storage. Guardians
+// are emailed when a post is published, through the notifications queued
+// with it. A post's title and body may use merge fields, filled for each
+// guardian, and may be started from a saved template. Posts are written in
+// English and may be translated into Chinese, Malay and Tamil; each guardian
+// is sent the version in the language they prefer, or the English.
+//
+// A school may require some posts, by type or by how many students they
+// reach, to be approved before they are published. The author submits such
+// a post, and one of the school's leaders or named approvers approves or
+// rejects it, with a comment; changing it afterwards withdraws it, so that
+// it must be submitted again.
+package posts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/middleware"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/blob"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/richtext"
+	"github.com/String-sg/teacher-workspace/server/pkg/scan"
+)
+
+var (
+	// ErrNotFound is returned for posts and groups that do not exist or that
+	// the viewer may not see.
+	ErrNotFound = errors.New("posts: not found")
+	// ErrForbidden is returned when the viewer may see a post but not change
+	// it.
+	ErrForbidden = errors.New("posts: only a post's author may change it")
+	// ErrConflict is returned for changes a post's status does not allow,
+	// such as editing a published post.
+	ErrConflict = errors.New("posts: not allowed in the post's status")
+	// ErrInvalid wraps every validation failure of a post or group.
+	ErrInvalid = errors.New("posts: invalid input")
+)
+
+const (
+	// MaxTitleLength bounds a post's title, in characters.
+	MaxTitleLength = 120
+	// MaxBodyLength bounds a post's sanitized body, in bytes.
+	MaxBodyLength = 20000
+	// MaxTargets bounds the targets of one post.
+	MaxTargets = 50
+)
+
+// Input is the content of a post to create or update. Body is rich text,
+// sanitized on save.
+type Input struct {
+	// Type defaults to an announcement.
+	Type    store.PostType `json:"type"`
+	Title   string         `json:"title"`
+	Body    string         `json:"body"`
+	Targets []store.Target `json:"targets"`
+	// Translations are into the other official languages, and fall back to
+	// Title and Body, which are in English.
+	Translations map[store.Language]store.Translation `json:"translations"`
+	// Consent is required for consent forms and not allowed otherwise.
+	Consent *ConsentInput `json:"consent"`
+}
+
+// Transitions a post's author may make, listed in View.
+const (
+	TransitionEdit       = "edit"
+	TransitionDelete     = "delete"
+	TransitionPublish    = "publish"
+	TransitionSchedule   = "schedule"
+	TransitionReschedule = "reschedule"
+	TransitionCancel     = "cancel"
+	TransitionSubmit     = "submit"
+	TransitionWithdraw   = "withdraw"
+	// TransitionApprove and TransitionReject are an approver's, not the
+	// author's.
+	TransitionApprove = "approve"
+	TransitionReject  = "reject"
+)
+
+// View is a post with the audience it resolves to now, the translations it
+// lacks, its attachments and the transitions the viewer may make.
+type View struct {
+	store.Post
+	// Version is the post in the viewer's language, English unless they
+	// asked for another.
+	Version             Version              `json:"version"`
+	Audience            Audience             `json:"audience"`
+	MissingTranslations []MissingTranslation `json:"missing_translations"`
+	// NeedsApproval is set when the post must be approved before it is
+	// published.
+	NeedsApproval bool               `json:"needs_approval"`
+	Attachments   []store.Attachment `json:"attachments"`
+	Transitions   []string           `json:"transitions"`
+}
+
+// Filter narrows a post listing. Empty fields match everything.
+type Filter struct {
+	Status   store.PostStatus
+	AuthorID string
+}
+
+// Service reads and writes posts and groups, limited to what the viewing
+// teacher may see and change.
+type Service struct {
+	store *store.Store
+	ids   *random.IDGenerator
+	now   func() time.Time
+	log   *audit.Log
+	save  func() error
+	blobs blob.Store
+	// scanner is nil when attachments are not scanned.
+	scanner scan.Scanner
+
+	// mu serializes changes to posts, so that the scheduler and teachers
+	// never act on the same status at once.
+	mu sync.Mutex
+	// unsaved is set while a change that save must make durable has not
+	// been saved.
+	unsaved bool
+	// notify is nil when guardians are not notified.
+	notify func(ids ...string)
+	// queued holds the IDs of notifications not yet given to notify, which
+	// waits until they have been saved.
+	queued []string
+}
+
+// New returns a Service over s that names new posts and groups with ids,
+// timestamps changes with now and records publishing in log. save, if not
+// nil, writes s durably; it is called after every change that must survive
+// a restart, such as scheduling a post or recording a consent response.
+// A change stands even if saving it fails, and is saved again by the next
+// run of the scheduler. Attachments are kept in blobs and,
+// if scanner is not nil, scanned before they are. If notify is not nil,
+// publishing a post or sending a reminder queues a notification for each
+// guardian, and notify is given their IDs once they are saved.
+func New(s *store.Store, ids *random.IDGenerator, now func() time.Time, log *audit.Log, save func() error, blobs blob.Store, scanner scan.Scanner, notify func(ids ...string)) *Service {
+	return &Service{store: s, ids: ids, now: now, log: log, save: save, blobs: blobs, scanner: scanner, notify: notify}
+}
+
+// List returns the posts the viewer may see, newest first: their own, or
+// every post in the school for school leaders.
+func (s *Service) List(scope authz.Scope, f Filter) []store.Post {
+	out := []store.Post{}
+	for _, p := range s.store.PostsInSchool(scope.Teacher.SchoolID) {
+		if !canView(scope, p) {
+			continue
+		}
+		if f.Status != "" && p.Status != f.Status || f.AuthorID != "" && p.AuthorID != f.AuthorID {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Get returns post id with its audience. Approvers may see the posts
+// submitted to them.
+func (s *Service) Get(scope authz.Scope, id string) (View, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !(canView(scope, p) || p.SubmittedAt != nil && s.canReview(scope, p)) {
+		return View{}, ErrNotFound
+	}
+	return s.view(scope, p), nil
+}
+
+// Create saves a new draft by the viewer.
+func (s *Service) Create(scope authz.Scope, in Input) (View, error) {
+	in, err := s.validate(scope, in)
+	if err != nil {
+		return View{}, err
+	}
+	id, err := s.ids.New()
+	if err != nil {
+		return View{}, err
+	}
+
+	now := s.now().UTC()
+	p := store.Post{
+		ID:        id.String(),
+		SchoolID:  scope.Teacher.SchoolID,
+		AuthorID:  scope.Teacher.ID,
+		Status:    store.PostDraft,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	apply(&p, in)
+	s.store.PutPost(p)
+	return s.view(scope, p), nil
+}
+
+// Update replaces the content of post id, which must be the viewer's own
+// and not yet published. A scheduled post stays scheduled, so its new
+// content must still be publishable when it is due; one that needed
+// approval must be cancelled first. A post submitted for approval, approved
+// or rejected goes back to being a draft, to be submitted again.
+func (s *Service) Update(scope authz.Scope, id string, in Input) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionEdit)
+	if err != nil {
+		return View{}, err
+	}
+	in, err = s.validate(scope, in)
+	if err != nil {
+		return View{}, err
+	}
+
+	apply(&p, in)
+	p.UpdatedAt = s.now().UTC()
+	if p.Status == store.PostScheduled {
+		// Changes need approving again.
+		p.SubmittedAt = nil
+		if err := s.publishable(scope, p, *p.PublishAt); err != nil {
+			return View{}, err
+		}
+	}
+	if p.Status != store.PostDraft && p.Status != store.PostScheduled {
+		s.withdraw(scope, &p, "changed")
+		s.store.PutPost(p)
+		s.commit()
+		return s.view(scope, p), nil
+	}
+	s.store.PutPost(p)
+	return s.view(scope, p), nil
+}
+
+// Delete removes post id, which must be the viewer's own and not yet
+// published.
+func (s *Service) Delete(scope authz.Scope, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionDelete)
+	if err != nil {
+		return err
+	}
+	attachments := s.store.Attachments(p.ID)
+	s.store.DeletePost(p.ID)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostDelete, TargetType: "post", TargetID: p.ID})
+	// The content goes only once nothing saved refers to it.
+	if len(attachments) > 0 && s.commit() {
+		s.deleteBlobs(context.Background(), attachments...)
+	}
+	return nil
+}
+
+// Publish publishes post id straight away. It must be the viewer's own, not
+// yet published, and reach at least one guardian. The notifications it
+// queues are traced to the request in ctx.
+func (s *Service) Publish(ctx context.Context, scope authz.Scope, id string) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionPublish)
+	if err != nil {
+		return View{}, err
+	}
+	now := s.now().UTC()
+	if err := s.publishable(scope, p, now); err != nil {
+		return View{}, err
+	}
+	requestID, _ := middleware.RequestIDFromContext(ctx)
+	ns, err := s.notifications(p, store.NotifyPost, s.resolve(p).Recipients, requestID)
+	if err != nil {
+		return View{}, err
+	}
+
+	s.store.PutPostRecipients(s.record(p)...)
+	p.Status = store.PostPublished
+	p.PublishAt = nil
+	p.PublishedAt = &now
+	p.UpdatedAt = now
+	s.store.PutPost(p)
+	s.enqueue(ns)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostPublish, TargetType: "post", TargetID: p.ID})
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// publishable reports whether post p may be published by the viewer at at.
+// A post that needs approval must have been approved.
+func (s *Service) publishable(scope authz.Scope, p store.Post, at time.Time) error {
+	if s.needsApproval(p) && !approved(p) {
+		return fmt.Errorf("%w: the post must be approved before it is published", ErrConflict)
+	}
+	return s.ready(scope, p, at)
+}
+
+// ready reports whether post p's content and targets let the viewer
+// publish it at at.
+func (s *Service) ready(scope authz.Scope, p store.Post, at time.Time) error {
+	// Targets are checked again: the viewer may have lost a class or
+	// deleted a group since the post was saved.
+	if _, err := s.validateTargets(scope, p.Targets); err != nil {
+		return err
+	}
+	if p.Consent != nil && !p.Consent.DueAt.After(at) {
+		return fmt.Errorf("%w: the consent form would be due before it is published", ErrInvalid)
+	}
+	if err := s.reachable(p); err != nil {
+		return err
+	}
+	return s.mergeable(p)
+}
+
+// reachable reports whether post p's targets reach at least one guardian.
+func (s *Service) reachable(p store.Post) error {
+	if s.audience(p).Guardians == 0 {
+		return fmt.Errorf("%w: the post's targets reach no guardians", ErrInvalid)
+	}
+	return nil
+}
+
+// persist saves the store, if the Service was given a way to, and then
+// hands queued notifications to notify. A failed save is retried by the
+// next run of the scheduler, and the notifications wait for it.
+func (s *Service) persist() error {
+	if s.save != nil {
+		if err := s.save(); err != nil {
+			s.unsaved = true
+			return fmt.Errorf("posts: saving: %w", err)
+		}
+	}
+	s.unsaved = false
+	if len(s.queued) > 0 {
+		s.notify(s.queued...)
+		s.queued = nil
+	}
+	return nil
+}
+
+// commit persists a change a teacher or Parents Gateway asked for. The
+// change has been made in the store, where readers already see it, so a
+// failed save does not undo it: the failure is logged and the save retried
+// by the next run of the scheduler. It reports whether the change was
+// saved.
+func (s *Service) commit() bool {
+	if err := s.persist(); err != nil {
+		slog.Error("saving a change failed; the scheduler will retry", "err", err)
+		return false
+	}
+	return true
+}
+
+func (s *Service) view(scope authz.Scope, p store.Post) View {
+	return View{
+		Post:                p,
+		Version:             Localize(p, store.LanguageEnglish),
+		Audience:            s.audience(p),
+		MissingTranslations: missingTranslations(p, s.resolve(p).Recipients),
+		NeedsApproval:       s.needsApproval(p),
+		Attachments:         s.store.Attachments(p.ID),
+		Transitions:         s.transitions(scope, p),
+	}
+}
+
+// transitions returns the transitions the viewer may make from post p's
+// status.
+func (s *Service) transitions(scope authz.Scope, p store.Post) []string {
+	if p.AuthorID != scope.Teacher.ID {
+		if p.Status == store.PostPendingApproval && s.canReview(scope, p) {
+			return []string{TransitionApprove, TransitionReject}
+		}
+		return []string{}
+	}
+	switch p.Status {
+	case store.PostDraft:
+		if s.needsApproval(p) {
+			return []string{TransitionEdit, TransitionDelete, TransitionSubmit}
+		}
+		return []string{TransitionEdit, TransitionDelete, TransitionPublish, TransitionSchedule}
+	case store.PostPendingApproval:
+		return []string{TransitionEdit, TransitionDelete, TransitionWithdraw}
+	case store.PostRejected:
+		return []string{TransitionEdit, TransitionDelete, TransitionSubmit}
+	case store.PostApproved:
+		return []string{TransitionEdit, TransitionDelete, TransitionPublish, TransitionSchedule}
+	case store.PostScheduled:
+		return []string{TransitionEdit, TransitionDelete, TransitionPublish, TransitionReschedule, TransitionCancel}
+	default:
+		return []string{}
+	}
+}
+
+// editable returns post id if the viewer wrote it and it may still be
+// changed. transition names the change, for the error.
+func (s *Service) editable(scope authz.Scope, id, transition string) (store.Post, error) {
+	p, ok := s.store.Post(id)
+	if !ok || !canView(scope, p) {
+		return store.Post{}, ErrNotFound
+	}
+	if p.AuthorID != scope.Teacher.ID {
+		return store.Post{}, ErrForbidden
+	}
+	if p.Status == store.PostPublished {
+		return store.Post{}, fmt.Errorf("%w: cannot %s a published post", ErrConflict, transition)
+	}
+	return p, nil
+}
+
+// canView reports whether the viewer may see post p: its author and the
+// leaders of its school may.
+func canView(scope authz.Scope, p store.Post) bool {
+	return p.SchoolID == scope.Teacher.SchoolID && (p.AuthorID == scope.Teacher.ID || scope.SchoolWide())
+}
+
+// validate normalises in and checks it.
+func (s *Service) validate(scope authz.Scope, in Input) (Input, error) {
+	var err error
+	if in.Title, in.Body, err = validateContent(in.Title, in.Body); err != nil {
+		return Input{}, err
+	}
+
+	if in.Translations, err = validateTranslations(in.Translations); err != nil {
+		return Input{}, err
+	}
+
+	targets, err := s.validateTargets(scope, in.Targets)
+	if err != nil {
+		return Input{}, err
+	}
+	in.Targets = targets
+
+	switch in.Type {
+	case "", store.PostAnnouncement:
+		in.Type = store.PostAnnouncement
+		if in.Consent != nil {
+			return Input{}, fmt.Errorf("%w: only consent forms have consent", ErrInvalid)
+		}
+	case store.PostConsent:
+		if in.Consent == nil {
+			return Input{}, fmt.Errorf("%w: consent forms need consent questions and a due date", ErrInvalid)
+		}
+		c, err := s.validateConsent(*in.Consent)
+		if err != nil {
+			return Input{}, err
+		}
+		in.Consent = &c
+	default:
+		return Input{}, fmt.Errorf("%w: type must be announcement or consent", ErrInvalid)
+	}
+	return in, nil
+}
+
+// validateContent returns a post's title trimmed and its body sanitized,
+// checking them. Both may use merge fields, but only known ones.
+func validateContent(title, body string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > MaxTitleLength {
+		return "", "", fmt.Errorf("%w: title must be 1 to %d characters", ErrInvalid, MaxTitleLength)
+	}
+	body = richtext.Sanitize(body)
+	if richtext.Text(body) == "" || len(body) > MaxBodyLength {
+		return "", "", fmt.Errorf("%w: body must have text and be at most %d bytes", ErrInvalid, MaxBodyLength)
+	}
+	if err := checkFields(title, body); err != nil {
+		return "", "", err
+	}
+	return title, body, nil
+}
+
+// validateTargets returns targets without duplicates, checking the viewer
+// may post to each.
+func (s *Service) validateTargets(scope authz.Scope, targets []store.Target) ([]store.Target, error) {
+	out := []store.Target{}
+	for _, t := range targets {
+		if slices.Contains(out, t) {
+			continue
+		}
+		if err := s.checkTarget(scope, t); err != nil {
+			return nil, err
+		}
+		if out = append(out, t); len(out) > MaxTargets {
+			return nil, fmt.Errorf("%w: at most %d targets", ErrInvalid, MaxTargets)
+		}
+	}
+	return out, nil
+}
+
+// apply sets the content of post p to in, which has been validated.
+func apply(p *store.Post, in Input) {
+	p.Type, p.Title, p.Body, p.Targets = in.Type, in.Title, in.Body, in.Targets
+	p.Translations = in.Translations
+	p.Consent = nil
+	if in.Consent != nil {
+		p.Consent = &store.ConsentForm{DueAt: in.Consent.DueAt, RemindEveryDays: in.Consent.RemindEveryDays}
+		for i, q := range in.Consent.Questions {
+			p.Consent.Questions = append(p.Consent.Questions, store.ConsentQuestion{ID: fmt.Sprintf("q%d", i+1), Text: q})
+		}
+	}
+}
+
+// checkTarget reports whether the viewer may post to t. Teachers may post
+// to students and classes they can see, to any level of their school, and to
+// their own groups.
+func (s *Service) checkTarget(scope authz.Scope, t store.Target) error {
+	ok := false
+	switch t.Type {
+	case store.TargetStudent:
+		st, found := s.store.Student(t.ID)
+		ok = found && scope.CanViewStudent(st)
+	case store.TargetClass:
+		c, found := s.store.Class(t.ID)
+		ok = found && scope.CanViewClass(c)
+	case store.TargetLevel:
+		l, found := s.store.Level(t.ID)
+		ok = found && l.SchoolID == scope.Teacher.SchoolID
+	case store.TargetGroup:
+		g, found := s.store.Group(t.ID)
+		ok = found && g.OwnerID == scope.Teacher.ID
+	default:
+		return fmt.Errorf("%w: target type must be one of student, class, level, group", ErrInvalid)
+	}
+	if !ok {
+		return fmt.Errorf("%w: no %s %q to post to", ErrInvalid, t.Type, t.ID)
+	}
+	return nil
+}
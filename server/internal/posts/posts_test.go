@@ -0,0 +1,314 @@
+package posts
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/blob"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+	"github.com/String-sg/teacher-workspace/server/pkg/scan"
+)
+
+func testDataset() *store.Dataset {
+	return &store.Dataset{
+		Schools: []store.School{{ID: "s1"}, {ID: "s2"}},
+		Levels:  []store.Level{{ID: "l1", SchoolID: "s1"}, {ID: "l9", SchoolID: "s2"}},
+		Classes: []store.Class{
+			{ID: "c1", SchoolID: "s1", LevelID: "l1", FormTeacherID: "form"},
+			{ID: "c2", SchoolID: "s1", LevelID: "l1"},
+			{ID: "c9", SchoolID: "s2", LevelID: "l9"},
+		},
+		Students: []store.Student{
+			// a and b are siblings and share a guardian.
+			{ID: "a", SchoolID: "s1", ClassID: "c1", Guardians: []store.GuardianLink{{GuardianID: "g1"}, {GuardianID: "g2"}}},
+			{ID: "b", SchoolID: "s1", ClassID: "c2", Guardians: []store.GuardianLink{{GuardianID: "g1"}}},
+			{ID: "c", SchoolID: "s1", ClassID: "c2"},
+			{ID: "z", SchoolID: "s2", ClassID: "c9", Guardians: []store.GuardianLink{{GuardianID: "g9"}}},
+		},
+		Guardians: []store.Guardian{{ID: "g1", Name: "Ahmad bin Ali"}, {ID: "g2", Name: "Betty Tan"}, {ID: "g9", Name: "Zhang Wei"}},
+		Teachers: []store.Teacher{
+			{ID: "form", SchoolID: "s1", Role: store.RoleTeacher},
+			{ID: "lead", SchoolID: "s1", Role: store.RoleSchoolLeader},
+		},
+	}
+}
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(testDataset())
+	clock := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	now := func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	blobs, err := blob.NewDir(t.TempDir())
+	require.NoError(t, err)
+	return New(s, ids, now, audit.New(s, now), nil, blobs, scan.Fake{}, nil), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+func TestPosts(t *testing.T) {
+	in := Input{
+		Title:   " Sports Day ",
+		Body:    `<p onclick="x()">Bring a <b>water bottle</b>.</p><script>alert(1)</script>`,
+		Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}, {Type: store.TargetClass, ID: "c1"}},
+	}
+
+	t.Run("saves drafts with sanitized content", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		v, err := svc.Create(scopeOf(s, "form", store.RoleTeacher), in)
+
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		require.Equal(t, "Sports Day", v.Title)
+		require.Equal(t, "<p>Bring a <b>water bottle</b>.</p>", v.Body)
+		require.Equal(t, 1, len(v.Targets))
+		require.Equal(t, Audience{Students: 1, Guardians: 2}, v.Audience)
+	})
+
+	t.Run("counts each student and guardian once", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		a, err := svc.Audience(scopeOf(s, "form", store.RoleTeacher), []store.Target{
+			{Type: store.TargetClass, ID: "c1"},
+			{Type: store.TargetLevel, ID: "l1"},
+			{Type: store.TargetStudent, ID: "a"},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, Audience{Students: 3, Guardians: 2}, a)
+	})
+
+	t.Run("rejects targets the author may not post to", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		for _, target := range []store.Target{
+			{Type: store.TargetClass, ID: "c2"},
+			{Type: store.TargetStudent, ID: "b"},
+			{Type: store.TargetLevel, ID: "l9"},
+			{Type: store.TargetGroup, ID: "nope"},
+			{Type: "school", ID: "s1"},
+		} {
+			in := in
+			in.Targets = []store.Target{target}
+			_, err := svc.Create(form, in)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+
+	t.Run("rejects empty titles and bodies", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		_, err := svc.Create(form, Input{Title: " ", Body: "<p>Hi</p>"})
+		require.True(t, errors.Is(err, ErrInvalid))
+		_, err = svc.Create(form, Input{Title: "Hi", Body: "<p> </p><script>x</script>"})
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("publishes drafts once", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		v, err = svc.Publish(t.Context(), form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostPublished, v.Status)
+		require.True(t, v.PublishedAt != nil)
+		log := s.AuditLog()
+		require.Equal(t, audit.ActionPostPublish, log[len(log)-1].Action)
+
+		_, err = svc.Publish(t.Context(), form, v.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+		_, err = svc.Update(form, v.ID, in)
+		require.True(t, errors.Is(err, ErrConflict))
+		require.True(t, errors.Is(svc.Delete(form, v.ID), ErrConflict))
+	})
+
+	t.Run("publishes only posts that reach someone", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		in := in
+		in.Targets = []store.Target{{Type: store.TargetStudent, ID: "c"}}
+		v, err := svc.Create(leader, in)
+		require.NoError(t, err)
+
+		_, err = svc.Publish(t.Context(), leader, v.ID)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("leaders see every post but change only their own", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		_, err = svc.Get(leader, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(svc.List(leader, Filter{Status: store.PostDraft})))
+		require.Equal(t, 0, len(svc.List(leader, Filter{AuthorID: "lead"})))
+		_, err = svc.Update(leader, v.ID, in)
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		_, err = svc.Get(scopeOf(s, "other", store.RoleTeacher), v.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("deletes drafts", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Delete(form, v.ID))
+
+		_, err = svc.Get(form, v.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestRecipients(t *testing.T) {
+	targets := []store.Target{{Type: store.TargetClass, ID: "c1"}, {Type: store.TargetClass, ID: "c2"}, {Type: store.TargetStudent, ID: "a"}}
+
+	t.Run("sends each guardian one post for all their children", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		v, err := svc.Create(leader, Input{Title: "Sports Day", Body: "Bring a water bottle.", Targets: targets})
+		require.NoError(t, err)
+
+		res, err := svc.Recipients(leader, v.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, len(res.Recipients))
+		g1 := res.Recipients[0]
+		require.Equal(t, "g1", g1.GuardianID)
+		require.Equal(t, 2, len(g1.Students))
+		require.Equal(t, "a", g1.Students[0].StudentID)
+		require.Equal(t, 2, len(g1.Students[0].Via))
+		require.Equal(t, store.Target{Type: store.TargetClass, ID: "c2"}, g1.Students[1].Via[0])
+		require.Equal(t, 1, len(res.Unreached))
+		require.Equal(t, "c", res.Unreached[0].StudentID)
+	})
+
+	t.Run("leaves out opted-out and restricted guardians", func(t *testing.T) {
+		svc, s := newTestService(t)
+		a, _ := s.Student("a")
+		a.Guardians[1].Restricted = true
+		s.PutRoster([]store.Student{a}, nil)
+		_, err := svc.SetOptOut("g1", true)
+		require.NoError(t, err)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		v, err := svc.Create(leader, Input{Title: "Sports Day", Body: "Bring a water bottle.", Targets: targets})
+		require.NoError(t, err)
+
+		res, err := svc.Recipients(leader, v.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, 0, len(res.Recipients))
+		require.Equal(t, 3, len(res.Excluded))
+		require.Equal(t, Exclusion{GuardianID: "g2", GuardianName: "Betty Tan", StudentID: "a", Reason: ExcludedRestricted}, res.Excluded[1])
+		require.Equal(t, 3, len(res.Unreached))
+		require.Equal(t, Audience{Students: 3}, v.Audience)
+
+		_, err = svc.Publish(t.Context(), leader, v.ID)
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("opting out of an unknown guardian fails", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		_, err := svc.SetOptOut("nobody", true)
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestGroups(t *testing.T) {
+	t.Run("targets a teacher's own group", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		g, err := svc.CreateGroup(leader, GroupInput{Name: " Choir ", StudentIDs: []string{"b", "c", "b"}})
+		require.NoError(t, err)
+		require.Equal(t, "Choir", g.Name)
+		require.Equal(t, 2, len(g.StudentIDs))
+
+		v, err := svc.Create(leader, Input{Title: "Concert", Body: "Practice on Friday.", Targets: []store.Target{{Type: store.TargetGroup, ID: g.ID}}})
+
+		require.NoError(t, err)
+		require.Equal(t, Audience{Students: 2, Guardians: 1}, v.Audience)
+	})
+
+	t.Run("keeps groups to their owner", func(t *testing.T) {
+		svc, s := newTestService(t)
+		g, err := svc.CreateGroup(scopeOf(s, "lead", store.RoleSchoolLeader), GroupInput{Name: "Choir", StudentIDs: []string{"b"}})
+		require.NoError(t, err)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		_, err = svc.Group(form, g.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.True(t, errors.Is(svc.DeleteGroup(form, g.ID), ErrNotFound))
+		require.Equal(t, 0, len(svc.Groups(form)))
+	})
+
+	t.Run("holds only students the owner may see", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.CreateGroup(scopeOf(s, "form", store.RoleTeacher), GroupInput{Name: "Mixed", StudentIDs: []string{"a", "b"}})
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("published posts keep the students they were sent about", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		g, err := svc.CreateGroup(leader, GroupInput{Name: "Choir", StudentIDs: []string{"b"}})
+		require.NoError(t, err)
+		v, err := svc.Create(leader, Input{Title: "Concert", Body: "Friday.", Targets: []store.Target{{Type: store.TargetGroup, ID: g.ID}}})
+		require.NoError(t, err)
+		_, err = svc.Publish(t.Context(), leader, v.ID)
+		require.NoError(t, err)
+
+		_, err = svc.UpdateGroup(leader, g.ID, GroupInput{Name: "Choir", StudentIDs: []string{"c"}})
+		require.NoError(t, err)
+		res, err := svc.Recipients(leader, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(res.Recipients))
+		require.Equal(t, "g1", res.Recipients[0].GuardianID)
+
+		require.NoError(t, svc.DeleteGroup(leader, g.ID))
+		res, err = svc.Recipients(leader, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(res.Recipients))
+		require.Equal(t, "b", res.Recipients[0].Students[0].StudentID)
+	})
+
+	t.Run("stops drafts publishing once deleted", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+		g, err := svc.CreateGroup(leader, GroupInput{Name: "Choir", StudentIDs: []string{"b"}})
+		require.NoError(t, err)
+		v, err := svc.Create(leader, Input{Title: "Concert", Body: "Friday.", Targets: []store.Target{{Type: store.TargetGroup, ID: g.ID}}})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.DeleteGroup(leader, g.ID))
+		_, err = svc.Publish(t.Context(), leader, v.ID)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+}
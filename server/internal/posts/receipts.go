@@ -0,0 +1,278 @@
+package posts
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// MaxReceiptEvents bounds the events recorded in one call to Record. When a
+// post goes out to a whole level, Parents Gateway sends its events in
+// batches of up to this many, each saved once.
+const MaxReceiptEvents = 1000
+
+// ReceiptEventType is what happened to a post on a guardian's device.
+type ReceiptEventType string
+
+const (
+	EventDelivered    ReceiptEventType = "delivered"
+	EventOpened       ReceiptEventType = "opened"
+	EventAcknowledged ReceiptEventType = "acknowledged"
+)
+
+// ReceiptEvent is one delivery, open or acknowledgement of a post by a
+// guardian. A zero At means now.
+type ReceiptEvent struct {
+	GuardianID string           `json:"guardian_id"`
+	Type       ReceiptEventType `json:"type"`
+	At         time.Time        `json:"at"`
+}
+
+// Ingested counts the events of a batch that changed a receipt and those
+// that did not, because they repeated what was already recorded or were for
+// a guardian the post does not reach.
+type Ingested struct {
+	Applied int `json:"applied"`
+	Ignored int `json:"ignored"`
+}
+
+// ReceiptStatus filters a post's recipients by how far they have got.
+type ReceiptStatus string
+
+const (
+	// ReceiptUnread is the recipients who have not opened the post.
+	ReceiptUnread ReceiptStatus = "unread"
+	// ReceiptUnacknowledged is the recipients who have not acknowledged it.
+	ReceiptUnacknowledged ReceiptStatus = "unacknowledged"
+)
+
+// ReceiptFilter selects the recipients Receipts lists. Zero fields match
+// every recipient.
+type ReceiptFilter struct {
+	Status ReceiptStatus
+	// ClassID limits the list to guardians with a child in the class.
+	ClassID string
+}
+
+// ReadStats counts a post's recipients by how far they have got. ReadRate
+// is the share of recipients who have opened the post.
+type ReadStats struct {
+	Recipients   int     `json:"recipients"`
+	Delivered    int     `json:"delivered"`
+	Opened       int     `json:"opened"`
+	Acknowledged int     `json:"acknowledged"`
+	ReadRate     float64 `json:"read_rate"`
+}
+
+// ClassReadStats is ReadStats for the guardians of one class's students.
+type ClassReadStats struct {
+	ClassID   string `json:"class_id"`
+	ClassName string `json:"class_name"`
+	ReadStats
+}
+
+// Receipts is how far a published post has reached its recipients.
+type Receipts struct {
+	ReadStats
+	// Classes breaks the stats down by class, in class name order. A
+	// guardian with children in several classes counts in each.
+	Classes []ClassReadStats `json:"classes"`
+	// Items lists the recipients matching the filter, by name.
+	Items []Recipient `json:"items"`
+}
+
+// Record applies events for published post postID to its recipients'
+// receipts.
+//
+// Each receipt keeps the first time of each event, so an event delivered
+// twice, or out of order, is recorded once. Opening a post implies it was
+// delivered, and acknowledging it that it was opened. Event times are
+// clamped to between the post's publication and now. The batch is checked
+// before any of it is applied, and saved once.
+func (s *Service) Record(postID string, events []ReceiptEvent) (Ingested, error) {
+	if len(events) == 0 || len(events) > MaxReceiptEvents {
+		return Ingested{}, fmt.Errorf("%w: send 1 to %d events", ErrInvalid, MaxReceiptEvents)
+	}
+	for _, e := range events {
+		if e.GuardianID == "" {
+			return Ingested{}, fmt.Errorf("%w: events need a guardian_id", ErrInvalid)
+		}
+		if e.Type != EventDelivered && e.Type != EventOpened && e.Type != EventAcknowledged {
+			return Ingested{}, fmt.Errorf("%w: event type must be delivered, opened or acknowledged", ErrInvalid)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.store.Post(postID)
+	if !ok || p.Status != store.PostPublished || p.PublishedAt == nil {
+		return Ingested{}, ErrNotFound
+	}
+	reached := make(map[string]bool)
+	for _, r := range s.resolve(p).Recipients {
+		reached[r.GuardianID] = true
+	}
+
+	now := s.now().UTC()
+	changed := make(map[string]store.PostReceipt)
+	var order []string
+	var n Ingested
+	for _, e := range events {
+		if !reached[e.GuardianID] {
+			n.Ignored++
+			continue
+		}
+		r, ok := changed[e.GuardianID]
+		if !ok {
+			if r, ok = s.store.PostReceipt(p.ID, e.GuardianID); !ok {
+				r = store.PostReceipt{PostID: p.ID, GuardianID: e.GuardianID}
+			}
+		}
+		at := e.At.UTC()
+		if at.IsZero() || at.After(now) {
+			at = now
+		}
+		if at.Before(*p.PublishedAt) {
+			at = *p.PublishedAt
+		}
+
+		applied := false
+		switch e.Type {
+		case EventAcknowledged:
+			applied = earliest(&r.AcknowledgedAt, at)
+			fallthrough
+		case EventOpened:
+			applied = earliest(&r.OpenedAt, at) || applied
+			fallthrough
+		case EventDelivered:
+			applied = earliest(&r.DeliveredAt, at) || applied
+		}
+		if !applied {
+			n.Ignored++
+			continue
+		}
+		n.Applied++
+		if _, ok := changed[e.GuardianID]; !ok {
+			order = append(order, e.GuardianID)
+		}
+		changed[e.GuardianID] = r
+	}
+	if n.Applied == 0 {
+		return n, nil
+	}
+
+	rs := make([]store.PostReceipt, len(order))
+	for i, id := range order {
+		rs[i] = changed[id]
+	}
+	s.store.PutPostReceipts(rs...)
+	s.commit()
+	return n, nil
+}
+
+// earliest sets *t to at if it is unset or later, reporting whether it
+// changed.
+func earliest(t **time.Time, at time.Time) bool {
+	if *t != nil && !at.Before(**t) {
+		return false
+	}
+	*t = &at
+	return true
+}
+
+// Receipts returns how far published post postID has reached its
+// recipients, listing those that match f. The recipients are the guardians
+// of the students the post reaches now.
+func (s *Service) Receipts(scope authz.Scope, postID string, f ReceiptFilter) (Receipts, error) {
+	p, ok := s.store.Post(postID)
+	if !ok || !canView(scope, p) {
+		return Receipts{}, ErrNotFound
+	}
+	if p.Status != store.PostPublished {
+		return Receipts{}, fmt.Errorf("%w: post is not published", ErrInvalid)
+	}
+	if f.Status != "" && f.Status != ReceiptUnread && f.Status != ReceiptUnacknowledged {
+		return Receipts{}, fmt.Errorf("%w: status must be unread or unacknowledged", ErrInvalid)
+	}
+
+	classes := make(map[string]string)
+	for _, c := range s.store.Classes(p.SchoolID) {
+		classes[c.ID] = c.Name
+	}
+	if _, ok := classes[f.ClassID]; f.ClassID != "" && !ok {
+		return Receipts{}, fmt.Errorf("%w: unknown class %q", ErrInvalid, f.ClassID)
+	}
+
+	recipients := s.recipients(p)
+	out := Receipts{Classes: []ClassReadStats{}, Items: []Recipient{}}
+	byClass := make(map[string]*ClassReadStats)
+	for _, r := range recipients {
+		out.count(r)
+		// A guardian of two children in one class counts once in it.
+		counted := make(map[string]bool)
+		for _, st := range r.Students {
+			if counted[st.ClassID] {
+				continue
+			}
+			counted[st.ClassID] = true
+			c, ok := byClass[st.ClassID]
+			if !ok {
+				c = &ClassReadStats{ClassID: st.ClassID, ClassName: classes[st.ClassID]}
+				byClass[st.ClassID] = c
+			}
+			c.count(r)
+		}
+		if f.ClassID != "" && !counted[f.ClassID] {
+			continue
+		}
+		if f.Status == ReceiptUnread && r.OpenedAt != nil || f.Status == ReceiptUnacknowledged && r.AcknowledgedAt != nil {
+			continue
+		}
+		out.Items = append(out.Items, r)
+	}
+	out.rate()
+	for _, c := range byClass {
+		c.rate()
+		out.Classes = append(out.Classes, *c)
+	}
+	slices.SortFunc(out.Classes, func(a, b ClassReadStats) int {
+		return cmp.Or(cmp.Compare(a.ClassName, b.ClassName), cmp.Compare(a.ClassID, b.ClassID))
+	})
+	return out, nil
+}
+
+func (st *ReadStats) count(r Recipient) {
+	st.Recipients++
+	if r.DeliveredAt != nil {
+		st.Delivered++
+	}
+	if r.OpenedAt != nil {
+		st.Opened++
+	}
+	if r.AcknowledgedAt != nil {
+		st.Acknowledged++
+	}
+}
+
+func (st *ReadStats) rate() {
+	if st.Recipients > 0 {
+		st.ReadRate = float64(st.Opened) / float64(st.Recipients)
+	}
+}
+
+// recipients returns the guardians post p reaches, by name, each with the
+// children they receive it for and their receipt.
+func (s *Service) recipients(p store.Post) []Recipient {
+	out := s.resolve(p).Recipients
+	for i, r := range out {
+		if rc, ok := s.store.PostReceipt(p.ID, r.GuardianID); ok {
+			out[i].DeliveredAt, out[i].OpenedAt, out[i].AcknowledgedAt = rc.DeliveredAt, rc.OpenedAt, rc.AcknowledgedAt
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package posts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestReceipts(t *testing.T) {
+	publish := func(sc *scheduler) View {
+		t.Helper()
+
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+		v, err := sc.svc.Create(leader, Input{Title: "Sports Day", Body: "Bring a water bottle.", Targets: []store.Target{{Type: store.TargetLevel, ID: "l1"}}})
+		require.NoError(t, err)
+		v, err = sc.svc.Publish(t.Context(), leader, v.ID)
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("records each event once, however often it arrives", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := publish(sc)
+		opened := sc.clock.Add(time.Minute)
+		sc.clock = sc.clock.Add(time.Hour)
+		events := []ReceiptEvent{
+			{GuardianID: "g1", Type: EventDelivered, At: sc.clock},
+			{GuardianID: "g1", Type: EventOpened, At: opened},
+			{GuardianID: "g1", Type: EventOpened, At: opened.Add(time.Minute)},
+			{GuardianID: "g9", Type: EventDelivered},
+		}
+
+		n, err := sc.svc.Record(v.ID, events)
+		require.NoError(t, err)
+		require.Equal(t, Ingested{Applied: 2, Ignored: 2}, n)
+		n, err = sc.svc.Record(v.ID, events)
+		require.NoError(t, err)
+		require.Equal(t, Ingested{Applied: 0, Ignored: 4}, n)
+
+		sc.restart()
+		r, ok := sc.store.PostReceipt(v.ID, "g1")
+		require.True(t, ok)
+		require.Equal(t, opened, *r.OpenedAt)
+		require.Equal(t, 1, len(sc.store.PostReceipts(v.ID)))
+	})
+
+	t.Run("an acknowledgement implies delivery and opening", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := publish(sc)
+
+		_, err := sc.svc.Record(v.ID, []ReceiptEvent{{GuardianID: "g2", Type: EventAcknowledged}})
+		require.NoError(t, err)
+
+		r, _ := sc.store.PostReceipt(v.ID, "g2")
+		require.True(t, r.DeliveredAt != nil && r.OpenedAt != nil && r.AcknowledgedAt != nil)
+	})
+
+	t.Run("rejects invalid batches whole", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := publish(sc)
+
+		_, err := sc.svc.Record(v.ID, []ReceiptEvent{{GuardianID: "g1", Type: EventOpened}, {GuardianID: "g2", Type: "read"}})
+		require.True(t, errors.Is(err, ErrInvalid))
+		_, err = sc.svc.Record(v.ID, nil)
+		require.True(t, errors.Is(err, ErrInvalid))
+		require.Equal(t, 0, len(sc.store.PostReceipts(v.ID)))
+
+		_, err = sc.svc.Record("missing", []ReceiptEvent{{GuardianID: "g1", Type: EventOpened}})
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("counts recipients per post and per class", func(t *testing.T) {
+		sc := newScheduler(t)
+		v := publish(sc)
+		_, err := sc.svc.Record(v.ID, []ReceiptEvent{
+			{GuardianID: "g1", Type: EventOpened},
+			{GuardianID: "g2", Type: EventDelivered},
+		})
+		require.NoError(t, err)
+		leader := scopeOf(sc.store, "lead", store.RoleSchoolLeader)
+
+		rs, err := sc.svc.Receipts(leader, v.ID, ReceiptFilter{})
+		require.NoError(t, err)
+		require.Equal(t, ReadStats{Recipients: 2, Delivered: 2, Opened: 1, ReadRate: 0.5}, rs.ReadStats)
+		require.Equal(t, 2, len(rs.Classes))
+		require.Equal(t, ReadStats{Recipients: 2, Delivered: 2, Opened: 1, ReadRate: 0.5}, rs.Classes[0].ReadStats)
+		require.Equal(t, ReadStats{Recipients: 1, Delivered: 1, Opened: 1, ReadRate: 1}, rs.Classes[1].ReadStats)
+		require.Equal(t, 2, len(rs.Items[0].Students))
+
+		rs, err = sc.svc.Receipts(leader, v.ID, ReceiptFilter{Status: ReceiptUnread, ClassID: "c1"})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(rs.Items))
+		require.Equal(t, "g2", rs.Items[0].GuardianID)
+		rs, err = sc.svc.Receipts(leader, v.ID, ReceiptFilter{Status: ReceiptUnread, ClassID: "c2"})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(rs.Items))
+
+		_, err = sc.svc.Receipts(scopeOf(sc.store, "form", store.RoleTeacher), v.ID, ReceiptFilter{})
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
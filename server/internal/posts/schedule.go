@@ -0,0 +1,168 @@
+package posts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// MaxScheduleAhead bounds how far ahead a post may be scheduled.
+const MaxScheduleAhead = 366 * 24 * time.Hour
+
+// DefaultSchedulerInterval is how often RunScheduler looks for due posts.
+const DefaultSchedulerInterval = 15 * time.Second
+
+// Schedule sets post id to be published at at, scheduling a draft or
+// rescheduling a scheduled post. The post must be the viewer's own and reach
+// at least one guardian, and at must be in the future.
+func (s *Service) Schedule(scope authz.Scope, id string, at time.Time) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionSchedule)
+	if err != nil {
+		return View{}, err
+	}
+	now := s.now().UTC()
+	if !at.After(now) || at.Sub(now) > MaxScheduleAhead {
+		return View{}, fmt.Errorf("%w: publish_at must be in the future and within %d days", ErrInvalid, MaxScheduleAhead/(24*time.Hour))
+	}
+	if err := s.publishable(scope, p, at); err != nil {
+		return View{}, err
+	}
+
+	at = at.UTC()
+	p.Status = store.PostScheduled
+	p.PublishAt = &at
+	p.ScheduleError = ""
+	p.UpdatedAt = now
+	s.store.PutPost(p)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostSchedule, TargetType: "post", TargetID: p.ID, Detail: at.Format(time.RFC3339)})
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// Cancel returns scheduled post id, which must be the viewer's own, to
+// being a draft, or to being approved if it was.
+func (s *Service) Cancel(scope authz.Scope, id string) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.editable(scope, id, TransitionCancel)
+	if err != nil {
+		return View{}, err
+	}
+	if p.Status != store.PostScheduled {
+		return View{}, fmt.Errorf("%w: post is not scheduled", ErrConflict)
+	}
+
+	p.Status = store.PostDraft
+	if approved(p) {
+		p.Status = store.PostApproved
+	}
+	p.PublishAt = nil
+	p.UpdatedAt = s.now().UTC()
+	s.store.PutPost(p)
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionPostUnschedule, TargetType: "post", TargetID: p.ID})
+	s.commit()
+	return s.view(scope, p), nil
+}
+
+// PublishDue publishes every scheduled post that is due and returns how
+// many it published. A post whose author may no longer publish it, or whose
+// targets no longer reach anyone, goes back to being a draft with its
+// ScheduleError set.
+//
+// Each post moves out of the scheduled status once, under the lock every
+// other change to a post takes, so a post is never published twice. The
+// changes are saved before PublishDue returns; if tw stops before they are
+// saved, the posts are still scheduled when it starts again and are
+// published then.
+func (s *Service) PublishDue() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	due := s.store.PostsDue(now)
+	if len(due) == 0 && !s.unsaved {
+		return 0, nil
+	}
+
+	n := 0
+	for _, p := range due {
+		if s.publishScheduled(p, now) {
+			n++
+		}
+	}
+	return n, s.persist()
+}
+
+// publishScheduled publishes due post p as its author, reporting whether
+// it could.
+func (s *Service) publishScheduled(p store.Post, now time.Time) bool {
+	p.UpdatedAt = now
+	author, ok := s.store.Teacher(p.AuthorID)
+	err := fmt.Errorf("%w: the post's author no longer exists", ErrInvalid)
+	if ok {
+		err = s.publishable(authz.For(s.store, author), p, now)
+	}
+	if err != nil {
+		p.Status = store.PostDraft
+		p.PublishAt = nil
+		p.ScheduleError = err.Error()
+		s.store.PutPost(p)
+		s.log.Record(p.AuthorID, audit.Event{Action: audit.ActionPostUnschedule, TargetType: "post", TargetID: p.ID, Detail: "not publishable when due"})
+		return false
+	}
+
+	ns, err := s.notifications(p, store.NotifyPost, s.resolve(p).Recipients, "")
+	if err != nil {
+		// The post stays due, to be published by the next run.
+		slog.Error("queueing notifications for a scheduled post failed", "post_id", p.ID, "err", err)
+		return false
+	}
+	s.store.PutPostRecipients(s.record(p)...)
+	p.Status = store.PostPublished
+	p.PublishedAt = &now
+	s.store.PutPost(p)
+	s.enqueue(ns)
+	s.log.Record(p.AuthorID, audit.Event{Action: audit.ActionPostPublish, TargetType: "post", TargetID: p.ID, Detail: "scheduled"})
+	return true
+}
+
+// RunScheduler publishes due posts and sends due consent form reminders
+// every interval until ctx is done. It runs once straight away, publishing
+// posts that fell due while tw was not running. A run in progress when ctx
+// is done is finished, so that its changes are saved, before RunScheduler
+// returns.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		n, err := s.PublishDue()
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "publishing scheduled posts failed", "err", err)
+		case n > 0:
+			slog.InfoContext(ctx, "published scheduled posts", "count", n)
+		}
+		n, err = s.SendReminders()
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "sending consent form reminders failed", "err", err)
+		case n > 0:
+			slog.InfoContext(ctx, "sent consent form reminders", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package posts
+
+import (
+	"errors"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// scheduler is a Service over a store saved to a file, whose clock only
+// moves when told to.
+type scheduler struct {
+	t     *testing.T
+	path  string
+	clock time.Time
+	store *store.Store
+	svc   *Service
+	// saveErr, if set, fails saves.
+	saveErr error
+	// released holds the IDs of the notifications handed over for sending.
+	released []string
+}
+
+func newScheduler(t *testing.T) *scheduler {
+	t.Helper()
+
+	sc := &scheduler{t: t, path: filepath.Join(t.TempDir(), "data.json"), clock: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+	require.NoError(t, store.New(testDataset()).Save(sc.path))
+	sc.restart()
+	return sc
+}
+
+// restart loads the store from its file, as tw does when it starts, losing
+// whatever was not saved.
+func (sc *scheduler) restart() {
+	sc.t.Helper()
+
+	s, err := store.Load(sc.path)
+	require.NoError(sc.t, err)
+	now := func() time.Time { return sc.clock }
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	sc.store = s
+	save := func() error {
+		if sc.saveErr != nil {
+			return sc.saveErr
+		}
+		return s.Save(sc.path)
+	}
+	sc.released = nil
+	sc.svc = New(s, ids, now, audit.New(s, now), save, nil, nil, func(ids ...string) { sc.released = append(sc.released, ids...) })
+}
+
+func (sc *scheduler) published() int {
+	n := 0
+	for _, e := range sc.store.AuditLog() {
+		if e.Action == audit.ActionPostPublish {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSchedule(t *testing.T) {
+	in := Input{Title: "Excursion", Body: "Meet at 7.30am.", Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}}}
+
+	t.Run("schedules, reschedules and cancels", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		require.Equal(t, TransitionSchedule, v.Transitions[3])
+
+		v, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, store.PostScheduled, v.Status)
+		require.Equal(t, TransitionReschedule, v.Transitions[3])
+		v, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.True(t, v.PublishAt.Equal(sc.clock.Add(2*time.Hour)))
+
+		v, err = sc.svc.Cancel(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		require.True(t, v.PublishAt == nil)
+		_, err = sc.svc.Cancel(form, v.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+	})
+
+	t.Run("rejects times in the past and far ahead", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+
+		for _, at := range []time.Time{sc.clock, sc.clock.Add(-time.Hour), sc.clock.Add(MaxScheduleAhead + time.Hour)} {
+			_, err := sc.svc.Schedule(form, v.ID, at)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+
+	t.Run("publishes due posts once", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+
+		n, err := sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		sc.clock = sc.clock.Add(time.Hour)
+		n, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		n, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		v, err = sc.svc.Get(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostPublished, v.Status)
+		require.True(t, v.PublishedAt.Equal(sc.clock))
+		require.Equal(t, 0, len(v.Transitions))
+		_, err = sc.svc.Cancel(form, v.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+		require.Equal(t, 1, sc.published())
+	})
+
+	t.Run("publishes posts that fell due while stopped", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+
+		sc.restart()
+		sc.clock = sc.clock.Add(3 * time.Hour)
+		n, err := sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		sc.restart()
+		n, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+		p, _ := sc.store.Post(v.ID)
+		require.Equal(t, store.PostPublished, p.Status)
+		require.Equal(t, 1, sc.published())
+	})
+
+	t.Run("retries a failed save", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		save := sc.svc.save
+		sc.svc.save = func() error { return errors.New("disk full") }
+
+		sc.clock = sc.clock.Add(time.Hour)
+		_, err = sc.svc.PublishDue()
+		require.Error(t, err)
+		sc.svc.save = save
+		n, err := sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		sc.restart()
+		p, _ := sc.store.Post(v.ID)
+		require.Equal(t, store.PostPublished, p.Status)
+	})
+
+	t.Run("keeps changes whose save failed until a later save", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		published, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		cancelled, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(form, cancelled.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		sc.saveErr = errors.New("disk full")
+
+		v, err := sc.svc.Publish(t.Context(), form, published.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostPublished, v.Status)
+		_, err = sc.svc.Publish(t.Context(), form, published.ID)
+		require.True(t, errors.Is(err, ErrConflict))
+		_, err = sc.svc.Cancel(form, cancelled.ID)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(sc.released))
+
+		_, err = sc.svc.PublishDue()
+		require.Error(t, err)
+		sc.saveErr = nil
+		_, err = sc.svc.PublishDue()
+		require.NoError(t, err)
+		require.Equal(t, 2, len(sc.released))
+
+		sc.restart()
+		p, _ := sc.store.Post(published.ID)
+		require.Equal(t, store.PostPublished, p.Status)
+		p, _ = sc.store.Post(cancelled.ID)
+		require.Equal(t, store.PostDraft, p.Status)
+	})
+
+	t.Run("returns posts that can no longer be published to drafts", func(t *testing.T) {
+		sc := newScheduler(t)
+		form := scopeOf(sc.store, "form", store.RoleTeacher)
+		g, err := sc.svc.CreateGroup(form, GroupInput{Name: "Reading", StudentIDs: []string{"a"}})
+		require.NoError(t, err)
+		in := in
+		in.Targets = []store.Target{{Type: store.TargetGroup, ID: g.ID}}
+		v, err := sc.svc.Create(form, in)
+		require.NoError(t, err)
+		_, err = sc.svc.Schedule(form, v.ID, sc.clock.Add(time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, sc.svc.DeleteGroup(form, g.ID))
+
+		sc.clock = sc.clock.Add(time.Hour)
+		n, err := sc.svc.PublishDue()
+
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+		v, err = sc.svc.Get(form, v.ID)
+		require.NoError(t, err)
+		require.Equal(t, store.PostDraft, v.Status)
+		require.NotEqual(t, "", v.ScheduleError)
+	})
+}
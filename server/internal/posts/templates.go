@@ -0,0 +1,188 @@
+package posts
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// MaxTemplateNameLength bounds a post template's name, in characters.
+const MaxTemplateNameLength = 80
+
+// TemplateInput is the content of a post template to create or update.
+// Title and Body may use merge fields.
+type TemplateInput struct {
+	// Scope defaults to personal.
+	Scope store.TemplateScope `json:"scope"`
+	Name  string              `json:"name"`
+	// Type defaults to an announcement.
+	Type  store.PostType `json:"type"`
+	Title string         `json:"title"`
+	Body  string         `json:"body"`
+	// Translations are as for a post.
+	Translations map[store.Language]store.Translation `json:"translations"`
+	// Questions are required for consent form templates and not allowed
+	// otherwise.
+	Questions []string `json:"questions"`
+}
+
+// Templates returns the post templates the viewer may use: their own and
+// their school's, ordered by name.
+func (s *Service) Templates(scope authz.Scope) []store.PostTemplate {
+	out := []store.PostTemplate{}
+	for _, t := range s.store.PostTemplatesInSchool(scope.Teacher.SchoolID) {
+		if canUseTemplate(scope, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Template returns post template id, which the viewer must be able to use.
+func (s *Service) Template(scope authz.Scope, id string) (store.PostTemplate, error) {
+	t, ok := s.store.PostTemplate(id)
+	if !ok || !canUseTemplate(scope, t) {
+		return store.PostTemplate{}, ErrNotFound
+	}
+	return t, nil
+}
+
+// CreateTemplate saves a new post template owned by the viewer. Only
+// school leaders may create school templates.
+func (s *Service) CreateTemplate(scope authz.Scope, in TemplateInput) (store.PostTemplate, error) {
+	in, err := validateTemplate(in)
+	if err != nil {
+		return store.PostTemplate{}, err
+	}
+	if in.Scope == store.TemplateSchool && !scope.SchoolWide() {
+		return store.PostTemplate{}, ErrForbidden
+	}
+	id, err := s.ids.New()
+	if err != nil {
+		return store.PostTemplate{}, err
+	}
+
+	now := s.now().UTC()
+	t := store.PostTemplate{
+		ID:        id.String(),
+		SchoolID:  scope.Teacher.SchoolID,
+		OwnerID:   scope.Teacher.ID,
+		CreatedAt: now,
+	}
+	applyTemplate(&t, in)
+	t.UpdatedAt = now
+	s.store.PutPostTemplate(t)
+	return t, nil
+}
+
+// UpdateTemplate replaces the content of post template id. A personal
+// template may be changed by its owner, a school template by the school's
+// leaders. A leader who changes its scope becomes its owner, so a school
+// template made personal is theirs. Posts already started from the
+// template are not affected.
+func (s *Service) UpdateTemplate(scope authz.Scope, id string, in TemplateInput) (store.PostTemplate, error) {
+	t, err := s.changeableTemplate(scope, id)
+	if err != nil {
+		return store.PostTemplate{}, err
+	}
+	in, err = validateTemplate(in)
+	if err != nil {
+		return store.PostTemplate{}, err
+	}
+	if in.Scope == store.TemplateSchool && !scope.SchoolWide() {
+		return store.PostTemplate{}, ErrForbidden
+	}
+
+	if in.Scope != t.Scope {
+		t.OwnerID = scope.Teacher.ID
+	}
+	applyTemplate(&t, in)
+	t.UpdatedAt = s.now().UTC()
+	s.store.PutPostTemplate(t)
+	return t, nil
+}
+
+// DeleteTemplate removes post template id, which the viewer must be able to
+// change.
+func (s *Service) DeleteTemplate(scope authz.Scope, id string) error {
+	t, err := s.changeableTemplate(scope, id)
+	if err != nil {
+		return err
+	}
+	s.store.DeletePostTemplate(t.ID)
+	return nil
+}
+
+// changeableTemplate returns post template id if the viewer may change it.
+func (s *Service) changeableTemplate(scope authz.Scope, id string) (store.PostTemplate, error) {
+	t, err := s.Template(scope, id)
+	if err != nil {
+		return store.PostTemplate{}, err
+	}
+	if t.Scope == store.TemplateSchool && !scope.SchoolWide() {
+		return store.PostTemplate{}, ErrForbidden
+	}
+	return t, nil
+}
+
+// canUseTemplate reports whether the viewer may use post template t: every
+// teacher in its school may use a school template, and only its owner a
+// personal one.
+func canUseTemplate(scope authz.Scope, t store.PostTemplate) bool {
+	if t.SchoolID != scope.Teacher.SchoolID {
+		return false
+	}
+	return t.Scope == store.TemplateSchool || t.OwnerID == scope.Teacher.ID
+}
+
+// validateTemplate normalises in and checks it.
+func validateTemplate(in TemplateInput) (TemplateInput, error) {
+	switch in.Scope {
+	case "":
+		in.Scope = store.TemplatePersonal
+	case store.TemplatePersonal, store.TemplateSchool:
+	default:
+		return TemplateInput{}, fmt.Errorf("%w: scope must be personal or school", ErrInvalid)
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || utf8.RuneCountInString(in.Name) > MaxTemplateNameLength {
+		return TemplateInput{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalid, MaxTemplateNameLength)
+	}
+
+	var err error
+	if in.Title, in.Body, err = validateContent(in.Title, in.Body); err != nil {
+		return TemplateInput{}, err
+	}
+	if in.Translations, err = validateTranslations(in.Translations); err != nil {
+		return TemplateInput{}, err
+	}
+
+	switch in.Type {
+	case "", store.PostAnnouncement:
+		in.Type = store.PostAnnouncement
+		if len(in.Questions) > 0 {
+			return TemplateInput{}, fmt.Errorf("%w: only consent forms have questions", ErrInvalid)
+		}
+		in.Questions = nil
+	case store.PostConsent:
+		if in.Questions, err = validateQuestions(in.Questions); err != nil {
+			return TemplateInput{}, err
+		}
+	default:
+		return TemplateInput{}, fmt.Errorf("%w: type must be announcement or consent", ErrInvalid)
+	}
+	return in, nil
+}
+
+func applyTemplate(t *store.PostTemplate, in TemplateInput) {
+	t.Scope = in.Scope
+	t.Name = in.Name
+	t.Type = in.Type
+	t.Title = in.Title
+	t.Body = in.Body
+	t.Translations = in.Translations
+	t.Questions = in.Questions
+}
@@ -0,0 +1,117 @@
+package posts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestTemplates(t *testing.T) {
+	in := TemplateInput{
+		Name:  " Excursion ",
+		Title: "Excursion for {{class}}",
+		Body:  `<p onclick="x()">Dear {{guardian_name}},</p>`,
+	}
+
+	t.Run("saves personal templates for their owner alone", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		tpl, err := svc.CreateTemplate(form, in)
+
+		require.NoError(t, err)
+		require.Equal(t, store.TemplatePersonal, tpl.Scope)
+		require.Equal(t, store.PostAnnouncement, tpl.Type)
+		require.Equal(t, "Excursion", tpl.Name)
+		require.Equal(t, "<p>Dear {{guardian_name}},</p>", tpl.Body)
+		require.Equal(t, 1, len(svc.Templates(form)))
+
+		lead := scopeOf(s, "lead", store.RoleSchoolLeader)
+		require.Equal(t, 0, len(svc.Templates(lead)))
+		_, err = svc.Template(lead, tpl.ID)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("shares school templates, kept by leaders", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		lead := scopeOf(s, "lead", store.RoleSchoolLeader)
+		in := in
+		in.Scope = store.TemplateSchool
+
+		_, err := svc.CreateTemplate(form, in)
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		tpl, err := svc.CreateTemplate(lead, in)
+		require.NoError(t, err)
+		got, err := svc.Template(form, tpl.ID)
+		require.NoError(t, err)
+		require.Equal(t, tpl.ID, got.ID)
+
+		_, err = svc.UpdateTemplate(form, tpl.ID, in)
+		require.True(t, errors.Is(err, ErrForbidden))
+		require.True(t, errors.Is(svc.DeleteTemplate(form, tpl.ID), ErrForbidden))
+
+		in.Name = "School excursion"
+		tpl, err = svc.UpdateTemplate(lead, tpl.ID, in)
+		require.NoError(t, err)
+		require.Equal(t, "School excursion", tpl.Name)
+		require.NoError(t, svc.DeleteTemplate(lead, tpl.ID))
+		require.Equal(t, 0, len(svc.Templates(form)))
+	})
+
+	t.Run("gives a template whose scope changes to the leader changing it", func(t *testing.T) {
+		svc, s := newTestService(t)
+		lead := scopeOf(s, "lead", store.RoleSchoolLeader)
+		other := scopeOf(s, "lead2", store.RoleSchoolLeader)
+		in := in
+		in.Scope = store.TemplateSchool
+		tpl, err := svc.CreateTemplate(lead, in)
+		require.NoError(t, err)
+
+		in.Scope = store.TemplatePersonal
+		tpl, err = svc.UpdateTemplate(other, tpl.ID, in)
+		require.NoError(t, err)
+		require.Equal(t, "lead2", tpl.OwnerID)
+		require.Equal(t, 1, len(svc.Templates(other)))
+		require.Equal(t, 0, len(svc.Templates(lead)))
+
+		in.Scope = store.TemplateSchool
+		tpl, err = svc.UpdateTemplate(other, tpl.ID, in)
+		require.NoError(t, err)
+		require.Equal(t, "lead2", tpl.OwnerID)
+		require.Equal(t, 1, len(svc.Templates(lead)))
+	})
+
+	t.Run("keeps a consent form's questions", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		in := in
+		in.Type = store.PostConsent
+
+		_, err := svc.CreateTemplate(form, in)
+		require.True(t, errors.Is(err, ErrInvalid))
+
+		in.Questions = []string{" May your child attend? "}
+		tpl, err := svc.CreateTemplate(form, in)
+		require.NoError(t, err)
+		require.Equal(t, "May your child attend?", tpl.Questions[0])
+	})
+
+	t.Run("rejects unknown merge fields and bad input", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		for _, bad := range []TemplateInput{
+			{Name: "x", Title: "Hi {{nickname}}", Body: "<p>Hi</p>"},
+			{Name: " ", Title: "Hi", Body: "<p>Hi</p>"},
+			{Name: "x", Scope: "class", Title: "Hi", Body: "<p>Hi</p>"},
+			{Name: "x", Title: "Hi", Body: "<p>Hi</p>", Questions: []string{"Why?"}},
+		} {
+			_, err := svc.CreateTemplate(form, bad)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+}
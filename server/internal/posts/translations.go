@@ -0,0 +1,73 @@
+package posts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/lang"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// Version is a post's title and body in one language.
+type Version struct {
+	Language store.Language `json:"language"`
+	Title    string         `json:"title"`
+	Body     string         `json:"body"`
+}
+
+// Localize returns post p as a reader of language l reads it: in l if p has
+// been translated into it, and in English if not.
+func Localize(p store.Post, l store.Language) Version {
+	t, l := lang.Post(p, l)
+	return Version{Language: l, Title: t.Title, Body: t.Body}
+}
+
+// MissingTranslation is a language a post has not been translated into,
+// and how many of its guardians prefer it and so will be sent the English.
+type MissingTranslation struct {
+	Language  store.Language `json:"language"`
+	Guardians int            `json:"guardians"`
+}
+
+// missingTranslations returns the languages post p has not been translated
+// into, with the recipients who prefer each.
+func missingTranslations(p store.Post, recipients []Recipient) []MissingTranslation {
+	out := []MissingTranslation{}
+	for _, l := range lang.Missing(p) {
+		m := MissingTranslation{Language: l}
+		for _, rc := range recipients {
+			if rc.Language == l {
+				m.Guardians++
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// validateTranslations returns a post's translations trimmed and
+// sanitized, checking them. A translation left blank is dropped.
+func validateTranslations(ts map[store.Language]store.Translation) (map[store.Language]store.Translation, error) {
+	var out map[store.Language]store.Translation
+	for l, t := range ts {
+		if l == store.LanguageEnglish {
+			return nil, fmt.Errorf("%w: a post's own title and body are its English", ErrInvalid)
+		}
+		if !slices.Contains(store.Languages, l) {
+			return nil, fmt.Errorf("%w: translations must be into zh, ms or ta, not %q", ErrInvalid, l)
+		}
+		if strings.TrimSpace(t.Title) == "" && strings.TrimSpace(t.Body) == "" {
+			continue
+		}
+		title, body, err := validateContent(t.Title, t.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s translation)", err, l)
+		}
+		if out == nil {
+			out = make(map[store.Language]store.Translation)
+		}
+		out[l] = store.Translation{Title: title, Body: body}
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package posts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestTranslations(t *testing.T) {
+	in := Input{
+		Title: "Sports Day",
+		Body:  "<p>Dear {{guardian_name}}, bring a water bottle.</p>",
+		Translations: map[store.Language]store.Translation{
+			store.LanguageChinese: {Title: " 运动会 ", Body: `<p onclick="x()">{{guardian_name}}，请带水壶。</p>`},
+			store.LanguageTamil:   {Title: " ", Body: ""},
+		},
+		Targets: []store.Target{{Type: store.TargetClass, ID: "c1"}},
+	}
+
+	t.Run("saves translations, dropping blank ones", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		v, err := svc.Create(scopeOf(s, "form", store.RoleTeacher), in)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(v.Translations))
+		zh := v.Translations[store.LanguageChinese]
+		require.Equal(t, "运动会", zh.Title)
+		require.Equal(t, "<p>{{guardian_name}}，请带水壶。</p>", zh.Body)
+	})
+
+	t.Run("rejects English and other languages", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		for _, l := range []store.Language{store.LanguageEnglish, "fr", "zh-SG"} {
+			in := in
+			in.Translations = map[store.Language]store.Translation{l: {Title: "Hi", Body: "<p>Hi</p>"}}
+			_, err := svc.Create(form, in)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+
+		in := in
+		in.Translations = map[store.Language]store.Translation{store.LanguageMalay: {Title: "Hari Sukan", Body: "<p>{{nama}}</p>"}}
+		_, err := svc.Create(form, in)
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("shows which translations guardians will miss", func(t *testing.T) {
+		svc, s := newTestService(t)
+		_, err := svc.SetLanguage("g1", store.LanguageTamil)
+		require.NoError(t, err)
+
+		v, err := svc.Create(scopeOf(s, "form", store.RoleTeacher), in)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, len(v.MissingTranslations))
+		require.Equal(t, MissingTranslation{Language: store.LanguageMalay}, v.MissingTranslations[0])
+		require.Equal(t, MissingTranslation{Language: store.LanguageTamil, Guardians: 1}, v.MissingTranslations[1])
+	})
+
+	t.Run("previews the version each guardian is sent", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		_, err := svc.SetLanguage("g2", store.LanguageChinese)
+		require.NoError(t, err)
+		v, err := svc.Create(form, in)
+		require.NoError(t, err)
+
+		p, err := svc.Preview(form, v.ID, "g2", "")
+		require.NoError(t, err)
+		require.Equal(t, store.LanguageChinese, p.Language)
+		require.Equal(t, "<p>Betty Tan，请带水壶。</p>", p.Body)
+
+		p, err = svc.Preview(form, v.ID, "g2", store.LanguageTamil)
+		require.NoError(t, err)
+		require.Equal(t, store.LanguageEnglish, p.Language)
+		require.Equal(t, "Sports Day", p.Title)
+
+		_, err = svc.Preview(form, v.ID, "g2", "fr")
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("records a guardian's language", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		g, err := svc.SetLanguage("g1", store.LanguageMalay)
+		require.NoError(t, err)
+		require.Equal(t, store.LanguageMalay, g.Language)
+		g, _ = s.Guardian("g1")
+		require.Equal(t, store.LanguageMalay, g.Language)
+
+		_, err = svc.SetLanguage("g1", "fr")
+		require.True(t, errors.Is(err, ErrInvalid))
+		_, err = svc.SetLanguage("nobody", store.LanguageMalay)
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
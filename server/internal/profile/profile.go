@@ -0,0 +1,153 @@
+// Package profile assembles the one-page student profile from independent
+// sources. Sources are fetched concurrently, each under its own deadline, and
+// a source that is slow or fails leaves a marked gap in the profile instead of
+// failing the whole page.
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ErrNotFound is returned for students that do not exist or that the viewer
+// may not see.
+var ErrNotFound = roster.ErrNotFound
+
+// Source produces one section of a profile.
+type Source struct {
+	// Name is the section's key in Profile.Sections.
+	Name string
+	// Timeout bounds Fetch. A source that has not returned by then is
+	// reported as timed out.
+	Timeout time.Duration
+	// Fetch returns the section's data for student st as seen by scope. It
+	// should return promptly once ctx is done.
+	Fetch func(ctx context.Context, scope authz.Scope, st store.Student) (any, error)
+}
+
+// SectionStatus reports how a section was fetched.
+type SectionStatus string
+
+const (
+	StatusOK      SectionStatus = "ok"
+	StatusTimeout SectionStatus = "timeout"
+	StatusError   SectionStatus = "error"
+)
+
+// Section is one part of a profile. Data is set only when Status is StatusOK.
+type Section struct {
+	Status SectionStatus `json:"status"`
+	Data   any           `json:"data,omitempty"`
+
+	err error
+}
+
+// Err returns the error that caused the section to be missing, if any.
+func (s Section) Err() error {
+	return s.err
+}
+
+// Particulars are the student's own details.
+type Particulars struct {
+	roster.StudentSummary
+	DateOfBirth store.Date `json:"date_of_birth"`
+}
+
+// Profile is the aggregated view of one student. Partial is true when any
+// section is missing.
+type Profile struct {
+	Student  Particulars        `json:"student"`
+	Sections map[string]Section `json:"sections"`
+	Partial  bool               `json:"partial"`
+}
+
+// Service builds profiles from a fixed set of sources.
+type Service struct {
+	store   *store.Store
+	roster  *roster.Service
+	sources []Source
+}
+
+// New returns a Service that builds profiles from sources.
+func New(s *store.Store, sources ...Source) *Service {
+	return &Service{store: s, roster: roster.New(s), sources: sources}
+}
+
+// Build returns the profile of the student with the given ID. Particulars
+// are required, so Build fails only if the student cannot be seen; every other
+// section is fetched concurrently and may be missing.
+func (s *Service) Build(ctx context.Context, scope authz.Scope, studentID string) (Profile, error) {
+	summary, err := s.roster.Student(scope, studentID)
+	if err != nil {
+		return Profile{}, err
+	}
+	st, _ := s.store.Student(studentID)
+
+	p := Profile{
+		Student:  Particulars{StudentSummary: summary, DateOfBirth: st.DateOfBirth},
+		Sections: make(map[string]Section, len(s.sources)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, src := range s.sources {
+		wg.Go(func() {
+			section := fetch(ctx, src, scope, st)
+
+			mu.Lock()
+			defer mu.Unlock()
+			p.Sections[src.Name] = section
+			if section.Status != StatusOK {
+				p.Partial = true
+			}
+		})
+	}
+	wg.Wait()
+
+	return p, nil
+}
+
+// fetch runs one source under its deadline. A source that overruns is
+// abandoned: its goroutine finishes in the background and its result is
+// dropped.
+func fetch(ctx context.Context, src Source, scope authz.Scope, st store.Student) Section {
+	ctx, cancel := context.WithTimeout(ctx, src.Timeout)
+	defer cancel()
+
+	type result struct {
+		data any
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("profile: source %s panicked: %v", src.Name, r)}
+			}
+		}()
+		data, err := src.Fetch(ctx, scope, st)
+		done <- result{data: data, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		switch {
+		case errors.Is(r.err, context.DeadlineExceeded), errors.Is(r.err, context.Canceled):
+			return Section{Status: StatusTimeout, err: r.err}
+		case r.err != nil:
+			return Section{Status: StatusError, err: r.err}
+		}
+		return Section{Status: StatusOK, Data: r.data}
+	case <-ctx.Done():
+		return Section{Status: StatusTimeout, err: fmt.Errorf("profile: source %s: %w", src.Name, ctx.Err())}
+	}
+}
@@ -0,0 +1,142 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestStore() *store.Store {
+	day := store.Date{Year: 2026, Month: time.January, Day: 5}
+	return store.New(&store.Dataset{
+		Classes: []store.Class{{ID: "c1", SchoolID: "s1", LevelID: "l1", Name: "1A", FormTeacherID: "form"}},
+		Teaching: []store.Teaching{
+			{TeacherID: "maths", ClassID: "c1", Subject: "Mathematics"},
+		},
+		Students: []store.Student{{
+			ID: "a", SchoolID: "s1", ClassID: "c1", IndexNumber: 1, Name: "Aisyah",
+			Subjects:  []string{"English", "Mathematics"},
+			Guardians: []store.GuardianLink{{GuardianID: "g1", Relationship: "Mother"}},
+		}},
+		Guardians: []store.Guardian{{ID: "g1", Name: "Siti Aminah", Email: "siti@example.com"}},
+		CCAs:      []store.CCA{{StudentID: "a", Name: "Choir", Category: "Performing Arts", Role: "Member"}},
+		Attendance: []store.Attendance{
+			{StudentID: "a", ClassID: "c1", Date: day, Status: store.AttendancePresent},
+			{StudentID: "a", ClassID: "c1", Date: day.AddDays(1), Status: store.AttendanceAbsent},
+		},
+		Assessments: []store.Assessment{
+			{ID: "e1", ClassID: "c1", Subject: "English", Name: "Essay", Date: day, MaxMarks: 40},
+			{ID: "m1", ClassID: "c1", Subject: "Mathematics", Name: "Quiz 1", Date: day, MaxMarks: 20},
+			{ID: "m2", ClassID: "c1", Subject: "Mathematics", Name: "Quiz 2", Date: day.AddDays(7), MaxMarks: 20},
+		},
+		Scores: []store.Score{
+			{AssessmentID: "e1", StudentID: "a", Marks: 30},
+			{AssessmentID: "m1", StudentID: "a", Marks: 15},
+			{AssessmentID: "m2", StudentID: "a", Status: store.ScoreAbsent},
+		},
+	})
+}
+
+func TestBuild(t *testing.T) {
+	s := newTestStore()
+	form := authz.For(s, store.Teacher{ID: "form", SchoolID: "s1", Role: store.RoleTeacher})
+
+	t.Run("combines every default section", func(t *testing.T) {
+		p, err := New(s, DefaultSources(s)...).Build(t.Context(), form, "a")
+
+		require.NoError(t, err)
+		require.False(t, p.Partial)
+		require.Equal(t, "Aisyah", p.Student.Name)
+		for _, name := range []string{"guardians", "attendance", "assessments", "cca"} {
+			require.Equal(t, StatusOK, p.Sections[name].Status)
+		}
+		require.Equal(t, "Mother", p.Sections["guardians"].Data.([]Guardian)[0].Relationship)
+		require.Equal(t, 0.5, p.Sections["attendance"].Data.(attendance.Summary).Rate)
+		require.Equal(t, "Choir", p.Sections["cca"].Data.([]CCA)[0].Name)
+	})
+
+	t.Run("lists recent results newest first", func(t *testing.T) {
+		p, err := New(s, DefaultSources(s)...).Build(t.Context(), form, "a")
+		require.NoError(t, err)
+
+		results := p.Sections["assessments"].Data.([]Result)
+		require.Equal(t, 3, len(results))
+		require.Equal(t, "m2", results[0].AssessmentID)
+		require.True(t, results[0].Percentage == nil)
+		require.Equal(t, 75.0, *results[2].Percentage)
+	})
+
+	t.Run("subject teachers see only their subjects' results", func(t *testing.T) {
+		maths := authz.For(s, store.Teacher{ID: "maths", SchoolID: "s1", Role: store.RoleTeacher})
+
+		p, err := New(s, DefaultSources(s)...).Build(t.Context(), maths, "a")
+		require.NoError(t, err)
+
+		for _, r := range p.Sections["assessments"].Data.([]Result) {
+			require.Equal(t, "Mathematics", r.Subject)
+		}
+	})
+
+	t.Run("students outside scope are not found", func(t *testing.T) {
+		other := authz.For(s, store.Teacher{ID: "other", SchoolID: "s1", Role: store.RoleTeacher})
+
+		_, err := New(s).Build(t.Context(), other, "a")
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("marks slow and failing sources without failing the profile", func(t *testing.T) {
+		boom := errors.New("boom")
+		svc := New(s,
+			Source{Name: "fast", Timeout: time.Second, Fetch: func(context.Context, authz.Scope, store.Student) (any, error) {
+				return "ok", nil
+			}},
+			Source{Name: "slow", Timeout: 10 * time.Millisecond, Fetch: func(ctx context.Context, _ authz.Scope, _ store.Student) (any, error) {
+				<-ctx.Done()
+				time.Sleep(time.Second)
+				return "late", nil
+			}},
+			Source{Name: "failing", Timeout: time.Second, Fetch: func(context.Context, authz.Scope, store.Student) (any, error) {
+				return nil, boom
+			}},
+			Source{Name: "panicking", Timeout: time.Second, Fetch: func(context.Context, authz.Scope, store.Student) (any, error) {
+				panic("oops")
+			}},
+		)
+
+		start := time.Now()
+		p, err := svc.Build(t.Context(), form, "a")
+
+		require.NoError(t, err)
+		require.True(t, time.Since(start) < 500*time.Millisecond)
+		require.True(t, p.Partial)
+		require.Equal(t, StatusOK, p.Sections["fast"].Status)
+		require.Equal(t, "ok", p.Sections["fast"].Data)
+		require.Equal(t, StatusTimeout, p.Sections["slow"].Status)
+		require.True(t, p.Sections["slow"].Data == nil)
+		require.Equal(t, StatusError, p.Sections["failing"].Status)
+		require.True(t, errors.Is(p.Sections["failing"].Err(), boom))
+		require.Equal(t, StatusError, p.Sections["panicking"].Status)
+	})
+
+	t.Run("a cancelled request leaves sources timed out", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		svc := New(s, Source{Name: "blocked", Timeout: time.Second, Fetch: func(ctx context.Context, _ authz.Scope, _ store.Student) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}})
+
+		p, err := svc.Build(ctx, form, "a")
+
+		require.NoError(t, err)
+		require.True(t, p.Partial)
+		require.Equal(t, StatusTimeout, p.Sections["blocked"].Status)
+	})
+}
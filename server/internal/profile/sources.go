@@ -0,0 +1,134 @@
+package profile
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+const (
+	// DefaultTimeout bounds each of the built-in sources.
+	DefaultTimeout = 500 * time.Millisecond
+	// RecentResults is how many assessment results the profile shows.
+	RecentResults = 10
+)
+
+// Guardian is a guardian as listed on a student's profile.
+type Guardian struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Relationship string `json:"relationship"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone"`
+}
+
+// Result is one assessment result as listed on a student's profile.
+// Percentage is nil when the score carries no marks.
+type Result struct {
+	AssessmentID string            `json:"assessment_id"`
+	Subject      string            `json:"subject"`
+	Name         string            `json:"name"`
+	Term         int               `json:"term"`
+	Date         store.Date        `json:"date"`
+	Marks        float64           `json:"marks"`
+	MaxMarks     float64           `json:"max_marks"`
+	Percentage   *float64          `json:"percentage"`
+	Status       store.ScoreStatus `json:"status,omitempty"`
+}
+
+// CCA is a co-curricular activity as listed on a student's profile.
+type CCA struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Role     string `json:"role"`
+}
+
+// DefaultSources returns the sources that read from s: guardians, attendance,
+// recent assessment results and CCAs.
+func DefaultSources(s *store.Store) []Source {
+	return []Source{
+		{Name: "guardians", Timeout: DefaultTimeout, Fetch: guardians(s)},
+		{Name: "attendance", Timeout: DefaultTimeout, Fetch: attendanceSummary(s)},
+		{Name: "assessments", Timeout: DefaultTimeout, Fetch: recentResults(s)},
+		{Name: "cca", Timeout: DefaultTimeout, Fetch: ccas(s)},
+	}
+}
+
+func guardians(s *store.Store) func(context.Context, authz.Scope, store.Student) (any, error) {
+	return func(_ context.Context, _ authz.Scope, st store.Student) (any, error) {
+		out := make([]Guardian, 0, len(st.Guardians))
+		for _, link := range st.Guardians {
+			g, ok := s.Guardian(link.GuardianID)
+			if !ok {
+				continue
+			}
+			out = append(out, Guardian{
+				ID:           g.ID,
+				Name:         g.Name,
+				Relationship: link.Relationship,
+				Email:        g.Email,
+				Phone:        g.Phone,
+			})
+		}
+		return out, nil
+	}
+}
+
+func attendanceSummary(s *store.Store) func(context.Context, authz.Scope, store.Student) (any, error) {
+	return func(_ context.Context, _ authz.Scope, st store.Student) (any, error) {
+		return attendance.Summarize(s.AttendanceForStudent(st.ID)), nil
+	}
+}
+
+// recentResults lists the student's latest results, newest first. Subject
+// teachers see only the subjects they teach the student.
+func recentResults(s *store.Store) func(context.Context, authz.Scope, store.Student) (any, error) {
+	return func(_ context.Context, scope authz.Scope, st store.Student) (any, error) {
+		out := []Result{}
+		for _, sc := range s.ScoresForStudent(st.ID) {
+			a, ok := s.Assessment(sc.AssessmentID)
+			if !ok || !scope.CanViewSubject(st.ClassID, a.Subject) {
+				continue
+			}
+			r := Result{
+				AssessmentID: a.ID,
+				Subject:      a.Subject,
+				Name:         a.Name,
+				Term:         a.Term,
+				Date:         a.Date,
+				Marks:        sc.Marks,
+				MaxMarks:     a.MaxMarks,
+				Status:       sc.Status,
+			}
+			if sc.Status == "" && a.MaxMarks > 0 {
+				pct := math.Round(sc.Marks/a.MaxMarks*1000) / 10
+				r.Percentage = &pct
+			}
+			out = append(out, r)
+		}
+
+		slices.SortFunc(out, func(a, b Result) int {
+			if c := b.Date.Compare(a.Date); c != 0 {
+				return c
+			}
+			return strings.Compare(a.AssessmentID, b.AssessmentID)
+		})
+		return out[:min(len(out), RecentResults)], nil
+	}
+}
+
+func ccas(s *store.Store) func(context.Context, authz.Scope, store.Student) (any, error) {
+	return func(_ context.Context, _ authz.Scope, st store.Student) (any, error) {
+		out := []CCA{}
+		for _, c := range s.CCAsForStudent(st.ID) {
+			out = append(out, CCA{Name: c.Name, Category: c.Category, Role: c.Role})
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,336 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/pdf"
+)
+
+// content is everything printed in one student's report.
+type content struct {
+	student    store.Student
+	school     store.School
+	class      store.Class
+	level      store.Level
+	attendance attendance.Summary
+	results    []gradebook.SubjectResult
+	comments   []comment
+}
+
+type comment struct {
+	author string
+	at     time.Time
+	body   string
+}
+
+// gather reads the report of st, limited to what the viewer may see.
+func (s *Service) gather(scope authz.Scope, st store.Student) (content, error) {
+	c := content{student: st}
+	c.school, _ = s.store.School(st.SchoolID)
+	c.class, _ = s.store.Class(st.ClassID)
+	c.level, _ = s.store.Level(c.class.LevelID)
+
+	att, err := s.attendance.Student(scope, st.ID, attendance.Period{})
+	if err != nil {
+		return content{}, err
+	}
+	c.attendance = att.Summary
+	results, err := s.grades.Student(scope, st.ID)
+	if err != nil {
+		return content{}, err
+	}
+	c.results = results.Subjects
+	notes, err := s.notes.ReportComments(scope, st.ID)
+	if err != nil {
+		return content{}, err
+	}
+	for _, n := range notes {
+		author, _ := s.store.Teacher(n.AuthorID)
+		c.comments = append(c.comments, comment{author: author.Name, at: n.CreatedAt, body: n.Body})
+	}
+	return c, nil
+}
+
+// defaultAccent colours the header band of schools without branding.
+var defaultAccent = pdf.Color{R: 31, G: 58, B: 95}
+
+var (
+	grey  = pdf.Color{R: 110, G: 110, B: 110}
+	track = pdf.Color{R: 228, G: 228, B: 228}
+)
+
+// branding is a school's ReportBranding ready to draw.
+type branding struct {
+	accent pdf.Color
+	logo   image.Image
+	footer string
+
+	// doc and image are the document the logo was last added to and the
+	// logo within it, so that a batch adds it once.
+	doc   *pdf.Document
+	image *pdf.Image
+}
+
+func (s *Service) branding(schoolID string) (*branding, error) {
+	br := &branding{accent: defaultAccent}
+	school, ok := s.store.School(schoolID)
+	if !ok || school.ReportBranding == nil {
+		return br, nil
+	}
+	b := school.ReportBranding
+	if c, err := pdf.ParseColor(b.AccentColour); err == nil {
+		br.accent = c
+	}
+	if len(b.Logo) > 0 {
+		img, _, err := image.Decode(bytes.NewReader(b.Logo))
+		if err != nil {
+			return nil, fmt.Errorf("reports: decoding logo of school %s: %w", schoolID, err)
+		}
+		br.logo = img
+	}
+	br.footer = b.Footer
+	return br, nil
+}
+
+func (b *branding) logoIn(doc *pdf.Document) (*pdf.Image, error) {
+	if b.logo == nil {
+		return nil, nil
+	}
+	if b.doc != doc {
+		im, err := doc.AddImage(b.logo)
+		if err != nil {
+			return nil, err
+		}
+		b.doc, b.image = doc, im
+	}
+	return b.image, nil
+}
+
+// Page layout, in points.
+const (
+	margin     = 50
+	textWidth  = pdf.PageWidth - 2*margin
+	bandHeight = 80
+	// bottom is the lowest a line of the report may sit, clear of the
+	// footer.
+	bottom   = 60
+	logoSize = 56
+
+	labelWidth = 110
+	barX       = margin + 140
+	barWidth   = 240
+	barHeight  = 10
+)
+
+// layout places a report's lines down its pages, starting a new page when
+// one fills.
+type layout struct {
+	doc   *pdf.Document
+	br    *branding
+	c     content
+	pages []*pdf.Page
+	page  *pdf.Page
+	y     float64
+}
+
+// render appends the report of c to doc, starting on a new page.
+func render(doc *pdf.Document, br *branding, c content) error {
+	l := &layout{doc: doc, br: br, c: c}
+	if err := l.header(); err != nil {
+		return err
+	}
+	l.particulars()
+	l.attendanceSection()
+	l.resultsSection()
+	l.commentsSection()
+	l.footers()
+	return nil
+}
+
+// newPage starts a page, leaving y at the top margin.
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.pages = append(l.pages, l.page)
+	l.y = pdf.PageHeight - margin
+}
+
+// need starts a new page unless h points remain above the bottom.
+func (l *layout) need(h float64) {
+	if l.y-h >= bottom {
+		return
+	}
+	l.newPage()
+	l.page.Text(margin, l.y, pdf.Helvetica, 9, grey, l.c.student.Name+" (continued)")
+	l.y -= 24
+}
+
+func (l *layout) header() error {
+	l.newPage()
+	top := pdf.PageHeight
+	l.page.Rect(0, top-bandHeight, pdf.PageWidth, bandHeight, l.br.accent)
+	x := float64(margin)
+	logo, err := l.br.logoIn(l.doc)
+	if err != nil {
+		return err
+	}
+	if logo != nil {
+		scale := min(logoSize/float64(logo.Width), logoSize/float64(logo.Height))
+		w, h := float64(logo.Width)*scale, float64(logo.Height)*scale
+		l.page.Image(logo, x, top-bandHeight/2-h/2, w, h)
+		x += w + 14
+	}
+	l.page.Text(x, top-38, pdf.HelveticaBold, 18, pdf.White, l.c.school.Name)
+	l.page.Text(x, top-56, pdf.Helvetica, 11, pdf.White, "Student progress report")
+
+	l.y = top - bandHeight - 34
+	l.page.Text(margin, l.y, pdf.HelveticaBold, 16, pdf.Black, l.c.student.Name)
+	l.y -= 12
+	return nil
+}
+
+// section starts a section titled title, keeping room for a line of it.
+func (l *layout) section(title string) {
+	l.need(56)
+	l.y -= 22
+	l.page.Text(margin, l.y, pdf.HelveticaBold, 12, l.br.accent, title)
+	l.y -= 6
+	l.page.Line(margin, l.y, margin+textWidth, l.y, 0.5, l.br.accent)
+	l.y -= 16
+}
+
+// row prints a label and its value.
+func (l *layout) row(label, value string) {
+	l.need(14)
+	l.page.Text(margin, l.y, pdf.Helvetica, 10, grey, label)
+	l.page.Text(margin+labelWidth, l.y, pdf.Helvetica, 10, pdf.Black, value)
+	l.y -= 14
+}
+
+// note prints s in grey as a line on its own.
+func (l *layout) note(s string) {
+	l.need(14)
+	l.page.Text(margin, l.y, pdf.Helvetica, 10, grey, s)
+	l.y -= 14
+}
+
+// bar draws a bar filled to pct percent on the current line, with label to
+// its right.
+func (l *layout) bar(pct float64, label string) {
+	pct = max(0, min(pct, 100))
+	l.page.Rect(barX, l.y-1, barWidth, barHeight, track)
+	if pct > 0 {
+		l.page.Rect(barX, l.y-1, barWidth*pct/100, barHeight, l.br.accent)
+	}
+	l.page.Text(barX+barWidth+10, l.y, pdf.HelveticaBold, 10, pdf.Black, label)
+}
+
+func (l *layout) particulars() {
+	st := l.c.student
+	l.section("Particulars")
+	l.row("Class", strings.TrimSpace(l.c.class.Name+", "+l.c.level.Name))
+	l.row("Index number", strconv.Itoa(st.IndexNumber))
+	if st.ExternalID != "" {
+		l.row("Student ID", st.ExternalID)
+	}
+	if !st.DateOfBirth.IsZero() {
+		l.row("Date of birth", longDate(st.DateOfBirth.Time()))
+	}
+	if st.Gender != "" {
+		l.row("Gender", st.Gender)
+	}
+}
+
+func (l *layout) attendanceSection() {
+	a := l.c.attendance
+	l.section("Attendance")
+	if a.Days == 0 {
+		l.note("No attendance has been recorded.")
+		return
+	}
+	l.need(30)
+	l.page.Text(margin, l.y, pdf.Helvetica, 10, pdf.Black, "Attendance rate")
+	l.bar(a.Rate*100, percent(a.Rate*100))
+	l.y -= 16
+	l.page.Text(margin, l.y, pdf.Helvetica, 9, grey, fmt.Sprintf(
+		"%d school days: present %d, late %d, absent %d, MC %d, official leave %d",
+		a.Days, a.Present, a.Late, a.Absent, a.MC, a.OfficialLeave))
+	l.y -= 14
+}
+
+func (l *layout) resultsSection() {
+	l.section("Results")
+	if len(l.c.results) == 0 {
+		l.note("No results have been recorded.")
+		return
+	}
+	for _, r := range l.c.results {
+		l.need(30)
+		l.page.Text(margin, l.y, pdf.Helvetica, 10, pdf.Black, pdf.Helvetica.Wrap(r.Subject, 10, barX-margin-10)[0])
+		if r.Overall.Percentage == nil {
+			l.page.Text(barX, l.y, pdf.Helvetica, 10, grey, "No marks yet")
+		} else {
+			l.bar(*r.Overall.Percentage, strings.TrimSpace(percent(*r.Overall.Percentage)+" "+r.Overall.Grade))
+		}
+		l.y -= 13
+
+		var terms []string
+		for _, t := range r.Terms {
+			if t.Percentage != nil {
+				terms = append(terms, fmt.Sprintf("Term %d: %s %s", t.Term, percent(*t.Percentage), t.Grade))
+			}
+		}
+		if !r.Overall.Complete {
+			terms = append(terms, "some marks still to come")
+		}
+		l.page.Text(barX, l.y, pdf.Helvetica, 8, grey, strings.Join(terms, " · "))
+		l.y -= 17
+	}
+}
+
+func (l *layout) commentsSection() {
+	l.section("Teachers' comments")
+	if len(l.c.comments) == 0 {
+		l.note("There are no comments.")
+		return
+	}
+	for _, c := range l.c.comments {
+		l.need(28)
+		l.page.Text(margin, l.y, pdf.HelveticaBold, 9, grey, strings.TrimLeft(c.author+", "+longDate(c.at), ", "))
+		l.y -= 14
+		for _, line := range pdf.Helvetica.Wrap(c.body, 10, textWidth) {
+			l.need(13)
+			l.page.Text(margin, l.y, pdf.Helvetica, 10, pdf.Black, line)
+			l.y -= 13
+		}
+		l.y -= 8
+	}
+}
+
+// footers prints the branding footer and page numbers on the report's pages.
+func (l *layout) footers() {
+	for i, p := range l.pages {
+		p.Line(margin, 42, margin+textWidth, 42, 0.5, track)
+		if l.br.footer != "" {
+			p.Text(margin, 28, pdf.Helvetica, 8, grey, pdf.Helvetica.Wrap(l.br.footer, 8, textWidth-80)[0])
+		}
+		n := fmt.Sprintf("Page %d of %d", i+1, len(l.pages))
+		p.Text(margin+textWidth-pdf.Helvetica.Width(n, 8), 28, pdf.Helvetica, 8, grey, n)
+	}
+}
+
+func percent(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64) + "%"
+}
+
+func longDate(t time.Time) string {
+	return t.Format("2 January 2006")
+}
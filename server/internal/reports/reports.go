@@ -0,0 +1,292 @@
+// Package reports prints student progress reports as PDF for parent-teacher
+// meetings: a student's particulars, attendance, results and their teachers'
+// comments, styled with the school's branding. A class's reports are
+// generated as a background job, as one PDF or a ZIP of one PDF per student.
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // logos may be JPEG
+	_ "image/png"  // or PNG
+	"strings"
+	"unicode/utf8"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
+	"github.com/String-sg/teacher-workspace/server/internal/notes"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/pdf"
+)
+
+var (
+	// ErrNotFound is returned for students and classes that do not exist or
+	// that the viewer may not see.
+	ErrNotFound = errors.New("reports: not found")
+	// ErrForbidden is returned when a teacher who is not a school leader
+	// changes the school's branding.
+	ErrForbidden = errors.New("reports: only school leaders may change report branding")
+	// ErrInvalid wraps every validation failure of a request or branding.
+	ErrInvalid = errors.New("reports: invalid request")
+	// ErrNotReady is returned for the download of a job that is still
+	// running or has failed.
+	ErrNotReady = errors.New("reports: reports are not ready")
+
+	// errNoStudents fails a class report job whose students have all left
+	// the class, leaving nothing to download.
+	errNoStudents = errors.New("every student has left the class")
+)
+
+const (
+	// MaxLogoBytes bounds the size of a school's logo file.
+	MaxLogoBytes = 256 << 10
+	// MaxLogoPixels bounds the width and height of a school's logo.
+	MaxLogoPixels = 1000
+	// MaxFooterLength bounds the branding footer, in characters.
+	MaxFooterLength = 200
+)
+
+// JobKind is the kind of the jobs that generate a class's reports.
+const JobKind = "class_reports"
+
+// Format is how a class's reports are delivered.
+type Format string
+
+const (
+	// FormatPDF puts every student's report in one PDF, each starting on a
+	// new page, for printing in one go.
+	FormatPDF Format = "pdf"
+	// FormatZIP puts each student's report in its own PDF, for sending on.
+	FormatZIP Format = "zip"
+)
+
+const contentTypePDF = "application/pdf"
+
+// Service prints reports, limited to what the viewing teacher may see.
+type Service struct {
+	store      *store.Store
+	attendance *attendance.Service
+	grades     *gradebook.Service
+	notes      *notes.Service
+	jobs       *jobs.Runner
+	log        *audit.Log
+}
+
+// New returns a Service over s that takes each section of a report from
+// the matching service, runs class batches on runner and records printed
+// reports in log.
+func New(s *store.Store, att *attendance.Service, grades *gradebook.Service, n *notes.Service, runner *jobs.Runner, log *audit.Log) *Service {
+	return &Service{store: s, attendance: att, grades: grades, notes: n, jobs: runner, log: log}
+}
+
+// Student returns the report of student studentID as a PDF.
+func (s *Service) Student(scope authz.Scope, studentID string) (jobs.Result, error) {
+	st, ok := s.store.Student(studentID)
+	if !ok || !scope.CanViewStudent(st) {
+		return jobs.Result{}, ErrNotFound
+	}
+	br, err := s.branding(st.SchoolID)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+	c, err := s.gather(scope, st)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+
+	doc := pdf.New()
+	doc.Title = st.Name + " progress report"
+	if err := render(doc, br, c); err != nil {
+		return jobs.Result{}, err
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return jobs.Result{}, err
+	}
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionReportStudent, TargetType: "student", TargetID: st.ID})
+	return jobs.Result{Filename: filename(st.Name + " report.pdf"), ContentType: contentTypePDF, Data: buf.Bytes()}, nil
+}
+
+// Class starts a job that generates the reports of every student in class
+// classID the viewer may see, in format f, and returns the queued job.
+func (s *Service) Class(scope authz.Scope, classID string, f Format) (jobs.Job, error) {
+	c, ok := s.store.Class(classID)
+	if !ok || !scope.CanViewClass(c) {
+		return jobs.Job{}, ErrNotFound
+	}
+	if f != FormatPDF && f != FormatZIP {
+		return jobs.Job{}, fmt.Errorf("%w: format must be pdf or zip", ErrInvalid)
+	}
+	var students []store.Student
+	for _, st := range s.store.StudentsInClass(c.ID) {
+		if scope.CanViewStudent(st) {
+			students = append(students, st)
+		}
+	}
+	if len(students) == 0 {
+		return jobs.Job{}, fmt.Errorf("%w: class %s has no students", ErrInvalid, c.Name)
+	}
+
+	job, err := s.jobs.Start(scope.Teacher.ID, JobKind, len(students), func(ctx context.Context, progress func(int)) (jobs.Result, error) {
+		return s.batch(ctx, scope, c, students, f, progress)
+	})
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	s.log.Record(scope.Teacher.ID, audit.Event{Action: audit.ActionReportClass, TargetType: "class", TargetID: c.ID, Detail: string(f)})
+	return job, nil
+}
+
+// batch generates the reports of students, who were in class c when the job
+// was started.
+func (s *Service) batch(ctx context.Context, scope authz.Scope, c store.Class, students []store.Student, f Format, progress func(int)) (jobs.Result, error) {
+	br, err := s.branding(c.SchoolID)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	doc := pdf.New()
+	doc.Title = c.Name + " progress reports"
+	reported := 0
+	for i, st := range students {
+		if err := ctx.Err(); err != nil {
+			return jobs.Result{}, err
+		}
+		// Read the student afresh: one who has left the class since the job
+		// started is left out rather than reported on stale data.
+		st, ok := s.store.Student(st.ID)
+		if !ok || st.ClassID != c.ID {
+			progress(i + 1)
+			continue
+		}
+		content, err := s.gather(scope, st)
+		if err != nil {
+			return jobs.Result{}, err
+		}
+
+		if f == FormatZIP {
+			doc = pdf.New()
+			doc.Title = st.Name + " progress report"
+		}
+		if err := render(doc, br, content); err != nil {
+			return jobs.Result{}, err
+		}
+		if f == FormatZIP {
+			w, err := zw.Create(filename(fmt.Sprintf("%02d %s.pdf", st.IndexNumber, st.Name)))
+			if err != nil {
+				return jobs.Result{}, err
+			}
+			if _, err := doc.WriteTo(w); err != nil {
+				return jobs.Result{}, err
+			}
+		}
+		reported++
+		progress(i + 1)
+	}
+	if reported == 0 {
+		return jobs.Result{}, errNoStudents
+	}
+
+	name := c.Name + " reports"
+	if f == FormatZIP {
+		if err := zw.Close(); err != nil {
+			return jobs.Result{}, err
+		}
+		return jobs.Result{Filename: filename(name + ".zip"), ContentType: "application/zip", Data: buf.Bytes()}, nil
+	}
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return jobs.Result{}, err
+	}
+	return jobs.Result{Filename: filename(name + ".pdf"), ContentType: contentTypePDF, Data: buf.Bytes()}, nil
+}
+
+// Job returns the class report job id started by the viewer.
+func (s *Service) Job(scope authz.Scope, id string) (jobs.Job, error) {
+	job, err := s.jobs.Get(scope.Teacher.ID, id)
+	if err != nil || job.Kind != JobKind {
+		return jobs.Job{}, ErrNotFound
+	}
+	return job, nil
+}
+
+// Download returns the file made by the class report job id started by the
+// viewer, once it has succeeded. A job whose students have all left the
+// class has nothing to download and is not found.
+func (s *Service) Download(scope authz.Scope, id string) (jobs.Result, error) {
+	job, res, err := s.jobs.Result(scope.Teacher.ID, id)
+	switch {
+	case errors.Is(err, errNoStudents) && job.Kind == JobKind:
+		return jobs.Result{}, fmt.Errorf("%w: %w", ErrNotFound, errNoStudents)
+	case errors.Is(err, jobs.ErrNotReady) && job.Kind == JobKind:
+		return jobs.Result{}, fmt.Errorf("%w: job is %s", ErrNotReady, job.Status)
+	case err != nil || job.Kind != JobKind:
+		return jobs.Result{}, ErrNotFound
+	}
+	return res, nil
+}
+
+// Branding returns the report branding of the viewer's school.
+func (s *Service) Branding(scope authz.Scope) store.ReportBranding {
+	school, ok := s.store.School(scope.Teacher.SchoolID)
+	if !ok || school.ReportBranding == nil {
+		return store.ReportBranding{}
+	}
+	return *school.ReportBranding
+}
+
+// SetBranding replaces the report branding of the viewer's school.
+func (s *Service) SetBranding(scope authz.Scope, b store.ReportBranding) (store.ReportBranding, error) {
+	if !scope.SchoolWide() {
+		return store.ReportBranding{}, ErrForbidden
+	}
+	b, err := ValidateBranding(b)
+	if err != nil {
+		return store.ReportBranding{}, err
+	}
+	if !s.store.SetReportBranding(scope.Teacher.SchoolID, &b) {
+		return store.ReportBranding{}, ErrNotFound
+	}
+	return b, nil
+}
+
+// ValidateBranding normalises b and checks it.
+func ValidateBranding(b store.ReportBranding) (store.ReportBranding, error) {
+	b.AccentColour = strings.ToLower(strings.TrimSpace(b.AccentColour))
+	if b.AccentColour != "" {
+		if _, err := pdf.ParseColor(b.AccentColour); err != nil {
+			return store.ReportBranding{}, fmt.Errorf("%w: accent_colour must be of the form #rrggbb", ErrInvalid)
+		}
+	}
+	if len(b.Logo) > 0 {
+		if len(b.Logo) > MaxLogoBytes {
+			return store.ReportBranding{}, fmt.Errorf("%w: logo must not exceed %d bytes", ErrInvalid, MaxLogoBytes)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(b.Logo))
+		if err != nil {
+			return store.ReportBranding{}, fmt.Errorf("%w: logo must be a PNG or JPEG image", ErrInvalid)
+		}
+		if cfg.Width > MaxLogoPixels || cfg.Height > MaxLogoPixels {
+			return store.ReportBranding{}, fmt.Errorf("%w: logo must be at most %d pixels wide and high", ErrInvalid, MaxLogoPixels)
+		}
+	}
+	b.Footer = strings.TrimSpace(b.Footer)
+	if utf8.RuneCountInString(b.Footer) > MaxFooterLength {
+		return store.ReportBranding{}, fmt.Errorf("%w: footer must not exceed %d characters", ErrInvalid, MaxFooterLength)
+	}
+	return b, nil
+}
+
+// filename makes name safe to use as a file name.
+func filename(name string) string {
+	// Names such as "Kumar s/o Raju" would otherwise become a directory.
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(name)
+}
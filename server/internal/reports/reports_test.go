@@ -0,0 +1,261 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/attendance"
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/gradebook"
+	"github.com/String-sg/teacher-workspace/server/internal/jobs"
+	"github.com/String-sg/teacher-workspace/server/internal/notes"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Schools:  []store.School{{ID: "s1", Name: "Rivervale Secondary"}},
+		Levels:   []store.Level{{ID: "l1", SchoolID: "s1", Number: 1, Name: "Secondary 1"}},
+		Classes:  []store.Class{{ID: "c1", SchoolID: "s1", LevelID: "l1", Name: "1A", FormTeacherID: "form"}},
+		Teachers: []store.Teacher{{ID: "form", SchoolID: "s1", Name: "Ms Lim"}},
+		Teaching: []store.Teaching{{TeacherID: "maths", ClassID: "c1", Subject: "Mathematics"}},
+		Students: []store.Student{
+			{ID: "a", SchoolID: "s1", ClassID: "c1", IndexNumber: 1, Name: "Tan Wei Ming", Subjects: []string{"Mathematics"}},
+			{ID: "b", SchoolID: "s1", ClassID: "c1", IndexNumber: 2, Name: "Kumar s/o Raju", Subjects: []string{"Mathematics"}},
+		},
+		Assessments: []store.Assessment{{ID: "m1", ClassID: "c1", Subject: "Mathematics", Name: "Test 1", Term: 1, MaxMarks: 50, Weight: 1}},
+		Scores:      []store.Score{{AssessmentID: "m1", StudentID: "a", Marks: 36}},
+		Attendance: []store.Attendance{
+			{StudentID: "a", ClassID: "c1", Date: store.Date{Year: 2026, Month: time.January, Day: 5}, Status: store.AttendancePresent},
+			{StudentID: "a", ClassID: "c1", Date: store.Date{Year: 2026, Month: time.January, Day: 6}, Status: store.AttendanceMC},
+		},
+	})
+	now := func() time.Time { return time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC) }
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	log := audit.New(s, now)
+	runner := jobs.New(ids, now, 1)
+	t.Cleanup(runner.Close)
+	n := notes.New(s, log, ids, now)
+	return New(s, attendance.New(s, now), gradebook.New(s, ids), n, runner, log), s
+}
+
+func scopeOf(s *store.Store, id string, role store.Role) authz.Scope {
+	return authz.For(s, store.Teacher{ID: id, SchoolID: "s1", Role: role})
+}
+
+// text returns what the pages of a PDF draw, decompressed.
+func text(t *testing.T, b []byte) string {
+	t.Helper()
+
+	var out strings.Builder
+	re := regexp.MustCompile(`/Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		n, _ := strconv.Atoi(string(b[m[2]:m[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(b[m[1] : m[1]+n]))
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		out.Write(data)
+	}
+	return out.String()
+}
+
+func pages(b []byte) string {
+	return string(regexp.MustCompile(`/Type /Pages /Kids \[[^]]*\] /Count (\d+)`).FindSubmatch(b)[1])
+}
+
+func TestStudent(t *testing.T) {
+	t.Run("prints every section", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		_, err := svc.notes.Create(form, "a", notes.Input{
+			Visibility: store.NoteFormTeacher, Category: "academic", Tags: []string{notes.ReportTag},
+			Body: "Wei Ming asks good questions in class.",
+		})
+		require.NoError(t, err)
+
+		res, err := svc.Student(form, "a")
+
+		require.NoError(t, err)
+		require.Equal(t, "Tan Wei Ming report.pdf", res.Filename)
+		require.Equal(t, "application/pdf", res.ContentType)
+		out := text(t, res.Data)
+		for _, want := range []string{
+			"(Rivervale Secondary)", "(Tan Wei Ming)", "(1A, Secondary 1)",
+			"(50.0%)", "(Mathematics)", "(72.0% A2)",
+			"(Ms Lim, 2 March 2026)", "(Wei Ming asks good questions in class.)", "(Page 1 of 1)",
+		} {
+			require.True(t, strings.Contains(out, want))
+		}
+		log := s.AuditLog()
+		require.Equal(t, audit.ActionReportStudent, log[len(log)-1].Action)
+	})
+
+	t.Run("runs on to further pages", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		for range 8 {
+			_, err := svc.notes.Create(form, "a", notes.Input{
+				Visibility: store.NoteFormTeacher, Category: "academic", Tags: []string{notes.ReportTag},
+				Body: strings.Repeat("Wei Ming has made steady progress this term. ", 12),
+			})
+			require.NoError(t, err)
+		}
+
+		res, err := svc.Student(form, "a")
+
+		require.NoError(t, err)
+		require.Equal(t, "2", pages(res.Data))
+		require.True(t, strings.Contains(text(t, res.Data), "(Tan Wei Ming \\(continued\\))"))
+	})
+
+	t.Run("hides students the viewer may not see", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.Student(scopeOf(s, "stranger", store.RoleTeacher), "a")
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestClass(t *testing.T) {
+	t.Run("prints one PDF for the class", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+
+		job, err := svc.Class(form, "c1", FormatPDF)
+		require.NoError(t, err)
+		require.Equal(t, 2, job.Total)
+		svc.jobs.Wait()
+
+		job, err = svc.Job(form, job.ID)
+		require.NoError(t, err)
+		require.Equal(t, jobs.StatusSucceeded, job.Status)
+		require.Equal(t, 2, job.Done)
+		res, err := svc.Download(form, job.ID)
+		require.NoError(t, err)
+		require.Equal(t, "1A reports.pdf", res.Filename)
+		require.Equal(t, "2", pages(res.Data))
+	})
+
+	t.Run("prints a ZIP of one PDF per student", func(t *testing.T) {
+		svc, s := newTestService(t)
+		form := scopeOf(s, "form", store.RoleTeacher)
+		job, err := svc.Class(form, "c1", FormatZIP)
+		require.NoError(t, err)
+		svc.jobs.Wait()
+
+		res, err := svc.Download(form, job.ID)
+		require.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(res.Data), int64(len(res.Data)))
+		require.NoError(t, err)
+
+		require.Equal(t, "application/zip", res.ContentType)
+		require.Equal(t, 2, len(zr.File))
+		require.Equal(t, "01 Tan Wei Ming.pdf", zr.File[0].Name)
+		require.Equal(t, "02 Kumar s-o Raju.pdf", zr.File[1].Name)
+	})
+
+	t.Run("has nothing to download once every student has left", func(t *testing.T) {
+		for _, f := range []Format{FormatPDF, FormatZIP} {
+			svc, s := newTestService(t)
+			form := scopeOf(s, "form", store.RoleTeacher)
+			c, _ := s.Class("c1")
+			students := s.StudentsInClass("c1")
+			job, err := svc.jobs.Start(form.Teacher.ID, JobKind, len(students), func(ctx context.Context, progress func(int)) (jobs.Result, error) {
+				moved := slices.Clone(students)
+				for i := range moved {
+					moved[i].ClassID = "c2"
+				}
+				s.PutRoster(moved, nil)
+				return svc.batch(ctx, form, c, students, f, progress)
+			})
+			require.NoError(t, err)
+			svc.jobs.Wait()
+
+			_, err = svc.Download(form, job.ID)
+			require.True(t, errors.Is(err, ErrNotFound))
+		}
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.Class(scopeOf(s, "form", store.RoleTeacher), "c1", Format("docx"))
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("keeps jobs to the teacher who started them", func(t *testing.T) {
+		svc, s := newTestService(t)
+		job, err := svc.Class(scopeOf(s, "form", store.RoleTeacher), "c1", FormatPDF)
+		require.NoError(t, err)
+		svc.jobs.Wait()
+
+		_, err = svc.Download(scopeOf(s, "lead", store.RoleSchoolLeader), job.ID)
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestBranding(t *testing.T) {
+	var logo bytes.Buffer
+	require.NoError(t, png.Encode(&logo, image.NewGray(image.Rect(0, 0, 20, 10))))
+
+	t.Run("school leaders set it", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+
+		b, err := svc.SetBranding(leader, store.ReportBranding{AccentColour: " #AA0000 ", Logo: logo.Bytes(), Footer: "For parent-teacher meetings"})
+		require.NoError(t, err)
+		require.Equal(t, "#aa0000", b.AccentColour)
+		require.Equal(t, "#aa0000", svc.Branding(leader).AccentColour)
+
+		res, err := svc.Student(leader, "a")
+		require.NoError(t, err)
+		require.True(t, bytes.Contains(res.Data, []byte("/Subtype /Image /Width 20 /Height 10")))
+		out := text(t, res.Data)
+		require.True(t, strings.Contains(out, "0.67 0 0 rg 0 761.89 595.28 80 re f"))
+		require.True(t, strings.Contains(out, "(For parent-teacher meetings)"))
+	})
+
+	t.Run("other teachers may not", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		_, err := svc.SetBranding(scopeOf(s, "form", store.RoleTeacher), store.ReportBranding{})
+
+		require.True(t, errors.Is(err, ErrForbidden))
+	})
+
+	t.Run("rejects bad colours and logos", func(t *testing.T) {
+		svc, s := newTestService(t)
+		leader := scopeOf(s, "lead", store.RoleSchoolLeader)
+
+		for _, b := range []store.ReportBranding{
+			{AccentColour: "red"},
+			{Logo: []byte("GIF89a")},
+			{Footer: strings.Repeat("x", MaxFooterLength+1)},
+		} {
+			_, err := svc.SetBranding(leader, b)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+}
@@ -0,0 +1,263 @@
+// Package roster answers the class and student listing queries behind the
+// Student Insights screens, limited to what the viewing teacher may see.
+package roster
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ErrNotFound is returned for records that do not exist or that the viewer
+// may not see. The two cases are deliberately indistinguishable.
+var ErrNotFound = errors.New("roster: not found")
+
+// Sort orders a student listing. Every order falls back to student ID, so
+// pages are stable across requests.
+type Sort string
+
+const (
+	// SortClass orders by level, class name and index number.
+	SortClass     Sort = "class"
+	SortClassDesc Sort = "-class"
+	SortName      Sort = "name"
+	SortNameDesc  Sort = "-name"
+)
+
+// ParseSort validates s, returning SortClass when s is empty.
+func ParseSort(s string) (Sort, error) {
+	switch v := Sort(s); v {
+	case "":
+		return SortClass, nil
+	case SortClass, SortClassDesc, SortName, SortNameDesc:
+		return v, nil
+	default:
+		return "", fmt.Errorf("roster: unknown sort %q", s)
+	}
+}
+
+// Ref is a reference to a related record.
+type Ref struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ClassSummary describes a class for listing.
+type ClassSummary struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Year         int      `json:"year"`
+	Level        Ref      `json:"level"`
+	FormTeacher  *Ref     `json:"form_teacher,omitempty"`
+	IsFormClass  bool     `json:"is_form_class"`
+	Subjects     []string `json:"subjects"`
+	StudentCount int      `json:"student_count"`
+}
+
+// StudentSummary describes a student for listing.
+type StudentSummary struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	IndexNumber int      `json:"index_number"`
+	Gender      string   `json:"gender"`
+	Class       Ref      `json:"class"`
+	Level       Ref      `json:"level"`
+	Subjects    []string `json:"subjects"`
+}
+
+// ClassFilter narrows a class listing. Empty fields match everything.
+type ClassFilter struct {
+	LevelID string
+	Subject string
+}
+
+// StudentFilter narrows a student listing. Empty fields match everything.
+type StudentFilter struct {
+	LevelID string
+	ClassID string
+	Subject string
+	Sort    Sort
+}
+
+// Service answers roster queries from a Store.
+type Service struct {
+	store *store.Store
+}
+
+// New returns a Service reading from s.
+func New(s *store.Store) *Service {
+	return &Service{store: s}
+}
+
+// Classes returns the classes visible to the viewer, ordered by level and
+// class name.
+func (s *Service) Classes(scope authz.Scope, f ClassFilter) []ClassSummary {
+	var out []ClassSummary
+	for _, c := range s.store.Classes(scope.Teacher.SchoolID) {
+		if !scope.CanViewClass(c) || (f.LevelID != "" && c.LevelID != f.LevelID) {
+			continue
+		}
+		if f.Subject != "" && !s.offers(c.ID, f.Subject) {
+			continue
+		}
+		out = append(out, s.classSummary(scope, c))
+	}
+	s.sortClasses(out)
+	return out
+}
+
+// Class returns one class visible to the viewer.
+func (s *Service) Class(scope authz.Scope, id string) (ClassSummary, error) {
+	c, ok := s.store.Class(id)
+	if !ok || !scope.CanViewClass(c) {
+		return ClassSummary{}, ErrNotFound
+	}
+	return s.classSummary(scope, c), nil
+}
+
+// Students returns the students visible to the viewer that match f. When
+// f.ClassID names a class the viewer may not see, it returns ErrNotFound.
+func (s *Service) Students(scope authz.Scope, f StudentFilter) ([]StudentSummary, error) {
+	var candidates []store.Student
+	if f.ClassID != "" {
+		c, ok := s.store.Class(f.ClassID)
+		if !ok || !scope.CanViewClass(c) {
+			return nil, ErrNotFound
+		}
+		candidates = s.store.StudentsInClass(c.ID)
+	} else {
+		candidates = s.store.StudentsInSchool(scope.Teacher.SchoolID)
+	}
+
+	var out []StudentSummary
+	for _, st := range candidates {
+		if !scope.CanViewStudent(st) {
+			continue
+		}
+		if f.Subject != "" && !slices.Contains(st.Subjects, f.Subject) {
+			continue
+		}
+		summary := s.studentSummary(st)
+		if f.LevelID != "" && summary.Level.ID != f.LevelID {
+			continue
+		}
+		out = append(out, summary)
+	}
+
+	if err := s.sortStudents(out, f.Sort); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Student returns one student visible to the viewer.
+func (s *Service) Student(scope authz.Scope, id string) (StudentSummary, error) {
+	st, ok := s.store.Student(id)
+	if !ok || !scope.CanViewStudent(st) {
+		return StudentSummary{}, ErrNotFound
+	}
+	return s.studentSummary(st), nil
+}
+
+func (s *Service) offers(classID, subject string) bool {
+	for _, t := range s.store.TeachingByClass(classID) {
+		if t.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) classSummary(scope authz.Scope, c store.Class) ClassSummary {
+	summary := ClassSummary{
+		ID:          c.ID,
+		Name:        c.Name,
+		Year:        c.Year,
+		IsFormClass: scope.IsFormTeacher(c.ID),
+		Subjects:    scope.Subjects(c.ID),
+	}
+	if l, ok := s.store.Level(c.LevelID); ok {
+		summary.Level = Ref{ID: l.ID, Name: l.Name}
+	}
+	if t, ok := s.store.Teacher(c.FormTeacherID); ok {
+		summary.FormTeacher = &Ref{ID: t.ID, Name: t.Name}
+	}
+	if summary.Subjects == nil {
+		summary.Subjects = []string{}
+	}
+	for _, st := range s.store.StudentsInClass(c.ID) {
+		if scope.CanViewStudent(st) {
+			summary.StudentCount++
+		}
+	}
+	return summary
+}
+
+func (s *Service) studentSummary(st store.Student) StudentSummary {
+	summary := StudentSummary{
+		ID:          st.ID,
+		Name:        st.Name,
+		IndexNumber: st.IndexNumber,
+		Gender:      st.Gender,
+		Subjects:    st.Subjects,
+	}
+	if c, ok := s.store.Class(st.ClassID); ok {
+		summary.Class = Ref{ID: c.ID, Name: c.Name}
+		if l, ok := s.store.Level(c.LevelID); ok {
+			summary.Level = Ref{ID: l.ID, Name: l.Name}
+		}
+	}
+	return summary
+}
+
+func (s *Service) levelNumber(levelID string) int {
+	l, _ := s.store.Level(levelID)
+	return l.Number
+}
+
+func (s *Service) sortClasses(classes []ClassSummary) {
+	slices.SortFunc(classes, func(a, b ClassSummary) int {
+		return cmp.Or(
+			cmp.Compare(s.levelNumber(a.Level.ID), s.levelNumber(b.Level.ID)),
+			strings.Compare(a.Name, b.Name),
+			strings.Compare(a.ID, b.ID),
+		)
+	})
+}
+
+func (s *Service) sortStudents(students []StudentSummary, order Sort) error {
+	var compare func(a, b StudentSummary) int
+	byClass := func(a, b StudentSummary) int {
+		return cmp.Or(
+			cmp.Compare(s.levelNumber(a.Level.ID), s.levelNumber(b.Level.ID)),
+			strings.Compare(a.Class.Name, b.Class.Name),
+			cmp.Compare(a.IndexNumber, b.IndexNumber),
+		)
+	}
+	byName := func(a, b StudentSummary) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+
+	switch order {
+	case "", SortClass:
+		compare = byClass
+	case SortClassDesc:
+		compare = func(a, b StudentSummary) int { return byClass(b, a) }
+	case SortName:
+		compare = byName
+	case SortNameDesc:
+		compare = func(a, b StudentSummary) int { return byName(b, a) }
+	default:
+		return fmt.Errorf("roster: unknown sort %q", order)
+	}
+
+	slices.SortFunc(students, func(a, b StudentSummary) int {
+		return cmp.Or(compare(a, b), strings.Compare(a.ID, b.ID))
+	})
+	return nil
+}
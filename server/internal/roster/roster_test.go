@@ -0,0 +1,123 @@
+package roster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestService() (*Service, *store.Store) {
+	s := store.New(&store.Dataset{
+		Levels: []store.Level{
+			{ID: "l1", SchoolID: "s1", Number: 1, Name: "Secondary 1"},
+			{ID: "l2", SchoolID: "s1", Number: 2, Name: "Secondary 2"},
+		},
+		Classes: []store.Class{
+			{ID: "c2A", SchoolID: "s1", LevelID: "l2", Name: "2A"},
+			{ID: "c1B", SchoolID: "s1", LevelID: "l1", Name: "1B"},
+			{ID: "c1A", SchoolID: "s1", LevelID: "l1", Name: "1A"},
+		},
+		Teaching: []store.Teaching{
+			{TeacherID: "t", ClassID: "c1A", Subject: "Malay"},
+		},
+		Students: []store.Student{
+			{ID: "a", SchoolID: "s1", ClassID: "c2A", IndexNumber: 1, Name: "Aisyah", Subjects: []string{"Malay"}},
+			{ID: "b", SchoolID: "s1", ClassID: "c1A", IndexNumber: 2, Name: "benjamin", Subjects: []string{"Chinese"}},
+			{ID: "c", SchoolID: "s1", ClassID: "c1A", IndexNumber: 1, Name: "Chloe", Subjects: []string{"Malay"}},
+			{ID: "d", SchoolID: "s1", ClassID: "c1B", IndexNumber: 1, Name: "Chloe", Subjects: []string{"Malay"}},
+		},
+	})
+	return New(s), s
+}
+
+func TestParseSort(t *testing.T) {
+	t.Run("defaults to class order", func(t *testing.T) {
+		got, err := ParseSort("")
+
+		require.NoError(t, err)
+		require.Equal(t, SortClass, got)
+	})
+
+	t.Run("rejects unknown orders", func(t *testing.T) {
+		_, err := ParseSort("age")
+
+		require.Error(t, err)
+	})
+}
+
+func TestStudents(t *testing.T) {
+	svc, s := newTestService()
+	leader := authz.For(s, store.Teacher{ID: "leader", SchoolID: "s1", Role: store.RoleSchoolLeader})
+
+	ids := func(students []StudentSummary) string {
+		var out string
+		for _, st := range students {
+			out += st.ID
+		}
+		return out
+	}
+
+	cases := []struct {
+		name string
+		f    StudentFilter
+		want string
+	}{
+		{name: "class order is level, class name, index number", f: StudentFilter{}, want: "cbda"},
+		{name: "reverse class order", f: StudentFilter{Sort: SortClassDesc}, want: "adbc"},
+		{name: "name order ignores case and breaks ties by ID", f: StudentFilter{Sort: SortName}, want: "abcd"},
+		{name: "reverse name order still breaks ties by ascending ID", f: StudentFilter{Sort: SortNameDesc}, want: "cdba"},
+		{name: "filters by level", f: StudentFilter{LevelID: "l2"}, want: "a"},
+		{name: "filters by subject", f: StudentFilter{Subject: "Chinese"}, want: "b"},
+		{name: "filters by class", f: StudentFilter{ClassID: "c1A"}, want: "cb"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := svc.Students(leader, tc.f)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ids(got))
+		})
+	}
+
+	t.Run("subject teacher sees only their subject's students", func(t *testing.T) {
+		malay := authz.For(s, store.Teacher{ID: "t", SchoolID: "s1", Role: store.RoleTeacher})
+
+		got, err := svc.Students(malay, StudentFilter{})
+
+		require.NoError(t, err)
+		require.Equal(t, "c", ids(got))
+	})
+
+	t.Run("class outside scope is not found", func(t *testing.T) {
+		malay := authz.For(s, store.Teacher{ID: "t", SchoolID: "s1", Role: store.RoleTeacher})
+
+		_, err := svc.Students(malay, StudentFilter{ClassID: "c2A"})
+
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestClasses(t *testing.T) {
+	svc, s := newTestService()
+	leader := authz.For(s, store.Teacher{ID: "leader", SchoolID: "s1", Role: store.RoleSchoolLeader})
+
+	t.Run("orders by level then name and counts students", func(t *testing.T) {
+		got := svc.Classes(leader, ClassFilter{})
+
+		require.Equal(t, 3, len(got))
+		require.Equal(t, "1A", got[0].Name)
+		require.Equal(t, "1B", got[1].Name)
+		require.Equal(t, "2A", got[2].Name)
+		require.Equal(t, 2, got[0].StudentCount)
+	})
+
+	t.Run("filters by subject offered", func(t *testing.T) {
+		got := svc.Classes(leader, ClassFilter{Subject: "Malay"})
+
+		require.Equal(t, 1, len(got))
+		require.Equal(t, "c1A", got[0].ID)
+	})
+}
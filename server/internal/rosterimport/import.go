@@ -0,0 +1,491 @@
+// Package rosterimport onboards a school's students from the class lists its
+// existing systems export, as CSV or XLSX. Every row is validated before
+// anything is written, and rows are matched to existing students by the
+// school's own student ID, so importing the same file twice changes nothing.
+package rosterimport
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+var (
+	// ErrNotFound is returned when the school does not exist.
+	ErrNotFound = errors.New("rosterimport: school not found")
+	// ErrForbidden is returned when a teacher who is not a school leader
+	// imports a class list.
+	ErrForbidden = errors.New("rosterimport: only school leaders may import class lists")
+	// ErrInvalid wraps every problem with a file as a whole, such as a
+	// missing column. Problems with single rows are reported in Report.
+	ErrInvalid = errors.New("rosterimport: invalid file")
+)
+
+// DefaultRelationship is a guardian's relationship when the file leaves it
+// blank.
+const DefaultRelationship = "guardian"
+
+// Options controls an import.
+type Options struct {
+	// Mapping maps field names to the headers of the columns holding them,
+	// for files whose headers are not recognised.
+	Mapping map[string]string `json:"mapping"`
+	// DryRun validates the file and reports what would change without
+	// writing anything.
+	DryRun bool `json:"dry_run"`
+}
+
+// RowError is a problem with one row. Row is the row's number in the file,
+// counting the header as row 1, as a spreadsheet shows it.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the outcome of an import. The counts say what the import did,
+// or for a dry run or a file with errors, what it would do once the errors
+// are fixed. Applied is false unless every row was valid and DryRun unset.
+type Report struct {
+	DryRun    bool       `json:"dry_run"`
+	Applied   bool       `json:"applied"`
+	Rows      int        `json:"rows"`
+	Created   int        `json:"created"`
+	Updated   int        `json:"updated"`
+	Unchanged int        `json:"unchanged"`
+	Errors    []RowError `json:"errors"`
+}
+
+// Service imports class lists into a Store.
+type Service struct {
+	store *store.Store
+	audit *audit.Log
+	ids   *random.IDGenerator
+}
+
+// New returns a Service over s that names new records with ids and writes
+// applied imports to log.
+func New(s *store.Store, log *audit.Log, ids *random.IDGenerator) *Service {
+	return &Service{store: s, audit: log, ids: ids}
+}
+
+// Import imports rows, a class list with a header row, into the viewer's
+// school. Only school leaders may import.
+func (s *Service) Import(scope authz.Scope, rows [][]string, o Options) (Report, error) {
+	if !scope.SchoolWide() {
+		return Report{}, ErrForbidden
+	}
+	r, err := s.ImportSchool(scope.Teacher.SchoolID, rows, o)
+	if err != nil || !r.Applied {
+		return r, err
+	}
+	s.audit.Record(scope.Teacher.ID, audit.Event{
+		Action:     audit.ActionRosterImport,
+		TargetType: "school",
+		TargetID:   scope.Teacher.SchoolID,
+		Detail:     fmt.Sprintf("created %d, updated %d, unchanged %d", r.Created, r.Updated, r.Unchanged),
+	})
+	return r, nil
+}
+
+// ImportSchool imports rows into school schoolID on behalf of a trusted
+// caller, such as `tw import`, without checking who asked. The roster stays
+// locked from validation until the rows are written, so the students and
+// seats they were checked against cannot change in between.
+func (s *Service) ImportSchool(schoolID string, rows [][]string, o Options) (Report, error) {
+	if _, ok := s.store.School(schoolID); !ok {
+		return Report{}, ErrNotFound
+	}
+	defer s.store.LockRoster()()
+	start := slices.IndexFunc(rows, func(row []string) bool { return !blank(row) })
+	if start < 0 {
+		return Report{}, fmt.Errorf("%w: file is empty", ErrInvalid)
+	}
+	cols, err := resolve(rows[start], o.Mapping)
+	if err != nil {
+		return Report{}, err
+	}
+
+	b := &batch{
+		svc:      s,
+		schoolID: schoolID,
+		cols:     cols,
+		classes:  make(map[string]store.Class),
+		errs:     []RowError{},
+		byID:     make(map[string]int),
+		bySeat:   make(map[seat]int),
+	}
+	// Class names repeat from year to year; a class list is for the latest.
+	for _, c := range s.store.Classes(schoolID) {
+		if prev, ok := b.classes[strings.ToLower(c.Name)]; !ok || c.Year > prev.Year {
+			b.classes[strings.ToLower(c.Name)] = c
+		}
+	}
+	report := Report{DryRun: o.DryRun}
+	for i := start + 1; i < len(rows); i++ {
+		if !blank(rows[i]) {
+			b.parse(i+1, rows[i])
+			report.Rows++
+		}
+	}
+	b.checkSeats()
+	report.Errors = b.errs
+	var students []store.Student
+	var guardians []store.Guardian
+	for _, r := range b.rows {
+		st, gs, changed, err := b.merge(r)
+		if err != nil {
+			return Report{}, err
+		}
+		switch {
+		case !changed:
+			report.Unchanged++
+			continue
+		case r.existing == nil:
+			report.Created++
+		default:
+			report.Updated++
+		}
+		students = append(students, st)
+		guardians = append(guardians, gs...)
+	}
+
+	if len(report.Errors) == 0 && !o.DryRun {
+		s.store.PutRoster(students, guardians)
+		report.Applied = true
+	}
+	return report, nil
+}
+
+// row is a valid row of a class list.
+type row struct {
+	line        int
+	externalID  string
+	name        string
+	class       store.Class
+	indexNumber int
+	gender      string
+	dateOfBirth store.Date
+	subjects    []string
+	guardians   []guardianRow
+	existing    *store.Student
+}
+
+type guardianRow struct {
+	name, relationship, email, phone string
+}
+
+// seat is a student's place in a class register.
+type seat struct {
+	classID     string
+	indexNumber int
+}
+
+// batch collects the rows of one import and the errors found in them.
+type batch struct {
+	svc      *Service
+	schoolID string
+	cols     columns
+	classes  map[string]store.Class
+	rows     []row
+	errs     []RowError
+	byID     map[string]int
+	bySeat   map[seat]int
+
+	// guardians holds the school's guardians as the batch would leave them,
+	// indexed by lower-cased email and by phone. They are loaded on the
+	// first merge.
+	guardians map[string]store.Guardian
+	byEmail   map[string]string
+	byPhone   map[string]string
+}
+
+func (b *batch) fail(line int, field, format string, args ...any) {
+	b.errs = append(b.errs, RowError{Row: line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// parse validates one row, recording it when it is valid.
+func (b *batch) parse(line int, values []string) {
+	errs := len(b.errs)
+	get := func(field string) string { return b.cols.get(values, field) }
+	r := row{line: line, externalID: get(FieldStudentID), name: get(FieldName)}
+
+	if r.externalID == "" {
+		b.fail(line, FieldStudentID, "student ID is required")
+	} else if first, dup := b.byID[r.externalID]; dup {
+		b.fail(line, FieldStudentID, "student ID %s is also on row %d", r.externalID, first)
+	} else {
+		b.byID[r.externalID] = line
+	}
+	if r.name == "" {
+		b.fail(line, FieldName, "name is required")
+	}
+
+	code := get(FieldClass)
+	class, ok := b.classes[strings.ToLower(code)]
+	if code == "" {
+		b.fail(line, FieldClass, "class is required")
+	} else if !ok {
+		b.fail(line, FieldClass, "no class %q in the school", code)
+	}
+	r.class = class
+
+	n, err := strconv.Atoi(get(FieldIndexNumber))
+	if err != nil || n < 1 {
+		b.fail(line, FieldIndexNumber, "index number must be a whole number from 1")
+	}
+	r.indexNumber = n
+	if ok && n > 0 {
+		key := seat{class.ID, n}
+		if first, dup := b.bySeat[key]; dup {
+			b.fail(line, FieldIndexNumber, "index number %d in %s is also on row %d", n, class.Name, first)
+		} else {
+			b.bySeat[key] = line
+		}
+	}
+
+	switch g := strings.ToUpper(get(FieldGender)); g {
+	case "":
+	case "M", "MALE":
+		r.gender = "M"
+	case "F", "FEMALE":
+		r.gender = "F"
+	default:
+		b.fail(line, FieldGender, "gender must be M or F")
+	}
+	if v := get(FieldDateOfBirth); v != "" {
+		if r.dateOfBirth, err = parseDate(v); err != nil {
+			b.fail(line, FieldDateOfBirth, "date of birth must be YYYY-MM-DD or DD/MM/YYYY")
+		}
+	}
+	for _, subject := range strings.FieldsFunc(get(FieldSubjects), func(r rune) bool { return r == ';' || r == ',' }) {
+		if subject = strings.TrimSpace(subject); subject != "" && !slices.Contains(r.subjects, subject) {
+			r.subjects = append(r.subjects, subject)
+		}
+	}
+
+	slots := [][4]string{
+		{FieldGuardianName, FieldRelationship, FieldGuardianEmail, FieldGuardianPhone},
+		{FieldGuardian2Name, FieldRelationship2, FieldGuardian2Email, FieldGuardian2Phone},
+	}
+	for i, slot := range slots {
+		g := guardianRow{name: get(slot[0]), relationship: strings.ToLower(get(slot[1])), email: get(slot[2]), phone: get(slot[3])}
+		if g == (guardianRow{}) {
+			if i == 0 {
+				b.fail(line, slot[0], "at least one guardian is required")
+			}
+			continue
+		}
+		if g.name == "" {
+			b.fail(line, slot[0], "guardian name is required")
+		}
+		if g.email == "" && g.phone == "" {
+			b.fail(line, slot[2], "guardian needs an email or phone number")
+		}
+		if g.email != "" {
+			if a, err := mail.ParseAddress(g.email); err != nil || a.Address != g.email {
+				b.fail(line, slot[2], "%q is not an email address", g.email)
+			}
+		}
+		g.relationship = cmp.Or(g.relationship, DefaultRelationship)
+		r.guardians = append(r.guardians, g)
+	}
+
+	if len(b.errs) > errs {
+		return
+	}
+	if st, ok := b.svc.store.StudentByExternalID(b.schoolID, r.externalID); ok {
+		r.existing = &st
+	}
+	b.rows = append(b.rows, r)
+}
+
+// checkSeats reports rows that take an index number held by a student the
+// file does not move elsewhere.
+func (b *batch) checkSeats() {
+	for _, r := range b.rows {
+		for _, st := range b.svc.store.StudentsInClass(r.class.ID) {
+			if st.IndexNumber != r.indexNumber || st.ExternalID == r.externalID {
+				continue
+			}
+			if _, moved := b.byID[st.ExternalID]; st.ExternalID != "" && moved {
+				continue
+			}
+			b.fail(r.line, FieldIndexNumber, "index number %d in %s belongs to %s", r.indexNumber, r.class.Name, st.Name)
+		}
+	}
+	slices.SortStableFunc(b.errs, func(a, b RowError) int { return cmp.Compare(a.Row, b.Row) })
+}
+
+// merge returns the student r describes and the guardians to save with them,
+// keeping the existing student's ID and any values the file leaves blank.
+// Guardians are matched by email, then phone, first to the student's
+// existing ones and then to the rest of the school's, so siblings share
+// their parents; guardians the file does not list are kept.
+func (b *batch) merge(r row) (store.Student, []store.Guardian, bool, error) {
+	if b.guardians == nil {
+		b.loadGuardians()
+	}
+
+	var st store.Student
+	if r.existing != nil {
+		st = *r.existing
+		st.Subjects = slices.Clone(st.Subjects)
+	} else {
+		id, err := b.svc.ids.New()
+		if err != nil {
+			return store.Student{}, nil, false, err
+		}
+		st = store.Student{ID: id.String(), SchoolID: b.schoolID, ExternalID: r.externalID, Subjects: []string{}}
+	}
+	old := st
+	st.Name = r.name
+	st.ClassID = r.class.ID
+	st.IndexNumber = r.indexNumber
+	st.Gender = cmp.Or(r.gender, st.Gender)
+	if !r.dateOfBirth.IsZero() {
+		st.DateOfBirth = r.dateOfBirth
+	}
+	if len(r.subjects) > 0 {
+		st.Subjects = r.subjects
+	}
+
+	changed := r.existing == nil || !sameStudent(old, st)
+	links := []store.GuardianLink{}
+	var guardians []store.Guardian
+	remaining := slices.Clone(old.Guardians)
+	for _, gr := range r.guardians {
+		var link store.GuardianLink
+		ids := make([]string, len(remaining))
+		for i, l := range remaining {
+			ids[i] = l.GuardianID
+		}
+		g, i := b.match(ids, gr)
+		if i >= 0 {
+			link = remaining[i]
+			remaining = slices.Delete(remaining, i, i+1)
+		} else if g, i = b.sibling(gr, links); i < 0 {
+			id, err := b.svc.ids.New()
+			if err != nil {
+				return store.Student{}, nil, false, err
+			}
+			g = store.Guardian{ID: id.String()}
+		}
+		// Opt-outs and custody restrictions are not in the file, so they
+		// carry over from the guardian and link being updated.
+		updated := g
+		updated.Name, updated.Email, updated.Phone = gr.name, cmp.Or(gr.email, g.Email), cmp.Or(gr.phone, g.Phone)
+		if updated != g {
+			guardians = append(guardians, updated)
+			changed = true
+		}
+		b.remember(updated)
+		links = append(links, store.GuardianLink{GuardianID: g.ID, Relationship: gr.relationship, Restricted: link.Restricted})
+	}
+	st.Guardians = append(links, remaining...)
+	changed = changed || !slices.Equal(old.Guardians, st.Guardians)
+	return st, guardians, changed, nil
+}
+
+// loadGuardians fills the batch's guardians with those of the school's
+// students.
+func (b *batch) loadGuardians() {
+	b.guardians = make(map[string]store.Guardian)
+	b.byEmail = make(map[string]string)
+	b.byPhone = make(map[string]string)
+	for _, st := range b.svc.store.StudentsInSchool(b.schoolID) {
+		for _, l := range st.Guardians {
+			if _, ok := b.guardians[l.GuardianID]; ok {
+				continue
+			}
+			if g, ok := b.svc.store.Guardian(l.GuardianID); ok {
+				b.remember(g)
+			}
+		}
+	}
+}
+
+// remember records g as the batch will leave it.
+func (b *batch) remember(g store.Guardian) {
+	b.guardians[g.ID] = g
+	if e := strings.ToLower(g.Email); e != "" {
+		if _, ok := b.byEmail[e]; !ok {
+			b.byEmail[e] = g.ID
+		}
+	}
+	if g.Phone != "" {
+		if _, ok := b.byPhone[g.Phone]; !ok {
+			b.byPhone[g.Phone] = g.ID
+		}
+	}
+}
+
+// match returns the guardian among ids that gr describes, and its position,
+// or -1 when there is none.
+func (b *batch) match(ids []string, gr guardianRow) (store.Guardian, int) {
+	for _, by := range []func(store.Guardian) bool{
+		func(g store.Guardian) bool { return gr.email != "" && strings.EqualFold(g.Email, gr.email) },
+		func(g store.Guardian) bool { return gr.phone != "" && g.Phone == gr.phone },
+	} {
+		for i, id := range ids {
+			if g, ok := b.guardians[id]; ok && by(g) {
+				return g, i
+			}
+		}
+	}
+	return store.Guardian{}, -1
+}
+
+// sibling returns the guardian of another student of the school, or of an
+// earlier row, that gr describes, leaving out those already in links. The
+// int is -1 when there is none.
+func (b *batch) sibling(gr guardianRow, links []store.GuardianLink) (store.Guardian, int) {
+	var ids []string
+	if gr.email != "" {
+		ids = append(ids, b.byEmail[strings.ToLower(gr.email)])
+	}
+	if gr.phone != "" {
+		ids = append(ids, b.byPhone[gr.phone])
+	}
+	ids = slices.DeleteFunc(ids, func(id string) bool {
+		return slices.ContainsFunc(links, func(l store.GuardianLink) bool { return l.GuardianID == id })
+	})
+	return b.match(ids, gr)
+}
+
+func sameStudent(a, b store.Student) bool {
+	return a.Name == b.Name && a.ClassID == b.ClassID && a.IndexNumber == b.IndexNumber &&
+		a.Gender == b.Gender && a.DateOfBirth == b.DateOfBirth && slices.Equal(a.Subjects, b.Subjects)
+}
+
+// excelEpoch is day zero of the serial numbers spreadsheets store dates as.
+var excelEpoch = store.Date{Year: 1899, Month: time.December, Day: 30}
+
+// parseDate reads a date as YYYY-MM-DD, as DD/MM/YYYY, or as the serial
+// number a spreadsheet stores an unformatted date cell as.
+func parseDate(v string) (store.Date, error) {
+	if d, err := store.ParseDate(v); err == nil {
+		return d, nil
+	}
+	if t, err := time.Parse("2/1/2006", v); err == nil {
+		return store.DateOf(t), nil
+	}
+	if n, err := strconv.Atoi(v); err == nil && n > 0 && n < 100_000 {
+		return excelEpoch.AddDays(n), nil
+	}
+	return store.Date{}, fmt.Errorf("rosterimport: bad date %q", v)
+}
+
+func blank(row []string) bool {
+	return !slices.ContainsFunc(row, func(v string) bool { return strings.TrimSpace(v) != "" })
+}
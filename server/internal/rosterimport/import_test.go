@@ -0,0 +1,297 @@
+package rosterimport
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/audit"
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestService(t *testing.T) (*Service, *store.Store) {
+	t.Helper()
+
+	s := store.New(&store.Dataset{
+		Schools: []store.School{{ID: "s1"}},
+		Classes: []store.Class{
+			{ID: "old-1a", SchoolID: "s1", Name: "1A", Year: 2025},
+			{ID: "1a", SchoolID: "s1", Name: "1A", Year: 2026},
+			{ID: "1b", SchoolID: "s1", Name: "1B", Year: 2026},
+		},
+		Students: []store.Student{{ID: "seeded", SchoolID: "s1", ClassID: "1b", IndexNumber: 1, Name: "Nur Aisyah"}},
+	})
+	now := func() time.Time { return time.Date(2026, time.January, 2, 9, 0, 0, 0, time.UTC) }
+	ids := random.NewIDGenerator(random.New(rand.NewChaCha8([32]byte{})), now)
+	return New(s, audit.New(s, now), ids), s
+}
+
+// parse reads a CSV class list.
+func parse(t *testing.T, csv string) [][]string {
+	t.Helper()
+
+	rows, err := Read(FormatCSV, strings.NewReader(csv))
+	require.NoError(t, err)
+	return rows
+}
+
+const classList = "\xef\xbb\xbfStudent ID,Name,Class,Index No.,Gender,DOB,Subjects,Parent Name,Relationship,Parent Email,Parent Phone\n" +
+	"T1,Tan Wei Ming,1A,1,M,2013-04-02,English;Mathematics,Tan Ah Kow,Father,ahkow@example.com,91234567\n" +
+	"T2,Siti Rahmah,1a,2,Female,15/09/2013,English,Rahmah binte Ali,,,98765432\n"
+
+func TestImport(t *testing.T) {
+	t.Run("creates students and guardians", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		r, err := svc.ImportSchool("s1", parse(t, classList), Options{})
+
+		require.NoError(t, err)
+		require.True(t, r.Applied)
+		require.Equal(t, 2, r.Created)
+		st, ok := s.StudentByExternalID("s1", "T2")
+		require.True(t, ok)
+		require.Equal(t, "1a", st.ClassID)
+		require.Equal(t, "F", st.Gender)
+		require.Equal(t, store.Date{Year: 2013, Month: time.September, Day: 15}, st.DateOfBirth)
+		require.Equal(t, DefaultRelationship, st.Guardians[0].Relationship)
+		g, _ := s.Guardian(st.Guardians[0].GuardianID)
+		require.Equal(t, "98765432", g.Phone)
+	})
+
+	t.Run("importing the same file again changes nothing", func(t *testing.T) {
+		svc, s := newTestService(t)
+		_, err := svc.ImportSchool("s1", parse(t, classList), Options{})
+		require.NoError(t, err)
+		before, _ := s.StudentByExternalID("s1", "T1")
+
+		r, err := svc.ImportSchool("s1", parse(t, classList), Options{})
+
+		require.NoError(t, err)
+		require.Equal(t, 0, r.Created)
+		require.Equal(t, 0, r.Updated)
+		require.Equal(t, 2, r.Unchanged)
+		after, _ := s.StudentByExternalID("s1", "T1")
+		require.Equal(t, before.ID, after.ID)
+		require.Equal(t, before.Guardians[0], after.Guardians[0])
+		require.Equal(t, 2, len(s.StudentsInClass("1a")))
+	})
+
+	t.Run("updates matched students and keeps blank values", func(t *testing.T) {
+		svc, s := newTestService(t)
+		_, err := svc.ImportSchool("s1", parse(t, classList), Options{})
+		require.NoError(t, err)
+
+		moved := "student id,name,class,index_number,guardian_name,guardian_email\n" +
+			"T1,Tan Wei Ming,1B,2,Tan Ah Kow,ahkow@example.com\n"
+		r, err := svc.ImportSchool("s1", parse(t, moved), Options{})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, r.Updated)
+		st, _ := s.StudentByExternalID("s1", "T1")
+		require.Equal(t, "1b", st.ClassID)
+		require.Equal(t, "M", st.Gender)
+		require.Equal(t, 2, len(st.Subjects))
+		g, _ := s.Guardian(st.Guardians[0].GuardianID)
+		require.Equal(t, "91234567", g.Phone)
+	})
+
+	t.Run("keeps opt-outs and custody restrictions", func(t *testing.T) {
+		svc, s := newTestService(t)
+		_, err := svc.ImportSchool("s1", parse(t, classList), Options{})
+		require.NoError(t, err)
+		st, _ := s.StudentByExternalID("s1", "T1")
+		g, _ := s.Guardian(st.Guardians[0].GuardianID)
+		g.OptedOut = true
+		st.Guardians[0].Restricted = true
+		s.PutRoster([]store.Student{st}, []store.Guardian{g})
+
+		_, err = svc.ImportSchool("s1", parse(t, strings.Replace(classList, "91234567", "81234567", 1)), Options{})
+
+		require.NoError(t, err)
+		st, _ = s.StudentByExternalID("s1", "T1")
+		require.True(t, st.Guardians[0].Restricted)
+		g, _ = s.Guardian(st.Guardians[0].GuardianID)
+		require.Equal(t, "81234567", g.Phone)
+		require.True(t, g.OptedOut)
+	})
+
+	t.Run("siblings share their guardians", func(t *testing.T) {
+		svc, s := newTestService(t)
+		list := classList + "T3,Tan Wei Ling,1B,2,F,2015-06-01,English,Tan Ah Kow,Father,AHKOW@example.com,\n"
+
+		_, err := svc.ImportSchool("s1", parse(t, list), Options{})
+		require.NoError(t, err)
+		_, err = svc.ImportSchool("s1", parse(t, "Student ID,Name,Class,Index No.,Parent Name,Parent Phone\n"+
+			"T4,Tan Wei Jie,1B,3,Tan Ah Kow,91234567\n"), Options{})
+		require.NoError(t, err)
+
+		first, _ := s.StudentByExternalID("s1", "T1")
+		for _, id := range []string{"T3", "T4"} {
+			sibling, _ := s.StudentByExternalID("s1", id)
+			require.Equal(t, first.Guardians[0].GuardianID, sibling.Guardians[0].GuardianID)
+		}
+		distinct := make(map[string]bool)
+		for _, st := range s.StudentsInSchool("s1") {
+			for _, l := range st.Guardians {
+				distinct[l.GuardianID] = true
+			}
+		}
+		require.Equal(t, 2, len(distinct))
+	})
+
+	t.Run("dry run reports without writing", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		r, err := svc.ImportSchool("s1", parse(t, classList), Options{DryRun: true})
+
+		require.NoError(t, err)
+		require.False(t, r.Applied)
+		require.Equal(t, 2, r.Created)
+		_, ok := s.StudentByExternalID("s1", "T1")
+		require.False(t, ok)
+	})
+
+	t.Run("reports every invalid row and writes nothing", func(t *testing.T) {
+		svc, s := newTestService(t)
+		bad := "student_id,name,class,index_number,gender,guardian_name,guardian_email,guardian_phone\n" +
+			"T1,Tan Wei Ming,1A,1,M,Tan Ah Kow,ahkow@example.com,\n" +
+			"T1,Lim Jia Hui,1A,2,F,Lim Boon Huat,,91112222\n" +
+			"T3,Ravi Kumar,3Z,1,M,Kumar s/o Raj,not-an-email,91113333\n" +
+			"T4,Chloe Pereira,1A,1,X,,,\n" +
+			"T5,Muhammad Iskandar,1B,1,M,Iskandar bin Osman,osman@example.com,\n"
+
+		r, err := svc.ImportSchool("s1", parse(t, bad), Options{})
+
+		require.NoError(t, err)
+		require.False(t, r.Applied)
+		require.Equal(t, 5, r.Rows)
+		type problem struct {
+			row   int
+			field string
+		}
+		var got []problem
+		for _, e := range r.Errors {
+			got = append(got, problem{e.Row, e.Field})
+		}
+		want := []problem{
+			{3, FieldStudentID},
+			{4, FieldClass},
+			{4, FieldGuardianEmail},
+			{5, FieldIndexNumber},
+			{5, FieldGender},
+			{5, FieldGuardianName},
+			{6, FieldIndexNumber},
+		}
+		require.Equal(t, len(want), len(got))
+		for i := range want {
+			require.Equal(t, want[i], got[i])
+		}
+		require.Equal(t, 1, len(s.StudentsInSchool("s1")))
+	})
+
+	t.Run("maps columns with unrecognised headers", func(t *testing.T) {
+		svc, s := newTestService(t)
+		list := "NRIC,Pupil,Form,Reg,Contact person,Contact no\n" +
+			"T9,Goh Kai Xin,1A,5,Goh Siew Lan,90001111\n"
+
+		_, err := svc.ImportSchool("s1", parse(t, list), Options{})
+		require.True(t, errors.Is(err, ErrInvalid))
+
+		r, err := svc.ImportSchool("s1", parse(t, list), Options{Mapping: map[string]string{
+			FieldStudentID:     "NRIC",
+			FieldName:          "Pupil",
+			FieldClass:         "Form",
+			FieldIndexNumber:   "Reg",
+			FieldGuardianName:  "Contact person",
+			FieldGuardianPhone: "contact no",
+		}})
+		require.NoError(t, err)
+		require.Equal(t, 1, r.Created)
+		_, ok := s.StudentByExternalID("s1", "T9")
+		require.True(t, ok)
+	})
+
+	t.Run("overlapping imports see each other's students", func(t *testing.T) {
+		svc, s := newTestService(t)
+
+		var wg sync.WaitGroup
+		reports := make([]Report, 8)
+		errs := make([]error, len(reports))
+		for i := range reports {
+			// Each file also brings a class of its own, to keep the imports
+			// running long enough to overlap.
+			list := "Student ID,Name,Class,Index No.,Parent Name,Parent Phone\n" +
+				"T9,Goh Kai Xin,1A," + strconv.Itoa(i+1) + ",Goh Siew Lan,90001111\n"
+			for j := range 100 {
+				n := strconv.Itoa(2 + i*100 + j)
+				list += "B" + n + ",Student " + n + ",1B," + n + ",Guardian " + n + ",9000" + n + "\n"
+			}
+			rows := parse(t, list)
+			wg.Go(func() { reports[i], errs[i] = svc.ImportSchool("s1", rows, Options{}) })
+		}
+		wg.Wait()
+
+		created := 0
+		for i, r := range reports {
+			require.NoError(t, errs[i])
+			created += r.Created
+		}
+		require.Equal(t, 1+len(reports)*100, created)
+		require.Equal(t, 2+len(reports)*100, len(s.StudentsInSchool("s1")))
+	})
+
+	t.Run("only school leaders import, and imports are audited", func(t *testing.T) {
+		svc, s := newTestService(t)
+		rows := parse(t, classList)
+
+		_, err := svc.Import(authz.For(s, store.Teacher{ID: "t1", SchoolID: "s1", Role: store.RoleTeacher}), rows, Options{})
+		require.True(t, errors.Is(err, ErrForbidden))
+
+		_, err = svc.Import(authz.For(s, store.Teacher{ID: "p1", SchoolID: "s1", Role: store.RoleSchoolLeader}), rows, Options{})
+		require.NoError(t, err)
+		log := s.AuditLog()
+		require.Equal(t, 1, len(log))
+		require.Equal(t, audit.ActionRosterImport, log[0].Action)
+		require.Equal(t, "created 2, updated 0, unchanged 0", log[0].Detail)
+	})
+}
+
+func TestParseDate(t *testing.T) {
+	for in, want := range map[string]store.Date{
+		"2013-04-02": {Year: 2013, Month: time.April, Day: 2},
+		"2/4/2013":   {Year: 2013, Month: time.April, Day: 2},
+		"41366":      {Year: 2013, Month: time.April, Day: 2},
+	} {
+		got, err := parseDate(in)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	_, err := parseDate("April 2013")
+	require.Error(t, err)
+}
+
+func TestFormatOf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		head string
+		want Format
+	}{
+		{"list.CSV", "PK\x03\x04", FormatCSV},
+		{"list.xlsx", "", FormatXLSX},
+		{"upload", "PK\x03\x04", FormatXLSX},
+		{"upload", "student_id,name", FormatCSV},
+	} {
+		got, err := FormatOf(tc.name, []byte(tc.head))
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got)
+	}
+	_, err := FormatOf("list.pdf", nil)
+	require.True(t, errors.Is(err, ErrInvalid))
+}
@@ -0,0 +1,190 @@
+package rosterimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/xlsx"
+)
+
+// MaxFileSize bounds an uploaded class list, in bytes.
+const MaxFileSize = 10 << 20
+
+// Format is the file format of a class list.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var (
+	// zipMagic starts every XLSX file, which is a zip archive.
+	zipMagic = []byte("PK\x03\x04")
+	// bom is the UTF-8 byte order mark spreadsheet programs start CSV
+	// exports with.
+	bom = []byte("\xef\xbb\xbf")
+)
+
+// FormatOf returns the format of a file named name that starts with head,
+// going by its extension and, failing that, its content.
+func FormatOf(name string, head []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	case "", ".txt":
+		if bytes.HasPrefix(head, zipMagic) {
+			return FormatXLSX, nil
+		}
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %s is neither CSV nor XLSX", ErrInvalid, name)
+}
+
+// Read returns the rows of a class list in format f.
+func Read(f Format, r io.Reader) ([][]string, error) {
+	b, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxFileSize {
+		return nil, fmt.Errorf("%w: file is larger than %d MB", ErrInvalid, MaxFileSize>>20)
+	}
+
+	switch f {
+	case FormatCSV:
+		cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, bom)))
+		cr.FieldsPerRecord = -1
+		rows, err := cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return rows, nil
+	case FormatXLSX:
+		rows, err := xlsx.ReadRows(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalid, f)
+}
+
+// Field is a column a class list may have.
+type Field struct {
+	Name     string
+	Required bool
+	// Aliases are other headers that name the column in common exports.
+	Aliases []string
+}
+
+// Field names.
+const (
+	FieldStudentID      = "student_id"
+	FieldName           = "name"
+	FieldClass          = "class"
+	FieldIndexNumber    = "index_number"
+	FieldGender         = "gender"
+	FieldDateOfBirth    = "date_of_birth"
+	FieldSubjects       = "subjects"
+	FieldGuardianName   = "guardian_name"
+	FieldRelationship   = "guardian_relationship"
+	FieldGuardianEmail  = "guardian_email"
+	FieldGuardianPhone  = "guardian_phone"
+	FieldGuardian2Name  = "guardian2_name"
+	FieldRelationship2  = "guardian2_relationship"
+	FieldGuardian2Email = "guardian2_email"
+	FieldGuardian2Phone = "guardian2_phone"
+)
+
+// Fields are the columns a class list may have. Columns with other headers
+// are ignored.
+var Fields = []Field{
+	{Name: FieldStudentID, Required: true, Aliases: []string{"student_no", "admission_no", "admission_number"}},
+	{Name: FieldName, Required: true, Aliases: []string{"student_name", "full_name"}},
+	{Name: FieldClass, Required: true, Aliases: []string{"form_class", "class_code"}},
+	{Name: FieldIndexNumber, Required: true, Aliases: []string{"index", "index_no", "register_no"}},
+	{Name: FieldGender, Aliases: []string{"sex"}},
+	{Name: FieldDateOfBirth, Aliases: []string{"dob", "birth_date"}},
+	{Name: FieldSubjects},
+	{Name: FieldGuardianName, Required: true, Aliases: []string{"parent_name"}},
+	{Name: FieldRelationship, Aliases: []string{"relationship"}},
+	{Name: FieldGuardianEmail, Aliases: []string{"parent_email"}},
+	{Name: FieldGuardianPhone, Aliases: []string{"parent_phone", "parent_contact"}},
+	{Name: FieldGuardian2Name},
+	{Name: FieldRelationship2},
+	{Name: FieldGuardian2Email},
+	{Name: FieldGuardian2Phone},
+}
+
+// columns maps field names to their column in a file.
+type columns map[string]int
+
+// resolve finds each field's column in header. A field named in mapping
+// takes the column with that header; other fields take the column headed by
+// their name or one of their aliases. Headers are compared ignoring case,
+// spaces and punctuation.
+func resolve(header []string, mapping map[string]string) (columns, error) {
+	at := make(map[string]int, len(header))
+	for i, h := range header {
+		if k := normalise(h); k != "" {
+			if _, dup := at[k]; !dup {
+				at[k] = i
+			}
+		}
+	}
+
+	for name := range mapping {
+		if !slices.ContainsFunc(Fields, func(f Field) bool { return f.Name == name }) {
+			return nil, fmt.Errorf("%w: mapping names unknown field %q", ErrInvalid, name)
+		}
+	}
+
+	cols := make(columns)
+	for _, f := range Fields {
+		if h, ok := mapping[f.Name]; ok {
+			i, ok := at[normalise(h)]
+			if !ok {
+				return nil, fmt.Errorf("%w: no column %q for %s", ErrInvalid, h, f.Name)
+			}
+			cols[f.Name] = i
+			continue
+		}
+		for _, k := range append([]string{f.Name}, f.Aliases...) {
+			if i, ok := at[k]; ok {
+				cols[f.Name] = i
+				break
+			}
+		}
+		if _, ok := cols[f.Name]; !ok && f.Required {
+			return nil, fmt.Errorf("%w: no column for %s", ErrInvalid, f.Name)
+		}
+	}
+	return cols, nil
+}
+
+// get returns the trimmed value of field in row, or "" when the file has no
+// such column.
+func (c columns) get(row []string, field string) string {
+	i, ok := c[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// normalise lowercases h and joins its words with underscores, so that
+// "Date of Birth", "date-of-birth" and "DATE_OF_BIRTH" compare equal.
+func normalise(h string) string {
+	words := strings.FieldsFunc(strings.ToLower(h), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(words, "_")
+}
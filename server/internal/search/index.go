@@ -0,0 +1,249 @@
+// Package search finds students as a teacher types into a search box, by
+// name, index number or class. Terms match in any order, as prefixes of
+// what is indexed and with one typo, and the closest matches rank first.
+package search
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/roster"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+)
+
+// ErrInvalid is returned for queries with nothing to search for.
+var ErrInvalid = errors.New("search: query has no letters or digits")
+
+// Match is how well a query term matched a student's term.
+type Match int
+
+const (
+	MatchNone Match = iota
+	// MatchFuzzy is a match with one typo.
+	MatchFuzzy
+	// MatchPrefix is a query term the student's term starts with.
+	MatchPrefix
+	MatchExact
+)
+
+// Index is a search index over the students of a Store. It catches up with
+// roster changes on each search, so it never needs rebuilding.
+type Index struct {
+	store  *store.Store
+	roster *roster.Service
+
+	mu sync.Mutex
+	// seen is the number of roster changes applied.
+	seen      int
+	docs      map[string]doc
+	postings  map[string]map[string]struct{}
+	deletions map[string][]string
+	// sorted holds the keys of postings in order, for prefix lookups. It is
+	// rebuilt on the next search once a term is added or removed.
+	sorted []string
+	dirty  bool
+}
+
+// doc is an indexed student.
+type doc struct {
+	schoolID string
+	name     string
+	terms    []string
+}
+
+// New returns an Index over the students of s.
+func New(s *store.Store) *Index {
+	x := &Index{
+		store:     s,
+		roster:    roster.New(s),
+		docs:      make(map[string]doc),
+		postings:  make(map[string]map[string]struct{}),
+		deletions: make(map[string][]string),
+	}
+	// Changes made while indexing are applied again by the first search.
+	_, x.seen = s.RosterChanges(0)
+	for _, school := range s.Schools() {
+		for _, st := range s.StudentsInSchool(school.ID) {
+			x.put(st)
+		}
+	}
+	return x
+}
+
+// Search returns up to limit students visible to the viewer that match every
+// term of query, best match first. Exact matches outrank prefix matches,
+// which outrank matches with a typo; ties go to the shorter name, since it
+// has less that the query did not match.
+func (x *Index) Search(scope authz.Scope, query string, limit int) ([]roster.StudentSummary, error) {
+	qterms := terms(query)
+	if len(qterms) == 0 {
+		return nil, ErrInvalid
+	}
+
+	x.mu.Lock()
+	x.catchUp()
+	scores := x.match(qterms[0], scope.Teacher.SchoolID)
+	for _, t := range qterms[1:] {
+		if len(scores) == 0 {
+			break
+		}
+		next := x.match(t, scope.Teacher.SchoolID)
+		for id, score := range scores {
+			if m, ok := next[id]; ok {
+				scores[id] = score + m
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	type hit struct {
+		id, name string
+		score    int
+	}
+	hits := make([]hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, hit{id, x.docs[id].name, score})
+	}
+	x.mu.Unlock()
+
+	slices.SortFunc(hits, func(a, b hit) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(len(a.name), len(b.name)),
+			strings.Compare(a.name, b.name),
+			strings.Compare(a.id, b.id),
+		)
+	})
+	out := []roster.StudentSummary{}
+	for _, h := range hits {
+		if len(out) == limit {
+			break
+		}
+		if summary, err := x.roster.Student(scope, h.id); err == nil {
+			out = append(out, summary)
+		}
+	}
+	return out, nil
+}
+
+// match returns the students of school schoolID with a term matching t and
+// the score of their best matching term.
+func (x *Index) match(t, schoolID string) map[string]int {
+	scores := make(map[string]int)
+	add := func(term string, m Match) {
+		for id := range x.postings[term] {
+			if x.docs[id].schoolID == schoolID && scores[id] < int(m) {
+				scores[id] = int(m)
+			}
+		}
+	}
+
+	add(t, MatchExact)
+	if isNumber(t) {
+		return scores
+	}
+	i, _ := slices.BinarySearch(x.sorted, t)
+	for _, term := range x.sorted[i:] {
+		if !strings.HasPrefix(term, t) {
+			break
+		}
+		if term != t {
+			add(term, MatchPrefix)
+		}
+	}
+	if len(t) < minFuzzyLen {
+		return scores
+	}
+	for _, d := range deletions(t) {
+		for _, term := range x.deletions[d] {
+			if term != t && withinOneEdit(t, term) {
+				add(term, MatchFuzzy)
+			}
+		}
+	}
+	return scores
+}
+
+// catchUp indexes the students changed since the last search.
+func (x *Index) catchUp() {
+	ids, next := x.store.RosterChanges(x.seen)
+	for _, id := range ids {
+		if st, ok := x.store.Student(id); ok {
+			x.put(st)
+		}
+	}
+	x.seen = next
+	if x.dirty {
+		x.sorted = slices.Sorted(maps.Keys(x.postings))
+		x.dirty = false
+	}
+}
+
+// put indexes st, replacing what was indexed for it before.
+func (x *Index) put(st store.Student) {
+	x.remove(st.ID)
+
+	ts := nameTerms(st.Name)
+	if c, ok := x.store.Class(st.ClassID); ok {
+		ts = append(ts, terms(c.Name)...)
+	}
+	if st.IndexNumber > 0 {
+		ts = append(ts, strconv.Itoa(st.IndexNumber))
+	}
+	slices.Sort(ts)
+	ts = slices.Compact(ts)
+
+	x.docs[st.ID] = doc{schoolID: st.SchoolID, name: strings.ToLower(st.Name), terms: ts}
+	for _, t := range ts {
+		ids, ok := x.postings[t]
+		if !ok {
+			ids = make(map[string]struct{})
+			x.postings[t] = ids
+			x.addTerm(t)
+		}
+		ids[st.ID] = struct{}{}
+	}
+}
+
+func (x *Index) remove(id string) {
+	d, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	delete(x.docs, id)
+	for _, t := range d.terms {
+		delete(x.postings[t], id)
+		if len(x.postings[t]) == 0 {
+			delete(x.postings, t)
+			x.removeTerm(t)
+		}
+	}
+}
+
+func (x *Index) addTerm(t string) {
+	x.dirty = true
+	if isNumber(t) || len(t) < minFuzzyLen-1 {
+		return
+	}
+	for _, d := range deletions(t) {
+		x.deletions[d] = append(x.deletions[d], t)
+	}
+}
+
+func (x *Index) removeTerm(t string) {
+	x.dirty = true
+	for _, d := range deletions(t) {
+		terms := slices.DeleteFunc(x.deletions[d], func(v string) bool { return v == t })
+		if len(terms) == 0 {
+			delete(x.deletions, d)
+		} else {
+			x.deletions[d] = terms
+		}
+	}
+}
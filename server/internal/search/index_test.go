@@ -0,0 +1,182 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/authz"
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestStore() *store.Store {
+	return store.New(&store.Dataset{
+		Schools: []store.School{{ID: "s1"}, {ID: "s2"}},
+		Classes: []store.Class{
+			{ID: "c1", SchoolID: "s1", Name: "1A", FormTeacherID: "form"},
+			{ID: "c2", SchoolID: "s1", Name: "2B"},
+			{ID: "c3", SchoolID: "s2", Name: "1A"},
+		},
+		Teachers: []store.Teacher{
+			{ID: "lead", SchoolID: "s1", Role: store.RoleSchoolLeader},
+			{ID: "form", SchoolID: "s1", Role: store.RoleTeacher},
+		},
+		Students: []store.Student{
+			{ID: "tan", SchoolID: "s1", ClassID: "c1", IndexNumber: 1, Name: "Tan Wei Ming"},
+			{ID: "tanjh", SchoolID: "s1", ClassID: "c2", IndexNumber: 12, Name: "Tan Jia Hui"},
+			{ID: "tang", SchoolID: "s1", ClassID: "c2", IndexNumber: 5, Name: "Tang Hui Ling"},
+			{ID: "siti", SchoolID: "s1", ClassID: "c2", IndexNumber: 2, Name: "Siti Nurhaliza binte Ismail"},
+			{ID: "ravi", SchoolID: "s1", ClassID: "c1", IndexNumber: 3, Name: "Ravi s/o Subramaniam"},
+			{ID: "chloe", SchoolID: "s1", ClassID: "c1", IndexNumber: 4, Name: "Chloe D'Souza"},
+			{ID: "other", SchoolID: "s2", ClassID: "c3", IndexNumber: 1, Name: "Tan Wei Ming"},
+		},
+	})
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestStore()
+	x := New(s)
+	lead, _ := s.Teacher("lead")
+	leader := authz.For(s, lead)
+
+	search := func(t *testing.T, scope authz.Scope, query string) []string {
+		t.Helper()
+		got, err := x.Search(scope, query, 10)
+		require.NoError(t, err)
+		out := []string{}
+		for _, st := range got {
+			out = append(out, st.ID)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"matches prefixes in any order", "wei ta", []string{"tan"}},
+		{"matches a given name run together", "weiming", []string{"tan"}},
+		{"ranks exact matches before prefixes, then shorter names", "tan", []string{"tanjh", "tan", "tang"}},
+		{"tolerates a typo", "nurhalzia", []string{"siti"}},
+		{"ignores connectors and punctuation", "ravi s/o", []string{"ravi"}},
+		{"matches across apostrophes", "dsouza", []string{"chloe"}},
+		{"matches class and index number", "1a 1", []string{"tan"}},
+		{"matches index numbers exactly", "1", []string{"tan"}},
+		{"finds nothing", "xavier", []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := search(t, leader, tc.query)
+
+			require.Equal(t, fmt.Sprint(tc.want), fmt.Sprint(got))
+		})
+	}
+
+	t.Run("only returns students the viewer may see", func(t *testing.T) {
+		form, _ := s.Teacher("form")
+
+		got := search(t, authz.For(s, form), "tan")
+
+		require.Equal(t, fmt.Sprint([]string{"tan"}), fmt.Sprint(got))
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		got, err := x.Search(leader, "1a", 2)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, len(got))
+	})
+
+	t.Run("rejects queries without terms", func(t *testing.T) {
+		_, err := x.Search(leader, " -/ ", 10)
+
+		require.True(t, errors.Is(err, ErrInvalid))
+	})
+
+	t.Run("follows roster changes", func(t *testing.T) {
+		s.PutRoster([]store.Student{
+			{ID: "tan", SchoolID: "s1", ClassID: "c2", IndexNumber: 1, Name: "Tan Wei Ming"},
+			{ID: "goh", SchoolID: "s1", ClassID: "c1", IndexNumber: 5, Name: "Goh Kai Xin"},
+		}, nil)
+
+		require.Equal(t, fmt.Sprint([]string{"goh"}), fmt.Sprint(search(t, leader, "kai")))
+		require.Equal(t, fmt.Sprint([]string{"tan"}), fmt.Sprint(search(t, leader, "wei 2b")))
+		require.Equal(t, fmt.Sprint([]string{}), fmt.Sprint(search(t, leader, "wei 1a")))
+	})
+}
+
+func TestWithinOneEdit(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"tan", "tan", true},
+		{"tan", "tn", true},
+		{"tan", "tang", true},
+		{"tan", "ten", true},
+		{"tan", "tna", true},
+		{"tan", "nat", false},
+		{"tan", "tango", false},
+		{"aisyah", "aisha", false},
+	} {
+		require.Equal(t, tc.want, withinOneEdit(tc.a, tc.b))
+		require.Equal(t, tc.want, withinOneEdit(tc.b, tc.a))
+	}
+}
+
+// largeSchool returns a store holding one school of 100,000 students, the
+// size the index is built for, and the scope of its school leader. Names are
+// drawn from a fixed pool, so every run searches the same school.
+func largeSchool(b *testing.B) (*store.Store, authz.Scope) {
+	b.Helper()
+
+	surnames := []string{"Tan", "Lim", "Lee", "Ng", "Ong", "Wong", "Goh", "Chua", "Koh", "Teo", "Nur", "Muhammad", "Siti", "Ravi", "Kumar", "D'Souza"}
+	given := []string{"Wei", "Ming", "Jia", "Hui", "Ling", "Kai", "Xin", "Aisyah", "Farhan", "Hafiz", "Priya", "Arjun", "Chloe", "Ethan", "Zhi", "Hao", "Yi", "En"}
+	rng := rand.New(rand.NewPCG(1, 2))
+	pick := func(names []string) string { return names[rng.IntN(len(names))] }
+
+	leader := store.Teacher{ID: "lead", SchoolID: "s1", Role: store.RoleSchoolLeader}
+	ds := &store.Dataset{Schools: []store.School{{ID: "s1"}}, Teachers: []store.Teacher{leader}}
+	for level := 1; level <= 6; level++ {
+		for class := range 25 {
+			c := store.Class{ID: fmt.Sprintf("c%d-%d", level, class), SchoolID: "s1", Name: fmt.Sprintf("%d%c", level, 'A'+class)}
+			ds.Classes = append(ds.Classes, c)
+			for i := range 667 {
+				ds.Students = append(ds.Students, store.Student{
+					ID:          fmt.Sprintf("%s-%d", c.ID, i),
+					SchoolID:    "s1",
+					ClassID:     c.ID,
+					IndexNumber: i + 1,
+					Name:        pick(surnames) + " " + pick(given) + " " + pick(given),
+				})
+			}
+		}
+	}
+	s := store.New(ds)
+	return s, authz.For(s, leader)
+}
+
+func BenchmarkNew(b *testing.B) {
+	s, _ := largeSchool(b)
+
+	for b.Loop() {
+		New(s)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	s, scope := largeSchool(b)
+	x := New(s)
+
+	for _, query := range []string{"t", "tan wei", "tna wie ming", "nur aisyah 3"} {
+		b.Run(query, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := x.Search(scope, query, 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// connectors are the words joining a name to a parent's name. They are in
+// so many names that matching them says nothing about the student.
+var connectors = map[string]bool{
+	"bin": true, "binte": true, "binti": true, "bte": true, "bt": true,
+	"s/o": true, "d/o": true, "a/l": true, "a/p": true,
+}
+
+var apostrophes = strings.NewReplacer("'", "", "’", "")
+
+// terms splits s into lower-case terms of letters and digits, dropping
+// connectors. Apostrophes are removed rather than split on, so "D'Souza" is
+// the single term "dsouza".
+func terms(s string) []string {
+	var out []string
+	for _, word := range strings.Fields(strings.ToLower(s)) {
+		if connectors[word] {
+			continue
+		}
+		word = apostrophes.Replace(word)
+		out = append(out, strings.FieldsFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return out
+}
+
+// nameTerms returns the terms of a name together with each adjacent pair
+// run together, so "Tan Wei Ming" is also found as "weiming" and
+// "De Souza" as "desouza".
+func nameTerms(name string) []string {
+	ts := terms(name)
+	for i := range len(ts) - 1 {
+		ts = append(ts, ts[i]+ts[i+1])
+	}
+	return ts
+}
+
+// isNumber reports whether t is all digits. Numbers only match exactly:
+// index number 1 should not match a search for 12, or the other way round.
+func isNumber(t string) bool {
+	return strings.IndexFunc(t, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// minFuzzyLen is the shortest term matched with a typo. Shorter terms have
+// too many neighbours for a typo match to mean anything.
+const minFuzzyLen = 3
+
+// deletions returns t and every string made by deleting one rune from it.
+// Two terms within one edit of each other share at least one deletion.
+func deletions(t string) []string {
+	out := []string{t}
+	for i, r := range t {
+		out = append(out, t[:i]+t[i+utf8.RuneLen(r):])
+	}
+	return out
+}
+
+// withinOneEdit reports whether a can be turned into b by at most one
+// insertion, deletion, substitution or transposition of adjacent runes.
+func withinOneEdit(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(ra) && ra[i] == rb[i] {
+		i++
+	}
+	if i == len(ra) {
+		return true
+	}
+	if len(ra) < len(rb) {
+		return string(ra[i:]) == string(rb[i+1:])
+	}
+	if string(ra[i+1:]) == string(rb[i+1:]) {
+		return true
+	}
+	return i+1 < len(ra) && ra[i] == rb[i+1] && ra[i+1] == rb[i] && string(ra[i+2:]) == string(rb[i+2:])
+}
@@ -0,0 +1,42 @@
+package seed
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/token"
+)
+
+// Credential is the plaintext API key minted for a teacher.
+type Credential struct {
+	TeacherID string
+	Email     string
+	Role      store.Role
+	Token     string
+}
+
+// MintAPIKeys issues one API key per teacher in ds, which must have been
+// generated from cfg, appending the key hashes to ds and returning the
+// plaintext tokens. Keys are derived from cfg.Seed, so they are reproducible
+// and must only ever be used with development data.
+func MintAPIKeys(ds *store.Dataset, cfg Config) ([]Credential, error) {
+	// A distinct key keeps API keys independent of the dataset stream.
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], cfg.Seed)
+	copy(key[8:], "tw seed api keys")
+	g := random.New(rand.NewChaCha8(key))
+	created := cfg.Start.Time()
+
+	creds := make([]Credential, 0, len(ds.Teachers))
+	for _, t := range ds.Teachers {
+		tok, err := token.Generate(g, token.KindAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		ds.APIKeys = append(ds.APIKeys, store.APIKey{Hash: token.Hash(tok), TeacherID: t.ID, CreatedAt: created})
+		creds = append(creds, Credential{TeacherID: t.ID, Email: t.Email, Role: t.Role, Token: tok})
+	}
+	return creds, nil
+}
@@ -0,0 +1,116 @@
+package seed
+
+import "github.com/String-sg/teacher-workspace/server/internal/store"
+
+// ethnicity selects the naming convention and mother tongue of a family.
+type ethnicity int
+
+const (
+	chinese ethnicity = iota
+	malay
+	indian
+	eurasian
+)
+
+// ethnicityWeights approximates the resident population mix, in percent.
+var ethnicityWeights = [...]int{
+	chinese:  74,
+	malay:    14,
+	indian:   9,
+	eurasian: 3,
+}
+
+var (
+	chineseSurnames = []string{
+		"Tan", "Lim", "Lee", "Ng", "Ong", "Wong", "Goh", "Chua", "Chan", "Koh",
+		"Teo", "Ang", "Yeo", "Tay", "Ho", "Low", "Toh", "Sim", "Chong", "Chia",
+	}
+	chineseGivenSyllables = []string{
+		"Wei", "Ming", "Jia", "Hui", "Xin", "Yi", "Jun", "Kai", "Zhi", "Hao",
+		"Li", "Ting", "En", "Yu", "Rui", "Qi", "Shu", "Xuan", "Jie", "Hong",
+		"Kok", "Leong", "Mei", "Ling", "Siew", "Boon", "Choon", "Seng",
+	}
+
+	malayMaleNames = []string{
+		"Muhammad Hafiz", "Ahmad Faris", "Danial", "Muhammad Irfan", "Haziq",
+		"Amir", "Rizwan", "Muhammad Aidil", "Iskandar", "Firdaus",
+	}
+	malayFemaleNames = []string{
+		"Nur Aisyah", "Siti Nurhaliza", "Nurul Huda", "Farah", "Alya",
+		"Nur Sofea", "Siti Aminah", "Nabilah", "Aqilah", "Syafiqah",
+	}
+	malayFatherNames = []string{
+		"Ismail", "Rahman", "Abdullah", "Hassan", "Osman", "Yusof", "Salleh",
+		"Ibrahim", "Rashid", "Kamal",
+	}
+
+	indianMaleNames = []string{
+		"Arjun", "Rahul", "Vikram", "Karthik", "Suresh", "Dinesh", "Ravi",
+		"Ganesh", "Prakash", "Naveen",
+	}
+	indianFemaleNames = []string{
+		"Priya", "Kavya", "Divya", "Anjali", "Meera", "Lakshmi", "Nandhini",
+		"Revathi", "Shalini", "Deepa",
+	}
+	indianFatherNames = []string{
+		"Rajendran", "Kumar", "Subramaniam", "Krishnan", "Muthu", "Pillai",
+		"Nair", "Selvam", "Ramasamy", "Govindasamy",
+	}
+
+	eurasianMaleNames   = []string{"Daniel", "Ryan", "Nathan", "Michael", "Joshua", "Adrian"}
+	eurasianFemaleNames = []string{"Sarah", "Chloe", "Emma", "Rachel", "Natalie", "Anne"}
+	eurasianSurnames    = []string{"Pereira", "De Souza", "Rodrigues", "Fernandez", "Oliveira", "Scully"}
+)
+
+var schoolNames = []string{
+	"Northbrook", "Riverside", "Kingfisher", "Bukit Cendana", "Seaview",
+	"Jurong Heights", "Tampines Grove", "Woodlands Ridge", "Marine Vale", "Bedok Park",
+}
+
+var (
+	coreSubjects = []string{"English", "Mathematics", "Science", "Humanities"}
+	// motherTongues are the mother tongue subjects, indexed by ethnicity.
+	motherTongues = [...]string{
+		chinese:  "Chinese",
+		malay:    "Malay",
+		indian:   "Tamil",
+		eurasian: "Malay",
+	}
+	// homeLanguages are the languages households may prefer posts in,
+	// indexed by ethnicity. Eurasian households mostly speak English.
+	homeLanguages = [...]store.Language{
+		chinese:  store.LanguageChinese,
+		malay:    store.LanguageMalay,
+		indian:   store.LanguageTamil,
+		eurasian: store.LanguageEnglish,
+	}
+)
+
+var absenceReasons = map[string][]string{
+	"absent":         {"Family matter", "Unwell, no MC", "Overseas travel"},
+	"late":           {"Overslept", "Bus delay", "Medical appointment"},
+	"mc":             {"Fever", "Gastroenteritis", "Dental surgery", "Sprained ankle"},
+	"official_leave": {"National School Games", "Representing school at competition", "CCA camp"},
+}
+
+// ccaCatalogue lists co-curricular activities by category.
+var ccaCatalogue = []struct {
+	category string
+	names    []string
+}{
+	{category: "Sports", names: []string{"Basketball", "Badminton", "Football", "Netball", "Table Tennis", "Track and Field"}},
+	{category: "Uniformed Groups", names: []string{"National Cadet Corps", "National Police Cadet Corps", "Scouts", "St John Brigade"}},
+	{category: "Performing Arts", names: []string{"Chinese Orchestra", "Concert Band", "Malay Dance", "Indian Dance", "Choir"}},
+	{category: "Clubs and Societies", names: []string{"Robotics Club", "Media Club", "Debate Society", "Environmental Club"}},
+}
+
+var postTitles = []string{
+	"Term Calendar and Key Dates",
+	"Learning Journey to the Science Centre",
+	"Parent-Teacher Conference",
+	"Sports Day Arrangements",
+	"Reminder: School Uniform and Grooming",
+	"Mid-Year Examination Timetable",
+	"Cyber Wellness Talk for Parents",
+	"CCA Open House",
+}
@@ -0,0 +1,662 @@
+// Package seed generates deterministic synthetic school data for development.
+// The same Config always produces the same Dataset, so screens can be built
+// and tested against realistic data without touching real student records.
+package seed
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+// maxClassesPerLevel keeps class names within "1A" to "1Z".
+const maxClassesPerLevel = 26
+
+// sgt is Singapore Standard Time, used for every generated timestamp.
+var sgt = time.FixedZone("SGT", 8*60*60)
+
+// Config controls the size and shape of the generated dataset.
+type Config struct {
+	// Seed selects the dataset; equal configs always yield equal datasets.
+	Seed uint64
+
+	Schools          int
+	LevelsPerSchool  int
+	ClassesPerLevel  int
+	StudentsPerClass int
+
+	// SchoolDays is the number of weekdays of attendance recorded from Start.
+	SchoolDays int
+	// Terms and AssessmentsPerTerm set the assessments per subject class.
+	Terms              int
+	AssessmentsPerTerm int
+	PostsPerTeacher    int
+
+	// Start is the first school day of the academic year.
+	Start store.Date
+}
+
+// DefaultConfig returns a configuration for a single mid-sized secondary
+// school with one term's worth of attendance.
+func DefaultConfig() Config {
+	return Config{
+		Seed:               1,
+		Schools:            1,
+		LevelsPerSchool:    4,
+		ClassesPerLevel:    4,
+		StudentsPerClass:   30,
+		SchoolDays:         40,
+		Terms:              2,
+		AssessmentsPerTerm: 2,
+		PostsPerTeacher:    1,
+		Start:              store.Date{Year: 2026, Month: time.January, Day: 5},
+	}
+}
+
+// Validate reports whether c describes a dataset that can be generated.
+func (c Config) Validate() error {
+	switch {
+	case c.Schools < 1:
+		return errors.New("seed: schools must be at least 1")
+	case c.LevelsPerSchool < 1:
+		return errors.New("seed: levels per school must be at least 1")
+	case c.ClassesPerLevel < 1 || c.ClassesPerLevel > maxClassesPerLevel:
+		return fmt.Errorf("seed: classes per level must be between 1 and %d", maxClassesPerLevel)
+	case c.StudentsPerClass < 0:
+		return errors.New("seed: students per class must be non-negative")
+	case c.SchoolDays < 0:
+		return errors.New("seed: school days must be non-negative")
+	case c.Terms < 0 || c.AssessmentsPerTerm < 0:
+		return errors.New("seed: terms and assessments per term must be non-negative")
+	case c.PostsPerTeacher < 0:
+		return errors.New("seed: posts per teacher must be non-negative")
+	case c.Start.IsZero():
+		return errors.New("seed: start date is required")
+	}
+	return nil
+}
+
+// Generate builds a synthetic dataset described by cfg.
+func Generate(cfg Config) (*store.Dataset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], cfg.Seed)
+	src := rand.NewChaCha8(key)
+
+	// The clock ticks one millisecond per identifier from the start of the
+	// year, so identifiers sort in generation order.
+	clock := cfg.Start.Time()
+	now := func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	g := &generator{
+		cfg:    cfg,
+		ids:    random.NewIDGenerator(random.New(src), now),
+		rng:    rand.New(src),
+		emails: make(map[string]int),
+		days:   schoolDays(cfg.Start, cfg.SchoolDays),
+	}
+	for n := range cfg.Schools {
+		g.school(n)
+	}
+	return &g.ds, nil
+}
+
+// schoolDays returns the first n weekdays on or after start.
+func schoolDays(start store.Date, n int) []store.Date {
+	days := make([]store.Date, 0, n)
+	for d := start; len(days) < n; d = d.AddDays(1) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// generator accumulates a dataset. Identifiers and every random choice are
+// drawn from one seeded ChaCha8 stream, in a fixed order, so the output
+// depends only on the config.
+type generator struct {
+	cfg    Config
+	ids    *random.IDGenerator
+	rng    *rand.Rand
+	ds     store.Dataset
+	emails map[string]int
+	days   []store.Date
+}
+
+// family is a household of guardians whose children share a naming convention.
+type family struct {
+	eth          ethnicity
+	surname      string
+	motherTongue string
+	guardians    []store.GuardianLink
+}
+
+func (g *generator) id() string {
+	id, err := g.ids.New()
+	if err != nil {
+		// ChaCha8 never fails to read.
+		panic(err)
+	}
+	return id.String()
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rng.IntN(len(values))]
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+func (g *generator) school(n int) {
+	name := schoolNames[n%len(schoolNames)] + " Secondary School"
+	if n >= len(schoolNames) {
+		name = fmt.Sprintf("%s %d", name, n/len(schoolNames)+1)
+	}
+	school := store.School{ID: g.id(), Code: fmt.Sprintf("%04d", 3001+n), Name: name}
+	g.ds.Schools = append(g.ds.Schools, school)
+
+	g.teacher(school.ID, store.RoleSchoolLeader)
+
+	var classes []store.Class
+	for l := 1; l <= g.cfg.LevelsPerSchool; l++ {
+		level := store.Level{ID: g.id(), SchoolID: school.ID, Number: l, Name: fmt.Sprintf("Secondary %d", l)}
+		g.ds.Levels = append(g.ds.Levels, level)
+
+		levelClasses := make([]store.Class, g.cfg.ClassesPerLevel)
+		for c := range levelClasses {
+			formTeacher := g.teacher(school.ID, store.RoleTeacher)
+			levelClasses[c] = store.Class{
+				ID:            g.id(),
+				SchoolID:      school.ID,
+				LevelID:       level.ID,
+				Name:          fmt.Sprintf("%d%c", l, 'A'+c),
+				Year:          g.cfg.Start.Year,
+				FormTeacherID: formTeacher.ID,
+			}
+			g.teach(formTeacher.ID, levelClasses[c].ID, coreSubjects[c%len(coreSubjects)])
+		}
+		g.staffLevel(school.ID, levelClasses)
+		classes = append(classes, levelClasses...)
+	}
+	g.ds.Classes = append(g.ds.Classes, classes...)
+
+	students := g.enrol(school.ID, classes)
+	g.attendance(students)
+	g.assessments(school.ID, students)
+	g.posts(school.ID)
+	g.ccas(students)
+}
+
+// staffLevel assigns subject teachers for every subject a level's form
+// teachers do not already teach. Each core subject teacher takes up to three
+// classes; one teacher per mother tongue covers the whole level.
+func (g *generator) staffLevel(schoolID string, classes []store.Class) {
+	const classesPerTeacher = 3
+
+	for _, subject := range coreSubjects {
+		var teacher store.Teacher
+		load := classesPerTeacher
+		for c, class := range classes {
+			if coreSubjects[c%len(coreSubjects)] == subject {
+				continue
+			}
+			if load == classesPerTeacher {
+				teacher = g.teacher(schoolID, store.RoleTeacher)
+				load = 0
+			}
+			g.teach(teacher.ID, class.ID, subject)
+			load++
+		}
+	}
+
+	var seen []string
+	for _, subject := range motherTongues {
+		if slices.Contains(seen, subject) {
+			continue
+		}
+		seen = append(seen, subject)
+
+		teacher := g.teacher(schoolID, store.RoleTeacher)
+		for _, class := range classes {
+			g.teach(teacher.ID, class.ID, subject)
+		}
+	}
+}
+
+func (g *generator) teach(teacherID, classID, subject string) {
+	g.ds.Teaching = append(g.ds.Teaching, store.Teaching{TeacherID: teacherID, ClassID: classID, Subject: subject})
+}
+
+func (g *generator) teacher(schoolID string, role store.Role) store.Teacher {
+	name := g.adultName(g.ethnicity(), g.rng.IntN(2) == 0)
+	t := store.Teacher{
+		ID:       g.id(),
+		SchoolID: schoolID,
+		Name:     name,
+		Email:    g.email(name, "schools.example.sg"),
+		Role:     role,
+	}
+	g.ds.Teachers = append(g.ds.Teachers, t)
+	return t
+}
+
+func (g *generator) ethnicity() ethnicity {
+	n := g.rng.IntN(100)
+	for e, w := range ethnicityWeights {
+		if n < w {
+			return ethnicity(e)
+		}
+		n -= w
+	}
+	return chinese
+}
+
+// adultName returns a full name for a guardian or teacher.
+func (g *generator) adultName(eth ethnicity, male bool) string {
+	switch eth {
+	case malay:
+		if male {
+			return g.pick(malayFatherNames) + " bin " + g.pick(malayFatherNames)
+		}
+		return g.pick(malayFemaleNames) + " binte " + g.pick(malayFatherNames)
+	case indian:
+		if male {
+			return g.pick(indianFatherNames) + " s/o " + g.pick(indianFatherNames)
+		}
+		return g.pick(indianFemaleNames) + " d/o " + g.pick(indianFatherNames)
+	case eurasian:
+		if male {
+			return g.pick(eurasianMaleNames) + " " + g.pick(eurasianSurnames)
+		}
+		return g.pick(eurasianFemaleNames) + " " + g.pick(eurasianSurnames)
+	default:
+		return g.pick(chineseSurnames) + " " + g.chineseGivenName()
+	}
+}
+
+func (g *generator) chineseGivenName() string {
+	return g.pick(chineseGivenSyllables) + " " + g.pick(chineseGivenSyllables)
+}
+
+// email derives a unique address from name, appending a counter on collision.
+func (g *generator) email(name, domain string) string {
+	var parts []string
+	for _, f := range strings.Fields(strings.ToLower(name)) {
+		switch f {
+		case "bin", "binte", "s/o", "d/o":
+			continue
+		}
+		parts = append(parts, f)
+	}
+	local := strings.Join(parts, ".")
+
+	g.emails[local]++
+	if n := g.emails[local]; n > 1 {
+		local = fmt.Sprintf("%s%d", local, n)
+	}
+	return local + "@" + domain
+}
+
+func (g *generator) phone() string {
+	return fmt.Sprintf("+65 %d%03d %04d", 8+g.rng.IntN(2), g.rng.IntN(1000), g.rng.IntN(10000))
+}
+
+func (g *generator) family() family {
+	f := family{eth: g.ethnicity()}
+	f.motherTongue = motherTongues[f.eth]
+	if f.eth == eurasian && g.chance(0.5) {
+		f.motherTongue = motherTongues[chinese]
+	}
+
+	var father, mother string
+	switch f.eth {
+	case malay:
+		f.surname = g.pick(malayFatherNames)
+		father = f.surname + " bin " + g.pick(malayFatherNames)
+		mother = g.pick(malayFemaleNames) + " binte " + g.pick(malayFatherNames)
+	case indian:
+		f.surname = g.pick(indianFatherNames)
+		father = f.surname + " s/o " + g.pick(indianFatherNames)
+		mother = g.pick(indianFemaleNames) + " d/o " + g.pick(indianFatherNames)
+	case eurasian:
+		f.surname = g.pick(eurasianSurnames)
+		father = g.pick(eurasianMaleNames) + " " + f.surname
+		mother = g.pick(eurasianFemaleNames) + " " + f.surname
+	default:
+		f.surname = g.pick(chineseSurnames)
+		father = f.surname + " " + g.chineseGivenName()
+		mother = g.pick(chineseSurnames) + " " + g.chineseGivenName()
+	}
+
+	// Most households list both parents; the rest list one. About a
+	// quarter prefer posts in their home language.
+	n := g.rng.IntN(100)
+	var language store.Language
+	if g.chance(0.25) {
+		language = homeLanguages[f.eth]
+	}
+	if n >= 5 {
+		f.guardians = append(f.guardians, g.guardian(mother, "mother", language))
+	}
+	if n < 5 || n >= 20 {
+		f.guardians = append(f.guardians, g.guardian(father, "father", language))
+	}
+	return f
+}
+
+func (g *generator) guardian(name, relationship string, language store.Language) store.GuardianLink {
+	guardian := store.Guardian{
+		ID:       g.id(),
+		Name:     name,
+		Email:    g.email(name, "example.com"),
+		Phone:    g.phone(),
+		Language: language,
+	}
+	g.ds.Guardians = append(g.ds.Guardians, guardian)
+	return store.GuardianLink{GuardianID: guardian.ID, Relationship: relationship}
+}
+
+func (g *generator) childName(f family, male bool) string {
+	switch f.eth {
+	case malay:
+		if male {
+			return g.pick(malayMaleNames) + " bin " + f.surname
+		}
+		return g.pick(malayFemaleNames) + " binte " + f.surname
+	case indian:
+		if male {
+			return g.pick(indianMaleNames) + " s/o " + f.surname
+		}
+		return g.pick(indianFemaleNames) + " d/o " + f.surname
+	case eurasian:
+		if male {
+			return g.pick(eurasianMaleNames) + " " + f.surname
+		}
+		return g.pick(eurasianFemaleNames) + " " + f.surname
+	default:
+		return f.surname + " " + g.chineseGivenName()
+	}
+}
+
+// enrol fills every class with students drawn from generated families. Some
+// families have several children, who are placed in different classes so that
+// siblings share guardians across the school.
+func (g *generator) enrol(schoolID string, classes []store.Class) []store.Student {
+	levelNumber := make(map[string]int)
+	for _, l := range g.ds.Levels {
+		levelNumber[l.ID] = l.Number
+	}
+
+	open := make([]int, 0, len(classes))
+	filled := make([]int, len(classes))
+	if g.cfg.StudentsPerClass > 0 {
+		for i := range classes {
+			open = append(open, i)
+		}
+	}
+
+	var students []store.Student
+	for len(open) > 0 {
+		f := g.family()
+
+		children := 1
+		switch n := g.rng.IntN(100); {
+		case n < 3:
+			children = 3
+		case n < 18:
+			children = 2
+		}
+
+		for range children {
+			if len(open) == 0 {
+				break
+			}
+			slot := g.rng.IntN(len(open))
+			class := classes[open[slot]]
+
+			male := g.rng.IntN(2) == 0
+			gender := "F"
+			if male {
+				gender = "M"
+			}
+			birthYear := g.cfg.Start.Year - 12 - levelNumber[class.LevelID]
+			students = append(students, store.Student{
+				ID:          g.id(),
+				SchoolID:    schoolID,
+				ClassID:     class.ID,
+				Name:        g.childName(f, male),
+				Gender:      gender,
+				DateOfBirth: store.Date{Year: birthYear, Month: time.January, Day: 1}.AddDays(g.rng.IntN(365)),
+				Subjects:    append(slices.Clone(coreSubjects), f.motherTongue),
+				Guardians:   slices.Clone(f.guardians),
+			})
+
+			filled[open[slot]]++
+			if filled[open[slot]] == g.cfg.StudentsPerClass {
+				open = slices.Delete(open, slot, slot+1)
+			}
+		}
+	}
+
+	// Index numbers follow the alphabetical order of each class register.
+	classOrder := make(map[string]int, len(classes))
+	for i, c := range classes {
+		classOrder[c.ID] = i
+	}
+	slices.SortStableFunc(students, func(a, b store.Student) int {
+		return cmp.Or(cmp.Compare(classOrder[a.ClassID], classOrder[b.ClassID]), cmp.Compare(a.Name, b.Name))
+	})
+	for i := range students {
+		if i > 0 && students[i-1].ClassID == students[i].ClassID {
+			students[i].IndexNumber = students[i-1].IndexNumber + 1
+		} else {
+			students[i].IndexNumber = 1
+		}
+	}
+
+	g.ds.Students = append(g.ds.Students, students...)
+	return students
+}
+
+// ccas enrols every student in one co-curricular activity. A few students
+// hold a leadership role.
+func (g *generator) ccas(students []store.Student) {
+	for _, s := range students {
+		group := ccaCatalogue[g.rng.IntN(len(ccaCatalogue))]
+		role := "Member"
+		switch n := g.rng.IntN(100); {
+		case n < 3:
+			role = "Captain"
+		case n < 8:
+			role = "Vice-Captain"
+		}
+		g.ds.CCAs = append(g.ds.CCAs, store.CCA{
+			StudentID: s.ID,
+			Name:      g.pick(group.names),
+			Category:  group.category,
+			Role:      role,
+		})
+	}
+}
+
+// attendance records every school day for every student. A small share of
+// students are given a much higher absence rate so that early-warning screens
+// have something to show.
+func (g *generator) attendance(students []store.Student) {
+	for _, s := range students {
+		rate := 0.03
+		switch n := g.rng.IntN(100); {
+		case n < 2:
+			rate = 0.35
+		case n < 10:
+			rate = 0.15
+		}
+
+		for _, day := range g.days {
+			a := store.Attendance{StudentID: s.ID, ClassID: s.ClassID, Date: day, Status: store.AttendancePresent}
+			if g.chance(rate) {
+				switch n := g.rng.IntN(100); {
+				case n < 40:
+					a.Status = store.AttendanceMC
+				case n < 70:
+					a.Status = store.AttendanceAbsent
+				case n < 90:
+					a.Status = store.AttendanceLate
+				default:
+					a.Status = store.AttendanceOfficialLeave
+				}
+				a.Reason = g.pick(absenceReasons[string(a.Status)])
+			}
+			g.ds.Attendance = append(g.ds.Attendance, a)
+		}
+	}
+}
+
+// assessments sets assessments for every subject class in the school and
+// scores each student who takes the subject.
+func (g *generator) assessments(schoolID string, students []store.Student) {
+	total := g.cfg.Terms * g.cfg.AssessmentsPerTerm
+	if total == 0 {
+		return
+	}
+	weight := math.Round(100/float64(total)*100) / 100
+	maxMarks := []float64{20, 30, 40, 50, 100}
+
+	var schoolClasses []string
+	for _, c := range g.ds.Classes {
+		if c.SchoolID == schoolID {
+			schoolClasses = append(schoolClasses, c.ID)
+		}
+	}
+
+	for _, t := range g.ds.Teaching {
+		if !slices.Contains(schoolClasses, t.ClassID) {
+			continue
+		}
+
+		var takers []store.Student
+		for _, s := range students {
+			if s.ClassID == t.ClassID && slices.Contains(s.Subjects, t.Subject) {
+				takers = append(takers, s)
+			}
+		}
+		ability := make([]float64, len(takers))
+		for i := range ability {
+			ability[i] = min(max(g.rng.NormFloat64()*0.15+0.68, 0.1), 1)
+		}
+
+		for term := 1; term <= g.cfg.Terms; term++ {
+			for n := 1; n <= g.cfg.AssessmentsPerTerm; n++ {
+				a := store.Assessment{
+					ID:       g.id(),
+					ClassID:  t.ClassID,
+					Subject:  t.Subject,
+					Name:     fmt.Sprintf("Term %d WA%d", term, n),
+					Term:     term,
+					Date:     g.assessmentDate((term-1)*g.cfg.AssessmentsPerTerm+n, total),
+					MaxMarks: maxMarks[g.rng.IntN(len(maxMarks))],
+					Weight:   weight,
+				}
+				g.ds.Assessments = append(g.ds.Assessments, a)
+
+				for i, s := range takers {
+					score := store.Score{AssessmentID: a.ID, StudentID: s.ID}
+					switch n := g.rng.IntN(1000); {
+					case n < 5:
+						score.Status = store.ScoreExempt
+					case n < 25:
+						score.Status = store.ScoreAbsent
+					default:
+						pct := min(max(ability[i]+g.rng.NormFloat64()*0.08, 0), 1)
+						score.Marks = math.Round(pct*a.MaxMarks*2) / 2
+					}
+					g.ds.Scores = append(g.ds.Scores, score)
+				}
+			}
+		}
+	}
+}
+
+// assessmentDate spreads the nth of total assessments evenly over the
+// generated school days.
+func (g *generator) assessmentDate(nth, total int) store.Date {
+	if len(g.days) == 0 {
+		return g.cfg.Start
+	}
+	return g.days[nth*len(g.days)/(total+1)]
+}
+
+// posts writes announcements for each teacher, targeting their form class
+// if they have one and otherwise a random level.
+func (g *generator) posts(schoolID string) {
+	formClass := make(map[string]string)
+	var levels []string
+	for _, c := range g.ds.Classes {
+		if c.SchoolID == schoolID {
+			formClass[c.FormTeacherID] = c.ID
+		}
+	}
+	for _, l := range g.ds.Levels {
+		if l.SchoolID == schoolID {
+			levels = append(levels, l.ID)
+		}
+	}
+
+	for _, t := range g.ds.Teachers {
+		if t.SchoolID != schoolID || t.Role != store.RoleTeacher {
+			continue
+		}
+		for range g.cfg.PostsPerTeacher {
+			target := store.Target{Type: store.TargetLevel, ID: levels[g.rng.IntN(len(levels))]}
+			if classID, ok := formClass[t.ID]; ok {
+				target = store.Target{Type: store.TargetClass, ID: classID}
+			}
+
+			day := g.cfg.Start
+			if len(g.days) > 0 {
+				day = g.days[g.rng.IntN(len(g.days))]
+			}
+			created := time.Date(day.Year, day.Month, day.Day, 7, 30+g.rng.IntN(30), 0, 0, sgt)
+			title := g.pick(postTitles)
+
+			p := store.Post{
+				ID:       g.id(),
+				SchoolID: schoolID,
+				AuthorID: t.ID,
+				Type:     store.PostAnnouncement,
+				Title:    title,
+				Body: fmt.Sprintf("<p>Dear Parents/Guardians,</p><p>Please note the details for <strong>%s</strong> below.</p><p>Regards,<br>%s</p>",
+					html.EscapeString(title), html.EscapeString(t.Name)),
+				Status:    store.PostDraft,
+				Targets:   []store.Target{target},
+				CreatedAt: created,
+				UpdatedAt: created,
+			}
+			if g.chance(0.75) {
+				published := created.Add(time.Hour)
+				p.Status = store.PostPublished
+				p.UpdatedAt = published
+				p.PublishedAt = &published
+			}
+			g.ds.Posts = append(g.ds.Posts, p)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package seed
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/internal/store"
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func smallConfig() Config {
+	cfg := DefaultConfig()
+	cfg.LevelsPerSchool = 2
+	cfg.ClassesPerLevel = 2
+	cfg.StudentsPerClass = 10
+	cfg.SchoolDays = 5
+	return cfg
+}
+
+func mustGenerate(t *testing.T, cfg Config) *store.Dataset {
+	t.Helper()
+
+	ds, err := Generate(cfg)
+	require.NoError(t, err)
+	return ds
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("same seed yields an identical dataset", func(t *testing.T) {
+		a := mustGenerate(t, smallConfig())
+		b := mustGenerate(t, smallConfig())
+
+		require.Equal(t, mustMarshal(t, a), mustMarshal(t, b))
+	})
+
+	t.Run("default config generates under any seed", func(t *testing.T) {
+		cfg := DefaultConfig()
+		for seed := range uint64(20) {
+			cfg.Seed = seed + 1
+			ds := mustGenerate(t, cfg)
+
+			require.Equal(t, 480, len(ds.Students))
+		}
+	})
+
+	t.Run("different seeds yield different datasets", func(t *testing.T) {
+		cfg := smallConfig()
+		a := mustGenerate(t, cfg)
+		cfg.Seed++
+		b := mustGenerate(t, cfg)
+
+		require.NotEqual(t, a.Students[0].ID, b.Students[0].ID)
+	})
+
+	t.Run("honours configured sizes", func(t *testing.T) {
+		cfg := smallConfig()
+		cfg.Schools = 2
+		ds := mustGenerate(t, cfg)
+
+		require.Equal(t, 2, len(ds.Schools))
+		require.Equal(t, 4, len(ds.Levels))
+		require.Equal(t, 8, len(ds.Classes))
+		require.Equal(t, 80, len(ds.Students))
+		require.Equal(t, 80*cfg.SchoolDays, len(ds.Attendance))
+	})
+
+	t.Run("records reference existing records", func(t *testing.T) {
+		ds := mustGenerate(t, smallConfig())
+
+		ids := make(map[string]bool)
+		for _, c := range ds.Classes {
+			ids[c.ID] = true
+		}
+		for _, tc := range ds.Teachers {
+			ids[tc.ID] = true
+		}
+		for _, gd := range ds.Guardians {
+			ids[gd.ID] = true
+		}
+		for _, a := range ds.Assessments {
+			ids[a.ID] = true
+		}
+
+		for _, c := range ds.Classes {
+			require.True(t, ids[c.FormTeacherID])
+		}
+		for _, s := range ds.Students {
+			require.True(t, ids[s.ClassID])
+			require.True(t, len(s.Guardians) > 0)
+			for _, l := range s.Guardians {
+				require.True(t, ids[l.GuardianID])
+			}
+		}
+		for _, sc := range ds.Scores {
+			require.True(t, ids[sc.AssessmentID])
+		}
+	})
+
+	t.Run("identifiers are time-sortable IDs in generation order", func(t *testing.T) {
+		ds := mustGenerate(t, smallConfig())
+
+		for _, s := range ds.Students {
+			require.NoError(t, random.ValidateID(s.ID))
+		}
+		require.True(t, ds.Schools[0].ID < ds.Levels[0].ID)
+		require.True(t, ds.Levels[0].ID < ds.Classes[0].ID)
+	})
+
+	t.Run("index numbers run from 1 within each class", func(t *testing.T) {
+		ds := mustGenerate(t, smallConfig())
+
+		next := make(map[string]int)
+		for _, s := range ds.Students {
+			next[s.ClassID]++
+			require.Equal(t, next[s.ClassID], s.IndexNumber)
+		}
+	})
+
+	t.Run("attendance skips weekends", func(t *testing.T) {
+		ds := mustGenerate(t, smallConfig())
+
+		for _, a := range ds.Attendance {
+			if wd := a.Date.Weekday(); wd == 0 || wd == 6 {
+				t.Fatalf("want: weekday; got: %s on %s", wd, a.Date)
+			}
+		}
+	})
+
+	t.Run("rejects invalid config", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			mutate func(*Config)
+		}{
+			{name: "no schools", mutate: func(c *Config) { c.Schools = 0 }},
+			{name: "too many classes", mutate: func(c *Config) { c.ClassesPerLevel = 27 }},
+			{name: "negative days", mutate: func(c *Config) { c.SchoolDays = -1 }},
+			{name: "missing start", mutate: func(c *Config) { c.Start = store.Date{} }},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				cfg := smallConfig()
+				tc.mutate(&cfg)
+
+				_, err := Generate(cfg)
+				require.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestDate(t *testing.T) {
+	t.Run("round-trips through JSON as YYYY-MM-DD", func(t *testing.T) {
+		d := Date{Year: 2026, Month: time.March, Day: 9}
+
+		b, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.Equal(t, `"2026-03-09"`, string(b))
+
+		var got Date
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.Equal(t, d, got)
+	})
+
+	t.Run("zero Date round-trips through JSON as an empty string", func(t *testing.T) {
+		b, err := json.Marshal(Date{})
+		require.NoError(t, err)
+		require.Equal(t, `""`, string(b))
+
+		got := Date{Year: 1}
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.True(t, got.IsZero())
+	})
+
+	t.Run("AddDays crosses month boundaries", func(t *testing.T) {
+		d := Date{Year: 2026, Month: time.January, Day: 31}
+
+		require.Equal(t, Date{Year: 2026, Month: time.February, Day: 1}, d.AddDays(1))
+		require.Equal(t, Date{Year: 2026, Month: time.January, Day: 30}, d.AddDays(-1))
+	})
+
+	t.Run("ParseDate rejects malformed input", func(t *testing.T) {
+		_, err := ParseDate("2026-13-01")
+
+		require.Error(t, err)
+	})
+}
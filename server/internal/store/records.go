@@ -0,0 +1,619 @@
+// Package store defines the Teacher Workspace domain records and the Dataset
+// snapshot they are loaded from and saved to.
+package store
+
+import "time"
+
+// Role is a staff member's role within their school.
+type Role string
+
+const (
+	RoleTeacher      Role = "teacher"
+	RoleSchoolLeader Role = "school_leader"
+)
+
+// School is a single school tenant.
+type School struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// GradeScale overrides the default grade boundaries for the school's
+	// computed results.
+	GradeScale *GradeScale `json:"grade_scale,omitempty"`
+	// InsightThresholds overrides the defaults of the early-warning rules
+	// that flag the school's students.
+	InsightThresholds *InsightThresholds `json:"insight_thresholds,omitempty"`
+	// ReportBranding styles the school's printed student reports.
+	ReportBranding *ReportBranding `json:"report_branding,omitempty"`
+	// PostApproval says which of the school's posts must be approved
+	// before they are published. Without it none need be.
+	PostApproval *ApprovalRules `json:"post_approval,omitempty"`
+}
+
+// ApprovalRules say which of a school's posts must be approved before they
+// are published, and who besides its leaders may approve them. A post
+// matching any rule must be.
+type ApprovalRules struct {
+	// MinStudents is the number of students a post must reach to need
+	// approval, as a level-wide post does. Zero turns the rule off.
+	MinStudents int `json:"min_students,omitempty"`
+	// Types lists the types of post that always need approval.
+	Types []PostType `json:"types,omitempty"`
+	// ApproverIDs are teachers, such as heads of department, who may
+	// approve posts as well as the school's leaders.
+	ApproverIDs []string `json:"approver_ids,omitempty"`
+}
+
+// GradeScale maps percentages to grades. Bands are ordered from the highest
+// grade down, and a percentage earns the first band whose Min it reaches.
+type GradeScale struct {
+	Name  string      `json:"name"`
+	Bands []GradeBand `json:"bands"`
+}
+
+// GradeBand is one grade of a GradeScale.
+type GradeBand struct {
+	Grade string  `json:"grade"`
+	Min   float64 `json:"min"`
+}
+
+// InsightThresholds tunes the early-warning rules. A zero field takes the
+// rule's default.
+type InsightThresholds struct {
+	// AttendanceWindowDays is how many of the latest recorded school days
+	// count as recent attendance.
+	AttendanceWindowDays int `json:"attendance_window_days"`
+	// MinAttendanceRate is the lowest recent attendance, in percent, that
+	// is not flagged.
+	MinAttendanceRate float64 `json:"min_attendance_rate"`
+	// AttendanceDropPoints is the fall in percentage points from earlier to
+	// recent attendance that is flagged.
+	AttendanceDropPoints float64 `json:"attendance_drop_points"`
+	// ConsecutiveAbsences is the run of school days absent or on MC that is
+	// flagged.
+	ConsecutiveAbsences int `json:"consecutive_absences"`
+	// GradeDropPoints is the fall in percentage points from one term's
+	// result in a subject to the next that is flagged.
+	GradeDropPoints float64 `json:"grade_drop_points"`
+	// MissingConsents is the number of overdue consent forms without a
+	// response that is flagged, and ConsentGraceDays how long past its due
+	// date a form becomes overdue.
+	MissingConsents  int `json:"missing_consents"`
+	ConsentGraceDays int `json:"consent_grace_days"`
+}
+
+// ReportBranding is how a school's printed reports look.
+type ReportBranding struct {
+	// AccentColour, written as "#rrggbb", fills the report's header band.
+	AccentColour string `json:"accent_colour,omitempty"`
+	// Logo is a PNG or JPEG image printed in the header band.
+	Logo []byte `json:"logo,omitempty"`
+	// Footer is printed at the foot of every page, as in "Printed for
+	// parent-teacher meetings".
+	Footer string `json:"footer,omitempty"`
+}
+
+// Level is a year of study within a school, such as "Secondary 1".
+type Level struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"school_id"`
+	Number   int    `json:"number"`
+	Name     string `json:"name"`
+}
+
+// Class is a form class within a level, such as "1A".
+type Class struct {
+	ID            string `json:"id"`
+	SchoolID      string `json:"school_id"`
+	LevelID       string `json:"level_id"`
+	Name          string `json:"name"`
+	Year          int    `json:"year"`
+	FormTeacherID string `json:"form_teacher_id"`
+}
+
+// Teacher is a member of staff who signs in to Teacher Workspace.
+type Teacher struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"school_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     Role   `json:"role"`
+}
+
+// APIKey grants a teacher API access. Only the token's hash is kept; see
+// package token.
+type APIKey struct {
+	Hash      string    `json:"hash"`
+	TeacherID string    `json:"teacher_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Teaching assigns a teacher to teach a subject to a class.
+type Teaching struct {
+	TeacherID string `json:"teacher_id"`
+	ClassID   string `json:"class_id"`
+	Subject   string `json:"subject"`
+}
+
+// Student is a student enrolled in a form class.
+type Student struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"school_id"`
+	// ExternalID is the student's ID in the school's own records, which
+	// roster imports match students by. It is unique within the school.
+	ExternalID  string         `json:"external_id,omitempty"`
+	ClassID     string         `json:"class_id"`
+	IndexNumber int            `json:"index_number"`
+	Name        string         `json:"name"`
+	Gender      string         `json:"gender"`
+	DateOfBirth Date           `json:"date_of_birth"`
+	Subjects    []string       `json:"subjects"`
+	Guardians   []GuardianLink `json:"guardians"`
+}
+
+// GuardianLink relates a student to one of their guardians.
+type GuardianLink struct {
+	GuardianID   string `json:"guardian_id"`
+	Relationship string `json:"relationship"`
+	// Restricted is set when the guardian may not be sent posts about the
+	// student, as under a custody order.
+	Restricted bool `json:"restricted,omitempty"`
+}
+
+// Guardian is a parent or other caregiver who receives posts about a student.
+type Guardian struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	// OptedOut is set when the guardian has asked not to be sent posts.
+	OptedOut bool `json:"opted_out,omitempty"`
+	// Language is the language the guardian prefers posts in. Empty is
+	// English.
+	Language Language `json:"language,omitempty"`
+}
+
+// Language is one of Singapore's four official languages, which posts may
+// be written in.
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageChinese Language = "zh"
+	LanguageMalay   Language = "ms"
+	LanguageTamil   Language = "ta"
+)
+
+// Languages lists the official languages, English first.
+var Languages = []Language{LanguageEnglish, LanguageChinese, LanguageMalay, LanguageTamil}
+
+// CCA is a student's membership of a co-curricular activity.
+type CCA struct {
+	StudentID string `json:"student_id"`
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	Role      string `json:"role"`
+}
+
+// AttendanceStatus is a student's attendance status for a school day.
+type AttendanceStatus string
+
+const (
+	AttendancePresent       AttendanceStatus = "present"
+	AttendanceAbsent        AttendanceStatus = "absent"
+	AttendanceLate          AttendanceStatus = "late"
+	AttendanceMC            AttendanceStatus = "mc"
+	AttendanceOfficialLeave AttendanceStatus = "official_leave"
+)
+
+// Attendance is a student's attendance record for one school day.
+type Attendance struct {
+	StudentID string           `json:"student_id"`
+	ClassID   string           `json:"class_id"`
+	Date      Date             `json:"date"`
+	Status    AttendanceStatus `json:"status"`
+	Reason    string           `json:"reason,omitempty"`
+	// RecordedBy is the teacher who first took the record, and RecordedAt
+	// when. Both are empty for imported records.
+	RecordedBy string    `json:"recorded_by,omitempty"`
+	RecordedAt time.Time `json:"recorded_at,omitzero"`
+}
+
+// AttendanceEdit is an audit entry for a change to an attendance record
+// after it was first taken.
+type AttendanceEdit struct {
+	StudentID  string           `json:"student_id"`
+	ClassID    string           `json:"class_id"`
+	Date       Date             `json:"date"`
+	FromStatus AttendanceStatus `json:"from_status"`
+	FromReason string           `json:"from_reason,omitempty"`
+	ToStatus   AttendanceStatus `json:"to_status"`
+	ToReason   string           `json:"to_reason,omitempty"`
+	EditedBy   string           `json:"edited_by"`
+	EditedAt   time.Time        `json:"edited_at"`
+}
+
+// Assessment is a graded piece of work set for a subject class.
+type Assessment struct {
+	ID       string  `json:"id"`
+	ClassID  string  `json:"class_id"`
+	Subject  string  `json:"subject"`
+	Name     string  `json:"name"`
+	Term     int     `json:"term"`
+	Date     Date    `json:"date"`
+	MaxMarks float64 `json:"max_marks"`
+	Weight   float64 `json:"weight"`
+}
+
+// ScoreStatus marks a score that carries no marks.
+type ScoreStatus string
+
+const (
+	ScoreAbsent ScoreStatus = "absent"
+	ScoreExempt ScoreStatus = "exempt"
+)
+
+// Score is a student's result for an assessment. Marks is meaningful only
+// when Status is empty.
+type Score struct {
+	AssessmentID string      `json:"assessment_id"`
+	StudentID    string      `json:"student_id"`
+	Marks        float64     `json:"marks"`
+	Status       ScoreStatus `json:"status,omitempty"`
+}
+
+// NoteVisibility controls who besides its author may read a note. Each level
+// widens the one before it.
+type NoteVisibility string
+
+const (
+	// NotePrivate notes are read only by their author.
+	NotePrivate NoteVisibility = "private"
+	// NoteFormTeacher notes are also read by the student's form teacher.
+	NoteFormTeacher NoteVisibility = "form_teacher"
+	// NoteSchoolLeaders notes are also read by the school's leaders.
+	NoteSchoolLeaders NoteVisibility = "school_leaders"
+)
+
+// Note is a teacher's observation about a student. Deleted notes keep their
+// content and history but are hidden from every listing.
+type Note struct {
+	ID         string         `json:"id"`
+	StudentID  string         `json:"student_id"`
+	AuthorID   string         `json:"author_id"`
+	Visibility NoteVisibility `json:"visibility"`
+	Category   string         `json:"category"`
+	Tags       []string       `json:"tags"`
+	Body       string         `json:"body"`
+	// Sensitive notes have every access written to the audit log.
+	Sensitive bool       `json:"sensitive"`
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NoteRevision is a note as it stood after one create, edit or delete.
+type NoteRevision struct {
+	NoteID     string         `json:"note_id"`
+	Version    int            `json:"version"`
+	EditorID   string         `json:"editor_id"`
+	EditedAt   time.Time      `json:"edited_at"`
+	Visibility NoteVisibility `json:"visibility"`
+	Category   string         `json:"category"`
+	Tags       []string       `json:"tags"`
+	Body       string         `json:"body"`
+	Sensitive  bool           `json:"sensitive"`
+	Deleted    bool           `json:"deleted,omitempty"`
+}
+
+// AuditEntry records that a teacher acted on a record.
+type AuditEntry struct {
+	At         time.Time `json:"at"`
+	ActorID    string    `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	// Detail is a short free-text description, never record content.
+	Detail string `json:"detail,omitempty"`
+}
+
+// PostStatus is the lifecycle state of a post.
+type PostStatus string
+
+const (
+	PostDraft PostStatus = "draft"
+	// PostPendingApproval posts have been submitted for approval and wait
+	// for an approver's review.
+	PostPendingApproval PostStatus = "pending_approval"
+	// PostApproved posts may be published or scheduled by their author.
+	PostApproved PostStatus = "approved"
+	// PostRejected posts have been sent back to their author, who may
+	// change them and submit them again.
+	PostRejected PostStatus = "rejected"
+	// PostScheduled posts are published automatically at their PublishAt.
+	PostScheduled PostStatus = "scheduled"
+	PostPublished PostStatus = "published"
+)
+
+// ReviewDecision is an approver's decision on a post.
+type ReviewDecision string
+
+const (
+	ReviewApproved ReviewDecision = "approved"
+	ReviewRejected ReviewDecision = "rejected"
+)
+
+// PostReview is an approver's decision on a post submitted for approval.
+type PostReview struct {
+	ReviewerID string         `json:"reviewer_id"`
+	Decision   ReviewDecision `json:"decision"`
+	// Comment says why, and is required when a post is rejected.
+	Comment string    `json:"comment,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// PostType is the kind of a post.
+type PostType string
+
+const (
+	PostAnnouncement PostType = "announcement"
+	// PostConsent posts ask guardians for consent, such as for a trip, and
+	// collect one response per student.
+	PostConsent PostType = "consent"
+)
+
+// TargetType is the kind of audience a post target selects.
+type TargetType string
+
+const (
+	TargetStudent TargetType = "student"
+	TargetClass   TargetType = "class"
+	TargetLevel   TargetType = "level"
+	TargetGroup   TargetType = "group"
+)
+
+// Target selects the students whose guardians receive a post.
+type Target struct {
+	Type TargetType `json:"type"`
+	ID   string     `json:"id"`
+}
+
+// Group is a teacher's own named list of students, such as a CCA or a
+// remedial class, that posts may target.
+type Group struct {
+	ID         string    `json:"id"`
+	SchoolID   string    `json:"school_id"`
+	OwnerID    string    `json:"owner_id"`
+	Name       string    `json:"name"`
+	StudentIDs []string  `json:"student_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TemplateScope is who may use a post template.
+type TemplateScope string
+
+const (
+	// TemplatePersonal templates are their owner's alone.
+	TemplatePersonal TemplateScope = "personal"
+	// TemplateSchool templates are shared with every teacher in the school
+	// and kept by its leaders.
+	TemplateSchool TemplateScope = "school"
+)
+
+// PostTemplate is saved post content to start new posts from. Its title and
+// body may use merge fields, filled for each guardian a post is sent to.
+type PostTemplate struct {
+	ID       string        `json:"id"`
+	SchoolID string        `json:"school_id"`
+	OwnerID  string        `json:"owner_id"`
+	Scope    TemplateScope `json:"scope"`
+	Name     string        `json:"name"`
+	Type     PostType      `json:"type"`
+	Title    string        `json:"title"`
+	// Body is rich text, like a post's.
+	Body         string                   `json:"body"`
+	Translations map[Language]Translation `json:"translations,omitempty"`
+	// Questions are a consent form template's questions.
+	Questions []string  `json:"questions,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Post is a Parents Gateway announcement sent to guardians.
+type Post struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"school_id"`
+	AuthorID string `json:"author_id"`
+	// Type is empty for posts saved before there were consent forms, which
+	// are announcements.
+	Type PostType `json:"type,omitempty"`
+	// Title and Body are in English.
+	Title string `json:"title"`
+	// Body is rich text: HTML limited to the tags richtext.Sanitize keeps.
+	Body string `json:"body"`
+	// Translations holds the post in the other official languages it has
+	// been translated into.
+	Translations map[Language]Translation `json:"translations,omitempty"`
+	Status       PostStatus               `json:"status"`
+	Targets      []Target                 `json:"targets"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+	// PublishAt is when a scheduled post is due to be published.
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	// ScheduleError says why a scheduled post could not be published when
+	// it was due, after which it went back to being a draft.
+	ScheduleError string `json:"schedule_error,omitempty"`
+	// Consent is set on consent forms only.
+	Consent *ConsentForm `json:"consent,omitempty"`
+	// SubmittedAt is when the post was last submitted for approval. It is
+	// cleared when the post is changed, which needs approving again.
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	// Reviews holds every approver's decision on the post, oldest first.
+	Reviews []PostReview `json:"reviews,omitempty"`
+}
+
+// Translation is a post's title and body in a language other than
+// English.
+type Translation struct {
+	Title string `json:"title"`
+	// Body is rich text, like a post's.
+	Body string `json:"body"`
+}
+
+// Attachment is a file attached to a post. Its content is kept in blob
+// storage under Key.
+type Attachment struct {
+	ID         string `json:"id"`
+	PostID     string `json:"post_id"`
+	UploaderID string `json:"uploader_id"`
+	Name       string `json:"name"`
+	// ContentType is the type sniffed from the file's content, not the one
+	// it was uploaded with.
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Key         string    `json:"key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ConsentForm is what a consent form asks and until when.
+type ConsentForm struct {
+	Questions []ConsentQuestion `json:"questions"`
+	DueAt     time.Time         `json:"due_at"`
+	// RemindEveryDays is how often guardians who have not responded are
+	// reminded until DueAt; 0 sends no reminders.
+	RemindEveryDays int        `json:"remind_every_days"`
+	LastRemindedAt  *time.Time `json:"last_reminded_at,omitempty"`
+}
+
+// ConsentQuestion is a yes/no question on a consent form.
+type ConsentQuestion struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// ConsentResponse is a guardian's answers to a consent form for one
+// student. A student's latest response is their current one; earlier ones
+// are its history.
+type ConsentResponse struct {
+	PostID      string          `json:"post_id"`
+	StudentID   string          `json:"student_id"`
+	GuardianID  string          `json:"guardian_id"`
+	Answers     []ConsentAnswer `json:"answers"`
+	RespondedAt time.Time       `json:"responded_at"`
+}
+
+// ConsentAnswer is the answer to one question of a consent form.
+type ConsentAnswer struct {
+	QuestionID string `json:"question_id"`
+	Yes        bool   `json:"yes"`
+}
+
+// ConsentReminder records a reminder sent to the guardians of the students
+// who had not responded to a consent form.
+type ConsentReminder struct {
+	PostID      string    `json:"post_id"`
+	SentAt      time.Time `json:"sent_at"`
+	StudentIDs  []string  `json:"student_ids"`
+	GuardianIDs []string  `json:"guardian_ids"`
+}
+
+// PostRecipient is a student a post was sent about, recorded when it was
+// published: the targets that selected them and their guardians then.
+type PostRecipient struct {
+	PostID    string         `json:"post_id"`
+	StudentID string         `json:"student_id"`
+	Via       []Target       `json:"via"`
+	Guardians []GuardianLink `json:"guardians"`
+}
+
+// PostReceipt records when a published post was delivered to, opened by and
+// acknowledged by one guardian. Each time is the first at which it happened.
+type PostReceipt struct {
+	PostID         string     `json:"post_id"`
+	GuardianID     string     `json:"guardian_id"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	OpenedAt       *time.Time `json:"opened_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// NotificationKind is what a notification tells its recipient about.
+type NotificationKind string
+
+const (
+	// NotifyPost tells a guardian a post has been published.
+	NotifyPost NotificationKind = "post"
+	// NotifyReminder reminds a guardian to respond to a consent form.
+	NotifyReminder NotificationKind = "reminder"
+	// NotifyApprovalRequest asks an approver to review a post.
+	NotifyApprovalRequest NotificationKind = "approval_request"
+	// NotifyApprovalDecision tells a post's author an approver's decision.
+	NotifyApprovalDecision NotificationKind = "approval_decision"
+)
+
+// NotificationStatus is where a notification is in being sent.
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"
+)
+
+// Notification is an email about a post to one guardian or, while the post
+// is being approved, to one teacher, sent once it has been saved.
+type Notification struct {
+	ID         string `json:"id"`
+	PostID     string `json:"post_id"`
+	GuardianID string `json:"guardian_id,omitempty"`
+	// TeacherID is set instead of GuardianID on notifications to teachers.
+	TeacherID string           `json:"teacher_id,omitempty"`
+	Kind      NotificationKind `json:"kind"`
+	// StudentIDs are the students the guardian is told about.
+	StudentIDs []string           `json:"student_ids"`
+	Status     NotificationStatus `json:"status"`
+	Attempts   int                `json:"attempts"`
+	// LastError says why the last attempt failed.
+	LastError string `json:"last_error,omitempty"`
+	// RequestID is the ID of the request that queued the notification, if
+	// a request did rather than the scheduler.
+	RequestID     string     `json:"request_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// Dataset is a complete snapshot of every record. It is the format written by
+// `tw seed` and read by the server at startup.
+type Dataset struct {
+	Schools    []School     `json:"schools"`
+	Levels     []Level      `json:"levels"`
+	Classes    []Class      `json:"classes"`
+	Teachers   []Teacher    `json:"teachers"`
+	APIKeys    []APIKey     `json:"api_keys"`
+	Teaching   []Teaching   `json:"teaching"`
+	Students   []Student    `json:"students"`
+	Guardians  []Guardian   `json:"guardians"`
+	CCAs       []CCA        `json:"ccas"`
+	Attendance []Attendance `json:"attendance"`
+	// AttendanceEdits is the audit trail of changes to Attendance.
+	AttendanceEdits []AttendanceEdit `json:"attendance_edits"`
+	Assessments     []Assessment     `json:"assessments"`
+	Scores          []Score          `json:"scores"`
+	Notes           []Note           `json:"notes"`
+	NoteRevisions   []NoteRevision   `json:"note_revisions"`
+	Groups          []Group          `json:"groups"`
+	Posts           []Post           `json:"posts"`
+	PostTemplates   []PostTemplate   `json:"post_templates"`
+	Attachments     []Attachment     `json:"attachments"`
+	// ConsentResponses is every response to a consent form, oldest first.
+	ConsentResponses []ConsentResponse `json:"consent_responses"`
+	ConsentReminders []ConsentReminder `json:"consent_reminders"`
+	PostRecipients   []PostRecipient   `json:"post_recipients"`
+	PostReceipts     []PostReceipt     `json:"post_receipts"`
+	Notifications    []Notification    `json:"notifications"`
+	AuditLog         []AuditEntry      `json:"audit_log"`
+}
@@ -0,0 +1,1293 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store holds every record in memory, indexed for the queries the API makes.
+// It is safe for concurrent use. Accessors return copies of records, but
+// slices within a record are shared and must not be modified.
+type Store struct {
+	mu sync.RWMutex
+	ds Dataset
+
+	// rosterMu is held by callers that read students or guardians and write
+	// them back with PutRoster; see LockRoster.
+	rosterMu sync.Mutex
+
+	schools     map[string]School
+	levels      map[string]Level
+	classes     map[string]Class
+	teachers    map[string]Teacher
+	students    map[string]Student
+	guardians   map[string]Guardian
+	apiKeys     map[string]APIKey
+	assessments map[string]Assessment
+
+	classesBySchool     map[string][]string
+	studentsByClass     map[string][]string
+	studentsBySchool    map[string][]string
+	studentsByExternal  map[externalKey]string
+	rosterChanges       []string
+	teachingByTeacher   map[string][]Teaching
+	teachingByClass     map[string][]Teaching
+	attendanceByStudent map[string][]int
+	attendanceByDay     map[attendanceKey]int
+	editsByClass        map[string][]int
+	attendanceRevision  map[string]uint64
+	scoresByStudent     map[string][]int
+	scoresByAssessment  map[string][]int
+	scoresByKey         map[scoreKey]int
+	assessmentsByClass  map[string][]string
+	gradesRevision      map[string]uint64
+	notes               map[string]int
+	notesByStudent      map[string][]int
+	revisionsByNote     map[string][]int
+	ccasByStudent       map[string][]int
+	groups              map[string]int
+	posts               map[string]int
+	templates           map[string]int
+	responsesByPost     map[string][]int
+	responded           map[recipientKey]bool
+	consentRevision     uint64
+	remindersByPost     map[string][]int
+	recipients          map[recipientKey]int
+	recipientsByPost    map[string][]int
+	attachmentsByPost   map[string][]int
+	receipts            map[receiptKey]int
+	receiptsByPost      map[string][]int
+	notifications       map[string]int
+	notificationsByPost map[string][]int
+}
+
+type receiptKey struct{ postID, guardianID string }
+
+type recipientKey struct{ postID, studentID string }
+
+// New returns a Store holding the records in ds. The Store takes ownership of
+// ds; callers must not modify it afterwards.
+func New(ds *Dataset) *Store {
+	s := &Store{ds: *ds}
+	s.reindex()
+	return s
+}
+
+// Load reads a Dataset written by `tw seed` from path.
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds Dataset
+	if err := json.Unmarshal(b, &ds); err != nil {
+		return nil, fmt.Errorf("store: decoding %s: %w", path, err)
+	}
+	return New(&ds), nil
+}
+
+// Save writes every record to path as JSON, in the format Load reads. The
+// file is replaced atomically, so a reader never sees a partial write, and
+// is synced to disk before Save returns.
+func (s *Store) Save(path string) error {
+	s.mu.RLock()
+	b, err := json.MarshalIndent(&s.ds, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *Store) reindex() {
+	s.schools = indexBy(s.ds.Schools, func(v School) string { return v.ID })
+	s.levels = indexBy(s.ds.Levels, func(v Level) string { return v.ID })
+	s.classes = indexBy(s.ds.Classes, func(v Class) string { return v.ID })
+	s.teachers = indexBy(s.ds.Teachers, func(v Teacher) string { return v.ID })
+	s.guardians = indexBy(s.ds.Guardians, func(v Guardian) string { return v.ID })
+	s.apiKeys = indexBy(s.ds.APIKeys, func(v APIKey) string { return v.Hash })
+	s.assessments = indexBy(s.ds.Assessments, func(v Assessment) string { return v.ID })
+
+	s.classesBySchool = make(map[string][]string)
+	for _, c := range s.ds.Classes {
+		s.classesBySchool[c.SchoolID] = append(s.classesBySchool[c.SchoolID], c.ID)
+	}
+	s.indexStudents()
+	s.teachingByTeacher = make(map[string][]Teaching)
+	s.teachingByClass = make(map[string][]Teaching)
+	for _, t := range s.ds.Teaching {
+		s.teachingByTeacher[t.TeacherID] = append(s.teachingByTeacher[t.TeacherID], t)
+		s.teachingByClass[t.ClassID] = append(s.teachingByClass[t.ClassID], t)
+	}
+	s.attendanceByStudent = positions(s.ds.Attendance, func(v Attendance) string { return v.StudentID })
+	s.attendanceByDay = make(map[attendanceKey]int, len(s.ds.Attendance))
+	for i, a := range s.ds.Attendance {
+		s.attendanceByDay[attendanceKey{a.StudentID, a.Date}] = i
+	}
+	s.attendanceRevision = make(map[string]uint64)
+	s.editsByClass = positions(s.ds.AttendanceEdits, func(v AttendanceEdit) string { return v.ClassID })
+	s.scoresByStudent = positions(s.ds.Scores, func(v Score) string { return v.StudentID })
+	s.scoresByAssessment = positions(s.ds.Scores, func(v Score) string { return v.AssessmentID })
+	s.scoresByKey = make(map[scoreKey]int, len(s.ds.Scores))
+	for i, sc := range s.ds.Scores {
+		s.scoresByKey[scoreKey{sc.AssessmentID, sc.StudentID}] = i
+	}
+	s.assessmentsByClass = make(map[string][]string)
+	for _, a := range s.ds.Assessments {
+		s.assessmentsByClass[a.ClassID] = append(s.assessmentsByClass[a.ClassID], a.ID)
+	}
+	s.gradesRevision = make(map[string]uint64)
+	s.notes = make(map[string]int, len(s.ds.Notes))
+	for i, n := range s.ds.Notes {
+		s.notes[n.ID] = i
+	}
+	s.notesByStudent = positions(s.ds.Notes, func(v Note) string { return v.StudentID })
+	s.revisionsByNote = positions(s.ds.NoteRevisions, func(v NoteRevision) string { return v.NoteID })
+	s.ccasByStudent = positions(s.ds.CCAs, func(v CCA) string { return v.StudentID })
+	s.rosterChanges = nil
+	s.indexPosts()
+}
+
+// indexPosts rebuilds the indexes of posts and of the records that belong
+// to them, and of groups and templates, which deletes change.
+func (s *Store) indexPosts() {
+	s.groups = make(map[string]int, len(s.ds.Groups))
+	for i, g := range s.ds.Groups {
+		s.groups[g.ID] = i
+	}
+	s.posts = make(map[string]int, len(s.ds.Posts))
+	for i, p := range s.ds.Posts {
+		s.posts[p.ID] = i
+	}
+	s.templates = make(map[string]int, len(s.ds.PostTemplates))
+	for i, t := range s.ds.PostTemplates {
+		s.templates[t.ID] = i
+	}
+	s.attachmentsByPost = positions(s.ds.Attachments, func(v Attachment) string { return v.PostID })
+	s.responsesByPost = positions(s.ds.ConsentResponses, func(v ConsentResponse) string { return v.PostID })
+	s.responded = make(map[recipientKey]bool)
+	for _, r := range s.ds.ConsentResponses {
+		s.responded[recipientKey{r.PostID, r.StudentID}] = true
+	}
+	s.remindersByPost = positions(s.ds.ConsentReminders, func(v ConsentReminder) string { return v.PostID })
+	s.recipients = make(map[recipientKey]int, len(s.ds.PostRecipients))
+	for i, r := range s.ds.PostRecipients {
+		s.recipients[recipientKey{r.PostID, r.StudentID}] = i
+	}
+	s.recipientsByPost = positions(s.ds.PostRecipients, func(v PostRecipient) string { return v.PostID })
+	s.receipts = make(map[receiptKey]int, len(s.ds.PostReceipts))
+	for i, r := range s.ds.PostReceipts {
+		s.receipts[receiptKey{r.PostID, r.GuardianID}] = i
+	}
+	s.receiptsByPost = positions(s.ds.PostReceipts, func(v PostReceipt) string { return v.PostID })
+	s.notifications = make(map[string]int, len(s.ds.Notifications))
+	for i, n := range s.ds.Notifications {
+		s.notifications[n.ID] = i
+	}
+	s.notificationsByPost = positions(s.ds.Notifications, func(v Notification) string { return v.PostID })
+}
+
+// indexStudents rebuilds the student indexes, which roster imports change.
+func (s *Store) indexStudents() {
+	s.students = indexBy(s.ds.Students, func(v Student) string { return v.ID })
+	s.studentsByClass = make(map[string][]string)
+	s.studentsBySchool = make(map[string][]string)
+	s.studentsByExternal = make(map[externalKey]string)
+	for _, st := range s.ds.Students {
+		s.studentsByClass[st.ClassID] = append(s.studentsByClass[st.ClassID], st.ID)
+		s.studentsBySchool[st.SchoolID] = append(s.studentsBySchool[st.SchoolID], st.ID)
+		if st.ExternalID != "" {
+			s.studentsByExternal[externalKey{st.SchoolID, st.ExternalID}] = st.ID
+		}
+	}
+}
+
+func indexBy[T any](records []T, key func(T) string) map[string]T {
+	m := make(map[string]T, len(records))
+	for _, r := range records {
+		m[key(r)] = r
+	}
+	return m
+}
+
+// School returns the school with the given ID.
+func (s *Store) School(id string) (School, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.schools[id]
+	return v, ok
+}
+
+// Schools returns every school.
+func (s *Store) Schools() []School {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.ds.Schools)
+}
+
+// SetInsightThresholds replaces the early-warning thresholds of school
+// schoolID, reporting whether the school exists. A nil th restores the
+// defaults.
+func (s *Store) SetInsightThresholds(schoolID string, th *InsightThresholds) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	school, ok := s.schools[schoolID]
+	if !ok {
+		return false
+	}
+	school.InsightThresholds = th
+	s.schools[schoolID] = school
+	i := slices.IndexFunc(s.ds.Schools, func(v School) bool { return v.ID == schoolID })
+	s.ds.Schools[i] = school
+	return true
+}
+
+// SetGradeScale replaces the grade scale of school schoolID, reporting
+// whether the school exists. A nil sc restores the default. The grades
+// revision of every student at the school moves on, since their grades may
+// change with it.
+func (s *Store) SetGradeScale(schoolID string, sc *GradeScale) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	school, ok := s.schools[schoolID]
+	if !ok {
+		return false
+	}
+	school.GradeScale = sc
+	s.schools[schoolID] = school
+	i := slices.IndexFunc(s.ds.Schools, func(v School) bool { return v.ID == schoolID })
+	s.ds.Schools[i] = school
+	for _, id := range s.studentsBySchool[schoolID] {
+		s.gradesRevision[id]++
+	}
+	return true
+}
+
+// SetReportBranding replaces the report branding of school schoolID,
+// reporting whether the school exists. A nil b restores the plain style.
+func (s *Store) SetReportBranding(schoolID string, b *ReportBranding) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	school, ok := s.schools[schoolID]
+	if !ok {
+		return false
+	}
+	school.ReportBranding = b
+	s.schools[schoolID] = school
+	i := slices.IndexFunc(s.ds.Schools, func(v School) bool { return v.ID == schoolID })
+	s.ds.Schools[i] = school
+	return true
+}
+
+// SetPostApproval replaces the post approval rules of school schoolID,
+// reporting whether the school exists. A nil rules lets every post be
+// published without approval.
+func (s *Store) SetPostApproval(schoolID string, rules *ApprovalRules) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	school, ok := s.schools[schoolID]
+	if !ok {
+		return false
+	}
+	school.PostApproval = rules
+	s.schools[schoolID] = school
+	i := slices.IndexFunc(s.ds.Schools, func(v School) bool { return v.ID == schoolID })
+	s.ds.Schools[i] = school
+	return true
+}
+
+// Level returns the level with the given ID.
+func (s *Store) Level(id string) (Level, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.levels[id]
+	return v, ok
+}
+
+// Levels returns the levels of a school ordered by number.
+func (s *Store) Levels(schoolID string) []Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var levels []Level
+	for _, l := range s.ds.Levels {
+		if l.SchoolID == schoolID {
+			levels = append(levels, l)
+		}
+	}
+	slices.SortFunc(levels, func(a, b Level) int {
+		return cmp.Compare(a.Number, b.Number)
+	})
+	return levels
+}
+
+// Class returns the class with the given ID.
+func (s *Store) Class(id string) (Class, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.classes[id]
+	return v, ok
+}
+
+// Classes returns the classes of a school in dataset order.
+func (s *Store) Classes(schoolID string) []Class {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return lookup(s.classes, s.classesBySchool[schoolID])
+}
+
+// Teacher returns the teacher with the given ID.
+func (s *Store) Teacher(id string) (Teacher, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.teachers[id]
+	return v, ok
+}
+
+// TeachersInSchool returns the teachers of a school.
+func (s *Store) TeachersInSchool(schoolID string) []Teacher {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Teacher
+	for _, t := range s.ds.Teachers {
+		if t.SchoolID == schoolID {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// TeacherByAPIKey returns the teacher who owns the API key with the given
+// hash (see token.Hash).
+func (s *Store) TeacherByAPIKey(hash string) (Teacher, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[hash]
+	if !ok {
+		return Teacher{}, false
+	}
+	t, ok := s.teachers[key.TeacherID]
+	return t, ok
+}
+
+// TeachingByTeacher returns a teacher's teaching assignments.
+func (s *Store) TeachingByTeacher(teacherID string) []Teaching {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.teachingByTeacher[teacherID])
+}
+
+// TeachingByClass returns the teaching assignments for a class.
+func (s *Store) TeachingByClass(classID string) []Teaching {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.teachingByClass[classID])
+}
+
+// Student returns the student with the given ID.
+func (s *Store) Student(id string) (Student, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.students[id]
+	return v, ok
+}
+
+// StudentsInClass returns the students of a class ordered by index number.
+func (s *Store) StudentsInClass(classID string) []Student {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	students := lookup(s.students, s.studentsByClass[classID])
+	slices.SortFunc(students, func(a, b Student) int {
+		return cmp.Compare(a.IndexNumber, b.IndexNumber)
+	})
+	return students
+}
+
+// StudentsInSchool returns the students of a school in dataset order.
+func (s *Store) StudentsInSchool(schoolID string) []Student {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return lookup(s.students, s.studentsBySchool[schoolID])
+}
+
+// externalKey identifies a student by their ID in their school's records.
+type externalKey struct {
+	schoolID   string
+	externalID string
+}
+
+// StudentByExternalID returns the student of school schoolID whose ID in the
+// school's own records is externalID.
+func (s *Store) StudentByExternalID(schoolID, externalID string) (Student, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.studentsByExternal[externalKey{schoolID, externalID}]
+	if !ok {
+		return Student{}, false
+	}
+	return s.students[id], true
+}
+
+// LockRoster serialises changes to the roster that read students or
+// guardians and then write them back with PutRoster, so that neither
+// overwrites the other's change. It returns the function that unlocks it.
+func (s *Store) LockRoster() (unlock func()) {
+	s.rosterMu.Lock()
+	return s.rosterMu.Unlock
+}
+
+// PutRoster adds students and guardians, or replaces those with the same
+// IDs, in one step. Students' results are recomputed on their next read,
+// since a change of class changes their assessments.
+func (s *Store) PutRoster(students []Student, guardians []Guardian) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range guardians {
+		if _, ok := s.guardians[g.ID]; ok {
+			i := slices.IndexFunc(s.ds.Guardians, func(v Guardian) bool { return v.ID == g.ID })
+			s.ds.Guardians[i] = g
+		} else {
+			s.ds.Guardians = append(s.ds.Guardians, g)
+		}
+		s.guardians[g.ID] = g
+	}
+
+	index := make(map[string]int, len(s.ds.Students))
+	for i, st := range s.ds.Students {
+		index[st.ID] = i
+	}
+	for _, st := range students {
+		if i, ok := index[st.ID]; ok {
+			s.ds.Students[i] = st
+		} else {
+			s.ds.Students = append(s.ds.Students, st)
+			index[st.ID] = len(s.ds.Students) - 1
+		}
+		s.gradesRevision[st.ID]++
+		s.rosterChanges = append(s.rosterChanges, st.ID)
+	}
+	s.indexStudents()
+}
+
+// RosterChanges returns the IDs of students added or replaced since the
+// change numbered since, oldest first, and the number to pass next time.
+// Passing 0 returns every change since the Store was created; an ID may
+// appear more than once.
+func (s *Store) RosterChanges(since int) (ids []string, next int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since = min(max(since, 0), len(s.rosterChanges))
+	return slices.Clone(s.rosterChanges[since:]), len(s.rosterChanges)
+}
+
+// Guardian returns the guardian with the given ID.
+func (s *Store) Guardian(id string) (Guardian, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.guardians[id]
+	return v, ok
+}
+
+// positions maps each key to the positions of its records in records, for
+// record types that have no ID of their own.
+func positions[T any](records []T, key func(T) string) map[string][]int {
+	m := make(map[string][]int)
+	for i, r := range records {
+		m[key(r)] = append(m[key(r)], i)
+	}
+	return m
+}
+
+func at[T any](records []T, positions []int) []T {
+	out := make([]T, 0, len(positions))
+	for _, i := range positions {
+		out = append(out, records[i])
+	}
+	return out
+}
+
+func lookup[T any](m map[string]T, ids []string) []T {
+	out := make([]T, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, m[id])
+	}
+	return out
+}
+
+// AttendanceForStudent returns a student's attendance records ordered by date.
+func (s *Store) AttendanceForStudent(studentID string) []Attendance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := at(s.ds.Attendance, s.attendanceByStudent[studentID])
+	slices.SortFunc(records, func(a, b Attendance) int {
+		return a.Date.Compare(b.Date)
+	})
+	return records
+}
+
+// attendanceKey identifies a student's attendance record for one day.
+type attendanceKey struct {
+	studentID string
+	date      Date
+}
+
+// AttendanceOn returns the attendance records taken for class classID on
+// date, in no particular order.
+func (s *Store) AttendanceOn(classID string, date Date) []Attendance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Attendance
+	for _, id := range s.studentsByClass[classID] {
+		if i, ok := s.attendanceByDay[attendanceKey{id, date}]; ok && s.ds.Attendance[i].ClassID == classID {
+			out = append(out, s.ds.Attendance[i])
+		}
+	}
+	return out
+}
+
+// PutAttendance records attendance taken by teacher by at time now. Records
+// for a student and day that has none are added; records that change an
+// existing status or reason replace it and are returned as audit entries,
+// which are also kept in the store.
+func (s *Store) PutAttendance(records []Attendance, by string, now time.Time) []AttendanceEdit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var edits []AttendanceEdit
+	for _, r := range records {
+		key := attendanceKey{r.StudentID, r.Date}
+		i, ok := s.attendanceByDay[key]
+		if !ok {
+			r.RecordedBy, r.RecordedAt = by, now
+			s.ds.Attendance = append(s.ds.Attendance, r)
+			i = len(s.ds.Attendance) - 1
+			s.attendanceByDay[key] = i
+			s.attendanceByStudent[r.StudentID] = append(s.attendanceByStudent[r.StudentID], i)
+			s.attendanceRevision[r.StudentID]++
+			continue
+		}
+
+		old := &s.ds.Attendance[i]
+		if old.Status == r.Status && old.Reason == r.Reason {
+			continue
+		}
+		e := AttendanceEdit{
+			StudentID:  r.StudentID,
+			ClassID:    old.ClassID,
+			Date:       r.Date,
+			FromStatus: old.Status,
+			FromReason: old.Reason,
+			ToStatus:   r.Status,
+			ToReason:   r.Reason,
+			EditedBy:   by,
+			EditedAt:   now,
+		}
+		old.Status, old.Reason = r.Status, r.Reason
+		s.attendanceRevision[r.StudentID]++
+		s.ds.AttendanceEdits = append(s.ds.AttendanceEdits, e)
+		s.editsByClass[e.ClassID] = append(s.editsByClass[e.ClassID], len(s.ds.AttendanceEdits)-1)
+		edits = append(edits, e)
+	}
+	return edits
+}
+
+// AttendanceEdits returns the audit trail of attendance changes for class
+// classID on date, oldest first.
+func (s *Store) AttendanceEdits(classID string, date Date) []AttendanceEdit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []AttendanceEdit{}
+	for _, e := range at(s.ds.AttendanceEdits, s.editsByClass[classID]) {
+		if e.Date == date {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// AttendanceRevision returns a counter that changes whenever the student's
+// attendance records change.
+func (s *Store) AttendanceRevision(studentID string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.attendanceRevision[studentID]
+}
+
+// Assessment returns the assessment with the given ID.
+func (s *Store) Assessment(id string) (Assessment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.assessments[id]
+	return v, ok
+}
+
+// ScoresForStudent returns a student's assessment scores.
+func (s *Store) ScoresForStudent(studentID string) []Score {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.Scores, s.scoresByStudent[studentID])
+}
+
+// AssessmentsForClass returns the assessments set for class classID,
+// ordered by date and then name.
+func (s *Store) AssessmentsForClass(classID string) []Assessment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := lookup(s.assessments, s.assessmentsByClass[classID])
+	slices.SortFunc(out, func(a, b Assessment) int {
+		return cmp.Or(a.Date.Compare(b.Date), cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return out
+}
+
+// ScoresForAssessment returns the scores entered for an assessment.
+func (s *Store) ScoresForAssessment(assessmentID string) []Score {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.Scores, s.scoresByAssessment[assessmentID])
+}
+
+// scoreKey identifies a student's score for one assessment.
+type scoreKey struct {
+	assessmentID string
+	studentID    string
+}
+
+// PutAssessment adds a, or replaces the assessment with a's ID. The class of
+// an existing assessment cannot change.
+func (s *Store) PutAssessment(a Assessment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.assessments[a.ID]; ok {
+		a.ClassID = old.ClassID
+		i := slices.IndexFunc(s.ds.Assessments, func(v Assessment) bool { return v.ID == a.ID })
+		s.ds.Assessments[i] = a
+	} else {
+		s.ds.Assessments = append(s.ds.Assessments, a)
+		s.assessmentsByClass[a.ClassID] = append(s.assessmentsByClass[a.ClassID], a.ID)
+	}
+	s.assessments[a.ID] = a
+
+	for _, id := range s.studentsByClass[a.ClassID] {
+		s.gradesRevision[id]++
+	}
+}
+
+// PutScores adds scores, replacing any a student already has for the same
+// assessment.
+func (s *Store) PutScores(scores []Score) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sc := range scores {
+		key := scoreKey{sc.AssessmentID, sc.StudentID}
+		if i, ok := s.scoresByKey[key]; ok {
+			s.ds.Scores[i] = sc
+		} else {
+			s.ds.Scores = append(s.ds.Scores, sc)
+			i = len(s.ds.Scores) - 1
+			s.scoresByKey[key] = i
+			s.scoresByStudent[sc.StudentID] = append(s.scoresByStudent[sc.StudentID], i)
+			s.scoresByAssessment[sc.AssessmentID] = append(s.scoresByAssessment[sc.AssessmentID], i)
+		}
+		s.gradesRevision[sc.StudentID]++
+	}
+}
+
+// GradesRevision returns a counter that changes whenever the student's scores,
+// or the assessments of their class, change. Caches of computed results
+// compare it to detect stale entries.
+func (s *Store) GradesRevision(studentID string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.gradesRevision[studentID]
+}
+
+// CCAsForStudent returns a student's co-curricular activities.
+func (s *Store) CCAsForStudent(studentID string) []CCA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.CCAs, s.ccasByStudent[studentID])
+}
+
+// Note returns the note with the given ID, including deleted notes.
+func (s *Store) Note(id string) (Note, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.notes[id]
+	if !ok {
+		return Note{}, false
+	}
+	return s.ds.Notes[i], true
+}
+
+// NotesForStudent returns every note about a student, including deleted
+// notes, newest first.
+func (s *Store) NotesForStudent(studentID string) []Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := at(s.ds.Notes, s.notesByStudent[studentID])
+	slices.SortFunc(notes, func(a, b Note) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return notes
+}
+
+// PutNote adds n, or replaces the note with n's ID, and appends rev to its
+// history in the same step.
+func (s *Store) PutNote(n Note, rev NoteRevision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, ok := s.notes[n.ID]; ok {
+		s.ds.Notes[i] = n
+	} else {
+		s.ds.Notes = append(s.ds.Notes, n)
+		i = len(s.ds.Notes) - 1
+		s.notes[n.ID] = i
+		s.notesByStudent[n.StudentID] = append(s.notesByStudent[n.StudentID], i)
+	}
+	s.ds.NoteRevisions = append(s.ds.NoteRevisions, rev)
+	s.revisionsByNote[rev.NoteID] = append(s.revisionsByNote[rev.NoteID], len(s.ds.NoteRevisions)-1)
+}
+
+// NoteRevisions returns the history of a note, oldest first.
+func (s *Store) NoteRevisions(noteID string) []NoteRevision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.NoteRevisions, s.revisionsByNote[noteID])
+}
+
+// Group returns the group with the given ID.
+func (s *Store) Group(id string) (Group, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.groups[id]
+	if !ok {
+		return Group{}, false
+	}
+	return s.ds.Groups[i], true
+}
+
+// GroupsByOwner returns the groups of a teacher, ordered by name.
+func (s *Store) GroupsByOwner(teacherID string) []Group {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var groups []Group
+	for _, g := range s.ds.Groups {
+		if g.OwnerID == teacherID {
+			groups = append(groups, g)
+		}
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return groups
+}
+
+// PutGroup adds g, or replaces the group with g's ID.
+func (s *Store) PutGroup(g Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.consentRevision++
+
+	if i, ok := s.groups[g.ID]; ok {
+		s.ds.Groups[i] = g
+		return
+	}
+	s.ds.Groups = append(s.ds.Groups, g)
+	s.groups[g.ID] = len(s.ds.Groups) - 1
+}
+
+// DeleteGroup removes group id, reporting whether it existed.
+func (s *Store) DeleteGroup(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.groups[id]
+	if !ok {
+		return false
+	}
+	s.ds.Groups = slices.Delete(s.ds.Groups, i, i+1)
+	s.consentRevision++
+	s.indexPosts()
+	return true
+}
+
+// PostTemplate returns the post template with the given ID.
+func (s *Store) PostTemplate(id string) (PostTemplate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.templates[id]
+	if !ok {
+		return PostTemplate{}, false
+	}
+	return s.ds.PostTemplates[i], true
+}
+
+// PostTemplatesInSchool returns the post templates of a school, ordered by
+// name.
+func (s *Store) PostTemplatesInSchool(schoolID string) []PostTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []PostTemplate
+	for _, t := range s.ds.PostTemplates {
+		if t.SchoolID == schoolID {
+			out = append(out, t)
+		}
+	}
+	slices.SortFunc(out, func(a, b PostTemplate) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return out
+}
+
+// PutPostTemplate adds t, or replaces the template with t's ID.
+func (s *Store) PutPostTemplate(t PostTemplate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, ok := s.templates[t.ID]; ok {
+		s.ds.PostTemplates[i] = t
+		return
+	}
+	s.ds.PostTemplates = append(s.ds.PostTemplates, t)
+	s.templates[t.ID] = len(s.ds.PostTemplates) - 1
+}
+
+// DeletePostTemplate removes template id, reporting whether it existed.
+func (s *Store) DeletePostTemplate(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.templates[id]
+	if !ok {
+		return false
+	}
+	s.ds.PostTemplates = slices.Delete(s.ds.PostTemplates, i, i+1)
+	s.indexPosts()
+	return true
+}
+
+// Post returns the post with the given ID.
+func (s *Store) Post(id string) (Post, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.posts[id]
+	if !ok {
+		return Post{}, false
+	}
+	return s.ds.Posts[i], true
+}
+
+// PostsInSchool returns every post of a school, newest first.
+func (s *Store) PostsInSchool(schoolID string) []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []Post
+	for _, p := range s.ds.Posts {
+		if p.SchoolID == schoolID {
+			posts = append(posts, p)
+		}
+	}
+	slices.SortFunc(posts, func(a, b Post) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return posts
+}
+
+// PostsDue returns every scheduled post due to be published at or before t,
+// the earliest due first.
+func (s *Store) PostsDue(t time.Time) []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []Post
+	for _, p := range s.ds.Posts {
+		if p.Status == PostScheduled && p.PublishAt != nil && !p.PublishAt.After(t) {
+			posts = append(posts, p)
+		}
+	}
+	slices.SortFunc(posts, func(a, b Post) int {
+		return cmp.Or(a.PublishAt.Compare(*b.PublishAt), cmp.Compare(a.ID, b.ID))
+	})
+	return posts
+}
+
+// OpenConsentForms returns every published consent form still open at t,
+// that is whose due time is after t.
+func (s *Store) OpenConsentForms(t time.Time) []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []Post
+	for _, p := range s.ds.Posts {
+		if p.Status == PostPublished && p.Consent != nil && p.Consent.DueAt.After(t) {
+			posts = append(posts, p)
+		}
+	}
+	return posts
+}
+
+// PutPost adds p, or replaces the post with p's ID.
+func (s *Store) PutPost(p Post) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.Consent != nil {
+		s.consentRevision++
+	}
+
+	if i, ok := s.posts[p.ID]; ok {
+		s.ds.Posts[i] = p
+		return
+	}
+	s.ds.Posts = append(s.ds.Posts, p)
+	s.posts[p.ID] = len(s.ds.Posts) - 1
+}
+
+// DeletePost removes post id with its attachments' records, consent
+// responses and reminders, recipients, receipts and notifications, so that
+// none still pending is sent, reporting whether it existed.
+func (s *Store) DeletePost(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.posts[id]
+	if !ok {
+		return false
+	}
+	s.ds.Posts = slices.Delete(s.ds.Posts, i, i+1)
+	s.consentRevision++
+	s.ds.Attachments = slices.DeleteFunc(s.ds.Attachments, func(v Attachment) bool { return v.PostID == id })
+	s.ds.ConsentResponses = slices.DeleteFunc(s.ds.ConsentResponses, func(v ConsentResponse) bool { return v.PostID == id })
+	s.ds.ConsentReminders = slices.DeleteFunc(s.ds.ConsentReminders, func(v ConsentReminder) bool { return v.PostID == id })
+	s.ds.PostRecipients = slices.DeleteFunc(s.ds.PostRecipients, func(v PostRecipient) bool { return v.PostID == id })
+	s.ds.PostReceipts = slices.DeleteFunc(s.ds.PostReceipts, func(v PostReceipt) bool { return v.PostID == id })
+	s.ds.Notifications = slices.DeleteFunc(s.ds.Notifications, func(v Notification) bool { return v.PostID == id })
+	s.indexPosts()
+	return true
+}
+
+// Attachments returns the attachments of post postID, oldest first.
+func (s *Store) Attachments(postID string) []Attachment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.Attachments, s.attachmentsByPost[postID])
+}
+
+// AppendAttachment adds a to its post's attachments.
+func (s *Store) AppendAttachment(a Attachment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ds.Attachments = append(s.ds.Attachments, a)
+	s.attachmentsByPost[a.PostID] = append(s.attachmentsByPost[a.PostID], len(s.ds.Attachments)-1)
+}
+
+// DeleteAttachment removes attachment id, reporting whether it existed.
+func (s *Store) DeleteAttachment(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.ds.Attachments, func(a Attachment) bool { return a.ID == id })
+	if i < 0 {
+		return false
+	}
+	s.ds.Attachments = slices.Delete(s.ds.Attachments, i, i+1)
+	s.indexPosts()
+	return true
+}
+
+// ConsentResponses returns every response to consent form postID, oldest
+// first.
+func (s *Store) ConsentResponses(postID string) []ConsentResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.ConsentResponses, s.responsesByPost[postID])
+}
+
+// AppendConsentResponse adds r to its consent form's responses.
+func (s *Store) AppendConsentResponse(r ConsentResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ds.ConsentResponses = append(s.ds.ConsentResponses, r)
+	s.responsesByPost[r.PostID] = append(s.responsesByPost[r.PostID], len(s.ds.ConsentResponses)-1)
+	s.responded[recipientKey{r.PostID, r.StudentID}] = true
+	s.consentRevision++
+}
+
+// HasConsentResponse reports whether consent form postID has been answered
+// for student studentID.
+func (s *Store) HasConsentResponse(postID, studentID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.responded[recipientKey{postID, studentID}]
+}
+
+// ConsentRevision returns a counter that changes whenever a consent form,
+// a response to one, or a group one may be sent to changes.
+func (s *Store) ConsentRevision() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.consentRevision
+}
+
+// ConsentReminders returns the reminders sent for consent form postID,
+// oldest first.
+func (s *Store) ConsentReminders(postID string) []ConsentReminder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.ConsentReminders, s.remindersByPost[postID])
+}
+
+// AppendConsentReminder records reminder r.
+func (s *Store) AppendConsentReminder(r ConsentReminder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ds.ConsentReminders = append(s.ds.ConsentReminders, r)
+	s.remindersByPost[r.PostID] = append(s.remindersByPost[r.PostID], len(s.ds.ConsentReminders)-1)
+}
+
+// PostRecipients returns the students post postID was sent about when it
+// was published, in the order its targets selected them. It is empty for
+// drafts and for posts published before recipients were recorded.
+func (s *Store) PostRecipients(postID string) []PostRecipient {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.PostRecipients, s.recipientsByPost[postID])
+}
+
+// HasPostRecipients reports whether the recipients of post postID were
+// recorded when it was published.
+func (s *Store) HasPostRecipients(postID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.recipientsByPost[postID]) > 0
+}
+
+// PostRecipient returns the record of post postID being sent about student
+// studentID.
+func (s *Store) PostRecipient(postID, studentID string) (PostRecipient, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.recipients[recipientKey{postID, studentID}]
+	if !ok {
+		return PostRecipient{}, false
+	}
+	return s.ds.PostRecipients[i], true
+}
+
+// PutPostRecipients records rs, replacing any record for the same post and
+// student, under one lock.
+func (s *Store) PutPostRecipients(rs ...PostRecipient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range rs {
+		k := recipientKey{r.PostID, r.StudentID}
+		if i, ok := s.recipients[k]; ok {
+			s.ds.PostRecipients[i] = r
+			continue
+		}
+		s.ds.PostRecipients = append(s.ds.PostRecipients, r)
+		s.recipients[k] = len(s.ds.PostRecipients) - 1
+		s.recipientsByPost[r.PostID] = append(s.recipientsByPost[r.PostID], len(s.ds.PostRecipients)-1)
+	}
+}
+
+// PostReceipts returns the receipts of post postID, in the order they were
+// first recorded.
+func (s *Store) PostReceipts(postID string) []PostReceipt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.PostReceipts, s.receiptsByPost[postID])
+}
+
+// PostReceipt returns the receipt of post postID for guardian guardianID.
+func (s *Store) PostReceipt(postID, guardianID string) (PostReceipt, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.receipts[receiptKey{postID, guardianID}]
+	if !ok {
+		return PostReceipt{}, false
+	}
+	return s.ds.PostReceipts[i], true
+}
+
+// PutPostReceipts adds rs, replacing any receipt for the same post and
+// guardian, under one lock.
+func (s *Store) PutPostReceipts(rs ...PostReceipt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range rs {
+		k := receiptKey{r.PostID, r.GuardianID}
+		if i, ok := s.receipts[k]; ok {
+			s.ds.PostReceipts[i] = r
+			continue
+		}
+		s.ds.PostReceipts = append(s.ds.PostReceipts, r)
+		s.receipts[k] = len(s.ds.PostReceipts) - 1
+		s.receiptsByPost[r.PostID] = append(s.receiptsByPost[r.PostID], len(s.ds.PostReceipts)-1)
+	}
+}
+
+// Notifications returns the notifications about post postID, oldest first.
+func (s *Store) Notifications(postID string) []Notification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return at(s.ds.Notifications, s.notificationsByPost[postID])
+}
+
+// Notification returns notification id.
+func (s *Store) Notification(id string) (Notification, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.notifications[id]
+	if !ok {
+		return Notification{}, false
+	}
+	return s.ds.Notifications[i], true
+}
+
+// PendingNotifications returns every notification not yet sent or given up
+// on, oldest first.
+func (s *Store) PendingNotifications() []Notification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Notification
+	for _, n := range s.ds.Notifications {
+		if n.Status == NotificationPending {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// UpdateNotification replaces notification n, reporting whether it still
+// exists: one deleted with its post is not brought back.
+func (s *Store) UpdateNotification(n Notification) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.notifications[n.ID]
+	if ok {
+		s.ds.Notifications[i] = n
+	}
+	return ok
+}
+
+// PutNotifications adds ns, replacing any notification with the same ID,
+// under one lock.
+func (s *Store) PutNotifications(ns ...Notification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range ns {
+		if i, ok := s.notifications[n.ID]; ok {
+			s.ds.Notifications[i] = n
+			continue
+		}
+		s.ds.Notifications = append(s.ds.Notifications, n)
+		s.notifications[n.ID] = len(s.ds.Notifications) - 1
+		s.notificationsByPost[n.PostID] = append(s.notificationsByPost[n.PostID], len(s.ds.Notifications)-1)
+	}
+}
+
+// AppendAudit adds entries to the audit log.
+func (s *Store) AppendAudit(entries ...AuditEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ds.AuditLog = append(s.ds.AuditLog, entries...)
+}
+
+// AuditLog returns the audit log, oldest first.
+func (s *Store) AuditLog() []AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.ds.AuditLog)
+}
@@ -0,0 +1,437 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func testDataset() *Dataset {
+	return &Dataset{
+		Schools:  []School{{ID: "s1"}, {ID: "s2"}},
+		Levels:   []Level{{ID: "l2", SchoolID: "s1", Number: 2}, {ID: "l1", SchoolID: "s1", Number: 1}},
+		Classes:  []Class{{ID: "c1", SchoolID: "s1", LevelID: "l1"}, {ID: "c2", SchoolID: "s2"}},
+		Teachers: []Teacher{{ID: "t1", SchoolID: "s1"}},
+		APIKeys:  []APIKey{{Hash: "h1", TeacherID: "t1"}, {Hash: "orphan", TeacherID: "gone"}},
+		Teaching: []Teaching{{TeacherID: "t1", ClassID: "c1", Subject: "English"}},
+		Students: []Student{
+			{ID: "st2", SchoolID: "s1", ClassID: "c1", IndexNumber: 2},
+			{ID: "st1", SchoolID: "s1", ClassID: "c1", IndexNumber: 1},
+			{ID: "st3", SchoolID: "s2", ClassID: "c2", IndexNumber: 1},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := New(testDataset())
+
+	t.Run("StudentsInClass orders by index number", func(t *testing.T) {
+		students := s.StudentsInClass("c1")
+
+		require.Equal(t, 2, len(students))
+		require.Equal(t, "st1", students[0].ID)
+		require.Equal(t, "st2", students[1].ID)
+	})
+
+	t.Run("StudentsInSchool stays within the school", func(t *testing.T) {
+		require.Equal(t, 2, len(s.StudentsInSchool("s1")))
+		require.Equal(t, 1, len(s.StudentsInSchool("s2")))
+	})
+
+	t.Run("Levels orders by number", func(t *testing.T) {
+		levels := s.Levels("s1")
+
+		require.Equal(t, "l1", levels[0].ID)
+		require.Equal(t, "l2", levels[1].ID)
+	})
+
+	t.Run("TeacherByAPIKey resolves the key owner", func(t *testing.T) {
+		teacher, ok := s.TeacherByAPIKey("h1")
+
+		require.True(t, ok)
+		require.Equal(t, "t1", teacher.ID)
+	})
+
+	t.Run("TeacherByAPIKey rejects unknown and orphaned keys", func(t *testing.T) {
+		_, ok := s.TeacherByAPIKey("nope")
+		require.False(t, ok)
+
+		_, ok = s.TeacherByAPIKey("orphan")
+		require.False(t, ok)
+	})
+
+	t.Run("TeachingByClass and TeachingByTeacher share assignments", func(t *testing.T) {
+		require.Equal(t, 1, len(s.TeachingByClass("c1")))
+		require.Equal(t, "English", s.TeachingByTeacher("t1")[0].Subject)
+	})
+
+	t.Run("SetInsightThresholds replaces a school's thresholds", func(t *testing.T) {
+		require.True(t, s.SetInsightThresholds("s1", &InsightThresholds{ConsecutiveAbsences: 5}))
+		require.False(t, s.SetInsightThresholds("gone", nil))
+
+		school, _ := s.School("s1")
+		require.Equal(t, 5, school.InsightThresholds.ConsecutiveAbsences)
+		require.Equal(t, 5, s.Schools()[0].InsightThresholds.ConsecutiveAbsences)
+	})
+
+	t.Run("SetReportBranding replaces a school's branding", func(t *testing.T) {
+		require.True(t, s.SetReportBranding("s1", &ReportBranding{AccentColour: "#123456"}))
+		require.False(t, s.SetReportBranding("gone", nil))
+
+		school, _ := s.School("s1")
+		require.Equal(t, "#123456", school.ReportBranding.AccentColour)
+		require.Equal(t, "#123456", s.Schools()[0].ReportBranding.AccentColour)
+	})
+}
+
+func TestPutAttendance(t *testing.T) {
+	s := New(testDataset())
+	day := Date{Year: 2026, Month: time.January, Day: 5}
+	taken := time.Date(2026, time.January, 5, 7, 30, 0, 0, time.UTC)
+
+	t.Run("adds records for days not yet taken", func(t *testing.T) {
+		edits := s.PutAttendance([]Attendance{
+			{StudentID: "st1", ClassID: "c1", Date: day, Status: AttendancePresent},
+			{StudentID: "st2", ClassID: "c1", Date: day, Status: AttendanceAbsent},
+		}, "t1", taken)
+
+		require.Equal(t, 0, len(edits))
+		require.Equal(t, 2, len(s.AttendanceOn("c1", day)))
+		require.Equal(t, "t1", s.AttendanceForStudent("st1")[0].RecordedBy)
+	})
+
+	t.Run("replaces changed records and audits the change", func(t *testing.T) {
+		edited := taken.Add(2 * time.Hour)
+
+		edits := s.PutAttendance([]Attendance{
+			{StudentID: "st1", ClassID: "c1", Date: day, Status: AttendancePresent},
+			{StudentID: "st2", ClassID: "c1", Date: day, Status: AttendanceLate, Reason: "Bus delay"},
+		}, "t2", edited)
+
+		require.Equal(t, 1, len(edits))
+		want := AttendanceEdit{
+			StudentID: "st2", ClassID: "c1", Date: day,
+			FromStatus: AttendanceAbsent, ToStatus: AttendanceLate, ToReason: "Bus delay",
+			EditedBy: "t2", EditedAt: edited,
+		}
+		require.Equal(t, want, edits[0])
+		require.Equal(t, 1, len(s.AttendanceEdits("c1", day)))
+		require.Equal(t, want, s.AttendanceEdits("c1", day)[0])
+		require.Equal(t, 0, len(s.AttendanceEdits("c1", day.AddDays(1))))
+
+		records := s.AttendanceForStudent("st2")
+		require.Equal(t, 1, len(records))
+		require.Equal(t, AttendanceLate, records[0].Status)
+		require.Equal(t, "t1", records[0].RecordedBy)
+	})
+
+	t.Run("AttendanceRevision changes only with the student's records", func(t *testing.T) {
+		before := s.AttendanceRevision("st1")
+
+		s.PutAttendance([]Attendance{{StudentID: "st1", ClassID: "c1", Date: day, Status: AttendancePresent}}, "t1", taken)
+		require.Equal(t, before, s.AttendanceRevision("st1"))
+
+		s.PutAttendance([]Attendance{{StudentID: "st1", ClassID: "c1", Date: day.AddDays(1), Status: AttendancePresent}}, "t1", taken)
+		require.True(t, s.AttendanceRevision("st1") > before)
+	})
+}
+
+func TestPutScores(t *testing.T) {
+	s := New(testDataset())
+	s.PutAssessment(Assessment{ID: "a2", ClassID: "c1", Name: "B", Date: Date{Year: 2026, Month: time.March, Day: 2}})
+	s.PutAssessment(Assessment{ID: "a1", ClassID: "c1", Name: "A", Date: Date{Year: 2026, Month: time.March, Day: 2}})
+
+	t.Run("AssessmentsForClass orders by date and name", func(t *testing.T) {
+		got := s.AssessmentsForClass("c1")
+
+		require.Equal(t, 2, len(got))
+		require.Equal(t, "a1", got[0].ID)
+	})
+
+	t.Run("replaces a student's existing score", func(t *testing.T) {
+		s.PutScores([]Score{{AssessmentID: "a1", StudentID: "st1", Marks: 5}})
+		s.PutScores([]Score{{AssessmentID: "a1", StudentID: "st1", Marks: 7}, {AssessmentID: "a1", StudentID: "st2", Status: ScoreAbsent}})
+
+		require.Equal(t, 2, len(s.ScoresForAssessment("a1")))
+		require.Equal(t, 1, len(s.ScoresForStudent("st1")))
+		require.Equal(t, 7.0, s.ScoresForStudent("st1")[0].Marks)
+	})
+
+	t.Run("GradesRevision changes with scores and assessments", func(t *testing.T) {
+		before := s.GradesRevision("st2")
+
+		s.PutScores([]Score{{AssessmentID: "a1", StudentID: "st1", Marks: 8}})
+		require.Equal(t, before, s.GradesRevision("st2"))
+
+		s.PutAssessment(Assessment{ID: "a1", ClassID: "c1", Name: "A", MaxMarks: 10})
+		require.NotEqual(t, before, s.GradesRevision("st2"))
+		require.Equal(t, 2, len(s.AssessmentsForClass("c1")))
+	})
+}
+
+func TestPutRoster(t *testing.T) {
+	s := New(testDataset())
+
+	t.Run("adds students and guardians", func(t *testing.T) {
+		s.PutRoster(
+			[]Student{{ID: "st4", SchoolID: "s1", ClassID: "c1", ExternalID: "T0412345A", IndexNumber: 3, Guardians: []GuardianLink{{GuardianID: "g1"}}}},
+			[]Guardian{{ID: "g1", Name: "Lim Mei Ling"}},
+		)
+
+		st, ok := s.StudentByExternalID("s1", "T0412345A")
+		require.True(t, ok)
+		require.Equal(t, "st4", st.ID)
+		require.Equal(t, 3, len(s.StudentsInClass("c1")))
+		_, ok = s.StudentByExternalID("s2", "T0412345A")
+		require.False(t, ok)
+		g, _ := s.Guardian("g1")
+		require.Equal(t, "Lim Mei Ling", g.Name)
+	})
+
+	t.Run("moves a replaced student between classes", func(t *testing.T) {
+		before := s.GradesRevision("st4")
+
+		s.PutRoster([]Student{{ID: "st4", SchoolID: "s1", ClassID: "c3", ExternalID: "T0412345A", IndexNumber: 1}}, nil)
+
+		require.Equal(t, 2, len(s.StudentsInClass("c1")))
+		require.Equal(t, 1, len(s.StudentsInClass("c3")))
+		require.Equal(t, 3, len(s.StudentsInSchool("s1")))
+		require.NotEqual(t, before, s.GradesRevision("st4"))
+	})
+
+	t.Run("logs changed students", func(t *testing.T) {
+		ids, next := s.RosterChanges(0)
+		require.Equal(t, 2, next)
+		require.Equal(t, "st4", ids[1])
+
+		s.PutRoster([]Student{{ID: "st5", SchoolID: "s1", ClassID: "c3"}}, nil)
+
+		ids, next = s.RosterChanges(next)
+		require.Equal(t, 3, next)
+		require.Equal(t, 1, len(ids))
+		require.Equal(t, "st5", ids[0])
+	})
+}
+
+func TestPosts(t *testing.T) {
+	s := New(testDataset())
+	at := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("PostsInSchool orders newest first", func(t *testing.T) {
+		s.PutPost(Post{ID: "p1", SchoolID: "s1", CreatedAt: at})
+		s.PutPost(Post{ID: "p2", SchoolID: "s1", CreatedAt: at.Add(time.Hour)})
+		s.PutPost(Post{ID: "p3", SchoolID: "s2", CreatedAt: at})
+
+		posts := s.PostsInSchool("s1")
+
+		require.Equal(t, 2, len(posts))
+		require.Equal(t, "p2", posts[0].ID)
+	})
+
+	t.Run("DeletePost keeps the other posts reachable", func(t *testing.T) {
+		require.True(t, s.DeletePost("p1"))
+		require.False(t, s.DeletePost("p1"))
+
+		_, ok := s.Post("p1")
+		require.False(t, ok)
+		p, ok := s.Post("p3")
+		require.True(t, ok)
+		require.Equal(t, "s2", p.SchoolID)
+	})
+
+	t.Run("PostsDue returns scheduled posts that are due, earliest first", func(t *testing.T) {
+		later, earlier := at.Add(2*time.Hour), at.Add(time.Hour)
+		s.PutPost(Post{ID: "p4", SchoolID: "s1", Status: PostScheduled, PublishAt: &later})
+		s.PutPost(Post{ID: "p5", SchoolID: "s2", Status: PostScheduled, PublishAt: &earlier})
+		s.PutPost(Post{ID: "p6", SchoolID: "s1", Status: PostPublished, PublishAt: &earlier})
+
+		require.Equal(t, 0, len(s.PostsDue(at)))
+		posts := s.PostsDue(later)
+		require.Equal(t, 2, len(posts))
+		require.Equal(t, "p5", posts[0].ID)
+	})
+
+	t.Run("consent responses and open forms", func(t *testing.T) {
+		s.PutPost(Post{ID: "p7", SchoolID: "s1", Status: PostPublished, Consent: &ConsentForm{DueAt: at.Add(time.Hour)}})
+		s.PutPost(Post{ID: "p8", SchoolID: "s1", Status: PostPublished, Consent: &ConsentForm{DueAt: at}})
+		s.AppendConsentResponse(ConsentResponse{PostID: "p7", StudentID: "st1", GuardianID: "g1"})
+		s.AppendConsentResponse(ConsentResponse{PostID: "p8", StudentID: "st1", GuardianID: "g1"})
+		s.AppendConsentResponse(ConsentResponse{PostID: "p7", StudentID: "st1", GuardianID: "g2"})
+
+		open := s.OpenConsentForms(at)
+		require.Equal(t, 1, len(open))
+		require.Equal(t, "p7", open[0].ID)
+		responses := s.ConsentResponses("p7")
+		require.Equal(t, 2, len(responses))
+		require.Equal(t, "g2", responses[1].GuardianID)
+		require.True(t, s.HasConsentResponse("p8", "st1"))
+		require.False(t, s.HasConsentResponse("p8", "st2"))
+	})
+
+	t.Run("post receipts are kept per guardian", func(t *testing.T) {
+		later := at.Add(time.Hour)
+		s.PutPostReceipts(
+			PostReceipt{PostID: "p7", GuardianID: "g1", DeliveredAt: &at},
+			PostReceipt{PostID: "p7", GuardianID: "g2", DeliveredAt: &at},
+			PostReceipt{PostID: "p8", GuardianID: "g1", DeliveredAt: &at},
+		)
+		s.PutPostReceipts(PostReceipt{PostID: "p7", GuardianID: "g1", DeliveredAt: &at, OpenedAt: &later})
+
+		receipts := s.PostReceipts("p7")
+		require.Equal(t, 2, len(receipts))
+		require.Equal(t, "g1", receipts[0].GuardianID)
+		require.Equal(t, later, *receipts[0].OpenedAt)
+		r, ok := s.PostReceipt("p8", "g1")
+		require.True(t, ok)
+		require.True(t, r.OpenedAt == nil)
+	})
+
+	t.Run("post recipients are kept per student", func(t *testing.T) {
+		s.PutPostRecipients(
+			PostRecipient{PostID: "p7", StudentID: "st1", Via: []Target{{Type: TargetGroup, ID: "gr1"}}},
+			PostRecipient{PostID: "p7", StudentID: "st2"},
+		)
+		s.PutPostRecipients(PostRecipient{PostID: "p7", StudentID: "st1", Guardians: []GuardianLink{{GuardianID: "g1"}}})
+
+		recipients := s.PostRecipients("p7")
+		require.Equal(t, 2, len(recipients))
+		require.Equal(t, "st1", recipients[0].StudentID)
+		r, ok := s.PostRecipient("p7", "st1")
+		require.True(t, ok)
+		require.Equal(t, "g1", r.Guardians[0].GuardianID)
+		_, ok = s.PostRecipient("p8", "st1")
+		require.False(t, ok)
+		require.False(t, s.HasPostRecipients("p8"))
+	})
+
+	t.Run("notifications are kept per post", func(t *testing.T) {
+		s.PutNotifications(
+			Notification{ID: "n1", PostID: "p7", GuardianID: "g1", Kind: NotifyPost, Status: NotificationPending},
+			Notification{ID: "n2", PostID: "p7", GuardianID: "g2", Kind: NotifyPost, Status: NotificationPending},
+		)
+		n, ok := s.Notification("n1")
+		require.True(t, ok)
+		n.Status, n.Attempts = NotificationSent, 1
+		s.PutNotifications(n)
+
+		require.Equal(t, 2, len(s.Notifications("p7")))
+		require.Equal(t, NotificationSent, s.Notifications("p7")[0].Status)
+		pending := s.PendingNotifications()
+		require.Equal(t, 1, len(pending))
+		require.Equal(t, "n2", pending[0].ID)
+	})
+
+	t.Run("a post's records go with it", func(t *testing.T) {
+		s.PutPost(Post{ID: "p10", SchoolID: "s1", Status: PostPublished, Consent: &ConsentForm{DueAt: at.Add(time.Hour)}})
+		s.AppendConsentResponse(ConsentResponse{PostID: "p10", StudentID: "st1", GuardianID: "g1"})
+		s.AppendConsentReminder(ConsentReminder{PostID: "p10", SentAt: at})
+		s.PutPostRecipients(PostRecipient{PostID: "p10", StudentID: "st1"})
+		s.PutPostReceipts(PostReceipt{PostID: "p10", GuardianID: "g1", DeliveredAt: &at})
+		s.PutNotifications(Notification{ID: "n10", PostID: "p10", GuardianID: "g1", Kind: NotifyReminder, Status: NotificationPending})
+
+		require.True(t, s.DeletePost("p10"))
+
+		require.Equal(t, 0, len(s.ConsentResponses("p10")))
+		require.False(t, s.HasConsentResponse("p10", "st1"))
+		require.Equal(t, 0, len(s.ConsentReminders("p10")))
+		require.False(t, s.HasPostRecipients("p10"))
+		_, ok := s.PostReceipt("p10", "g1")
+		require.False(t, ok)
+		_, ok = s.Notification("n10")
+		require.False(t, ok)
+		require.False(t, s.UpdateNotification(Notification{ID: "n10", PostID: "p10", Status: NotificationSent}))
+		require.Equal(t, 2, len(s.PostReceipts("p7")))
+		require.Equal(t, 2, len(s.Notifications("p7")))
+	})
+
+	t.Run("attachments go with their post", func(t *testing.T) {
+		s.PutPost(Post{ID: "p9", SchoolID: "s1", Status: PostDraft})
+		s.AppendAttachment(Attachment{ID: "a1", PostID: "p9", Name: "form.pdf"})
+		s.AppendAttachment(Attachment{ID: "a2", PostID: "p9", Name: "map.png"})
+		s.AppendAttachment(Attachment{ID: "a3", PostID: "p7", Name: "menu.pdf"})
+
+		require.True(t, s.DeleteAttachment("a1"))
+		require.False(t, s.DeleteAttachment("a1"))
+		require.Equal(t, "map.png", s.Attachments("p9")[0].Name)
+		require.True(t, s.DeletePost("p9"))
+
+		require.Equal(t, 0, len(s.Attachments("p9")))
+		require.Equal(t, 1, len(s.Attachments("p7")))
+	})
+
+	t.Run("groups are kept per owner", func(t *testing.T) {
+		s.PutGroup(Group{ID: "g2", OwnerID: "t1", Name: "Robotics"})
+		s.PutGroup(Group{ID: "g1", OwnerID: "t1", Name: "Choir"})
+		s.PutGroup(Group{ID: "g3", OwnerID: "t2", Name: "Chess"})
+		require.True(t, s.DeleteGroup("g3"))
+
+		groups := s.GroupsByOwner("t1")
+
+		require.Equal(t, 2, len(groups))
+		require.Equal(t, "Choir", groups[0].Name)
+		_, ok := s.Group("g3")
+		require.False(t, ok)
+	})
+
+	t.Run("post templates are kept per school", func(t *testing.T) {
+		s.PutPostTemplate(PostTemplate{ID: "pt1", SchoolID: "s1", Name: "Sports Day"})
+		s.PutPostTemplate(PostTemplate{ID: "pt2", SchoolID: "s1", Name: "Excursion"})
+		s.PutPostTemplate(PostTemplate{ID: "pt3", SchoolID: "s2", Name: "Term letter"})
+		s.PutPostTemplate(PostTemplate{ID: "pt2", SchoolID: "s1", Name: "Zoo trip"})
+		require.True(t, s.DeletePostTemplate("pt1"))
+		require.False(t, s.DeletePostTemplate("pt1"))
+
+		templates := s.PostTemplatesInSchool("s1")
+
+		require.Equal(t, 1, len(templates))
+		require.Equal(t, "Zoo trip", templates[0].Name)
+		pt, ok := s.PostTemplate("pt3")
+		require.True(t, ok)
+		require.Equal(t, "Term letter", pt.Name)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("reads a dataset file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.json")
+		b, err := json.Marshal(testDataset())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, b, 0o600))
+
+		s, err := Load(path)
+		require.NoError(t, err)
+
+		_, ok := s.Student("st3")
+		require.True(t, ok)
+	})
+
+	t.Run("reads what Save writes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.json")
+		s := New(testDataset())
+		s.PutRoster([]Student{{ID: "st4", SchoolID: "s1", ClassID: "c1", ExternalID: "T0412345A"}}, nil)
+
+		require.NoError(t, s.Save(path))
+		loaded, err := Load(path)
+		require.NoError(t, err)
+
+		st, ok := loaded.StudentByExternalID("s1", "T0412345A")
+		require.True(t, ok)
+		require.Equal(t, "st4", st.ID)
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(entries))
+	})
+
+	t.Run("reports malformed files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		_, err := Load(path)
+
+		require.Error(t, err)
+	})
+}
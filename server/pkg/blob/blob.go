@@ -0,0 +1,38 @@
+// Package blob stores files, such as post attachments, by key in a local
+// directory or an S3-compatible bucket.
+//
+// Keys are slash-separated paths like "attachments/0F3A…" with no empty,
+// "." or ".." elements, so that a key can never name a file outside the
+// store.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// ErrNotFound is returned by Get for keys that hold nothing.
+var ErrNotFound = errors.New("blob: not found")
+
+// Store holds blobs by key.
+type Store interface {
+	// Put stores the size bytes read from r under key, replacing what was
+	// there. The blob is never seen half written.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a key that holds nothing
+	// is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+func checkKey(key string) error {
+	if key == "." || !fs.ValidPath(key) || strings.Contains(key, `\`) {
+		return fmt.Errorf("blob: invalid key %q", key)
+	}
+	return nil
+}
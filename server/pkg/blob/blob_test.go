@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// testStore checks the behaviour every Store shares.
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	body := "%PDF-1.7 consent form"
+
+	t.Run("puts, gets and replaces blobs", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, "attachments/a 1", strings.NewReader("old"), 3, "text/plain"))
+		require.NoError(t, s.Put(ctx, "attachments/a 1", strings.NewReader(body), int64(len(body)), "application/pdf"))
+
+		r, err := s.Get(ctx, "attachments/a 1")
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, body, string(b))
+	})
+
+	t.Run("deletes blobs, missing or not", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, "attachments/a 1"))
+		require.NoError(t, s.Delete(ctx, "attachments/a 1"))
+
+		_, err := s.Get(ctx, "attachments/a 1")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("rejects keys outside the store", func(t *testing.T) {
+		for _, key := range []string{"", ".", "../x", "a/../../x", "/x", `a\x`, "a//x"} {
+			require.Error(t, s.Put(ctx, key, strings.NewReader(""), 0, "text/plain"))
+		}
+	})
+}
+
+func TestDir(t *testing.T) {
+	d, err := NewDir(t.TempDir())
+	require.NoError(t, err)
+
+	testStore(t, d)
+
+	t.Run("rejects short reads", func(t *testing.T) {
+		err := d.Put(context.Background(), "short", strings.NewReader("abc"), 4, "text/plain")
+
+		require.Error(t, err)
+		_, err = d.Get(context.Background(), "short")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+}
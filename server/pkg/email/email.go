@@ -0,0 +1,126 @@
+// Package email composes email messages and sends them through an SMTP
+// relay.
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// errInvalid is returned for messages that cannot be sent as they are.
+var errInvalid = errors.New("email: invalid message")
+
+// Sender sends messages.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Message is an email to one recipient.
+type Message struct {
+	From    mail.Address
+	To      mail.Address
+	Subject string
+	// Text is the plain text body. HTML, if not empty, is sent alongside it
+	// as an alternative.
+	Text string
+	HTML string
+	// Date defaults to when the message is composed.
+	Date time.Time
+	// ID, if not empty, is the local part of the Message-ID, which is
+	// qualified with the sender's domain.
+	ID string
+}
+
+// Bytes returns m as an RFC 5322 message. Both bodies are UTF-8 and
+// quoted-printable, so the message is 7-bit clean.
+func (m Message) Bytes() ([]byte, error) {
+	for _, a := range []mail.Address{m.From, m.To} {
+		if strings.ContainsAny(a.Address, "\r\n") {
+			return nil, fmt.Errorf("%w: address %q", errInvalid, a.Address)
+		}
+		if _, err := mail.ParseAddress(a.Address); err != nil {
+			return nil, fmt.Errorf("%w: address %q", errInvalid, a.Address)
+		}
+	}
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("Date", date.Format(time.RFC1123Z))
+	header("From", m.From.String())
+	header("To", m.To.String())
+	// Line breaks in the subject would start new headers.
+	subject := strings.Join(strings.Fields(m.Subject), " ")
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	if m.ID != "" {
+		_, domain, _ := strings.Cut(m.From.Address, "@")
+		header("Message-ID", "<"+m.ID+"@"+domain+">")
+	}
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQuotedPrintable(&b, m.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// Permanent reports whether err, returned by Send, is a permanent failure,
+// such as the relay refusing the recipient, that sending the same message
+// again would not fix. Other failures, such as the relay being unreachable
+// or asking the sender to try later, are transient.
+func Permanent(err error) bool {
+	var e *textproto.Error
+	if errors.As(err, &e) {
+		return e.Code >= 500
+	}
+	return errors.Is(err, errInvalid)
+}
@@ -0,0 +1,83 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestMessage(t *testing.T) {
+	m := Message{
+		From:    mail.Address{Name: "Teacher Workspace", Address: "noreply@school.example"},
+		To:      mail.Address{Name: "Zhang Wei 张伟", Address: "zhang@example.com"},
+		Subject: "Excursion to the Science Centre",
+		Text:    "Dear Zhang Wei,\nMeet at 7.30am.",
+		HTML:    "<p>Dear Zhang Wei,</p><p>Meet at 7.30am.</p>",
+		Date:    time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC),
+		ID:      "n1",
+	}
+
+	t.Run("composes both bodies as alternatives", func(t *testing.T) {
+		b, err := m.Bytes()
+		require.NoError(t, err)
+
+		msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+		require.NoError(t, err)
+		require.Equal(t, m.Subject, msg.Header.Get("Subject"))
+		require.Equal(t, "<n1@school.example>", msg.Header.Get("Message-ID"))
+		to, err := msg.Header.AddressList("To")
+		require.NoError(t, err)
+		require.Equal(t, m.To, *to[0])
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		require.Equal(t, "multipart/alternative", mediaType)
+		r := multipart.NewReader(msg.Body, params["boundary"])
+		for _, want := range []string{m.Text, m.HTML} {
+			p, err := r.NextPart()
+			require.NoError(t, err)
+			body, err := io.ReadAll(quotedprintable.NewReader(p))
+			require.NoError(t, err)
+			require.Equal(t, strings.ReplaceAll(want, "\n", "\r\n"), string(body))
+		}
+	})
+
+	t.Run("keeps line breaks out of the headers", func(t *testing.T) {
+		m := m
+		m.Subject = "Hello\r\nBcc: victim@example.com"
+		m.HTML = ""
+
+		b, err := m.Bytes()
+		require.NoError(t, err)
+		msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+		require.NoError(t, err)
+		require.Equal(t, "", msg.Header.Get("Bcc"))
+		require.Equal(t, "Hello Bcc: victim@example.com", msg.Header.Get("Subject"))
+
+		m.To.Address = "zhang@example.com\r\nBcc: victim@example.com"
+		_, err = m.Bytes()
+		require.Error(t, err)
+		require.True(t, Permanent(err))
+	})
+
+	t.Run("encodes non-ASCII subjects", func(t *testing.T) {
+		m := m
+		m.Subject = "家长会"
+
+		b, err := m.Bytes()
+		require.NoError(t, err)
+		require.False(t, strings.Contains(string(b), "家长会"))
+		msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+		require.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		require.NoError(t, err)
+		require.Equal(t, "家长会", subject)
+	})
+}
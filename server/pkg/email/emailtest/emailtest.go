@@ -0,0 +1,241 @@
+// Package emailtest provides an in-process SMTP server for tests, in the
+// manner of net/http/httptest.
+package emailtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is a message the Server accepted.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Parse parses the message the server received.
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(bytes.NewReader(m.Data))
+}
+
+// Server is an SMTP server on a loopback address that keeps the messages
+// it accepts. It speaks enough SMTP for net/smtp: EHLO, AUTH PLAIN, MAIL,
+// RCPT, DATA, RSET, NOOP and QUIT. It does not offer STARTTLS.
+type Server struct {
+	// Addr is the server's host and port.
+	Addr string
+
+	l  net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	conns    map[net.Conn]bool
+	messages []Message
+	failures []int
+	username string
+	password string
+}
+
+// NewServer starts a Server. The caller should Close it.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("emailtest: listening: " + err.Error())
+	}
+	s := &Server{Addr: l.Addr().String(), l: l, conns: make(map[net.Conn]bool)}
+	s.wg.Go(s.accept)
+	return s
+}
+
+// Close stops the server and waits for its connections to end.
+func (s *Server) Close() {
+	s.l.Close()
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// RequireAuth makes the server accept mail only from clients that
+// authenticate as username with password.
+func (s *Server) RequireAuth(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.username, s.password = username, password
+}
+
+// Fail makes the server refuse the next recipients, one per code, with
+// those reply codes: 4xx codes are transient failures and 5xx permanent.
+func (s *Server) Fail(codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, codes...)
+}
+
+// Messages returns the messages the server has accepted, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Go(func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.serve(textproto.NewConn(conn))
+		})
+	}
+}
+
+// serve holds one SMTP conversation.
+func (s *Server) serve(c *textproto.Conn) {
+	var (
+		authed bool
+		m      *Message
+	)
+	reply := func(line string) bool { return c.PrintfLine("%s", line) == nil }
+	if !reply("220 emailtest ESMTP") {
+		return
+	}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		s.mu.Lock()
+		needAuth := s.username != "" && !authed
+		s.mu.Unlock()
+
+		var ok bool
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ok = reply("250-emailtest") && reply("250-AUTH PLAIN") && reply("250 8BITMIME")
+		case "HELO", "NOOP":
+			ok = reply("250 OK")
+		case "AUTH":
+			authed = s.auth(arg)
+			if authed {
+				ok = reply("235 Authenticated")
+			} else {
+				ok = reply("535 Authentication failed")
+			}
+		case "MAIL":
+			switch {
+			case needAuth:
+				ok = reply("530 Authentication required")
+			case m != nil:
+				ok = reply("503 Sender already given")
+			default:
+				m = &Message{From: address(arg)}
+				ok = reply("250 OK")
+			}
+		case "RCPT":
+			if m == nil {
+				ok = reply("503 Need MAIL first")
+			} else if code, failed := s.failure(); failed {
+				ok = c.PrintfLine("%d Simulated failure", code) == nil
+			} else {
+				m.To = append(m.To, address(arg))
+				ok = reply("250 OK")
+			}
+		case "DATA":
+			if m == nil || len(m.To) == 0 {
+				ok = reply("503 Need RCPT first")
+				break
+			}
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			m.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, *m)
+			s.mu.Unlock()
+			m = nil
+			ok = reply("250 OK")
+		case "RSET":
+			m = nil
+			ok = reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			ok = reply("502 Command not implemented")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// auth checks the argument of AUTH PLAIN against the required credentials.
+func (s *Server) auth(arg string) bool {
+	mech, resp, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mech, "PLAIN") {
+		return false
+	}
+	b, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(string(b), "\x00")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(parts) == 3 && parts[1] == s.username && parts[2] == s.password
+}
+
+func (s *Server) failure() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) == 0 {
+		return 0, false
+	}
+	code := s.failures[0]
+	s.failures = s.failures[1:]
+	return code, true
+}
+
+// address returns the address in the argument of MAIL FROM:<...> or
+// RCPT TO:<...>.
+func address(arg string) string {
+	_, a, _ := strings.Cut(arg, "<")
+	a, _, _ = strings.Cut(a, ">")
+	return a
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// DefaultTimeout bounds one message's conversation with the relay unless
+// SMTPConfig says otherwise.
+const DefaultTimeout = 30 * time.Second
+
+// SMTPConfig locates an SMTP relay and the credentials to send through it
+// with.
+type SMTPConfig struct {
+	// Addr is the relay's host and port, as in "smtp.example.com:587".
+	Addr string
+	// Username and Password, if Username is set, are sent with AUTH PLAIN,
+	// which is only done over TLS or to a relay on localhost.
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// SMTP sends messages through an SMTP relay, one connection per message.
+// It upgrades to TLS whenever the relay offers STARTTLS.
+type SMTP struct {
+	cfg  SMTPConfig
+	host string
+}
+
+// NewSMTP returns an SMTP sender for cfg.
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil || host == "" || port == "" {
+		return nil, fmt.Errorf("email: relay address must be host:port, not %q", cfg.Addr)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &SMTP{cfg: cfg, host: host}, nil
+}
+
+// Send delivers m to the relay. The relay's replies are returned as
+// *textproto.Error, which Permanent tells apart.
+func (s *SMTP) Send(ctx context.Context, m Message) error {
+	msg, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("email: connecting to %s: %w", s.cfg.Addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Cancelling ctx interrupts a conversation in progress.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email: greeting from %s: %w", s.cfg.Addr, err)
+	}
+	defer c.Close()
+	if err := s.send(c, m, msg); err != nil {
+		return fmt.Errorf("email: sending through %s: %w", s.cfg.Addr, err)
+	}
+	return nil
+}
+
+func (s *SMTP) send(c *smtp.Client, m Message, msg []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("relay does not offer AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.From.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// The relay has accepted the message; failing to say goodbye does not
+	// make it worth sending again.
+	_ = c.Quit()
+	return nil
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"context"
+	"net/mail"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/email/emailtest"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestSMTP(t *testing.T) {
+	m := Message{
+		From:    mail.Address{Name: "Teacher Workspace", Address: "noreply@school.example"},
+		To:      mail.Address{Name: "Betty Tan", Address: "betty@example.com"},
+		Subject: "Excursion",
+		Text:    "Meet at 7.30am.",
+	}
+
+	t.Run("sends through the relay", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		defer srv.Close()
+		srv.RequireAuth("tw", "secret")
+		s, err := NewSMTP(SMTPConfig{Addr: srv.Addr, Username: "tw", Password: "secret"})
+		require.NoError(t, err)
+
+		require.NoError(t, s.Send(t.Context(), m))
+
+		got := srv.Messages()
+		require.Equal(t, 1, len(got))
+		require.Equal(t, "noreply@school.example", got[0].From)
+		require.Equal(t, "betty@example.com", got[0].To[0])
+		msg, err := got[0].Parse()
+		require.NoError(t, err)
+		require.Equal(t, "Excursion", msg.Header.Get("Subject"))
+	})
+
+	t.Run("tells transient failures from permanent ones", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		defer srv.Close()
+		s, err := NewSMTP(SMTPConfig{Addr: srv.Addr})
+		require.NoError(t, err)
+		srv.Fail(451, 550)
+
+		err = s.Send(t.Context(), m)
+		require.Error(t, err)
+		require.False(t, Permanent(err))
+		err = s.Send(t.Context(), m)
+		require.Error(t, err)
+		require.True(t, Permanent(err))
+		require.NoError(t, s.Send(t.Context(), m))
+		require.Equal(t, 1, len(srv.Messages()))
+	})
+
+	t.Run("fails without the relay's credentials", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		defer srv.Close()
+		srv.RequireAuth("tw", "secret")
+		s, err := NewSMTP(SMTPConfig{Addr: srv.Addr, Username: "tw", Password: "guess"})
+		require.NoError(t, err)
+
+		err = s.Send(t.Context(), m)
+
+		require.Error(t, err)
+		require.True(t, Permanent(err))
+		require.Equal(t, 0, len(srv.Messages()))
+	})
+
+	t.Run("treats an unreachable relay as transient", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		srv.Close()
+		s, err := NewSMTP(SMTPConfig{Addr: srv.Addr})
+		require.NoError(t, err)
+
+		err = s.Send(t.Context(), m)
+
+		require.Error(t, err)
+		require.False(t, Permanent(err))
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		srv := emailtest.NewServer()
+		defer srv.Close()
+		s, err := NewSMTP(SMTPConfig{Addr: srv.Addr})
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		require.Error(t, s.Send(ctx, m))
+	})
+
+	t.Run("rejects bad relay addresses", func(t *testing.T) {
+		_, err := NewSMTP(SMTPConfig{Addr: "smtp.example.com"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,134 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Font is one of the standard fonts every PDF reader provides, so none are
+// embedded. They cover the Latin-1 characters of the Windows code page;
+// other characters print as "?".
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var baseFonts = [...]string{
+	Helvetica:     "Helvetica",
+	HelveticaBold: "Helvetica-Bold",
+}
+
+// widths are the advance widths of the printable ASCII characters, from
+// space to tilde, in thousandths of the font size, as in the fonts' AFM
+// files.
+var widths = [...][95]uint16{
+	Helvetica: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	HelveticaBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// Width returns the width of s set in f at size points.
+func (f Font) Width(s string, size float64) float64 {
+	var w int
+	for _, b := range encode(s) {
+		if b >= ' ' && b <= '~' {
+			w += int(widths[f][b-' '])
+		} else {
+			// Accented letters are about as wide as the average letter.
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+// Wrap breaks s into lines no wider than width when set in f at size
+// points, breaking at spaces where it can and within words longer than a
+// line. Line breaks in s are kept.
+func (f Font) Wrap(s string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for f.Width(word, size) > width {
+				cut := f.fit(word, size, width)
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			switch {
+			case line == "":
+				line = word
+			case f.Width(line+" "+word, size) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit returns the length in bytes of the longest prefix of word, at least
+// one rune, that fits in width.
+func (f Font) fit(word string, size, width float64) int {
+	_, first := utf8.DecodeRuneInString(word)
+	n := first
+	for i, r := range word {
+		end := i + utf8.RuneLen(r)
+		if end <= first {
+			continue
+		}
+		if f.Width(word[:end], size) > width {
+			break
+		}
+		n = end
+	}
+	return n
+}
+
+// winAnsi maps the characters of the Windows code page outside Latin-1 to
+// their codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode converts s to the fonts' encoding.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		case r >= ' ' && r <= '~', r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
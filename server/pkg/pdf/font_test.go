@@ -0,0 +1,34 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestWidth(t *testing.T) {
+	require.Equal(t, 6.67, Helvetica.Width("A", 10))
+	require.Equal(t, 7.22, HelveticaBold.Width("A", 10))
+	require.Equal(t, Helvetica.Width("e", 10), Helvetica.Width("é", 10))
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("breaks at spaces", func(t *testing.T) {
+		lines := Helvetica.Wrap("Wei Ming has worked steadily this term.", 10, 100)
+
+		require.Equal(t, "Wei Ming has worked", lines[0])
+		for _, l := range lines {
+			require.True(t, Helvetica.Width(l, 10) <= 100)
+		}
+		require.Equal(t, "Wei Ming has worked steadily this term.", strings.Join(lines, " "))
+	})
+
+	t.Run("keeps line breaks and splits long words", func(t *testing.T) {
+		lines := Helvetica.Wrap("Term 1\n"+strings.Repeat("m", 30), 10, 50)
+
+		require.Equal(t, "Term 1", lines[0])
+		require.True(t, len(lines) > 2)
+		require.Equal(t, strings.Repeat("m", 30), strings.Join(lines[1:], ""))
+	})
+}
@@ -0,0 +1,284 @@
+// Package pdf writes simple PDF documents: text in the standard fonts,
+// filled rectangles, lines and images, on A4 pages. It is enough for
+// printable reports without an external renderer.
+//
+// Coordinates are in points, 72 to the inch, measured from the bottom-left
+// corner of the page as in PDF itself.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The size of an A4 page, in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color is an RGB colour.
+type Color struct{ R, G, B uint8 }
+
+var (
+	Black = Color{}
+	White = Color{255, 255, 255}
+)
+
+// ParseColor parses a colour written as "#rrggbb".
+func ParseColor(s string) (Color, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return Color{}, fmt.Errorf("pdf: colour %q is not of the form #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("pdf: colour %q is not of the form #rrggbb", s)
+	}
+	return Color{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+func (c Color) operands() string {
+	return fmt.Sprintf("%s %s %s", num(float64(c.R)/255), num(float64(c.G)/255), num(float64(c.B)/255))
+}
+
+// Document is a PDF document under construction.
+type Document struct {
+	// Title is shown by readers in place of the file name.
+	Title string
+
+	pages  []*Page
+	images []*Image
+}
+
+// New returns an empty Document.
+func New() *Document {
+	return &Document{}
+}
+
+// Pages returns the number of pages added so far.
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// AddPage adds a blank A4 page to the end of the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Image is an image added to a Document, which any of its pages may draw.
+type Image struct {
+	// Width and Height are the image's size in pixels.
+	Width, Height int
+
+	id   int
+	data []byte
+}
+
+// AddImage adds img to the document for its pages to draw. Transparent
+// areas are drawn white.
+func (d *Document) AddImage(img image.Image) (*Image, error) {
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Colours are premultiplied by alpha; add white in proportion
+			// to the transparency.
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	im := &Image{Width: b.Dx(), Height: b.Dy(), id: len(d.images) + 1, data: buf.Bytes()}
+	d.images = append(d.images, im)
+	return im, nil
+}
+
+// Page is one page of a Document. Its methods add to what the page draws.
+type Page struct {
+	content bytes.Buffer
+	images  []*Image
+}
+
+// Text draws s in f at size points, with its baseline starting at (x, y).
+func (p *Page) Text(x, y float64, f Font, size float64, c Color, s string) {
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td %s Tj ET\n",
+		c.operands(), f+1, num(size), num(x), num(y), literal(encode(s)))
+}
+
+// Rect fills the rectangle with its bottom-left corner at (x, y).
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", c.operands(), num(x), num(y), num(w), num(h))
+}
+
+// Line draws a line width points wide from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n",
+		c.operands(), num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Image draws im scaled to w by h points, with its bottom-left corner at
+// (x, y).
+func (p *Page) Image(im *Image, x, y, w, h float64) {
+	p.images = append(p.images, im)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(y), im.id)
+}
+
+// WriteTo writes the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		return 0, errors.New("pdf: document has no pages")
+	}
+	pw := &writer{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects are numbered in the order written: the catalog, the page
+	// tree, the info dictionary, the fonts, the images, then each page and
+	// its contents.
+	const catalog, pages, info, fonts = 1, 2, 3, 4
+	images := fonts + len(baseFonts)
+	first := images + len(d.images)
+
+	pw.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", first+2*i)
+	}
+	pw.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	pw.object(info, fmt.Sprintf("<< /Title %s /Producer (Teacher Workspace) >>", literal(encode(d.Title))))
+	for i, name := range baseFonts {
+		pw.object(fonts+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	for i, im := range d.images {
+		pw.stream(images+i, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+			im.Width, im.Height), im.data)
+	}
+
+	var fontRefs strings.Builder
+	for i := range baseFonts {
+		fmt.Fprintf(&fontRefs, "/F%d %d 0 R ", i+1, fonts+i)
+	}
+	for i, p := range d.pages {
+		var xobjects strings.Builder
+		seen := make(map[int]bool)
+		for _, im := range p.images {
+			if !seen[im.id] {
+				seen[im.id] = true
+				fmt.Fprintf(&xobjects, "/Im%d %d 0 R ", im.id, images+im.id-1)
+			}
+		}
+		pw.object(first+2*i, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << %s>> /XObject << %s>> >> /Contents %d 0 R >>",
+			pages, num(PageWidth), num(PageHeight), fontRefs.String(), xobjects.String(), first+2*i+1))
+
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return pw.n, err
+		}
+		if err := zw.Close(); err != nil {
+			return pw.n, err
+		}
+		pw.stream(first+2*i+1, "/Filter /FlateDecode", buf.Bytes())
+	}
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, off := range pw.offsets {
+		pw.printf("%010d 00000 n \n", off)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets)+1, catalog, info, xref)
+	if pw.err != nil {
+		return pw.n, pw.err
+	}
+	return pw.n, pw.w.Flush()
+}
+
+// writer writes objects, recording their offsets for the cross-reference
+// table. The first error stops all further writes.
+type writer struct {
+	w       *bufio.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	w.err = err
+}
+
+// object writes object id, which must be the next in sequence.
+func (w *writer) object(id int, body string) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, dict string, data []byte) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.write(data)
+	w.printf("\nendstream\nendobj\n")
+}
+
+// num formats f with at most two decimal places, which is finer than any
+// printer resolves.
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// literal returns b as a PDF literal string.
+func literal(b []byte) string {
+	var s strings.Builder
+	s.WriteByte('(')
+	for _, c := range b {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&s, "\\%03o", c)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	s.WriteByte(')')
+	return s.String()
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// contents returns the decompressed streams of a written document.
+func contents(t *testing.T, b []byte) string {
+	t.Helper()
+
+	var out strings.Builder
+	re := regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n`)
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		n, _ := strconv.Atoi(string(b[m[2]:m[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(b[m[1] : m[1]+n]))
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		out.Write(data)
+	}
+	return out.String()
+}
+
+func TestDocument(t *testing.T) {
+	t.Run("writes a well-formed file", func(t *testing.T) {
+		d := New()
+		d.Title = "Report (draft)"
+		logo := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		logo.Set(0, 0, color.RGBA{255, 0, 0, 255})
+		im, err := d.AddImage(logo)
+		require.NoError(t, err)
+		for range 2 {
+			p := d.AddPage()
+			p.Text(50, 800, HelveticaBold, 14, Black, "Siti (Café) \\ 1A")
+			p.Rect(50, 700, 100, 10, Color{0, 80, 160})
+			p.Line(50, 690, 150, 690, 0.5, Black)
+			p.Image(im, 400, 780, 40, 40)
+		}
+
+		var buf bytes.Buffer
+		_, err = d.WriteTo(&buf)
+		require.NoError(t, err)
+		b := buf.Bytes()
+
+		require.True(t, bytes.HasPrefix(b, []byte("%PDF-1.4\n")))
+		require.True(t, bytes.HasSuffix(b, []byte("%%EOF\n")))
+		start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+		xref, _ := strconv.Atoi(string(start[1]))
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(b[xref:], -1)
+		require.Equal(t, 10, len(entries))
+		for i, e := range entries {
+			off, _ := strconv.Atoi(string(e[1]))
+			require.True(t, bytes.HasPrefix(b[off:], fmt.Appendf(nil, "%d 0 obj\n", i+1)))
+		}
+		require.True(t, bytes.Contains(b, []byte("/Title (Report \\(draft\\))")))
+		require.True(t, strings.Contains(contents(t, b), `(Siti \(Caf\351\) \\ 1A) Tj`))
+	})
+
+	t.Run("needs a page", func(t *testing.T) {
+		_, err := New().WriteTo(io.Discard)
+
+		require.Error(t, err)
+	})
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := ParseColor("#0a50FF")
+	require.NoError(t, err)
+	require.Equal(t, Color{10, 80, 255}, c)
+
+	for _, s := range []string{"", "0a50ff", "#0a50f", "#0a50fg"} {
+		_, err := ParseColor(s)
+		require.Error(t, err)
+	}
+}
+
+func TestNum(t *testing.T) {
+	for f, want := range map[float64]string{0: "0", 1.5: "1.5", 595.28: "595.28", 1.0 / 3: "0.33", -0.001: "0"} {
+		require.Equal(t, want, num(f))
+	}
+}
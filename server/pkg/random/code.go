@@ -0,0 +1,146 @@
+package random
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CodeSeparator separates groups in a grouped code, as in "ABCD-EFGH".
+const CodeSeparator = '-'
+
+// codeModulus is the smallest prime above len(AlphabetBase58). Check
+// characters are computed over GF(59), where every position weight is
+// invertible.
+const codeModulus = 59
+
+// maxCodeLength keeps position weights 1..n distinct and non-zero modulo
+// codeModulus, which is what guarantees transposition detection.
+const maxCodeLength = codeModulus - 1
+
+// ErrInvalidCode is returned when a code is malformed or fails its check.
+var ErrInvalidCode = errors.New("random: invalid code")
+
+var codeDecoding = func() [256]byte {
+	var d [256]byte
+	for i := range d {
+		d[i] = 0xff
+	}
+	for i := range len(AlphabetBase58) {
+		d[AlphabetBase58[i]] = byte(i)
+	}
+	return d
+}()
+
+// Code returns a human-friendly code from Default. It panics on invalid
+// arguments; see Generator.Code.
+func Code(length, groupSize int) string {
+	return must(Default.Code(length, groupSize))
+}
+
+// Code returns a code of length characters drawn from AlphabetBase58 for
+// people to read and type, such as a class join code. The last character is a
+// check character, so ValidateCode catches any single mistyped character and
+// any two swapped characters. When groupSize is positive, groups of that many
+// characters are joined by CodeSeparator. It panics when length is below 2 or
+// above 58, or groupSize is negative.
+func (g *Generator) Code(length, groupSize int) (string, error) {
+	if length < 2 || length > maxCodeLength {
+		panic(fmt.Sprintf("random: code length must be between 2 and %d", maxCodeLength))
+	}
+	if groupSize < 0 {
+		panic("random: group size must be non-negative")
+	}
+
+	for {
+		body, err := g.Alphanumeric(length-1, AlphabetBase58)
+		if err != nil {
+			return "", err
+		}
+		// One residue in 59 has no character in the 58-character alphabet;
+		// such bodies are redrawn, which keeps the draw uniform over codes.
+		check, ok := checkCharacter(body)
+		if !ok {
+			continue
+		}
+		return group(body+string(check), groupSize), nil
+	}
+}
+
+// ValidateCode reports whether code is well formed and its check character
+// matches. Separators are ignored, so grouped and ungrouped forms of the same
+// code are both accepted.
+func ValidateCode(code string) error {
+	_, err := NormalizeCode(code)
+	return err
+}
+
+// NormalizeCode validates code and returns it with separators and surrounding
+// whitespace removed, for storage and comparison.
+func NormalizeCode(code string) (string, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(code), string(CodeSeparator), "")
+	if len(s) < 2 || len(s) > maxCodeLength {
+		return "", fmt.Errorf("%w: want 2 to %d characters; got %d", ErrInvalidCode, maxCodeLength, len(s))
+	}
+
+	var sum int
+	for i := range len(s) {
+		d := codeDecoding[s[i]]
+		if d == 0xff {
+			return "", fmt.Errorf("%w: unexpected character %q", ErrInvalidCode, s[i])
+		}
+		sum += (i + 1) * int(d)
+	}
+	if sum%codeModulus != 0 {
+		return "", fmt.Errorf("%w: check character mismatch", ErrInvalidCode)
+	}
+	return s, nil
+}
+
+// checkCharacter returns the character c such that the weighted sum of body
+// followed by c, with position i weighted by i+1, is divisible by
+// codeModulus. Distinct non-zero weights mean any single substitution or
+// transposition changes the sum by a non-zero amount modulo the prime.
+func checkCharacter(body string) (byte, bool) {
+	var sum int
+	for i := range len(body) {
+		sum += (i + 1) * int(codeDecoding[body[i]])
+	}
+
+	// Solve sum + w*c ≡ 0 (mod p) for c, where w is the check position weight.
+	w := len(body) + 1
+	c := (codeModulus - sum%codeModulus) * inverse(w) % codeModulus
+	if c >= len(AlphabetBase58) {
+		return 0, false
+	}
+	return AlphabetBase58[c], true
+}
+
+// inverse returns the multiplicative inverse of w modulo codeModulus, using
+// Fermat's little theorem.
+func inverse(w int) int {
+	result, base := 1, w%codeModulus
+	for e := codeModulus - 2; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = result * base % codeModulus
+		}
+		base = base * base % codeModulus
+	}
+	return result
+}
+
+func group(s string, size int) string {
+	if size == 0 || len(s) <= size {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/size)
+	for i := 0; i < len(s); i += size {
+		if i > 0 {
+			b.WriteByte(CodeSeparator)
+		}
+		b.WriteString(s[i:min(i+size, len(s))])
+	}
+	return b.String()
+}
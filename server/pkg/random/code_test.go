@@ -0,0 +1,154 @@
+package random
+
+import (
+	"errors"
+	mathrand "math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestCode(t *testing.T) {
+	t.Run("returns length characters drawn from AlphabetBase58", func(t *testing.T) {
+		const n = 12
+		result := Code(n, 0)
+
+		if got := len(result); n != got {
+			t.Errorf("want: %d; got: %d", n, got)
+		}
+		for i, c := range result {
+			if !strings.ContainsRune(AlphabetBase58, c) {
+				t.Errorf("want c: in AlphabetBase58; got: %q (index %d)", c, i)
+			}
+		}
+	})
+
+	t.Run("groups characters with CodeSeparator", func(t *testing.T) {
+		cases := []struct {
+			length, groupSize int
+			want              []int
+		}{
+			{length: 8, groupSize: 4, want: []int{4, 4}},
+			{length: 10, groupSize: 4, want: []int{4, 4, 2}},
+			{length: 4, groupSize: 4, want: []int{4}},
+		}
+		for _, tc := range cases {
+			groups := strings.Split(Code(tc.length, tc.groupSize), string(CodeSeparator))
+
+			require.Equal(t, len(tc.want), len(groups))
+			for i, g := range groups {
+				require.Equal(t, tc.want[i], len(g))
+			}
+		}
+	})
+
+	t.Run("generated codes validate", func(t *testing.T) {
+		for range 1000 {
+			code := Code(9, 3)
+
+			require.NoError(t, ValidateCode(code))
+		}
+	})
+
+	t.Run("same seed yields the same code", func(t *testing.T) {
+		a, err := New(mathrand.NewChaCha8([32]byte{9})).Code(8, 4)
+		require.NoError(t, err)
+		b, err := New(mathrand.NewChaCha8([32]byte{9})).Code(8, 4)
+		require.NoError(t, err)
+
+		require.Equal(t, a, b)
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		cases := []struct {
+			name              string
+			length, groupSize int
+		}{
+			{name: "length below 2", length: 1},
+			{name: "length above 58", length: 59},
+			{name: "negative group size", length: 8, groupSize: -1},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatal("want: panic; got: nil")
+					}
+				}()
+
+				Code(tc.length, tc.groupSize)
+			})
+		}
+	})
+}
+
+func TestValidateCode(t *testing.T) {
+	src := mathrand.New(mathrand.NewChaCha8([32]byte{29}))
+
+	t.Run("catches every single-character substitution", func(t *testing.T) {
+		for range 200 {
+			code := Code(8, 0)
+
+			for i := range len(code) {
+				for j := range len(AlphabetBase58) {
+					c := AlphabetBase58[j]
+					if c == code[i] {
+						continue
+					}
+					typo := code[:i] + string(c) + code[i+1:]
+
+					if err := ValidateCode(typo); err == nil {
+						t.Fatalf("want: error for %q (from %q); got: nil", typo, code)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("catches every transposition of two different characters", func(t *testing.T) {
+		for range 200 {
+			code := []byte(Code(2+src.IntN(20), 0))
+
+			for i := range code {
+				for j := i + 1; j < len(code); j++ {
+					if code[i] == code[j] {
+						continue
+					}
+					swapped := []byte(string(code))
+					swapped[i], swapped[j] = swapped[j], swapped[i]
+
+					if err := ValidateCode(string(swapped)); err == nil {
+						t.Fatalf("want: error for %q (from %q); got: nil", swapped, code)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("ignores separators and surrounding whitespace", func(t *testing.T) {
+		code := Code(12, 4)
+
+		require.NoError(t, ValidateCode(" "+code+"\n"))
+		require.NoError(t, ValidateCode(strings.ReplaceAll(code, "-", "")))
+	})
+
+	t.Run("rejects malformed codes", func(t *testing.T) {
+		for _, code := range []string{"", "A", "ABC0", "ABCl", strings.Repeat("A", 59)} {
+			err := ValidateCode(code)
+
+			require.True(t, errors.Is(err, ErrInvalidCode))
+		}
+	})
+}
+
+func TestNormalizeCode(t *testing.T) {
+	t.Run("strips separators", func(t *testing.T) {
+		code := Code(8, 4)
+
+		got, err := NormalizeCode(code)
+
+		require.NoError(t, err)
+		require.Equal(t, strings.ReplaceAll(code, "-", ""), got)
+	})
+}
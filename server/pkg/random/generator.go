@@ -0,0 +1,111 @@
+package random
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// Generator draws random strings from an entropy source. A Generator is safe
+// for concurrent use when its source is.
+type Generator struct {
+	src io.Reader
+}
+
+// New returns a Generator that draws its entropy from src. Passing a
+// deterministic source, such as a ChaCha8 generator from math/rand/v2, makes
+// the output reproducible.
+func New(src io.Reader) *Generator {
+	return &Generator{src: src}
+}
+
+// Alphanumeric returns an n-character string drawn uniformly from alphabet.
+// It returns an error if reading from the source fails, and panics when n is
+// negative or alphabet has fewer than 2 characters.
+func (g *Generator) Alphanumeric(n int, alphabet string) (string, error) {
+	if n < 0 {
+		panic("random: n must be non-negative")
+	}
+	size := uint64(len(alphabet))
+	if size < 2 {
+		panic("random: alphabet must have at least 2 characters")
+	}
+
+	// Pack as many alphabet draws as fit in a uint64 by repeatedly multiplying
+	// `size`; m is the chars-per-draw, limit is `size^m`.
+	limit := size
+	m := 1
+	for {
+		hi, lo := bits.Mul64(limit, size)
+		if hi != 0 {
+			break
+		}
+		limit = lo
+		m++
+	}
+
+	// Largest multiple of limit representable in uint64. Drawing above this
+	// threshold would bias the modulo, so we reject and redraw.
+	threshold := math.MaxUint64 - (math.MaxUint64 % limit)
+
+	// 64-byte batched buffer = 8 uint64 draws per refill. For n up to ~43 this
+	// covers a full result plus rejection slack in a single read.
+	var buf [64]byte
+	if err := g.read(buf[:]); err != nil {
+		return "", err
+	}
+	bufPos := 0
+
+	out := make([]byte, n)
+	pos := 0
+	for pos < n {
+		var r uint64
+
+		for {
+			if bufPos+8 > len(buf) {
+				if err := g.read(buf[:]); err != nil {
+					return "", err
+				}
+				bufPos = 0
+			}
+			r = binary.BigEndian.Uint64(buf[bufPos:])
+			bufPos += 8
+			if r < threshold {
+				r %= limit
+				break
+			}
+		}
+
+		batch := m
+		if remaining := n - pos; remaining < m {
+			batch = remaining
+		}
+
+		for i := 0; i < batch; i++ {
+			out[pos] = alphabet[r%size]
+			r /= size
+			pos++
+		}
+	}
+
+	return string(out), nil
+}
+
+// Base62 is shorthand for g.Alphanumeric(n, AlphabetBase62).
+func (g *Generator) Base62(n int) (string, error) {
+	return g.Alphanumeric(n, AlphabetBase62)
+}
+
+// Base58 is shorthand for g.Alphanumeric(n, AlphabetBase58).
+func (g *Generator) Base58(n int) (string, error) {
+	return g.Alphanumeric(n, AlphabetBase58)
+}
+
+func (g *Generator) read(buf []byte) error {
+	if _, err := io.ReadFull(g.src, buf); err != nil {
+		return fmt.Errorf("random: reading entropy: %w", err)
+	}
+	return nil
+}
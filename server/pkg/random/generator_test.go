@@ -0,0 +1,68 @@
+package random
+
+import (
+	"bytes"
+	"errors"
+	mathrand "math/rand/v2"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Run("same seed yields the same string", func(t *testing.T) {
+		seed := [32]byte{1, 2, 3}
+
+		a, err := New(mathrand.NewChaCha8(seed)).Base58(64)
+		require.NoError(t, err)
+		b, err := New(mathrand.NewChaCha8(seed)).Base58(64)
+		require.NoError(t, err)
+
+		require.Equal(t, a, b)
+	})
+
+	t.Run("different seeds yield different strings", func(t *testing.T) {
+		a, err := New(mathrand.NewChaCha8([32]byte{1})).Base58(64)
+		require.NoError(t, err)
+		b, err := New(mathrand.NewChaCha8([32]byte{2})).Base58(64)
+		require.NoError(t, err)
+
+		require.NotEqual(t, a, b)
+	})
+
+	t.Run("maps fixed entropy to a known string", func(t *testing.T) {
+		// An all-zero draw selects the first alphabet character every time.
+		got, err := New(bytes.NewReader(make([]byte, 64))).Base62(8)
+
+		require.NoError(t, err)
+		require.Equal(t, "AAAAAAAA", got)
+	})
+
+	t.Run("returns an error when the source fails", func(t *testing.T) {
+		boom := errors.New("boom")
+
+		_, err := New(iotest.ErrReader(boom)).Base58(8)
+
+		require.True(t, errors.Is(err, boom))
+	})
+
+	t.Run("returns an error when the source runs dry mid-string", func(t *testing.T) {
+		// 64 bytes cover one buffer refill; a long string needs several.
+		_, err := New(bytes.NewReader(make([]byte, 64))).Base58(200)
+
+		require.Error(t, err)
+	})
+
+	t.Run("Base62 draws from AlphabetBase62", func(t *testing.T) {
+		got, err := New(mathrand.NewChaCha8([32]byte{})).Base62(32)
+		require.NoError(t, err)
+
+		for i, c := range got {
+			if !strings.ContainsRune(AlphabetBase62, c) {
+				t.Errorf("want c: in AlphabetBase62; got: %q (index %d)", c, i)
+			}
+		}
+	})
+}
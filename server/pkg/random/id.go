@@ -0,0 +1,228 @@
+package random
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// ID is a 128-bit identifier made of a 48-bit Unix millisecond timestamp
+// followed by 80 random bits. IDs created later sort after IDs created
+// earlier, both as bytes and in their string form, which keeps database
+// indexes append-mostly. IDs from one IDGenerator are strictly increasing,
+// even within the same millisecond.
+type ID [16]byte
+
+// IDLength is the length of an ID's string form.
+const IDLength = 22
+
+// idAlphabet holds the characters of AlphabetBase58 in ASCII order, so that
+// string comparison of encoded IDs matches comparison of the underlying bytes.
+const idAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// maxTimestamp is the largest millisecond timestamp that fits in 48 bits.
+const maxTimestamp = 1<<48 - 1
+
+// ErrInvalidID is returned when parsing a malformed ID.
+var ErrInvalidID = errors.New("random: invalid id")
+
+var idDecoding = func() [256]byte {
+	var d [256]byte
+	for i := range d {
+		d[i] = 0xff
+	}
+	for i := range len(idAlphabet) {
+		d[idAlphabet[i]] = byte(i)
+	}
+	return d
+}()
+
+// NewID returns a new ID from DefaultIDs.
+func NewID() ID {
+	id, err := DefaultIDs.New()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ParseID parses the string form of an ID.
+func ParseID(s string) (ID, error) {
+	if len(s) != IDLength {
+		return ID{}, fmt.Errorf("%w: want %d characters; got %d", ErrInvalidID, IDLength, len(s))
+	}
+
+	var hi, lo uint64
+	for i := range len(s) {
+		d := idDecoding[s[i]]
+		if d == 0xff {
+			return ID{}, fmt.Errorf("%w: unexpected character %q", ErrInvalidID, s[i])
+		}
+
+		// (hi, lo) = (hi, lo)*58 + d, failing on overflow past 128 bits.
+		carry, newLo := bits.Mul64(lo, uint64(len(idAlphabet)))
+		overflow, newHi := bits.Mul64(hi, uint64(len(idAlphabet)))
+		newHi, c1 := bits.Add64(newHi, carry, 0)
+		newLo, c2 := bits.Add64(newLo, uint64(d), 0)
+		newHi, c3 := bits.Add64(newHi, 0, c2)
+		if overflow != 0 || c1 != 0 || c3 != 0 {
+			return ID{}, fmt.Errorf("%w: value out of range", ErrInvalidID)
+		}
+		hi, lo = newHi, newLo
+	}
+
+	var id ID
+	binary.BigEndian.PutUint64(id[:8], hi)
+	binary.BigEndian.PutUint64(id[8:], lo)
+	return id, nil
+}
+
+// ValidateID reports whether s is the string form of an ID.
+func ValidateID(s string) error {
+	_, err := ParseID(s)
+	return err
+}
+
+// String returns the fixed-width, 22-character encoding of id.
+func (id ID) String() string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	var out [IDLength]byte
+	for i := IDLength - 1; i >= 0; i-- {
+		var r uint64
+		hi, r = bits.Div64(0, hi, uint64(len(idAlphabet)))
+		lo, r = bits.Div64(r, lo, uint64(len(idAlphabet)))
+		out[i] = idAlphabet[r]
+	}
+	return string(out[:])
+}
+
+// Time returns the millisecond timestamp embedded in id.
+func (id ID) Time() time.Time {
+	var b [8]byte
+	copy(b[2:], id[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(b[:])))
+}
+
+// IsZero reports whether id is the zero ID.
+func (id ID) IsZero() bool {
+	return id == ID{}
+}
+
+// Compare returns -1, 0 or +1 depending on whether id sorts before, equal to
+// or after other.
+func (id ID) Compare(other ID) int {
+	return bytes.Compare(id[:], other[:])
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *ID) UnmarshalText(b []byte) error {
+	parsed, err := ParseID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing id in its string form.
+func (id ID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+// Scan implements sql.Scanner. It accepts the string form as a string or
+// []byte, and the raw 16-byte form as []byte.
+func (id *ID) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return id.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == len(id) {
+			copy(id[:], v)
+			return nil
+		}
+		return id.UnmarshalText(v)
+	default:
+		return fmt.Errorf("random: cannot scan %T into ID", src)
+	}
+}
+
+// DefaultIDs is the IDGenerator used by NewID. It draws from crypto/rand and
+// the system clock.
+var DefaultIDs = NewIDGenerator(Default, time.Now)
+
+// IDGenerator creates IDs that are strictly increasing in creation order. It
+// is safe for concurrent use.
+type IDGenerator struct {
+	mu     sync.Mutex
+	src    *Generator
+	now    func() time.Time
+	lastMS uint64
+	last   ID
+}
+
+// NewIDGenerator returns an IDGenerator that draws randomness from src and
+// reads the time from now.
+func NewIDGenerator(src *Generator, now func() time.Time) *IDGenerator {
+	return &IDGenerator{src: src, now: now}
+}
+
+// New returns an ID greater than every ID previously returned by g. When the
+// clock has not advanced past the previous ID's millisecond, including when it
+// moves backwards, the previous ID's random part is incremented instead of
+// drawing fresh randomness.
+func (g *IDGenerator) New() (ID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(max(g.now().UnixMilli(), 0))
+	if ms > maxTimestamp {
+		return ID{}, errors.New("random: clock beyond the ID timestamp range")
+	}
+
+	if !g.last.IsZero() && ms <= g.lastMS {
+		if next, ok := increment(g.last); ok {
+			g.last = next
+			return next, nil
+		}
+		// The random part overflowed; borrow the next millisecond.
+		ms = g.lastMS + 1
+		if ms > maxTimestamp {
+			return ID{}, errors.New("random: ID space exhausted")
+		}
+	}
+
+	var id ID
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(id[:6], ts[2:])
+	if err := g.src.read(id[6:]); err != nil {
+		return ID{}, err
+	}
+
+	g.lastMS = ms
+	g.last = id
+	return id, nil
+}
+
+// increment adds one to the random part of id, reporting false on overflow.
+func increment(id ID) (ID, bool) {
+	for i := len(id) - 1; i >= 6; i-- {
+		id[i]++
+		if id[i] != 0 {
+			return id, true
+		}
+	}
+	return id, false
+}
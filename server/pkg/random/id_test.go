@@ -0,0 +1,207 @@
+package random
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	mathrand "math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func newTestIDGenerator(now func() time.Time) *IDGenerator {
+	return NewIDGenerator(New(mathrand.NewChaCha8([32]byte{})), now)
+}
+
+func TestID(t *testing.T) {
+	t.Run("string form round-trips through ParseID", func(t *testing.T) {
+		for range 100 {
+			id := NewID()
+
+			parsed, err := ParseID(id.String())
+			require.NoError(t, err)
+			require.Equal(t, id, parsed)
+			require.Equal(t, IDLength, len(id.String()))
+		}
+	})
+
+	t.Run("string form uses only AlphabetBase58 characters", func(t *testing.T) {
+		s := NewID().String()
+
+		for i, c := range s {
+			if !strings.ContainsRune(AlphabetBase58, c) {
+				t.Errorf("want c: in AlphabetBase58; got: %q (index %d)", c, i)
+			}
+		}
+	})
+
+	t.Run("string order matches byte order", func(t *testing.T) {
+		src := mathrand.New(mathrand.NewChaCha8([32]byte{7}))
+		for range 1000 {
+			var a, b ID
+			for i := range a {
+				a[i] = byte(src.Uint32())
+				b[i] = byte(src.Uint32())
+			}
+
+			require.Equal(t, a.Compare(b), strings.Compare(a.String(), b.String()))
+		}
+	})
+
+	t.Run("extreme values encode and parse", func(t *testing.T) {
+		var hi ID
+		for i := range hi {
+			hi[i] = 0xff
+		}
+
+		for _, id := range []ID{{}, hi} {
+			parsed, err := ParseID(id.String())
+			require.NoError(t, err)
+			require.Equal(t, id, parsed)
+		}
+		require.Equal(t, "1111111111111111111111", ID{}.String())
+	})
+
+	t.Run("Time returns the embedded millisecond", func(t *testing.T) {
+		now := time.UnixMilli(1767225600123)
+		id, err := newTestIDGenerator(func() time.Time { return now }).New()
+
+		require.NoError(t, err)
+		require.True(t, now.Equal(id.Time()))
+	})
+
+	t.Run("round-trips through JSON", func(t *testing.T) {
+		id := NewID()
+
+		b, err := json.Marshal(struct{ ID ID }{id})
+		require.NoError(t, err)
+
+		var got struct{ ID ID }
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.Equal(t, id, got.ID)
+	})
+
+	t.Run("implements sql.Scanner and driver.Valuer", func(t *testing.T) {
+		id := NewID()
+
+		v, err := id.Value()
+		require.NoError(t, err)
+		require.Equal(t, id.String(), v.(string))
+
+		for _, src := range []any{id.String(), []byte(id.String()), id[:]} {
+			var got ID
+			require.NoError(t, got.Scan(src))
+			require.Equal(t, id, got)
+		}
+
+		var got ID
+		require.Error(t, got.Scan(42))
+	})
+}
+
+func TestParseID(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+	}{
+		{name: "empty", in: ""},
+		{name: "too short", in: "111111111111111111111"},
+		{name: "too long", in: "11111111111111111111111"},
+		{name: "ambiguous character", in: "111111111111111111111O"},
+		{name: "beyond 128 bits", in: "zzzzzzzzzzzzzzzzzzzzzz"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseID(tc.in)
+
+			require.True(t, errors.Is(err, ErrInvalidID))
+			require.Error(t, ValidateID(tc.in))
+		})
+	}
+}
+
+func TestIDGenerator(t *testing.T) {
+	t.Run("is monotonic within the same millisecond", func(t *testing.T) {
+		now := time.UnixMilli(1767225600000)
+		g := newTestIDGenerator(func() time.Time { return now })
+
+		prev, err := g.New()
+		require.NoError(t, err)
+		for range 1000 {
+			id, err := g.New()
+			require.NoError(t, err)
+			require.Equal(t, 1, id.Compare(prev))
+			require.True(t, now.Equal(id.Time()))
+			prev = id
+		}
+	})
+
+	t.Run("is monotonic when the clock moves backwards", func(t *testing.T) {
+		now := time.UnixMilli(1767225600000)
+		g := newTestIDGenerator(func() time.Time { return now })
+
+		first, err := g.New()
+		require.NoError(t, err)
+		now = now.Add(-time.Second)
+		second, err := g.New()
+		require.NoError(t, err)
+
+		require.Equal(t, 1, second.Compare(first))
+	})
+
+	t.Run("borrows the next millisecond when the random part overflows", func(t *testing.T) {
+		now := time.UnixMilli(1767225600000)
+		// All-ones randomness leaves no room to increment.
+		g := NewIDGenerator(New(bytes.NewReader(bytes.Repeat([]byte{0xff}, 20))), func() time.Time { return now })
+
+		first, err := g.New()
+		require.NoError(t, err)
+		second, err := g.New()
+		require.NoError(t, err)
+
+		require.Equal(t, 1, second.Compare(first))
+		require.True(t, now.Add(time.Millisecond).Equal(second.Time()))
+	})
+
+	t.Run("returns an error when the source fails", func(t *testing.T) {
+		g := NewIDGenerator(New(bytes.NewReader(nil)), time.Now)
+
+		_, err := g.New()
+
+		require.Error(t, err)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		const n = 50
+		g := NewIDGenerator(Default, time.Now)
+		ids := make([]ID, n)
+
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Go(func() {
+				id, err := g.New()
+				if err != nil {
+					t.Errorf("want err: nil; got: %v", err)
+				}
+				ids[i] = id
+			})
+		}
+		wg.Wait()
+
+		seen := make(map[ID]bool)
+		for _, id := range ids {
+			require.False(t, seen[id])
+			seen[id] = true
+		}
+	})
+}
+
+func BenchmarkNewID(b *testing.B) {
+	for b.Loop() {
+		_ = NewID()
+	}
+}
@@ -0,0 +1,195 @@
+// Package richtext cleans the HTML that rich-text editors produce down to a
+// small set of formatting tags that are safe to show to anyone, and turns it
+// into plain text for places that cannot show HTML.
+//
+// Sanitize keeps paragraphs, line breaks, headings, lists, quotes, bold,
+// italic, underline, strikethrough and links to http, https, mailto and tel
+// addresses. Every other tag is dropped with its content kept, except
+// script, style and similar tags whose content is dropped too. Attributes
+// other than a link's href are dropped.
+package richtext
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowed are the tags Sanitize keeps.
+var allowed = map[string]bool{
+	"p": true, "br": true, "h2": true, "h3": true, "blockquote": true,
+	"ul": true, "ol": true, "li": true,
+	"strong": true, "b": true, "em": true, "i": true, "u": true, "s": true,
+	"a": true,
+}
+
+// dropped are the tags whose content Sanitize drops along with the tag.
+var dropped = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "template": true,
+	"textarea": true, "title": true, "noscript": true, "svg": true, "math": true,
+}
+
+// blocks are the tags Text ends a line after.
+var blocks = map[string]bool{
+	"p": true, "br": true, "h2": true, "h3": true, "blockquote": true,
+	"ul": true, "ol": true, "li": true,
+}
+
+var (
+	// tagPattern matches a start or end tag at the start of the input,
+	// allowing ">" within quoted attribute values.
+	tagPattern  = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:"[^"]*"|'[^']*'|[^'">])*)>`)
+	hrefPattern = regexp.MustCompile(`(?i)(?:^|\s)href\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// token is a piece of HTML: text, or a start or end tag.
+type token struct {
+	text    string
+	tag     string
+	closing bool
+	attrs   string
+}
+
+// tokens splits s into text and tags. Comments are dropped, and a "<" that
+// does not start a tag is text.
+func tokens(s string, yield func(token)) {
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			yield(token{text: s})
+			return
+		}
+		if i > 0 {
+			yield(token{text: s[:i]})
+			s = s[i:]
+		}
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				return
+			}
+			s = s[end+3:]
+			continue
+		}
+		m := tagPattern.FindStringSubmatch(s)
+		if m == nil {
+			yield(token{text: "<"})
+			s = s[1:]
+			continue
+		}
+		yield(token{tag: strings.ToLower(m[2]), closing: m[1] == "/", attrs: m[3]})
+		s = s[len(m[0]):]
+	}
+}
+
+// Sanitize returns s with every tag and attribute it does not allow removed,
+// text escaped and tags balanced.
+func Sanitize(s string) string {
+	var b strings.Builder
+	var open []string
+	skip := ""
+	tokens(s, func(t token) {
+		switch {
+		case skip != "":
+			if t.tag == skip && t.closing {
+				skip = ""
+			}
+		case t.tag == "":
+			b.WriteString(html.EscapeString(html.UnescapeString(t.text)))
+		case dropped[t.tag]:
+			if !t.closing {
+				skip = t.tag
+			}
+		case !allowed[t.tag]:
+		case t.tag == "br":
+			b.WriteString("<br>")
+		case t.closing:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == t.tag {
+					closeTags(&b, open[i:])
+					open = open[:i]
+					break
+				}
+			}
+		case t.tag == "a":
+			href, ok := safeHref(t.attrs)
+			if !ok {
+				return
+			}
+			b.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+			open = append(open, t.tag)
+		default:
+			b.WriteString("<" + t.tag + ">")
+			open = append(open, t.tag)
+		}
+	})
+	closeTags(&b, open)
+	return b.String()
+}
+
+// closeTags writes the end tags of open, innermost first.
+func closeTags(b *strings.Builder, open []string) {
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+}
+
+// safeHref returns the href among attrs if it is an absolute link with a
+// scheme that cannot run script.
+func safeHref(attrs string) (string, bool) {
+	m := hrefPattern.FindStringSubmatch(attrs)
+	if m == nil {
+		return "", false
+	}
+	href := strings.TrimSpace(html.UnescapeString(m[1] + m[2] + m[3]))
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return href, u.Host != ""
+	case "mailto", "tel":
+		return href, u.Opaque != ""
+	default:
+		return "", false
+	}
+}
+
+// Text returns the text of s, which should have been sanitized, with a line
+// break after each block, list items marked with "- " and each link's
+// address after its text.
+func Text(s string) string {
+	var b strings.Builder
+	var hrefs []string
+	tokens(s, func(t token) {
+		switch {
+		case t.tag == "":
+			b.WriteString(html.UnescapeString(t.text))
+		case t.tag == "li" && !t.closing:
+			b.WriteString("- ")
+		case t.tag == "a" && !t.closing:
+			href, _ := safeHref(t.attrs)
+			hrefs = append(hrefs, href)
+		case t.tag == "a" && len(hrefs) > 0:
+			if href := hrefs[len(hrefs)-1]; href != "" {
+				b.WriteString(" (" + href + ")")
+			}
+			hrefs = hrefs[:len(hrefs)-1]
+		case t.tag == "br", t.closing && blocks[t.tag]:
+			b.WriteString("\n")
+		case t.tag == "p" || t.tag == "h2" || t.tag == "h3":
+			// A paragraph starts on a line of its own, after a blank one.
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+		}
+	})
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
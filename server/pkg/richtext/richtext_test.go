@@ -0,0 +1,43 @@
+package richtext
+
+import (
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestSanitize(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"keeps formatting", "<p>Bring <strong>water</strong> and <em>a hat</em>.</p>", "<p>Bring <strong>water</strong> and <em>a hat</em>.</p>"},
+		{"escapes text", "Fish & chips < 5", "Fish &amp; chips &lt; 5"},
+		{"keeps entities", "Caf&eacute; &amp; more", "Café &amp; more"},
+		{"drops attributes", `<p class="x" onclick="alert(1)">Hi</p>`, "<p>Hi</p>"},
+		{"drops unknown tags but keeps their text", `<div><span style="color:red">Hi</span></div>`, "Hi"},
+		{"drops scripts with their content", "<p>Hi<script>alert('x')</script></p>", "<p>Hi</p>"},
+		{"drops comments", "a<!-- secret -->b", "ab"},
+		{"keeps safe links", `<a href="https://moe.gov.sg/a?b=1&amp;c=2" target="_blank">site</a>`, `<a href="https://moe.gov.sg/a?b=1&amp;c=2">site</a>`},
+		{"keeps mail links", `<a href='mailto:office@school.edu.sg'>mail</a>`, `<a href="mailto:office@school.edu.sg">mail</a>`},
+		{"drops script links", `<a href="javascript:alert(1)">x</a>`, "x"},
+		{"drops relative links", `<a href="/login">x</a>`, "x"},
+		{"closes open tags", "<ul><li><b>one", "<ul><li><b>one</b></li></ul>"},
+		{"closes inner tags with outer ones", "<p><em>one</p>two", "<p><em>one</em></p>two"},
+		{"ignores stray end tags", "one</p></b>", "one"},
+		{"writes line breaks alike", "a<br/>b<BR>c</br>", "a<br>b<br>c<br>"},
+		{"treats a lone < as text", "1 < 2 and 3 <> 4", "1 &lt; 2 and 3 &lt;&gt; 4"},
+		{"allows > in attribute values", `<p title="a>b">x</p>`, "<p>x</p>"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Sanitize(tc.in))
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	in := Sanitize(`<h2>Excursion</h2><p>Dear Parents,</p><p>Please bring:</p><ul><li>water</li><li>a hat</li></ul>` +
+		`<p>Details are <a href="https://example.com/trip">online</a>.<br>Thank you &amp; regards</p>`)
+
+	require.Equal(t, "Excursion\n\nDear Parents,\n\nPlease bring:\n- water\n- a hat\n\nDetails are online (https://example.com/trip).\nThank you & regards", Text(in))
+	require.Equal(t, "", Text("<p> </p><br>"))
+}
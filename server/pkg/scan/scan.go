@@ -0,0 +1,72 @@
+// Package scan checks uploaded files for malware before they are stored.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Verdict is the outcome of a completed scan.
+type Verdict struct {
+	Clean bool
+	// Threat names what was found in a file that is not clean, if the
+	// scanner says.
+	Threat string
+}
+
+// Scanner checks files for malware. An error means the file could not be
+// scanned, not that it is unsafe; callers should refuse the file either way.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// EICAR is the EICAR anti-virus test file, which every scanner reports as
+// infected without it being harmful.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake is a Scanner for tests and development that reports files
+// containing EICAR as infected and every other file as clean.
+type Fake struct{}
+
+func (Fake) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Verdict{}, err
+	}
+	if bytes.Contains(b, []byte(EICAR)) {
+		return Verdict{Threat: "EICAR-Test-File"}, nil
+	}
+	return Verdict{Clean: true}, nil
+}
+
+// Command is a Scanner that runs an external program, such as
+// "clamdscan --no-summary -", with the file on its standard input. The
+// program must exit 0 for a clean file and 1 for an infected one, as ClamAV's
+// scanners do; its output describes the threat.
+type Command struct {
+	Name string
+	Args []string
+}
+
+func (c Command) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Stdin = r
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return Verdict{Clean: true}, nil
+	case errors.As(err, &exit) && exit.ExitCode() == 1:
+		return Verdict{Threat: string(bytes.TrimSpace(out.Bytes()))}, nil
+	default:
+		return Verdict{}, fmt.Errorf("scan: %s: %w: %s", c.Name, err, bytes.TrimSpace(out.Bytes()))
+	}
+}
@@ -0,0 +1,52 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestFake(t *testing.T) {
+	t.Run("finds the EICAR test file", func(t *testing.T) {
+		v, err := Fake{}.Scan(t.Context(), strings.NewReader("%PDF-1.4\n"+EICAR))
+
+		require.NoError(t, err)
+		require.False(t, v.Clean)
+		require.Equal(t, "EICAR-Test-File", v.Threat)
+	})
+
+	t.Run("passes other files", func(t *testing.T) {
+		v, err := Fake{}.Scan(t.Context(), strings.NewReader("%PDF-1.4\n"))
+
+		require.NoError(t, err)
+		require.True(t, v.Clean)
+	})
+}
+
+func TestCommand(t *testing.T) {
+	// sh stands in for a scanner: grep exits 1 when it finds nothing, so
+	// the exit status is inverted to match a scanner's.
+	scanner := Command{Name: "sh", Args: []string{"-c", `if grep -q EICAR; then echo "stdin: Eicar-Signature FOUND"; exit 1; fi`}}
+
+	t.Run("reports what the program finds", func(t *testing.T) {
+		v, err := scanner.Scan(t.Context(), strings.NewReader(EICAR))
+
+		require.NoError(t, err)
+		require.False(t, v.Clean)
+		require.Equal(t, "stdin: Eicar-Signature FOUND", v.Threat)
+	})
+
+	t.Run("passes files the program passes", func(t *testing.T) {
+		v, err := scanner.Scan(t.Context(), strings.NewReader("hello"))
+
+		require.NoError(t, err)
+		require.True(t, v.Clean)
+	})
+
+	t.Run("fails when the program does", func(t *testing.T) {
+		_, err := Command{Name: "sh", Args: []string{"-c", "exit 2"}}.Scan(t.Context(), strings.NewReader("hello"))
+
+		require.Error(t, err)
+	})
+}
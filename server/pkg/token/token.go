@@ -0,0 +1,136 @@
+// Package token issues bearer secrets such as API keys, magic-link tokens and
+// invite tokens.
+//
+// A token looks like "tw_api_<30 random characters><6 character checksum>".
+// The fixed prefix lets secret scanners find leaked tokens, and the CRC32
+// checksum lets them, and the server, reject mistyped or fabricated tokens
+// offline before any lookup. Tokens are stored only as their Hash; the
+// plaintext is shown to its owner once and never persisted.
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strings"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+)
+
+// Kind identifies what a token grants access to. It appears in the token's
+// prefix so a leaked token can be triaged without looking it up.
+type Kind string
+
+const (
+	KindAPIKey    Kind = "api"
+	KindMagicLink Kind = "link"
+	KindInvite    Kind = "inv"
+	// KindGateway keys let Parents Gateway pass on what guardians do, such
+	// as their consent form responses.
+	KindGateway Kind = "pg"
+)
+
+const (
+	prefix         = "tw"
+	separator      = "_"
+	secretLength   = 30
+	checksumLength = 6
+)
+
+// Pattern matches tokens of every kind. It is published for secret scanning
+// rules and log scrubbing.
+var Pattern = regexp.MustCompile(`\btw_[a-z]{2,8}_[A-Za-z0-9]{36}\b`)
+
+var (
+	exactPattern = regexp.MustCompile(`^tw_[a-z]{2,8}_[A-Za-z0-9]{36}$`)
+	kindPattern  = regexp.MustCompile(`^[a-z]{2,8}$`)
+)
+
+// ErrInvalid is returned when a token is malformed or its checksum does not
+// match.
+var ErrInvalid = errors.New("token: invalid token")
+
+// New returns a new token of the given kind drawn from random.Default.
+func New(kind Kind) (string, error) {
+	return Generate(random.Default, kind)
+}
+
+// Generate returns a new token of the given kind drawn from g.
+func Generate(g *random.Generator, kind Kind) (string, error) {
+	if !kindPattern.MatchString(string(kind)) {
+		return "", fmt.Errorf("token: invalid kind %q", kind)
+	}
+
+	secret, err := g.Base62(secretLength)
+	if err != nil {
+		return "", err
+	}
+	body := prefix + separator + string(kind) + separator + secret
+	return body + checksum(body), nil
+}
+
+// Parse checks the format and checksum of token without any lookup and
+// returns its kind.
+func Parse(token string) (Kind, error) {
+	if !exactPattern.MatchString(token) {
+		return "", ErrInvalid
+	}
+
+	body, sum := token[:len(token)-checksumLength], token[len(token)-checksumLength:]
+	if subtle.ConstantTimeCompare([]byte(checksum(body)), []byte(sum)) != 1 {
+		return "", ErrInvalid
+	}
+
+	kind, _, _ := strings.Cut(strings.TrimPrefix(body, prefix+separator), separator)
+	return Kind(kind), nil
+}
+
+// Validate reports whether token is well formed and of the given kind.
+func Validate(token string, kind Kind) error {
+	got, err := Parse(token)
+	if err != nil {
+		return err
+	}
+	if got != kind {
+		return fmt.Errorf("%w: want kind %q; got %q", ErrInvalid, kind, got)
+	}
+	return nil
+}
+
+// Hash returns the hex-encoded SHA-256 digest of token for storage. Tokens
+// carry about 178 bits of entropy, so a fast unsalted hash is sufficient and
+// lets the digest double as a lookup key.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Equal reports whether token hashes to hash, in constant time.
+func Equal(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(token)), []byte(hash)) == 1
+}
+
+// Redact returns token with its secret part elided, for logs and UI hints.
+func Redact(token string) string {
+	i := strings.LastIndex(token, separator)
+	if i < 0 || len(token)-i-1 < 4 {
+		return "[REDACTED]"
+	}
+	return token[:i+5] + "…"
+}
+
+// checksum returns the CRC32 of body as a fixed-width Base62 string.
+func checksum(body string) string {
+	n := crc32.ChecksumIEEE([]byte(body))
+
+	var out [checksumLength]byte
+	for i := checksumLength - 1; i >= 0; i-- {
+		out[i] = random.AlphabetBase62[n%62]
+		n /= 62
+	}
+	return string(out[:])
+}
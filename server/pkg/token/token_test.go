@@ -0,0 +1,127 @@
+package token
+
+import (
+	"errors"
+	mathrand "math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/random"
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("has the kind prefix and fixed length", func(t *testing.T) {
+		tok, err := New(KindAPIKey)
+
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(tok, "tw_api_"))
+		require.Equal(t, len("tw_api_")+secretLength+checksumLength, len(tok))
+	})
+
+	t.Run("matches Pattern inside surrounding text", func(t *testing.T) {
+		tok, err := New(KindInvite)
+		require.NoError(t, err)
+
+		got := Pattern.FindString("export TW_TOKEN=" + tok + " # do not commit")
+
+		require.Equal(t, tok, got)
+	})
+
+	t.Run("same seed yields the same token", func(t *testing.T) {
+		a, err := Generate(random.New(mathrand.NewChaCha8([32]byte{3})), KindMagicLink)
+		require.NoError(t, err)
+		b, err := Generate(random.New(mathrand.NewChaCha8([32]byte{3})), KindMagicLink)
+		require.NoError(t, err)
+
+		require.Equal(t, a, b)
+	})
+
+	t.Run("rejects invalid kinds", func(t *testing.T) {
+		for _, kind := range []Kind{"", "x", "API", "with_underscore", "toolongkind"} {
+			_, err := New(kind)
+
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("returns the kind of a valid token", func(t *testing.T) {
+		tok, err := New(KindInvite)
+		require.NoError(t, err)
+
+		kind, err := Parse(tok)
+
+		require.NoError(t, err)
+		require.Equal(t, KindInvite, kind)
+	})
+
+	t.Run("rejects any single-character change", func(t *testing.T) {
+		tok, err := New(KindAPIKey)
+		require.NoError(t, err)
+
+		for i := len("tw_api_"); i < len(tok); i++ {
+			c := byte('A')
+			if tok[i] == c {
+				c = 'B'
+			}
+			typo := tok[:i] + string(c) + tok[i+1:]
+
+			_, err := Parse(typo)
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+
+	t.Run("rejects malformed tokens", func(t *testing.T) {
+		for _, tok := range []string{"", "tw_api_", "gh_api_" + strings.Repeat("A", 36), "tw_api_" + strings.Repeat("A", 35)} {
+			_, err := Parse(tok)
+
+			require.True(t, errors.Is(err, ErrInvalid))
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("rejects a token of another kind", func(t *testing.T) {
+		tok, err := New(KindInvite)
+		require.NoError(t, err)
+
+		require.NoError(t, Validate(tok, KindInvite))
+		require.True(t, errors.Is(Validate(tok, KindAPIKey), ErrInvalid))
+	})
+}
+
+func TestHash(t *testing.T) {
+	t.Run("is deterministic and does not contain the token", func(t *testing.T) {
+		tok, err := New(KindAPIKey)
+		require.NoError(t, err)
+
+		require.Equal(t, Hash(tok), Hash(tok))
+		require.Equal(t, 64, len(Hash(tok)))
+		require.False(t, strings.Contains(Hash(tok), tok[7:]))
+	})
+
+	t.Run("Equal matches only the hashed token", func(t *testing.T) {
+		a, err := New(KindAPIKey)
+		require.NoError(t, err)
+		b, err := New(KindAPIKey)
+		require.NoError(t, err)
+
+		require.True(t, Equal(a, Hash(a)))
+		require.False(t, Equal(b, Hash(a)))
+		require.False(t, Equal(a, ""))
+	})
+}
+
+func TestRedact(t *testing.T) {
+	t.Run("keeps the prefix and four secret characters", func(t *testing.T) {
+		tok := "tw_api_" + strings.Repeat("x", 36)
+
+		require.Equal(t, "tw_api_xxxx…", Redact(tok))
+	})
+
+	t.Run("hides strings that are not tokens", func(t *testing.T) {
+		require.Equal(t, "[REDACTED]", Redact("secret"))
+	})
+}
@@ -0,0 +1,254 @@
+// Package xlsx reads and writes the subset of Office Open XML spreadsheets
+// that class lists and exports need: the cell values of a single worksheet,
+// as text. Styles and formulas are ignored.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrFormat is returned for files that are not a readable XLSX workbook.
+var ErrFormat = errors.New("xlsx: not a valid workbook")
+
+// MaxRows bounds the rows ReadRows returns, so that a malformed or hostile
+// file cannot exhaust memory.
+const MaxRows = 100_000
+
+// maxPartSize bounds the uncompressed size of one part of the workbook.
+const maxPartSize = 64 << 20
+
+// ReadRows returns the cell values of the first worksheet of the workbook in
+// r, which is size bytes long, one slice per row. Empty cells are "", rows
+// between non-empty rows are kept as empty slices, and numbers are returned
+// as written, so dates stored as serial numbers stay numbers.
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var strs []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if strs, err = sharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := files[sheet]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrFormat, sheet)
+	}
+	return rows(f, strs)
+}
+
+// firstSheet returns the name of the part holding the workbook's first
+// worksheet.
+func firstSheet(files map[string]*zip.File) (string, error) {
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", fmt.Errorf("%w: no worksheets", ErrFormat)
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: first worksheet has no relationship", ErrFormat)
+}
+
+func decodePart(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %s", ErrFormat, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrFormat, name, err)
+	}
+	return nil
+}
+
+// sharedStrings returns the workbook's shared string table. Rich text runs
+// are joined into plain text.
+func sharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			T    string `xml:"t"`
+			Runs []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodePart(map[string]*zip.File{f.Name: f}, f.Name, &sst); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		if len(si.Runs) == 0 {
+			out[i] = si.T
+			continue
+		}
+		var b strings.Builder
+		for _, r := range si.Runs {
+			b.WriteString(r.T)
+		}
+		out[i] = b.String()
+	}
+	return out, nil
+}
+
+type cell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		T string `xml:"t"`
+	} `xml:"is"`
+}
+
+// rows streams the worksheet in f, so that only its cell values are held
+// in memory.
+func rows(f *zip.File, strs []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	defer rc.Close()
+
+	out := [][]string{}
+	dec := xml.NewDecoder(io.LimitReader(rc, maxPartSize))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrFormat, f.Name, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "row":
+			n := len(out) + 1
+			for _, a := range start.Attr {
+				if a.Name.Local == "r" {
+					if n, err = strconv.Atoi(a.Value); err != nil || n < len(out)+1 {
+						return nil, fmt.Errorf("%w: bad row number %q", ErrFormat, a.Value)
+					}
+				}
+			}
+			if n > MaxRows {
+				return nil, fmt.Errorf("%w: more than %d rows", ErrFormat, MaxRows)
+			}
+			for len(out) < n {
+				out = append(out, []string{})
+			}
+		case "c":
+			if len(out) == 0 {
+				return nil, fmt.Errorf("%w: cell outside a row", ErrFormat)
+			}
+			var c cell
+			if err := dec.DecodeElement(&c, &start); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrFormat, f.Name, err)
+			}
+			row := out[len(out)-1]
+			col := len(row)
+			if c.Ref != "" {
+				if col, err = column(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			v, err := value(c, strs)
+			if err != nil {
+				return nil, err
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = v
+			out[len(out)-1] = row
+		}
+	}
+}
+
+func value(c cell, strs []string) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(strs) {
+			return "", fmt.Errorf("%w: cell %s refers to missing shared string %q", ErrFormat, c.Ref, c.Value)
+		}
+		return strs[i], nil
+	case "inlineStr":
+		return c.Inline.T, nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// maxColumns is the number of columns a worksheet may have, up to "XFD".
+const maxColumns = 16384
+
+// column returns the zero-based column of a cell reference such as "AB12".
+func column(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > maxColumns {
+			break
+		}
+	}
+	if i == 0 || col > maxColumns {
+		return 0, fmt.Errorf("%w: bad cell reference %q", ErrFormat, ref)
+	}
+	return col - 1, nil
+}
@@ -0,0 +1,84 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+// workbook zips parts into an XLSX file with a single worksheet.
+func workbook(t *testing.T, sheet, shared string) *bytes.Reader {
+	t.Helper()
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Class list" sheetId="1" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/list.xml"/></Relationships>`,
+		"xl/worksheets/list.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheet + `</sheetData></worksheet>`,
+	}
+	if shared != "" {
+		parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + shared + `</sst>`
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadRows(t *testing.T) {
+	t.Run("reads shared, inline and numeric cells", func(t *testing.T) {
+		r := workbook(t,
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>`+
+				`<row r="3"><c r="A3" t="inlineStr"><is><t>Tan Wei Ming</t></is></c><c r="C3"><v>40123</v></c></row>`,
+			`<si><t>Name</t></si><si><r><t>Date of </t></r><r><t>birth</t></r></si>`)
+
+		rows, err := ReadRows(r, r.Size())
+
+		require.NoError(t, err)
+		require.Equal(t, 3, len(rows))
+		require.Equal(t, "Date of birth", rows[0][1])
+		require.Equal(t, 0, len(rows[1]))
+		require.Equal(t, "Tan Wei Ming", rows[2][0])
+		require.Equal(t, "", rows[2][1])
+		require.Equal(t, "40123", rows[2][2])
+	})
+
+	t.Run("rejects files that are not workbooks", func(t *testing.T) {
+		r := bytes.NewReader([]byte("name,class\n"))
+
+		_, err := ReadRows(r, r.Size())
+
+		require.True(t, errors.Is(err, ErrFormat))
+	})
+
+	t.Run("rejects references to missing shared strings", func(t *testing.T) {
+		r := workbook(t, `<row r="1"><c r="A1" t="s"><v>4</v></c></row>`, `<si><t>Name</t></si>`)
+
+		_, err := ReadRows(r, r.Size())
+
+		require.True(t, errors.Is(err, ErrFormat))
+	})
+}
+
+func TestColumn(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "XFD1": 16383} {
+		got, err := column(ref)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	_, err := column("XFE1")
+	require.Error(t, err)
+	_, err = column("12")
+	require.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxSheetNameLength is the longest worksheet name spreadsheet applications
+// accept.
+const MaxSheetNameLength = 31
+
+// maxSheetRows is the number of rows a worksheet may have.
+const maxSheetRows = 1 << 20
+
+// parts are the fixed parts of a workbook with one worksheet, written before
+// the worksheet itself. %s is the worksheet name.
+var parts = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer writes a workbook with a single worksheet, a row at a time, like
+// csv.Writer. Rows are compressed and written to the underlying writer as
+// they come, so a large worksheet is never held in memory. Close must be
+// called to finish the file.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter returns a Writer to w whose worksheet is called sheet. Names
+// longer than MaxSheetNameLength are cut short, and characters not allowed
+// in names are replaced with spaces.
+func NewWriter(w io.Writer, sheet string) *Writer {
+	x := &Writer{zw: zip.NewWriter(w)}
+	x.err = x.start(sheetName(sheet))
+	return x
+}
+
+func (x *Writer) start(sheet string) error {
+	for _, p := range parts {
+		w, err := x.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		body := p.body
+		if strings.Contains(body, "%s") {
+			body = fmt.Sprintf(body, escape(sheet))
+		}
+		if _, err := io.WriteString(w, body); err != nil {
+			return err
+		}
+	}
+
+	w, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(w)
+	_, err = x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// Write writes one row. Values that are plain decimal numbers, written the
+// way strconv.FormatFloat would write them, are stored as numbers so that
+// they sum and sort; everything else, including numbers with leading zeros,
+// is stored as text, so a value starting with = is never run as a formula.
+// Empty values leave the cell empty.
+func (x *Writer) Write(row []string) error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.rows == maxSheetRows {
+		x.err = fmt.Errorf("xlsx: more than %d rows", maxSheetRows)
+		return x.err
+	}
+	if len(row) > maxColumns {
+		return fmt.Errorf("xlsx: more than %d columns", maxColumns)
+	}
+
+	x.rows++
+	n := strconv.Itoa(x.rows)
+	b := x.sheet
+	b.WriteString(`<row r="` + n + `">`)
+	for i, v := range row {
+		if v == "" {
+			continue
+		}
+		ref := columnName(i) + n
+		if isNumber(v) {
+			b.WriteString(`<c r="` + ref + `"><v>` + v + `</v></c>`)
+			continue
+		}
+		b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + escape(v) + `</t></is></c>`)
+	}
+	_, x.err = b.WriteString(`</row>`)
+	return x.err
+}
+
+// Close finishes the worksheet and the file. It does not close the
+// underlying writer.
+func (x *Writer) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	x.err = errors.New("xlsx: writer is closed")
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func isNumber(v string) bool {
+	f, err := strconv.ParseFloat(v, 64)
+	return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) && strconv.FormatFloat(f, 'f', -1, 64) == v
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// sheetName makes name acceptable as a worksheet name.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "Sheet1"
+	}
+	if r := []rune(name); len(r) > MaxSheetNameLength {
+		name = string(r[:MaxSheetNameLength])
+	}
+	return name
+}
+
+// columnName returns the letters of the zero-based column i, as in "AB".
+func columnName(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append(b, byte('A'+(i-1)%26))
+	}
+	for l, r := 0, len(b)-1; l < r; l, r = l+1, r-1 {
+		b[l], b[r] = b[r], b[l]
+	}
+	return string(b)
+}
@@ -0,0 +1,59 @@
+package xlsx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/String-sg/teacher-workspace/server/pkg/require"
+)
+
+func TestWriter(t *testing.T) {
+	t.Run("writes rows that ReadRows reads back", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, "Class list")
+		require.NoError(t, w.Write([]string{"Index", "Name", "Notes"}))
+		require.NoError(t, w.Write([]string{"1", "Chloe D'Souza & <family>", ""}))
+		require.NoError(t, w.Write([]string{"007", "  Tan Wei Ming", "95.5"}))
+		require.NoError(t, w.Close())
+
+		r := bytes.NewReader(buf.Bytes())
+		rows, err := ReadRows(r, r.Size())
+
+		require.NoError(t, err)
+		require.Equal(t, 3, len(rows))
+		require.Equal(t, 2, len(rows[1]))
+		require.Equal(t, "Chloe D'Souza & <family>", rows[1][1])
+		require.Equal(t, "007", rows[2][0])
+		require.Equal(t, "  Tan Wei Ming", rows[2][1])
+		require.Equal(t, "95.5", rows[2][2])
+	})
+
+	t.Run("stores plain numbers as numbers", func(t *testing.T) {
+		for v, want := range map[string]bool{"12": true, "-0.5": true, "007": false, "1e5": false, "NaN": false, "3A": false} {
+			require.Equal(t, want, isNumber(v))
+		}
+	})
+
+	t.Run("fails once closed", func(t *testing.T) {
+		w := NewWriter(&bytes.Buffer{}, "Sheet")
+		require.NoError(t, w.Close())
+
+		require.Error(t, w.Write([]string{"late"}))
+	})
+}
+
+func TestSheetName(t *testing.T) {
+	require.Equal(t, "Sheet1", sheetName("  "))
+	require.Equal(t, "3A attendance 2026 01 05", sheetName("3A attendance 2026/01/05"))
+	require.Equal(t, MaxSheetNameLength, len(sheetName(strings.Repeat("x", 40))))
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", maxColumns - 1: "XFD"} {
+		require.Equal(t, want, columnName(i))
+		got, err := column(want + "1")
+		require.NoError(t, err)
+		require.Equal(t, i, got)
+	}
+}